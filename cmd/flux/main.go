@@ -8,6 +8,7 @@ import (
 	fluxcmd "github.com/InfluxCommunity/flux/cmd/flux/cmd"
 	"github.com/InfluxCommunity/flux/codes"
 	"github.com/InfluxCommunity/flux/dependencies"
+	"github.com/InfluxCommunity/flux/dependencies/influxdb"
 	"github.com/InfluxCommunity/flux/dependency"
 	"github.com/InfluxCommunity/flux/fluxinit"
 	"github.com/InfluxCommunity/flux/internal/errors"
@@ -26,6 +27,7 @@ var flags struct {
 	Format            string
 	Features          string
 	EnableSuggestions bool
+	InfluxDBDir       string
 }
 
 func runE(cmd *cobra.Command, args []string) error {
@@ -107,6 +109,13 @@ const DefaultInfluxDBHost = "http://localhost:9999"
 
 func injectDependencies(ctx context.Context) (context.Context, *dependency.Span) {
 	deps := dependencies.NewDefaultDependencies(DefaultInfluxDBHost)
+	if flags.InfluxDBDir != "" {
+		// The provider is injected after the default
+		// dependencies so it replaces the http provider.
+		return dependency.Inject(ctx, deps, influxdb.Dependency{
+			Provider: influxdb.LocalProvider{Dir: flags.InfluxDBDir},
+		})
+	}
 	return dependency.Inject(ctx, deps)
 }
 
@@ -123,6 +132,7 @@ func main() {
 	fluxCmd.Flags().StringVar(&flags.Trace, "trace", "", "Trace query execution")
 	fluxCmd.Flags().StringVarP(&flags.Format, "format", "", "cli", "Output format one of: cli,csv. Defaults to cli")
 	fluxCmd.Flag("trace").NoOptDefVal = "jaeger"
+	fluxCmd.Flags().StringVar(&flags.InfluxDBDir, "influxdb-dir", "", "Read and write influxdb buckets stored in this directory instead of an influxdb instance")
 	fluxCmd.Flags().StringVar(&flags.Features, "features", "", "JSON object specifying the features to execute with. See internal/feature/flags.yml for a list of the current features")
 
 	fmtCmd := &cobra.Command{
@@ -5,11 +5,19 @@ import (
 
 	"github.com/InfluxCommunity/flux/codes"
 	"github.com/InfluxCommunity/flux/internal/errors"
+	fluxfeature "github.com/InfluxCommunity/flux/internal/feature"
 	"github.com/InfluxCommunity/flux/semantic"
 	"github.com/InfluxCommunity/flux/values"
 )
 
 func Compile(ctx context.Context, scope Scope, f *semantic.FunctionExpression, in semantic.MonoType) (Func, error) {
+	return compileFunction(ctx, scope, f, in, fluxfeature.OptimizeFunctions().Enabled(ctx))
+}
+
+// compileFunction compiles the function. If optimize is set, constant
+// expressions are folded and repeated expressions are evaluated once
+// for each call.
+func compileFunction(ctx context.Context, scope Scope, f *semantic.FunctionExpression, in semantic.MonoType, optimize bool) (Func, error) {
 	if scope == nil {
 		scope = NewScope()
 	}
@@ -17,6 +25,32 @@ func Compile(ctx context.Context, scope Scope, f *semantic.FunctionExpression, i
 		return nil, errors.Newf(codes.Invalid, "function input must be an object @ %v", f.Location())
 	}
 
+	subst, err := newSubstitution(f, in)
+	if err != nil {
+		return nil, err
+	}
+
+	compiler := &compiler{ctx: ctx}
+	if optimize {
+		folded, shared, err := optimizeFunction(ctx, scope, f, subst)
+		if err != nil {
+			return nil, errors.Wrapf(err, codes.Inherit, "cannot compile @ %v", f.Location())
+		}
+		compiler.folded, compiler.shared = folded, shared
+	}
+	root, err := compiler.compile(f.Block, subst)
+	if err != nil {
+		return nil, errors.Wrapf(err, codes.Inherit, "cannot compile @ %v", f.Location())
+	}
+	return compiledFn{
+		root:        root,
+		parentScope: scope,
+	}, nil
+}
+
+// newSubstitution maps the type variables in the function
+// parameters to the realized types in the input.
+func newSubstitution(f *semantic.FunctionExpression, in semantic.MonoType) (*semantic.Substitution, error) {
 	// Retrieve the function argument types and create an object type from them.
 	fnType := f.TypeOf()
 	argN, err := fnType.NumArguments()
@@ -61,16 +95,7 @@ func Compile(ctx context.Context, scope Scope, f *semantic.FunctionExpression, i
 			return nil, errors.Newf(codes.Invalid, "missing required argument %q", string(name))
 		}
 	}
-
-	compiler := &compiler{ctx}
-	root, err := compiler.compile(f.Block, subst)
-	if err != nil {
-		return nil, errors.Wrapf(err, codes.Inherit, "cannot compile @ %v", f.Location())
-	}
-	return compiledFn{
-		root:        root,
-		parentScope: scope,
-	}, nil
+	return subst, nil
 }
 
 // substituteTypes will populate a substitution map by recursing through
@@ -408,10 +433,35 @@ func apply(sub semantic.Substitutor, props []semantic.PropertyType, t semantic.M
 
 type compiler struct {
 	ctx context.Context
+
+	// folded and shared hold the results of optimizing the
+	// function body. They are nil when the body is not optimized.
+	folded map[semantic.Node]values.Value
+	shared map[semantic.Node]string
 }
 
 // compile recursively compiles semantic nodes into evaluators.
 func (compiler *compiler) compile(n semantic.Node, subst semantic.Substitutor) (Evaluator, error) {
+	if v, ok := compiler.folded[n]; ok {
+		return &constEvaluator{
+			t: apply(subst, nil, n.(semantic.Expression).TypeOf()),
+			v: v,
+		}, nil
+	}
+	if name, ok := compiler.shared[n]; ok {
+		node, err := compiler.compileNode(n, subst)
+		if err != nil {
+			return nil, err
+		}
+		return &sharedEvaluator{
+			Evaluator: node,
+			name:      name,
+		}, nil
+	}
+	return compiler.compileNode(n, subst)
+}
+
+func (compiler *compiler) compileNode(n semantic.Node, subst semantic.Substitutor) (Evaluator, error) {
 	switch n := n.(type) {
 	case *semantic.Block:
 		body := make([]Evaluator, len(n.Body))
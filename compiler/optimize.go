@@ -0,0 +1,383 @@
+package compiler
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/InfluxCommunity/flux/semantic"
+	"github.com/InfluxCommunity/flux/values"
+)
+
+// pureFunctions is the set of functions that have been marked as pure.
+var pureFunctions = make(map[values.Function]struct{})
+
+// MarkPure records that the result of fn depends only on its arguments
+// and that calling fn has no side effects. It returns fn so it can wrap
+// the value passed to runtime.RegisterPackageValue.
+//
+// The compiler evaluates a call to a pure function once when all of
+// its arguments are constant and evaluates identical calls within
+// a function body once per invocation of the function.
+//
+// MarkPure is not safe for concurrent use and should only be
+// called during package initialization.
+func MarkPure(fn values.Function) values.Function {
+	pureFunctions[fn] = struct{}{}
+	return fn
+}
+
+// isPure reports whether v is a function marked with MarkPure.
+func isPure(v values.Value) bool {
+	if v.IsNull() || v.Type().Nature() != semantic.Function {
+		return false
+	}
+	fn := v.Function()
+	if !reflect.TypeOf(fn).Comparable() {
+		return false
+	}
+	_, ok := pureFunctions[fn]
+	return ok
+}
+
+// exprInfo describes an expression in the function body.
+type exprInfo struct {
+	// key identifies the expression. Two expressions with the same
+	// key produce the same value when evaluated in the same scope.
+	// The key is empty when the expression cannot be shared.
+	key string
+	// constant is set when the expression does not depend on the
+	// function parameters or the local variables of the function.
+	constant bool
+}
+
+// optimizer analyzes the body of a function before it is compiled.
+//
+// Constant expressions are evaluated once at compile time. An expression
+// is constant when it only references values in the scope the function
+// is compiled in, and only calls pure functions. This folds literal
+// arithmetic and hoists loop-invariant expressions out of functions
+// that are evaluated for every row.
+//
+// Identical expressions that depend on the function parameters are
+// evaluated once for each invocation of the function and the result
+// is shared between each occurrence.
+type optimizer struct {
+	ctx   context.Context
+	scope Scope
+
+	// bound holds the names bound by the function parameters and the
+	// variable declarations in the body with the number of times each
+	// name has been bound.
+	bound map[string]int
+
+	// folded holds the value of each constant expression.
+	folded map[semantic.Node]values.Value
+
+	// candidates holds the key of each expression that may be shared
+	// and counts holds the number of occurrences of each key.
+	candidates map[semantic.Node]string
+	counts     map[string]int
+}
+
+// optimizeFunction analyzes the function body. It returns the values of the
+// constant expressions and the scope name that each shared expression
+// stores its value in.
+func optimizeFunction(ctx context.Context, scope Scope, f *semantic.FunctionExpression, subst semantic.Substitutor) (map[semantic.Node]values.Value, map[semantic.Node]string, error) {
+	o := &optimizer{
+		ctx:        ctx,
+		scope:      scope,
+		bound:      make(map[string]int),
+		folded:     make(map[semantic.Node]values.Value),
+		candidates: make(map[semantic.Node]string),
+		counts:     make(map[string]int),
+	}
+	if f.Parameters != nil {
+		for _, p := range f.Parameters.List {
+			o.bound[p.Key.Name.Name()]++
+		}
+	}
+
+	for _, s := range f.Block.Body {
+		switch s := s.(type) {
+		case *semantic.NativeVariableAssignment:
+			subst, err := s.Typ.Instantiator(subst)
+			if err != nil {
+				return nil, nil, err
+			}
+			if _, err := o.expr(s.Init, subst); err != nil {
+				return nil, nil, err
+			}
+			o.bound[s.Identifier.Name.Name()]++
+		case *semantic.ReturnStatement:
+			if _, err := o.expr(s.Argument, subst); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	v := &shareVisitor{
+		optimizer: o,
+		shared:    make(map[semantic.Node]string),
+		names:     make(map[string]string),
+	}
+	semantic.Walk(v, f.Block)
+	return o.folded, v.shared, nil
+}
+
+// expr analyzes an expression and folds it if it is constant.
+func (o *optimizer) expr(n semantic.Expression, subst semantic.Substitutor) (exprInfo, error) {
+	info, err := o.analyze(n, subst)
+	if err != nil {
+		return exprInfo{}, err
+	}
+
+	t := apply(subst, nil, n.TypeOf())
+	if info.constant && isFoldable(n, t) {
+		v, err := o.fold(n, subst)
+		if err != nil {
+			// Leave the expression to be evaluated at runtime
+			// so the error is reported in the same way.
+			info.constant = false
+		} else {
+			o.folded[n] = v
+		}
+	}
+	if !info.constant && info.key != "" && isShareable(n, t) {
+		o.candidates[n] = info.key
+		o.counts[info.key]++
+	}
+	return info, nil
+}
+
+// fold evaluates a constant expression.
+func (o *optimizer) fold(n semantic.Expression, subst semantic.Substitutor) (values.Value, error) {
+	c := &compiler{ctx: o.ctx, folded: o.folded}
+	e, err := c.compile(n, subst)
+	if err != nil {
+		return nil, err
+	}
+	return eval(o.ctx, e, nestScope(o.scope))
+}
+
+func (o *optimizer) analyze(n semantic.Expression, subst semantic.Substitutor) (exprInfo, error) {
+	switch n := n.(type) {
+	case *semantic.IdentifierExpression:
+		name := n.Name.Name()
+		if count, ok := o.bound[name]; ok {
+			t := apply(subst, nil, n.TypeOf())
+			return exprInfo{key: fmt.Sprintf("$%s#%d:%v", name, count, t)}, nil
+		}
+		if _, ok := o.scope.Lookup(name); ok {
+			return exprInfo{key: name, constant: true}, nil
+		}
+		return exprInfo{}, nil
+	case *semantic.MemberExpression:
+		obj, err := o.expr(n.Object, subst)
+		if err != nil {
+			return exprInfo{}, err
+		}
+		return exprInfo{
+			key:      sexpr("member "+strconv.Quote(n.Property.Name()), obj),
+			constant: obj.constant,
+		}, nil
+	case *semantic.IndexExpression:
+		return o.operator("index", subst, n.Array, n.Index)
+	case *semantic.UnaryExpression:
+		return o.operator("unary "+n.Operator.String(), subst, n.Argument)
+	case *semantic.BinaryExpression:
+		return o.operator("binary "+n.Operator.String(), subst, n.Left, n.Right)
+	case *semantic.LogicalExpression:
+		return o.operator("logical "+n.Operator.String(), subst, n.Left, n.Right)
+	case *semantic.ConditionalExpression:
+		return o.operator("if", subst, n.Test, n.Consequent, n.Alternate)
+	case *semantic.StringExpression:
+		parts := make([]exprInfo, len(n.Parts))
+		for i, p := range n.Parts {
+			switch p := p.(type) {
+			case *semantic.TextPart:
+				parts[i] = exprInfo{key: strconv.Quote(p.Value), constant: true}
+			case *semantic.InterpolatedPart:
+				info, err := o.expr(p.Expression, subst)
+				if err != nil {
+					return exprInfo{}, err
+				}
+				parts[i] = info
+			}
+		}
+		return exprInfo{key: sexpr("string", parts...), constant: isConstant(parts...)}, nil
+	case *semantic.ObjectExpression:
+		props := make([]exprInfo, 0, len(n.Properties)+1)
+		for _, p := range n.Properties {
+			info, err := o.expr(p.Value, subst)
+			if err != nil {
+				return exprInfo{}, err
+			}
+			if info.key != "" {
+				info.key = strconv.Quote(p.Key.Key()) + "=" + info.key
+			}
+			props = append(props, info)
+		}
+		if n.With != nil {
+			// The with identifier must remain an identifier
+			// so it is analyzed without being folded.
+			with, err := o.analyze(n.With, subst)
+			if err != nil {
+				return exprInfo{}, err
+			}
+			with.constant = false
+			props = append(props, with)
+		}
+		return exprInfo{key: sexpr("record", props...), constant: isConstant(props...)}, nil
+	case *semantic.ArrayExpression:
+		return o.operator("array", subst, n.Elements...)
+	case *semantic.DictExpression:
+		elements := make([]semantic.Expression, 0, 2*len(n.Elements))
+		for _, item := range n.Elements {
+			elements = append(elements, item.Key, item.Val)
+		}
+		return o.operator("dict", subst, elements...)
+	case *semantic.CallExpression:
+		operands := []semantic.Expression{n.Callee, n.Arguments}
+		if n.Pipe != nil {
+			operands = append(operands, n.Pipe)
+		}
+		info, err := o.operator("call", subst, operands...)
+		if err != nil {
+			return exprInfo{}, err
+		}
+		if fn, ok := o.folded[n.Callee]; !ok || !isPure(fn) {
+			return exprInfo{}, nil
+		}
+		return info, nil
+	case *semantic.BooleanLiteral:
+		return exprInfo{key: strconv.FormatBool(n.Value), constant: true}, nil
+	case *semantic.IntegerLiteral:
+		return exprInfo{key: "i" + strconv.FormatInt(n.Value, 10), constant: true}, nil
+	case *semantic.UnsignedIntegerLiteral:
+		return exprInfo{key: "u" + strconv.FormatUint(n.Value, 10), constant: true}, nil
+	case *semantic.FloatLiteral:
+		return exprInfo{key: "f" + strconv.FormatFloat(n.Value, 'g', -1, 64), constant: true}, nil
+	case *semantic.StringLiteral:
+		return exprInfo{key: strconv.Quote(n.Value), constant: true}, nil
+	case *semantic.RegexpLiteral:
+		return exprInfo{key: "re" + strconv.Quote(n.Value.String()), constant: true}, nil
+	case *semantic.DateTimeLiteral:
+		return exprInfo{key: "t" + n.Value.Format(time.RFC3339Nano), constant: true}, nil
+	case *semantic.DurationLiteral:
+		return exprInfo{key: fmt.Sprintf("d%v", n.Values), constant: true}, nil
+	default:
+		// Function expressions are compiled when they are called
+		// so they are not analyzed with the enclosing function.
+		return exprInfo{}, nil
+	}
+}
+
+// operator analyzes the operands of an expression that is
+// constant when all of its operands are constant.
+func (o *optimizer) operator(head string, subst semantic.Substitutor, operands ...semantic.Expression) (exprInfo, error) {
+	infos := make([]exprInfo, len(operands))
+	for i, operand := range operands {
+		info, err := o.expr(operand, subst)
+		if err != nil {
+			return exprInfo{}, err
+		}
+		infos[i] = info
+	}
+	return exprInfo{key: sexpr(head, infos...), constant: isConstant(infos...)}, nil
+}
+
+// sexpr constructs a key from the keys of the operands.
+// It returns an empty key if any operand cannot be shared.
+func sexpr(head string, operands ...exprInfo) string {
+	var sb strings.Builder
+	sb.WriteString("(")
+	sb.WriteString(head)
+	for _, op := range operands {
+		if op.key == "" {
+			return ""
+		}
+		sb.WriteString(" ")
+		sb.WriteString(op.key)
+	}
+	sb.WriteString(")")
+	return sb.String()
+}
+
+func isConstant(operands ...exprInfo) bool {
+	for _, op := range operands {
+		if !op.constant {
+			return false
+		}
+	}
+	return true
+}
+
+// isFoldable reports whether a constant expression should be replaced
+// by its value. Literals are already cheap to evaluate and composite
+// values are not folded so each evaluation produces a new value.
+func isFoldable(n semantic.Expression, t semantic.MonoType) bool {
+	switch n.(type) {
+	case *semantic.IdentifierExpression, *semantic.MemberExpression:
+		return true
+	case *semantic.BooleanLiteral,
+		*semantic.IntegerLiteral,
+		*semantic.UnsignedIntegerLiteral,
+		*semantic.FloatLiteral,
+		*semantic.StringLiteral,
+		*semantic.RegexpLiteral,
+		*semantic.DateTimeLiteral,
+		*semantic.DurationLiteral:
+		return false
+	default:
+		return t.Kind() == semantic.Basic
+	}
+}
+
+// isShareable reports whether the value of an expression may be
+// shared between its occurrences in a function body.
+func isShareable(n semantic.Expression, t semantic.MonoType) bool {
+	switch n.(type) {
+	case *semantic.UnaryExpression,
+		*semantic.BinaryExpression,
+		*semantic.LogicalExpression,
+		*semantic.ConditionalExpression,
+		*semantic.IndexExpression,
+		*semantic.StringExpression,
+		*semantic.CallExpression:
+		return t.Kind() == semantic.Basic
+	default:
+		return false
+	}
+}
+
+// shareVisitor assigns a scope name to each expression that occurs
+// more than once in the function body. The operands of a shared
+// expression are not shared unless they also occur elsewhere.
+type shareVisitor struct {
+	*optimizer
+	shared map[semantic.Node]string
+	names  map[string]string
+}
+
+func (v *shareVisitor) Visit(node semantic.Node) semantic.Visitor {
+	if _, ok := node.(*semantic.FunctionExpression); ok {
+		return nil
+	}
+	key, ok := v.candidates[node]
+	if !ok || v.counts[key] < 2 {
+		return v
+	}
+	name, ok := v.names[key]
+	if !ok {
+		name = fmt.Sprintf("~~cse%d~~", len(v.names))
+		v.names[key] = name
+	}
+	v.shared[node] = name
+	return nil
+}
+
+func (v *shareVisitor) Done(node semantic.Node) {}
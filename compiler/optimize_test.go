@@ -0,0 +1,165 @@
+package compiler_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/compiler"
+	"github.com/InfluxCommunity/flux/execute/executetest"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/internal/pkg/feature"
+	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/InfluxCommunity/flux/semantic"
+	"github.com/InfluxCommunity/flux/values"
+)
+
+func TestCompileOptimized(t *testing.T) {
+	var calls int
+	double := func(name string) values.Function {
+		return values.NewFunction(
+			name,
+			semantic.NewFunctionType(semantic.BasicInt, []semantic.ArgumentType{
+				{Name: []byte("v"), Type: semantic.BasicInt},
+			}),
+			func(ctx context.Context, args values.Object) (values.Value, error) {
+				calls++
+				v, _ := args.Get("v")
+				if v.Int() < 0 {
+					return nil, errors.New(codes.Invalid, "negative value")
+				}
+				return values.NewInt(v.Int() * 2), nil
+			}, false,
+		)
+	}
+
+	// The values in scope are also declared in the source
+	// so the functions can be type checked.
+	prelude := "pure = (v) => v * 2\nimpure = (v) => v * 2\nx = 3\n"
+	scope := compiler.NewScope()
+	scope.Set("pure", compiler.MarkPure(double("pure")))
+	scope.Set("impure", double("impure"))
+	scope.Set("x", values.NewInt(3))
+
+	testCases := []struct {
+		name     string
+		fn       string
+		disabled bool
+		want     int64
+		wantErr  bool
+		// compileCalls is the number of calls made when the
+		// function is compiled and evalCalls is the number of
+		// calls made each time the function is evaluated.
+		compileCalls int
+		evalCalls    int
+	}{
+		{
+			name:      "shared call",
+			fn:        `(r) => pure(v: r.n) + pure(v: r.n)`,
+			want:      20,
+			evalCalls: 1,
+		},
+		{
+			name:      "disabled",
+			fn:        `(r) => pure(v: r.n) + pure(v: r.n)`,
+			disabled:  true,
+			want:      20,
+			evalCalls: 2,
+		},
+		{
+			name:      "impure call",
+			fn:        `(r) => impure(v: r.n) + impure(v: r.n)`,
+			want:      20,
+			evalCalls: 2,
+		},
+		{
+			name:         "constant call",
+			fn:           `(r) => r.n + pure(v: x)`,
+			want:         11,
+			compileCalls: 1,
+		},
+		{
+			name:         "constant arithmetic",
+			fn:           `(r) => r.n + pure(v: x * 2 + 1)`,
+			want:         19,
+			compileCalls: 1,
+		},
+		{
+			name:      "shared declarations",
+			fn:        "(r) => {\na = pure(v: r.n)\nb = pure(v: r.n)\nreturn a + b\n}",
+			want:      20,
+			evalCalls: 1,
+		},
+		{
+			name:      "shared operands",
+			fn:        `(r) => pure(v: r.n + 1) * pure(v: r.n + 1) - pure(v: r.n)`,
+			want:      134,
+			evalCalls: 2,
+		},
+		{
+			name:      "conditional",
+			fn:        `(r) => if r.n > 0 then pure(v: r.n) else pure(v: r.n) + 1`,
+			want:      10,
+			evalCalls: 1,
+		},
+		{
+			name:         "constant error",
+			fn:           `(r) => r.n + pure(v: 0 - x)`,
+			wantErr:      true,
+			compileCalls: 1,
+			evalCalls:    1,
+		},
+	}
+
+	inType := semantic.NewObjectType([]semantic.PropertyType{
+		{Key: []byte("r"), Value: semantic.NewObjectType([]semantic.PropertyType{
+			{Key: []byte("n"), Value: semantic.BasicInt},
+		})},
+	})
+	input := values.NewObjectWithValues(map[string]values.Value{
+		"r": values.NewObjectWithValues(map[string]values.Value{
+			"n": values.NewInt(5),
+		}),
+	})
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := feature.Inject(context.Background(), executetest.TestFlagger{
+				"optimizeFunctions": !tc.disabled,
+			})
+			pkg, err := runtime.AnalyzeSource(ctx, prelude+tc.fn)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			body := pkg.Files[0].Body
+			fn := body[len(body)-1].(*semantic.ExpressionStatement).Expression.(*semantic.FunctionExpression)
+
+			calls = 0
+			f, err := compiler.Compile(ctx, scope, fn, inType)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if want, got := tc.compileCalls, calls; want != got {
+				t.Errorf("unexpected calls during compilation -want/+got:\n\t- %d\n\t+ %d", want, got)
+			}
+
+			for i := 0; i < 2; i++ {
+				calls = 0
+				got, err := f.Eval(ctx, input)
+				if tc.wantErr {
+					if err == nil {
+						t.Fatal("expected error")
+					}
+				} else if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				} else if want := values.NewInt(tc.want); !want.Equal(got) {
+					t.Errorf("unexpected value -want/+got:\n\t- %v\n\t+ %v", want, got)
+				}
+				if want, got := tc.evalCalls, calls; want != got {
+					t.Errorf("unexpected calls during evaluation -want/+got:\n\t- %d\n\t+ %d", want, got)
+				}
+			}
+		})
+	}
+}
@@ -1110,6 +1110,46 @@ func (e *durationEvaluator) Eval(ctx context.Context, scope Scope) (values.Value
 	return values.NewDuration(e.duration), nil
 }
 
+// constEvaluator returns the value of a constant expression
+// that was evaluated when the function was compiled.
+type constEvaluator struct {
+	t semantic.MonoType
+	v values.Value
+}
+
+func (e *constEvaluator) Type() semantic.MonoType {
+	return e.t
+}
+
+func (e *constEvaluator) Eval(ctx context.Context, scope Scope) (values.Value, error) {
+	e.v.Retain()
+	return e.v, nil
+}
+
+// sharedEvaluator evaluates an expression that occurs more than once
+// in a function body. The first evaluation stores the value in the scope
+// of the call and the remaining evaluations return the stored value.
+type sharedEvaluator struct {
+	Evaluator
+	name string
+}
+
+func (e *sharedEvaluator) Eval(ctx context.Context, scope Scope) (values.Value, error) {
+	if v, ok := scope.LocalLookup(e.name); ok {
+		v.Retain()
+		return v, nil
+	}
+	v, err := eval(ctx, e.Evaluator, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	scope.Set(e.name, v)
+	v.Retain()
+
+	return v, nil
+}
+
 type identifierEvaluator struct {
 	t    semantic.MonoType
 	name string
@@ -1319,7 +1359,9 @@ func (f *functionValue) Call(ctx context.Context, args values.Object) (values.Va
 	}
 	defer releaseScope(scope)
 
-	fn, err := Compile(ctx, scope, f.fn, args.Type())
+	// The function is compiled for each call so the body is
+	// not optimized since it is only evaluated once.
+	fn, err := compileFunction(ctx, scope, f.fn, args.Type(), false)
 	if err != nil {
 		return nil, err
 	}
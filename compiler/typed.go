@@ -0,0 +1,1020 @@
+package compiler
+
+import (
+	"context"
+	"math"
+	"regexp"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/ast"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/semantic"
+	"github.com/InfluxCommunity/flux/values"
+)
+
+// RowFunc is a function that has been specialized for rows
+// with a fixed set of columns.
+//
+// Functions compiled with Compile box every intermediate result
+// into a values.Value. A RowFunc is compiled from the same function,
+// but each expression is specialized for its monotype so intermediate
+// results stay unboxed, and the properties of the record parameter are
+// read directly from the column reader instead of being copied into
+// a record for each row.
+type RowFunc interface {
+	// Type returns the return type of the function.
+	Type() semantic.MonoType
+
+	// EvalRow evaluates the function for row i of the column reader.
+	EvalRow(ctx context.Context, i int, cr flux.ColReader) (values.Value, error)
+
+	// EvalRowBool evaluates a function that returns a boolean for
+	// row i of the column reader without boxing the result.
+	// A null result is reported as false.
+	EvalRowBool(ctx context.Context, i int, cr flux.ColReader) (bool, error)
+}
+
+// CompileRow compiles a function with a single record parameter
+// into a RowFunc for rows with the given columns.
+//
+// Only monomorphic functions can be specialized. A function is
+// eligible when its body is a single return statement, every
+// expression within it has a basic type, and it only refers to
+// the record parameter, literals, and values from the scope with
+// a basic type. The function may return a record literal, which
+// may extend the record parameter, whose properties follow the
+// same rules. Function calls, string interpolation, and durations
+// are not specialized.
+//
+// If the function is not eligible, an error with the code
+// codes.Unimplemented is returned and the caller should use Compile
+// instead. A RowFunc produces the same results as the function
+// returned by Compile, including for nulls and runtime errors.
+func CompileRow(ctx context.Context, scope Scope, f *semantic.FunctionExpression, cols []flux.ColMeta) (RowFunc, error) {
+	if f.Parameters == nil || len(f.Parameters.List) != 1 || f.Defaults != nil {
+		return nil, unimplemented("only functions with a single parameter can be specialized")
+	}
+	if len(f.Block.Body) != 1 {
+		return nil, unimplemented("only functions with a single return statement can be specialized")
+	}
+	ret, ok := f.Block.Body[0].(*semantic.ReturnStatement)
+	if !ok {
+		return nil, unimplemented("only functions with a single return statement can be specialized")
+	}
+
+	recordName := f.Parameters.List[0].Key.Name.Name()
+	properties := make([]semantic.PropertyType, len(cols))
+	for i, c := range cols {
+		typ := flux.SemanticType(c.Type)
+		if typ.Kind() == semantic.Unknown {
+			return nil, unimplemented("unknown column type: %s", c.Type)
+		}
+		properties[i] = semantic.PropertyType{
+			Key:   []byte(c.Label),
+			Value: typ,
+		}
+	}
+	in := semantic.NewObjectType([]semantic.PropertyType{
+		{Key: []byte(recordName), Value: semantic.NewObjectType(properties)},
+	})
+	subst, err := newSubstitution(f, in)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &rowCompiler{
+		scope:      scope,
+		subst:      subst,
+		recordName: recordName,
+		cols:       cols,
+	}
+
+	var root typedNode
+	if obj, ok := ret.Argument.(*semantic.ObjectExpression); ok {
+		root, err = c.compileRecord(obj)
+	} else {
+		root, err = c.compile(ret.Argument)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fn := &typedFn{root: root}
+	fn.pred, _ = root.(typedExpr[bool])
+	return fn, nil
+}
+
+type typedFn struct {
+	root typedNode
+	pred typedExpr[bool]
+}
+
+func (f *typedFn) Type() semantic.MonoType {
+	return f.root.Type()
+}
+
+func (f *typedFn) EvalRow(ctx context.Context, i int, cr flux.ColReader) (values.Value, error) {
+	return f.root.value(typedRow{cr: cr, i: i})
+}
+
+func (f *typedFn) EvalRowBool(ctx context.Context, i int, cr flux.ColReader) (bool, error) {
+	if f.pred == nil {
+		return false, errors.Newf(codes.Internal, "function returns %s, not a boolean", f.root.Type())
+	}
+	v, ok, err := f.pred.eval(typedRow{cr: cr, i: i})
+	if err != nil {
+		return false, err
+	}
+	return ok && v, nil
+}
+
+// typedRow is an unboxed record for a single row.
+// The properties of the record are read from the columns
+// by the nodes that reference them.
+type typedRow struct {
+	cr flux.ColReader
+	i  int
+}
+
+// typedNode is an expression that has been specialized for its type.
+type typedNode interface {
+	Type() semantic.MonoType
+
+	// value evaluates the node and boxes the result.
+	value(r typedRow) (values.Value, error)
+}
+
+// typedExpr is a typedNode that evaluates to an unboxed value.
+// The go type of the value is determined by the nature of the
+// node's type and is one of bool, int64, uint64, float64, string,
+// values.Time, or *regexp.Regexp.
+type typedExpr[T any] interface {
+	typedNode
+
+	// eval evaluates the node. The boolean reports whether
+	// the result is valid and is false when it is null.
+	eval(r typedRow) (T, bool, error)
+}
+
+// box converts an unboxed result into a value.
+// Null results are typed with the type of the node that produced them.
+func box[T any](t semantic.MonoType, v T, ok bool) values.Value {
+	if !ok {
+		return values.NewNull(t)
+	}
+	switch v := any(v).(type) {
+	case bool:
+		return values.NewBool(v)
+	case int64:
+		return values.NewInt(v)
+	case uint64:
+		return values.NewUInt(v)
+	case float64:
+		return values.NewFloat(v)
+	case string:
+		return values.NewString(v)
+	case values.Time:
+		return values.NewTime(v)
+	case *regexp.Regexp:
+		return values.NewRegexp(v)
+	default:
+		panic(errors.Newf(codes.Internal, "cannot box value of type %T", v))
+	}
+}
+
+type rowCompiler struct {
+	scope      Scope
+	subst      semantic.Substitutor
+	recordName string
+	cols       []flux.ColMeta
+}
+
+func unimplemented(format string, args ...interface{}) error {
+	return errors.Newf(codes.Unimplemented, format, args...)
+}
+
+// typeOf returns the realized type of an expression if it
+// is one that can be specialized.
+func (c *rowCompiler) typeOf(e semantic.Expression) (semantic.MonoType, error) {
+	t := apply(c.subst, nil, e.TypeOf())
+	if t.Kind() != semantic.Basic {
+		return semantic.MonoType{}, unimplemented("expression of type %s cannot be specialized", t)
+	}
+	switch t.Nature() {
+	case semantic.Bool, semantic.Int, semantic.UInt, semantic.Float,
+		semantic.String, semantic.Time, semantic.Regexp:
+		return t, nil
+	default:
+		return semantic.MonoType{}, unimplemented("expression of type %s cannot be specialized", t)
+	}
+}
+
+func (c *rowCompiler) compile(n semantic.Expression) (typedNode, error) {
+	switch n := n.(type) {
+	case *semantic.BooleanLiteral:
+		return &constNode[bool]{t: semantic.BasicBool, v: n.Value, valid: true}, nil
+	case *semantic.IntegerLiteral:
+		return &constNode[int64]{t: semantic.BasicInt, v: n.Value, valid: true}, nil
+	case *semantic.UnsignedIntegerLiteral:
+		return &constNode[uint64]{t: semantic.BasicUint, v: n.Value, valid: true}, nil
+	case *semantic.FloatLiteral:
+		return &constNode[float64]{t: semantic.BasicFloat, v: n.Value, valid: true}, nil
+	case *semantic.StringLiteral:
+		return &constNode[string]{t: semantic.BasicString, v: n.Value, valid: true}, nil
+	case *semantic.DateTimeLiteral:
+		return &constNode[values.Time]{t: semantic.BasicTime, v: values.ConvertTime(n.Value), valid: true}, nil
+	case *semantic.RegexpLiteral:
+		return &constNode[*regexp.Regexp]{t: semantic.BasicRegexp, v: n.Value, valid: true}, nil
+	case *semantic.IdentifierExpression:
+		return c.compileIdentifier(n)
+	case *semantic.MemberExpression:
+		return c.compileMember(n)
+	case *semantic.UnaryExpression:
+		return c.compileUnary(n)
+	case *semantic.LogicalExpression:
+		return c.compileLogical(n)
+	case *semantic.ConditionalExpression:
+		return c.compileConditional(n)
+	case *semantic.BinaryExpression:
+		return c.compileBinary(n)
+	default:
+		return nil, unimplemented("%s cannot be specialized", n.NodeType())
+	}
+}
+
+// compileRecord compiles a record literal returned by the function.
+func (c *rowCompiler) compileRecord(n *semantic.ObjectExpression) (typedNode, error) {
+	rec := &recordNode{
+		t: apply(c.subst, nil, n.TypeOf()),
+	}
+	if n.With != nil {
+		if n.With.Name.Name() != c.recordName {
+			return nil, unimplemented("only the record parameter can be extended")
+		}
+		for j, col := range c.cols {
+			node, err := c.column(j, col)
+			if err != nil {
+				return nil, err
+			}
+			rec.labels = append(rec.labels, col.Label)
+			rec.properties = append(rec.properties, node)
+		}
+	}
+	for _, p := range n.Properties {
+		node, err := c.compile(p.Value)
+		if err != nil {
+			return nil, err
+		}
+		rec.labels = append(rec.labels, p.Key.Key())
+		rec.properties = append(rec.properties, node)
+	}
+	return rec, nil
+}
+
+// compileIdentifier folds a reference to a value from
+// the enclosing scope into a constant.
+func (c *rowCompiler) compileIdentifier(n *semantic.IdentifierExpression) (typedNode, error) {
+	name := n.Name.Name()
+	if name == c.recordName {
+		return nil, unimplemented("the record parameter can only be used in a member expression")
+	} else if c.scope == nil {
+		return nil, unimplemented("identifier %q is not in scope", name)
+	}
+
+	v, ok := c.scope.Lookup(name)
+	if !ok || v.IsNull() {
+		return nil, unimplemented("identifier %q cannot be specialized", name)
+	}
+	return constant(v)
+}
+
+// constant returns a node for a value with a basic type.
+func constant(v values.Value) (typedNode, error) {
+	t := v.Type()
+	if v.IsNull() {
+		return null(t)
+	}
+	switch t.Nature() {
+	case semantic.Bool:
+		return &constNode[bool]{t: t, v: v.Bool(), valid: true}, nil
+	case semantic.Int:
+		return &constNode[int64]{t: t, v: v.Int(), valid: true}, nil
+	case semantic.UInt:
+		return &constNode[uint64]{t: t, v: v.UInt(), valid: true}, nil
+	case semantic.Float:
+		return &constNode[float64]{t: t, v: v.Float(), valid: true}, nil
+	case semantic.String:
+		return &constNode[string]{t: t, v: v.Str(), valid: true}, nil
+	case semantic.Time:
+		return &constNode[values.Time]{t: t, v: v.Time(), valid: true}, nil
+	case semantic.Regexp:
+		return &constNode[*regexp.Regexp]{t: t, v: v.Regexp(), valid: true}, nil
+	default:
+		return nil, unimplemented("value of type %s cannot be specialized", t)
+	}
+}
+
+// null returns a node that is always null.
+func null(t semantic.MonoType) (typedNode, error) {
+	switch t.Nature() {
+	case semantic.Bool:
+		return &constNode[bool]{t: t}, nil
+	case semantic.Int:
+		return &constNode[int64]{t: t}, nil
+	case semantic.UInt:
+		return &constNode[uint64]{t: t}, nil
+	case semantic.Float:
+		return &constNode[float64]{t: t}, nil
+	case semantic.String:
+		return &constNode[string]{t: t}, nil
+	case semantic.Time:
+		return &constNode[values.Time]{t: t}, nil
+	default:
+		return nil, unimplemented("null of type %s cannot be specialized", t)
+	}
+}
+
+func (c *rowCompiler) compileMember(n *semantic.MemberExpression) (typedNode, error) {
+	if id, ok := n.Object.(*semantic.IdentifierExpression); !ok || id.Name.Name() != c.recordName {
+		return nil, unimplemented("only members of the record parameter can be specialized")
+	}
+
+	property := n.Property.Name()
+	for j, col := range c.cols {
+		if col.Label == property {
+			return c.column(j, col)
+		}
+	}
+
+	// The property is not one of the columns so it is always null.
+	t, err := c.typeOf(n)
+	if err != nil {
+		return nil, err
+	}
+	return null(t)
+}
+
+func (c *rowCompiler) column(j int, col flux.ColMeta) (typedNode, error) {
+	switch col.Type {
+	case flux.TBool:
+		return &columnNode[bool]{t: semantic.BasicBool, j: j, read: readBool}, nil
+	case flux.TInt:
+		return &columnNode[int64]{t: semantic.BasicInt, j: j, read: readInt}, nil
+	case flux.TUInt:
+		return &columnNode[uint64]{t: semantic.BasicUint, j: j, read: readUInt}, nil
+	case flux.TFloat:
+		return &columnNode[float64]{t: semantic.BasicFloat, j: j, read: readFloat}, nil
+	case flux.TString:
+		return &columnNode[string]{t: semantic.BasicString, j: j, read: readString}, nil
+	case flux.TTime:
+		return &columnNode[values.Time]{t: semantic.BasicTime, j: j, read: readTime}, nil
+	default:
+		return nil, unimplemented("column %q of type %s cannot be specialized", col.Label, col.Type)
+	}
+}
+
+func (c *rowCompiler) compileUnary(n *semantic.UnaryExpression) (typedNode, error) {
+	x, err := c.compile(n.Argument)
+	if err != nil {
+		return nil, err
+	}
+	return unary(n.Operator, x)
+}
+
+// unary specializes a unary operator for the type of its operand.
+func unary(op ast.OperatorKind, x typedNode) (typedNode, error) {
+	t := x.Type()
+	switch op {
+	case ast.ExistsOperator:
+		switch x := x.(type) {
+		case typedExpr[bool]:
+			return &existsNode[bool]{x: x}, nil
+		case typedExpr[int64]:
+			return &existsNode[int64]{x: x}, nil
+		case typedExpr[uint64]:
+			return &existsNode[uint64]{x: x}, nil
+		case typedExpr[float64]:
+			return &existsNode[float64]{x: x}, nil
+		case typedExpr[string]:
+			return &existsNode[string]{x: x}, nil
+		case typedExpr[values.Time]:
+			return &existsNode[values.Time]{x: x}, nil
+		}
+	case ast.AdditionOperator:
+		return x, nil
+	case ast.SubtractionOperator:
+		switch t.Nature() {
+		case semantic.Int:
+			return &unaryNode[int64]{t: t, x: x.(typedExpr[int64]), fn: func(v int64) int64 { return -v }}, nil
+		case semantic.Float:
+			return &unaryNode[float64]{t: t, x: x.(typedExpr[float64]), fn: func(v float64) float64 { return -v }}, nil
+		}
+	case ast.NotOperator:
+		if t.Nature() == semantic.Bool {
+			return &unaryNode[bool]{t: t, x: x.(typedExpr[bool]), fn: func(v bool) bool { return !v }}, nil
+		}
+	}
+	return nil, unimplemented("unary operator %s on %s cannot be specialized", op, t)
+}
+
+func (c *rowCompiler) compileLogical(n *semantic.LogicalExpression) (typedNode, error) {
+	l, err := c.compile(n.Left)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.compile(n.Right)
+	if err != nil {
+		return nil, err
+	}
+	return logical(n.Operator, l, r)
+}
+
+func logical(op ast.LogicalOperatorKind, l, r typedNode) (typedNode, error) {
+	left, lok := l.(typedExpr[bool])
+	right, rok := r.(typedExpr[bool])
+	if !lok || !rok {
+		return nil, unimplemented("logical operator %s on %s and %s cannot be specialized", op, l.Type(), r.Type())
+	}
+	switch op {
+	case ast.AndOperator, ast.OrOperator:
+	default:
+		return nil, unimplemented("unknown logical operator %v", op)
+	}
+	return &logicalNode{operator: op, left: left, right: right}, nil
+}
+
+func (c *rowCompiler) compileConditional(n *semantic.ConditionalExpression) (typedNode, error) {
+	test, err := c.compile(n.Test)
+	if err != nil {
+		return nil, err
+	}
+	cons, err := c.compile(n.Consequent)
+	if err != nil {
+		return nil, err
+	}
+	alt, err := c.compile(n.Alternate)
+	if err != nil {
+		return nil, err
+	}
+	return conditional(test, cons, alt)
+}
+
+func conditional(test, cons, alt typedNode) (typedNode, error) {
+	cond, ok := test.(typedExpr[bool])
+	if !ok {
+		return nil, unimplemented("conditional test of type %s cannot be specialized", test.Type())
+	}
+	t := alt.Type()
+	if cons.Type().Nature() != t.Nature() {
+		return nil, unimplemented("conditional branches of type %s and %s cannot be specialized", cons.Type(), t)
+	}
+	switch t.Nature() {
+	case semantic.Bool:
+		return newConditionalNode[bool](t, cond, cons, alt), nil
+	case semantic.Int:
+		return newConditionalNode[int64](t, cond, cons, alt), nil
+	case semantic.UInt:
+		return newConditionalNode[uint64](t, cond, cons, alt), nil
+	case semantic.Float:
+		return newConditionalNode[float64](t, cond, cons, alt), nil
+	case semantic.String:
+		return newConditionalNode[string](t, cond, cons, alt), nil
+	case semantic.Time:
+		return newConditionalNode[values.Time](t, cond, cons, alt), nil
+	default:
+		return nil, unimplemented("conditional of type %s cannot be specialized", t)
+	}
+}
+
+func (c *rowCompiler) compileBinary(n *semantic.BinaryExpression) (typedNode, error) {
+	l, err := c.compile(n.Left)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.compile(n.Right)
+	if err != nil {
+		return nil, err
+	}
+	return binary(n.Operator, l, r)
+}
+
+// binary specializes a binary operator for the types of its operands.
+func binary(op ast.OperatorKind, l, r typedNode) (typedNode, error) {
+	lt, rt := l.Type().Nature(), r.Type().Nature()
+	switch op {
+	case ast.AdditionOperator, ast.SubtractionOperator, ast.MultiplicationOperator,
+		ast.DivisionOperator, ast.ModuloOperator, ast.PowerOperator:
+		if lt != rt {
+			break
+		}
+		switch lt {
+		case semantic.Int:
+			return arithmetic(op, semantic.BasicInt, l.(typedExpr[int64]), r.(typedExpr[int64]), true)
+		case semantic.UInt:
+			return arithmetic(op, semantic.BasicUint, l.(typedExpr[uint64]), r.(typedExpr[uint64]), true)
+		case semantic.Float:
+			return arithmetic(op, semantic.BasicFloat, l.(typedExpr[float64]), r.(typedExpr[float64]), false)
+		case semantic.String:
+			if op == ast.AdditionOperator {
+				return &binaryNode[string, string, string]{
+					t:     semantic.BasicString,
+					left:  l.(typedExpr[string]),
+					right: r.(typedExpr[string]),
+					fn:    func(l, r string) (string, error) { return l + r, nil },
+				}, nil
+			}
+		}
+	case ast.EqualOperator, ast.NotEqualOperator,
+		ast.LessThanOperator, ast.LessThanEqualOperator,
+		ast.GreaterThanOperator, ast.GreaterThanEqualOperator:
+		if node, ok := comparison(op, l, r); ok {
+			return node, nil
+		}
+	case ast.RegexpMatchOperator, ast.NotRegexpMatchOperator:
+		if lt != semantic.String || rt != semantic.Regexp {
+			break
+		}
+		match := op == ast.RegexpMatchOperator
+		return &binaryNode[string, *regexp.Regexp, bool]{
+			t:     semantic.BasicBool,
+			left:  l.(typedExpr[string]),
+			right: r.(typedExpr[*regexp.Regexp]),
+			fn: func(l string, r *regexp.Regexp) (bool, error) {
+				return r.MatchString(l) == match, nil
+			},
+		}, nil
+	}
+	return nil, unimplemented("binary operator %s on %s and %s cannot be specialized", op, lt, rt)
+}
+
+type number interface {
+	~int64 | ~uint64 | ~float64
+}
+
+type ordered interface {
+	~int64 | ~uint64 | ~float64 | ~string
+}
+
+// arithmetic specializes a math operator for operands of the same type.
+// Division and modulo by zero are errors for integral types.
+func arithmetic[T number](op ast.OperatorKind, t semantic.MonoType, l, r typedExpr[T], integral bool) (typedNode, error) {
+	var fn func(l, r T) (T, error)
+	switch op {
+	case ast.AdditionOperator:
+		fn = func(l, r T) (T, error) { return l + r, nil }
+	case ast.SubtractionOperator:
+		fn = func(l, r T) (T, error) { return l - r, nil }
+	case ast.MultiplicationOperator:
+		fn = func(l, r T) (T, error) { return l * r, nil }
+	case ast.DivisionOperator:
+		fn = func(l, r T) (T, error) {
+			if integral && r == 0 {
+				return 0, errors.Newf(codes.FailedPrecondition, "cannot divide by zero")
+			}
+			return l / r, nil
+		}
+	case ast.ModuloOperator:
+		if !integral {
+			return &binaryNode[T, T, T]{t: t, left: l, right: r, fn: func(l, r T) (T, error) {
+				return T(math.Mod(float64(l), float64(r))), nil
+			}}, nil
+		}
+		fn = func(l, r T) (T, error) {
+			if r == 0 {
+				return 0, errors.Newf(codes.FailedPrecondition, "cannot mod zero")
+			}
+			return modulo(l, r), nil
+		}
+	case ast.PowerOperator:
+		// The power of any two numbers is a float.
+		return &binaryNode[T, T, float64]{t: semantic.BasicFloat, left: l, right: r, fn: func(l, r T) (float64, error) {
+			return math.Pow(float64(l), float64(r)), nil
+		}}, nil
+	default:
+		return nil, unimplemented("unknown math operator %s", op)
+	}
+	return &binaryNode[T, T, T]{t: t, left: l, right: r, fn: fn}, nil
+}
+
+// modulo computes the remainder of two integral values.
+// The % operator is not defined for floats so it cannot be
+// used with the number constraint directly.
+func modulo[T number](l, r T) T {
+	switch l := any(l).(type) {
+	case int64:
+		return T(l % any(r).(int64))
+	case uint64:
+		return T(l % any(r).(uint64))
+	default:
+		panic(errors.Newf(codes.Internal, "modulo of non-integral type %T", l))
+	}
+}
+
+// comparison specializes a comparison operator. Numeric operands of
+// different types are compared in the same way as values.LookupBinaryFunction.
+func comparison(op ast.OperatorKind, l, r typedNode) (typedNode, bool) {
+	lt, rt := l.Type().Nature(), r.Type().Nature()
+	if lt == rt {
+		switch lt {
+		case semantic.Bool:
+			return compareBool(op, l.(typedExpr[bool]), r.(typedExpr[bool]))
+		case semantic.Int:
+			return compareSame(op, l.(typedExpr[int64]), r.(typedExpr[int64]))
+		case semantic.UInt:
+			return compareSame(op, l.(typedExpr[uint64]), r.(typedExpr[uint64]))
+		case semantic.Float:
+			return compareSame(op, l.(typedExpr[float64]), r.(typedExpr[float64]))
+		case semantic.String:
+			return compareSame(op, l.(typedExpr[string]), r.(typedExpr[string]))
+		case semantic.Time:
+			return compareSame(op, l.(typedExpr[values.Time]), r.(typedExpr[values.Time]))
+		}
+		return nil, false
+	}
+
+	switch {
+	case lt == semantic.Int && rt == semantic.UInt:
+		return compareMixed(op, l.(typedExpr[int64]), r.(typedExpr[uint64]), compareIntUInt)
+	case lt == semantic.UInt && rt == semantic.Int:
+		return compareMixed(op, l.(typedExpr[uint64]), r.(typedExpr[int64]), func(l uint64, r int64) int {
+			return -compareIntUInt(r, l)
+		})
+	case lt == semantic.Int && rt == semantic.Float:
+		return compareFloat(op, l.(typedExpr[int64]), r.(typedExpr[float64]))
+	case lt == semantic.UInt && rt == semantic.Float:
+		return compareFloat(op, l.(typedExpr[uint64]), r.(typedExpr[float64]))
+	case lt == semantic.Float && rt == semantic.Int:
+		return compareFloat(op, l.(typedExpr[float64]), r.(typedExpr[int64]))
+	case lt == semantic.Float && rt == semantic.UInt:
+		return compareFloat(op, l.(typedExpr[float64]), r.(typedExpr[uint64]))
+	}
+	return nil, false
+}
+
+func compareOp[T ordered](op ast.OperatorKind) func(l, r T) bool {
+	switch op {
+	case ast.EqualOperator:
+		return func(l, r T) bool { return l == r }
+	case ast.NotEqualOperator:
+		return func(l, r T) bool { return l != r }
+	case ast.LessThanOperator:
+		return func(l, r T) bool { return l < r }
+	case ast.LessThanEqualOperator:
+		return func(l, r T) bool { return l <= r }
+	case ast.GreaterThanOperator:
+		return func(l, r T) bool { return l > r }
+	case ast.GreaterThanEqualOperator:
+		return func(l, r T) bool { return l >= r }
+	default:
+		return nil
+	}
+}
+
+func compareSame[T ordered](op ast.OperatorKind, l, r typedExpr[T]) (typedNode, bool) {
+	cmp := compareOp[T](op)
+	if cmp == nil {
+		return nil, false
+	}
+	return &binaryNode[T, T, bool]{t: semantic.BasicBool, left: l, right: r, fn: func(l, r T) (bool, error) {
+		return cmp(l, r), nil
+	}}, true
+}
+
+func compareBool(op ast.OperatorKind, l, r typedExpr[bool]) (typedNode, bool) {
+	var fn func(l, r bool) (bool, error)
+	switch op {
+	case ast.EqualOperator:
+		fn = func(l, r bool) (bool, error) { return l == r, nil }
+	case ast.NotEqualOperator:
+		fn = func(l, r bool) (bool, error) { return l != r, nil }
+	default:
+		return nil, false
+	}
+	return &binaryNode[bool, bool, bool]{t: semantic.BasicBool, left: l, right: r, fn: fn}, true
+}
+
+// compareFloat compares an integral value with a float
+// by converting both operands to floats.
+func compareFloat[L, R number](op ast.OperatorKind, l typedExpr[L], r typedExpr[R]) (typedNode, bool) {
+	cmp := compareOp[float64](op)
+	if cmp == nil {
+		return nil, false
+	}
+	return &binaryNode[L, R, bool]{t: semantic.BasicBool, left: l, right: r, fn: func(l L, r R) (bool, error) {
+		return cmp(float64(l), float64(r)), nil
+	}}, true
+}
+
+// compareMixed compares two values using a three-way comparison function.
+func compareMixed[L, R any](op ast.OperatorKind, l typedExpr[L], r typedExpr[R], compare func(l L, r R) int) (typedNode, bool) {
+	cmp := compareOp[int64](op)
+	if cmp == nil {
+		return nil, false
+	}
+	return &binaryNode[L, R, bool]{t: semantic.BasicBool, left: l, right: r, fn: func(l L, r R) (bool, error) {
+		return cmp(int64(compare(l, r)), 0), nil
+	}}, true
+}
+
+// compareIntUInt returns -1, 0, or 1 if the int is less than,
+// equal to, or greater than the uint. A negative int is less
+// than every uint.
+func compareIntUInt(l int64, r uint64) int {
+	if l < 0 {
+		return -1
+	}
+	switch u := uint64(l); {
+	case u < r:
+		return -1
+	case u > r:
+		return 1
+	default:
+		return 0
+	}
+}
+
+type constNode[T any] struct {
+	t     semantic.MonoType
+	v     T
+	valid bool
+}
+
+func (n *constNode[T]) Type() semantic.MonoType {
+	return n.t
+}
+
+func (n *constNode[T]) eval(r typedRow) (T, bool, error) {
+	return n.v, n.valid, nil
+}
+
+func (n *constNode[T]) value(r typedRow) (values.Value, error) {
+	return box(n.t, n.v, n.valid), nil
+}
+
+// columnNode reads a property of the record parameter from its column.
+type columnNode[T any] struct {
+	t    semantic.MonoType
+	j    int
+	read func(cr flux.ColReader, j, i int) (T, bool)
+}
+
+func (n *columnNode[T]) Type() semantic.MonoType {
+	return n.t
+}
+
+func (n *columnNode[T]) eval(r typedRow) (T, bool, error) {
+	v, ok := n.read(r.cr, n.j, r.i)
+	return v, ok, nil
+}
+
+func (n *columnNode[T]) value(r typedRow) (values.Value, error) {
+	v, ok := n.read(r.cr, n.j, r.i)
+	return box(n.t, v, ok), nil
+}
+
+func readBool(cr flux.ColReader, j, i int) (bool, bool) {
+	arr := cr.Bools(j)
+	if arr.IsNull(i) {
+		return false, false
+	}
+	return arr.Value(i), true
+}
+
+func readInt(cr flux.ColReader, j, i int) (int64, bool) {
+	arr := cr.Ints(j)
+	if arr.IsNull(i) {
+		return 0, false
+	}
+	return arr.Value(i), true
+}
+
+func readUInt(cr flux.ColReader, j, i int) (uint64, bool) {
+	arr := cr.UInts(j)
+	if arr.IsNull(i) {
+		return 0, false
+	}
+	return arr.Value(i), true
+}
+
+func readFloat(cr flux.ColReader, j, i int) (float64, bool) {
+	arr := cr.Floats(j)
+	if arr.IsNull(i) {
+		return 0, false
+	}
+	return arr.Value(i), true
+}
+
+func readString(cr flux.ColReader, j, i int) (string, bool) {
+	arr := cr.Strings(j)
+	if arr.IsNull(i) {
+		return "", false
+	}
+	return arr.Value(i), true
+}
+
+func readTime(cr flux.ColReader, j, i int) (values.Time, bool) {
+	arr := cr.Times(j)
+	if arr.IsNull(i) {
+		return 0, false
+	}
+	return values.Time(arr.Value(i)), true
+}
+
+// binaryNode applies a function to two operands.
+// The result is null if either operand is null.
+type binaryNode[L, R, T any] struct {
+	t     semantic.MonoType
+	left  typedExpr[L]
+	right typedExpr[R]
+	fn    func(l L, r R) (T, error)
+}
+
+func (n *binaryNode[L, R, T]) Type() semantic.MonoType {
+	return n.t
+}
+
+func (n *binaryNode[L, R, T]) eval(r typedRow) (T, bool, error) {
+	var zero T
+	lv, lok, err := n.left.eval(r)
+	if err != nil {
+		return zero, false, err
+	}
+	rv, rok, err := n.right.eval(r)
+	if err != nil {
+		return zero, false, err
+	}
+	if !lok || !rok {
+		return zero, false, nil
+	}
+	v, err := n.fn(lv, rv)
+	if err != nil {
+		return zero, false, err
+	}
+	return v, true, nil
+}
+
+func (n *binaryNode[L, R, T]) value(r typedRow) (values.Value, error) {
+	v, ok, err := n.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	return box(n.t, v, ok), nil
+}
+
+// unaryNode applies a function to a single operand.
+// The result is null if the operand is null.
+type unaryNode[T any] struct {
+	t  semantic.MonoType
+	x  typedExpr[T]
+	fn func(v T) T
+}
+
+func (n *unaryNode[T]) Type() semantic.MonoType {
+	return n.t
+}
+
+func (n *unaryNode[T]) eval(r typedRow) (T, bool, error) {
+	v, ok, err := n.x.eval(r)
+	if err != nil || !ok {
+		return v, false, err
+	}
+	return n.fn(v), true, nil
+}
+
+func (n *unaryNode[T]) value(r typedRow) (values.Value, error) {
+	v, ok, err := n.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	return box(n.t, v, ok), nil
+}
+
+type existsNode[T any] struct {
+	x typedExpr[T]
+}
+
+func (n *existsNode[T]) Type() semantic.MonoType {
+	return semantic.BasicBool
+}
+
+func (n *existsNode[T]) eval(r typedRow) (bool, bool, error) {
+	_, ok, err := n.x.eval(r)
+	if err != nil {
+		return false, false, err
+	}
+	return ok, true, nil
+}
+
+func (n *existsNode[T]) value(r typedRow) (values.Value, error) {
+	v, ok, err := n.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	return box(semantic.BasicBool, v, ok), nil
+}
+
+// logicalNode short circuits in the same way as logicalEvaluator.
+// A null left operand is treated as false and the right operand
+// is returned as is when it determines the result.
+type logicalNode struct {
+	operator    ast.LogicalOperatorKind
+	left, right typedExpr[bool]
+}
+
+func (n *logicalNode) Type() semantic.MonoType {
+	return semantic.BasicBool
+}
+
+func (n *logicalNode) eval(r typedRow) (bool, bool, error) {
+	l, ok, err := n.left.eval(r)
+	if err != nil {
+		return false, false, err
+	}
+	switch n.operator {
+	case ast.AndOperator:
+		if !ok || !l {
+			return false, true, nil
+		}
+	case ast.OrOperator:
+		if ok && l {
+			return true, true, nil
+		}
+	}
+	return n.right.eval(r)
+}
+
+func (n *logicalNode) value(r typedRow) (values.Value, error) {
+	v, ok, err := n.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	return box(semantic.BasicBool, v, ok), nil
+}
+
+// conditionalNode evaluates the alternate when the test is null or false.
+type conditionalNode[T any] struct {
+	t          semantic.MonoType
+	test       typedExpr[bool]
+	consequent typedExpr[T]
+	alternate  typedExpr[T]
+}
+
+func newConditionalNode[T any](t semantic.MonoType, test typedExpr[bool], consequent, alternate typedNode) typedNode {
+	return &conditionalNode[T]{
+		t:          t,
+		test:       test,
+		consequent: consequent.(typedExpr[T]),
+		alternate:  alternate.(typedExpr[T]),
+	}
+}
+
+func (n *conditionalNode[T]) Type() semantic.MonoType {
+	return n.t
+}
+
+func (n *conditionalNode[T]) eval(r typedRow) (T, bool, error) {
+	v, ok, err := n.test.eval(r)
+	if err != nil {
+		var zero T
+		return zero, false, err
+	}
+	if !ok || !v {
+		return n.alternate.eval(r)
+	}
+	return n.consequent.eval(r)
+}
+
+func (n *conditionalNode[T]) value(r typedRow) (values.Value, error) {
+	v, ok, err := n.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	return box(n.t, v, ok), nil
+}
+
+// recordNode builds the record returned by the function.
+// When the record parameter is extended, its columns are
+// listed before the properties of the record literal.
+type recordNode struct {
+	t          semantic.MonoType
+	labels     []string
+	properties []typedNode
+}
+
+func (n *recordNode) Type() semantic.MonoType {
+	return n.t
+}
+
+func (n *recordNode) value(r typedRow) (values.Value, error) {
+	return values.BuildObjectWithSize(len(n.labels), func(set values.ObjectSetter) error {
+		for i, p := range n.properties {
+			v, err := p.value(r)
+			if err != nil {
+				return err
+			}
+			set(n.labels[i], v)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,221 @@
+package compiler
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"testing"
+
+	"github.com/InfluxCommunity/flux/ast"
+	"github.com/InfluxCommunity/flux/semantic"
+	"github.com/InfluxCommunity/flux/values"
+)
+
+// typedOperands are the operands used to check that the typed
+// evaluators produce the same results as the boxed evaluators.
+var typedOperands = []values.Value{
+	values.NewBool(true),
+	values.NewBool(false),
+	values.NewNull(semantic.BasicBool),
+	values.NewInt(-3),
+	values.NewInt(0),
+	values.NewInt(7),
+	values.NewNull(semantic.BasicInt),
+	values.NewUInt(0),
+	values.NewUInt(3),
+	values.NewUInt(7),
+	values.NewNull(semantic.BasicUint),
+	values.NewFloat(-1.5),
+	values.NewFloat(0),
+	values.NewFloat(7),
+	values.NewNull(semantic.BasicFloat),
+	values.NewString("a"),
+	values.NewString("cpu"),
+	values.NewNull(semantic.BasicString),
+	values.NewTime(0),
+	values.NewTime(1e9),
+	values.NewNull(semantic.BasicTime),
+	values.NewRegexp(regexp.MustCompile(`^c`)),
+}
+
+func TestTypedBinary(t *testing.T) {
+	operators := []ast.OperatorKind{
+		ast.AdditionOperator,
+		ast.SubtractionOperator,
+		ast.MultiplicationOperator,
+		ast.DivisionOperator,
+		ast.ModuloOperator,
+		ast.PowerOperator,
+		ast.EqualOperator,
+		ast.NotEqualOperator,
+		ast.LessThanOperator,
+		ast.LessThanEqualOperator,
+		ast.GreaterThanOperator,
+		ast.GreaterThanEqualOperator,
+		ast.RegexpMatchOperator,
+		ast.NotRegexpMatchOperator,
+	}
+	for _, op := range operators {
+		for _, lv := range typedOperands {
+			for _, rv := range typedOperands {
+				f, err := values.LookupBinaryFunction(values.BinaryFuncSignature{
+					Operator: op,
+					Left:     lv.Type().Nature(),
+					Right:    rv.Type().Nature(),
+				})
+				if err != nil {
+					continue
+				}
+
+				name := fmt.Sprintf("%v %v %v", lv, op, rv)
+				l, err := constant(lv)
+				if err != nil {
+					t.Fatal(err)
+				}
+				r, err := constant(rv)
+				if err != nil {
+					t.Fatal(err)
+				}
+				node, err := binary(op, l, r)
+				if err != nil {
+					t.Errorf("%s: unexpected error: %s", name, err)
+					continue
+				}
+
+				want, wantErr := f(lv, rv)
+				got, gotErr := node.value(typedRow{})
+				checkTyped(t, name, want, wantErr, got, gotErr)
+			}
+		}
+	}
+}
+
+func TestTypedUnary(t *testing.T) {
+	operators := []ast.OperatorKind{
+		ast.AdditionOperator,
+		ast.SubtractionOperator,
+		ast.NotOperator,
+		ast.ExistsOperator,
+	}
+	for _, op := range operators {
+		for _, v := range typedOperands {
+			name := fmt.Sprintf("%v %v", op, v)
+			x, err := constant(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			node, err := unary(op, x)
+			if err != nil {
+				// Only check the operators that can be specialized.
+				continue
+			}
+
+			want, wantErr := doUnary(v.Type(), op, v)
+			got, gotErr := node.value(typedRow{})
+			checkTyped(t, name, want, wantErr, got, gotErr)
+		}
+	}
+}
+
+func TestTypedLogical(t *testing.T) {
+	bools := []values.Value{
+		values.NewBool(true),
+		values.NewBool(false),
+		values.NewNull(semantic.BasicBool),
+	}
+	for _, op := range []ast.LogicalOperatorKind{ast.AndOperator, ast.OrOperator} {
+		for _, lv := range bools {
+			for _, rv := range bools {
+				name := fmt.Sprintf("%v %v %v", lv, op, rv)
+				l, _ := constant(lv)
+				r, _ := constant(rv)
+				node, err := logical(op, l, r)
+				if err != nil {
+					t.Fatalf("%s: unexpected error: %s", name, err)
+				}
+
+				e := &logicalEvaluator{
+					operator: op,
+					left:     valueEvaluator{v: lv},
+					right:    valueEvaluator{v: rv},
+				}
+				want, wantErr := e.Eval(context.Background(), nil)
+				got, gotErr := node.value(typedRow{})
+				checkTyped(t, name, want, wantErr, got, gotErr)
+			}
+		}
+	}
+}
+
+func TestTypedConditional(t *testing.T) {
+	tests := []values.Value{
+		values.NewBool(true),
+		values.NewBool(false),
+		values.NewNull(semantic.BasicBool),
+	}
+	for _, tv := range tests {
+		name := fmt.Sprintf("if %v", tv)
+		test, _ := constant(tv)
+		cons, _ := constant(values.NewInt(1))
+		alt, _ := null(semantic.BasicInt)
+		node, err := conditional(test, cons, alt)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", name, err)
+		}
+
+		e := &conditionalEvaluator{
+			test:       valueEvaluator{v: tv},
+			consequent: valueEvaluator{v: values.NewInt(1)},
+			alternate:  valueEvaluator{v: values.NewNull(semantic.BasicInt)},
+		}
+		want, wantErr := e.Eval(context.Background(), nil)
+		got, gotErr := node.value(typedRow{})
+		checkTyped(t, name, want, wantErr, got, gotErr)
+	}
+}
+
+func checkTyped(t *testing.T, name string, want values.Value, wantErr error, got values.Value, gotErr error) {
+	t.Helper()
+	if (wantErr != nil) != (gotErr != nil) {
+		t.Errorf("%s: unexpected error -want/+got:\n\t- %v\n\t+ %v", name, wantErr, gotErr)
+		return
+	} else if wantErr != nil {
+		if wantErr.Error() != gotErr.Error() {
+			t.Errorf("%s: unexpected error -want/+got:\n\t- %v\n\t+ %v", name, wantErr, gotErr)
+		}
+		return
+	}
+
+	// The boxed evaluators may return an untyped null so
+	// only check that both results are null.
+	if want.IsNull() || got.IsNull() {
+		if want.IsNull() != got.IsNull() {
+			t.Errorf("%s: unexpected value -want/+got:\n\t- %v\n\t+ %v", name, want, got)
+		}
+		return
+	}
+	if isNaN(want) && isNaN(got) {
+		return
+	}
+	if !want.Equal(got) {
+		t.Errorf("%s: unexpected value -want/+got:\n\t- %v\n\t+ %v", name, want, got)
+	}
+}
+
+func isNaN(v values.Value) bool {
+	return v.Type().Nature() == semantic.Float && math.IsNaN(v.Float())
+}
+
+// valueEvaluator is an Evaluator that returns a fixed value.
+type valueEvaluator struct {
+	v values.Value
+}
+
+func (e valueEvaluator) Type() semantic.MonoType {
+	return e.v.Type()
+}
+
+func (e valueEvaluator) Eval(ctx context.Context, scope Scope) (values.Value, error) {
+	return e.v, nil
+}
@@ -0,0 +1,175 @@
+package compiler_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/compiler"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/executetest"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/InfluxCommunity/flux/semantic"
+	"github.com/InfluxCommunity/flux/values"
+)
+
+func TestCompileRow(t *testing.T) {
+	cols := []flux.ColMeta{
+		{Label: "_time", Type: flux.TTime},
+		{Label: "_value", Type: flux.TFloat},
+		{Label: "n", Type: flux.TInt},
+		{Label: "u", Type: flux.TUInt},
+		{Label: "host", Type: flux.TString},
+		{Label: "ok", Type: flux.TBool},
+	}
+	data := [][]interface{}{
+		{values.Time(0), 1.5, int64(2), uint64(3), "a", true},
+		{values.Time(10), nil, int64(0), uint64(0), "cpu", false},
+		{values.Time(20), -2.0, int64(-1), uint64(7), "cpu0", nil},
+		{nil, nil, nil, nil, nil, nil},
+	}
+
+	testCases := []struct {
+		name        string
+		fn          string
+		specialized bool
+	}{
+		{name: "comparison", fn: `(r) => r._value > 1.0`, specialized: true},
+		{name: "scope value", fn: `(r) => r._value > x`, specialized: true},
+		{name: "arithmetic", fn: `(r) => r.n * 2 + 1`, specialized: true},
+		{name: "divide by zero", fn: `(r) => 10 / r.n`, specialized: true},
+		{name: "modulo", fn: `(r) => r.u % 2 == 0`, specialized: true},
+		{name: "power", fn: `(r) => r._value ^ 2.0`, specialized: true},
+		{name: "mixed comparison", fn: `(r) => r.n < r.u and r.n <= r._value`, specialized: true},
+		{name: "string concatenation", fn: `(r) => r.host + "-" + r.host`, specialized: true},
+		{name: "regexp literal", fn: `(r) => r.host =~ /^cpu/`, specialized: true},
+		{name: "regexp from scope", fn: `(r) => r.host !~ re`, specialized: true},
+		{name: "time comparison", fn: `(r) => r._time >= 1970-01-01T00:00:00.00000001Z`, specialized: true},
+		{name: "logical", fn: `(r) => not r.ok or exists r._value`, specialized: true},
+		{name: "conditional", fn: `(r) => if r.ok then r.host else "none"`, specialized: true},
+		{name: "unary", fn: `(r) => -r._value`, specialized: true},
+		{name: "missing column", fn: `(r) => r.missing + 1`, specialized: true},
+		{name: "record", fn: `(r) => ({v: r._value * 2.0, h: r.host})`, specialized: true},
+		{name: "record with", fn: `(r) => ({r with _value: r._value * 2.0, ok: exists r.ok})`, specialized: true},
+		{name: "string interpolation", fn: `(r) => "${r.host}"`},
+		{name: "function call", fn: `(r) => r._value > float(v: r.n)`},
+		{name: "block", fn: "(r) => {\nv = r.n\nreturn v + 1\n}"},
+		{name: "nested record", fn: `(r) => ({a: {b: r.n}})`},
+		{name: "record parameter", fn: `(r) => r`},
+		{name: "duration", fn: `(r) => 1h`},
+	}
+
+	// The values in scope are also declared in the source
+	// so the functions can be type checked.
+	prelude := "x = 1.0\nre = /^cpu/\n"
+	scope := compiler.NewScope()
+	scope.Set("x", values.NewFloat(1))
+	scope.Set("re", values.NewRegexp(regexp.MustCompile(`^cpu`)))
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			pkg, err := runtime.AnalyzeSource(ctx, prelude+tc.fn)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			body := pkg.Files[0].Body
+			stmt := body[len(body)-1].(*semantic.ExpressionStatement)
+			fn := stmt.Expression.(*semantic.FunctionExpression)
+
+			properties := make([]semantic.PropertyType, len(cols))
+			for i, c := range cols {
+				properties[i] = semantic.PropertyType{Key: []byte(c.Label), Value: flux.SemanticType(c.Type)}
+			}
+			inType := semantic.NewObjectType([]semantic.PropertyType{
+				{Key: []byte("r"), Value: semantic.NewObjectType(properties)},
+			})
+			boxed, err := compiler.Compile(ctx, scope, fn, inType)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			typed, err := compiler.CompileRow(ctx, scope, fn, cols)
+			if !tc.specialized {
+				if err == nil {
+					t.Fatal("expected function to not be specialized")
+				} else if code := errors.Code(err); code != codes.Unimplemented {
+					t.Fatalf("unexpected error code: %s", code)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if want, got := boxed.Type().String(), typed.Type().String(); want != got {
+				t.Fatalf("unexpected return type -want/+got:\n\t- %s\n\t+ %s", want, got)
+			}
+
+			tbl := &executetest.Table{ColMeta: cols, Data: data}
+			if err := tbl.Do(func(cr flux.ColReader) error {
+				for i := 0; i < cr.Len(); i++ {
+					record := make(map[string]values.Value, len(cols))
+					for j, c := range cols {
+						record[c.Label] = execute.ValueForRow(cr, i, j)
+					}
+					input := values.NewObjectWithValues(map[string]values.Value{
+						"r": values.NewObjectWithValues(record),
+					})
+
+					want, wantErr := boxed.Eval(ctx, input)
+					got, gotErr := typed.EvalRow(ctx, i, cr)
+					if (wantErr != nil) != (gotErr != nil) {
+						t.Fatalf("row %d: unexpected error -want/+got:\n\t- %v\n\t+ %v", i, wantErr, gotErr)
+					} else if wantErr != nil {
+						continue
+					}
+					if !equalRowValues(want, got) {
+						t.Errorf("row %d: unexpected value -want/+got:\n\t- %v\n\t+ %v", i, want, got)
+					}
+
+					if boxed.Type().Nature() == semantic.Bool {
+						ok, err := typed.EvalRowBool(ctx, i, cr)
+						if err != nil {
+							t.Fatalf("row %d: unexpected error: %s", i, err)
+						}
+						if want := !want.IsNull() && want.Bool(); want != ok {
+							t.Errorf("row %d: unexpected predicate result -want/+got:\n\t- %v\n\t+ %v", i, want, ok)
+						}
+					}
+				}
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// equalRowValues compares two values while ignoring the
+// type of null values. Compile may produce an untyped null
+// where CompileRow produces a null of the expression type.
+func equalRowValues(want, got values.Value) bool {
+	if want.IsNull() || got.IsNull() {
+		return want.IsNull() == got.IsNull()
+	}
+	if want.Type().Nature() != semantic.Object {
+		return want.Equal(got)
+	}
+
+	wobj, gobj := want.Object(), got.Object()
+	if wobj.Len() != gobj.Len() {
+		return false
+	}
+	equal := true
+	wobj.Range(func(name string, wv values.Value) {
+		gv, ok := gobj.Get(name)
+		if !ok || !equalRowValues(wv, gv) {
+			equal = false
+		}
+	})
+	return equal
+}
@@ -0,0 +1,300 @@
+package compiler
+
+import (
+	"context"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/ast"
+	"github.com/InfluxCommunity/flux/semantic"
+	"github.com/InfluxCommunity/flux/values"
+)
+
+// CompileVector compiles an expression of a record parameter into a
+// function that evaluates it for every row of a table at once.
+//
+// The analyzer only vectorizes functions with a single record parameter.
+// CompileVector vectorizes a single expression instead, such as the part of
+// a function with other parameters that only depends on the record, with
+// the same evaluators that are used for the functions vectorized by the
+// analyzer. The function takes the same input as those functions: the
+// record parameter is a record with a vector for each of the columns.
+// It returns a vector with an element for each row.
+//
+// An expression can be vectorized when it only refers to members of
+// the record parameter, literals, and values from the scope with a basic
+// type, and combines them with operators that have a vectorized
+// implementation for the types of their operands.
+//
+// If the expression cannot be vectorized, an error with the code
+// codes.Unimplemented is returned.
+func CompileVector(ctx context.Context, scope Scope, e semantic.Expression, recordName string, cols []flux.ColMeta) (Func, error) {
+	if scope == nil {
+		scope = NewScope()
+	}
+
+	properties := make([]semantic.PropertyType, len(cols))
+	for i, c := range cols {
+		typ := flux.SemanticType(c.Type)
+		if typ.Kind() == semantic.Unknown {
+			return nil, unimplemented("unknown column type: %s", c.Type)
+		}
+		properties[i] = semantic.PropertyType{
+			Key:   []byte(c.Label),
+			Value: semantic.NewVectorType(typ),
+		}
+	}
+
+	c := &vectorCompiler{
+		scope:      scope,
+		recordName: recordName,
+		recordType: semantic.NewObjectType(properties),
+		cols:       cols,
+	}
+	root, err := c.compile(e)
+	if err != nil {
+		return nil, err
+	}
+	return compiledFn{
+		root:        root,
+		parentScope: scope,
+	}, nil
+}
+
+type vectorCompiler struct {
+	scope      Scope
+	recordName string
+	recordType semantic.MonoType
+	cols       []flux.ColMeta
+}
+
+func (c *vectorCompiler) compile(n semantic.Expression) (Evaluator, error) {
+	switch n := n.(type) {
+	case *semantic.BooleanLiteral:
+		return repeat(&booleanEvaluator{b: n.Value}, semantic.BasicBool), nil
+	case *semantic.IntegerLiteral:
+		return repeat(&integerEvaluator{i: n.Value}, semantic.BasicInt), nil
+	case *semantic.UnsignedIntegerLiteral:
+		return repeat(&unsignedIntegerEvaluator{i: n.Value}, semantic.BasicUint), nil
+	case *semantic.FloatLiteral:
+		return repeat(&floatEvaluator{f: n.Value}, semantic.BasicFloat), nil
+	case *semantic.StringLiteral:
+		return repeat(&stringEvaluator{s: n.Value}, semantic.BasicString), nil
+	case *semantic.DateTimeLiteral:
+		return repeat(&timeEvaluator{time: values.ConvertTime(n.Value)}, semantic.BasicTime), nil
+	case *semantic.IdentifierExpression:
+		return c.compileIdentifier(n)
+	case *semantic.MemberExpression:
+		return c.compileMember(n)
+	case *semantic.UnaryExpression:
+		return c.compileUnary(n)
+	case *semantic.LogicalExpression:
+		return c.compileLogical(n)
+	case *semantic.ConditionalExpression:
+		return c.compileConditional(n)
+	case *semantic.BinaryExpression:
+		return c.compileBinary(n)
+	default:
+		return nil, unimplemented("%s cannot be vectorized", n.NodeType())
+	}
+}
+
+// repeat returns an evaluator that repeats the value
+// of a scalar evaluator for every row.
+func repeat(e Evaluator, t semantic.MonoType) Evaluator {
+	return &constVectorEvaluator{
+		t: semantic.NewVectorType(t),
+		v: e,
+	}
+}
+
+func (c *vectorCompiler) compileIdentifier(n *semantic.IdentifierExpression) (Evaluator, error) {
+	name := n.Name.Name()
+	if name == c.recordName {
+		return nil, unimplemented("the record parameter can only be used in a member expression")
+	}
+
+	v, ok := c.scope.Lookup(name)
+	if !ok || v.IsNull() {
+		return nil, unimplemented("identifier %q cannot be vectorized", name)
+	}
+	switch v.Type().Nature() {
+	case semantic.Bool, semantic.Int, semantic.UInt, semantic.Float, semantic.String, semantic.Time:
+	default:
+		return nil, unimplemented("identifier %q with type %s cannot be vectorized", name, v.Type())
+	}
+	return repeat(&identifierEvaluator{t: v.Type(), name: name}, v.Type()), nil
+}
+
+func (c *vectorCompiler) compileMember(n *semantic.MemberExpression) (Evaluator, error) {
+	if id, ok := n.Object.(*semantic.IdentifierExpression); !ok || id.Name.Name() != c.recordName {
+		return nil, unimplemented("only members of the record parameter can be vectorized")
+	}
+
+	property := n.Property.Name()
+	for _, col := range c.cols {
+		if col.Label == property {
+			return &memberEvaluator{
+				t: semantic.NewVectorType(flux.SemanticType(col.Type)),
+				object: &identifierEvaluator{
+					t:    c.recordType,
+					name: c.recordName,
+				},
+				property: property,
+				nullable: true,
+			}, nil
+		}
+	}
+	return nil, unimplemented("column %q is not in the table", property)
+}
+
+func (c *vectorCompiler) compileUnary(n *semantic.UnaryExpression) (Evaluator, error) {
+	node, err := c.compile(n.Argument)
+	if err != nil {
+		return nil, err
+	}
+
+	t := node.Type()
+	switch elem := elemNature(t); n.Operator {
+	case ast.SubtractionOperator:
+		if elem != semantic.Int && elem != semantic.Float {
+			return nil, unimplemented("cannot vectorize negation of %s", elem)
+		}
+	case ast.NotOperator:
+		if elem != semantic.Bool {
+			return nil, unimplemented("cannot vectorize not of %s", elem)
+		}
+	case ast.ExistsOperator:
+		t = semantic.NewVectorType(semantic.BasicBool)
+	default:
+		return nil, unimplemented("cannot vectorize unary operator %s", n.Operator)
+	}
+	return &unaryVectorEvaluator{
+		t:    t,
+		node: node,
+		op:   n.Operator,
+	}, nil
+}
+
+func (c *vectorCompiler) compileLogical(n *semantic.LogicalExpression) (Evaluator, error) {
+	l, err := c.compile(n.Left)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.compile(n.Right)
+	if err != nil {
+		return nil, err
+	}
+	if elemNature(l.Type()) != semantic.Bool || elemNature(r.Type()) != semantic.Bool {
+		return nil, unimplemented("logical operands must be booleans")
+	}
+	return &logicalVectorEvaluator{
+		operator: n.Operator,
+		left:     l,
+		right:    r,
+	}, nil
+}
+
+func (c *vectorCompiler) compileConditional(n *semantic.ConditionalExpression) (Evaluator, error) {
+	test, err := c.compile(n.Test)
+	if err != nil {
+		return nil, err
+	}
+	consequent, err := c.compile(n.Consequent)
+	if err != nil {
+		return nil, err
+	}
+	alternate, err := c.compile(n.Alternate)
+	if err != nil {
+		return nil, err
+	}
+	if elemNature(test.Type()) != semantic.Bool {
+		return nil, unimplemented("conditional test must be a boolean")
+	} else if elemNature(consequent.Type()) != elemNature(alternate.Type()) {
+		return nil, unimplemented("conditional branches must have the same type")
+	}
+	return &conditionalVectorEvaluator{
+		test:       test,
+		consequent: consequent,
+		alternate:  alternate,
+	}, nil
+}
+
+func (c *vectorCompiler) compileBinary(n *semantic.BinaryExpression) (Evaluator, error) {
+	l, err := c.compile(n.Left)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.compile(n.Right)
+	if err != nil {
+		return nil, err
+	}
+
+	t, ok := vectorBinaryType(n.Operator, elemNature(l.Type()), elemNature(r.Type()))
+	if !ok {
+		return nil, unimplemented("cannot vectorize %s %s %s", l.Type(), n.Operator, r.Type())
+	}
+	f, err := values.LookupBinaryVectorFunction(values.BinaryFuncSignature{
+		Operator: n.Operator,
+		Left:     semantic.Vector,
+		Right:    semantic.Vector,
+	})
+	if err != nil {
+		return nil, unimplemented("cannot vectorize binary operator %s", n.Operator)
+	}
+	return &binaryVectorEvaluator{
+		t:     semantic.NewVectorType(t),
+		left:  l,
+		right: r,
+		f:     f,
+	}, nil
+}
+
+// vectorBinaryType returns the element type of a binary operation on
+// vectors with the given element types. It reports false if there is no
+// vectorized implementation of the operator for those types.
+func vectorBinaryType(op ast.OperatorKind, l, r semantic.Nature) (semantic.MonoType, bool) {
+	isNumber := func(n semantic.Nature) bool {
+		return n == semantic.Int || n == semantic.UInt || n == semantic.Float
+	}
+	switch op {
+	case ast.AdditionOperator:
+		if l == r && l == semantic.String {
+			return semantic.BasicString, true
+		}
+		fallthrough
+	case ast.SubtractionOperator, ast.MultiplicationOperator, ast.DivisionOperator,
+		ast.ModuloOperator, ast.PowerOperator:
+		// Arithmetic is only implemented for operands of the same type.
+		if l != r || !isNumber(l) {
+			return semantic.MonoType{}, false
+		}
+		switch l {
+		case semantic.Int:
+			return semantic.BasicInt, true
+		case semantic.UInt:
+			return semantic.BasicUint, true
+		default:
+			return semantic.BasicFloat, true
+		}
+	case ast.EqualOperator, ast.NotEqualOperator:
+		if l == r && l == semantic.Bool {
+			return semantic.BasicBool, true
+		}
+		fallthrough
+	case ast.LessThanOperator, ast.LessThanEqualOperator,
+		ast.GreaterThanOperator, ast.GreaterThanEqualOperator:
+		if (isNumber(l) && isNumber(r)) || (l == r && (l == semantic.String || l == semantic.Time)) {
+			return semantic.BasicBool, true
+		}
+	}
+	return semantic.MonoType{}, false
+}
+
+// elemNature returns the nature of the elements of a vector type.
+func elemNature(t semantic.MonoType) semantic.Nature {
+	elem, err := t.ElemType()
+	if err != nil {
+		return semantic.Invalid
+	}
+	return elem.Nature()
+}
@@ -0,0 +1,212 @@
+package compiler_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/array"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/compiler"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/executetest"
+	"github.com/InfluxCommunity/flux/execute/table"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/InfluxCommunity/flux/semantic"
+	"github.com/InfluxCommunity/flux/values"
+)
+
+func TestCompileVector(t *testing.T) {
+	cols := []flux.ColMeta{
+		{Label: "_time", Type: flux.TTime},
+		{Label: "_value", Type: flux.TFloat},
+		{Label: "n", Type: flux.TInt},
+		{Label: "u", Type: flux.TUInt},
+		{Label: "host", Type: flux.TString},
+		{Label: "ok", Type: flux.TBool},
+	}
+	data := [][]interface{}{
+		{values.Time(0), 1.5, int64(2), uint64(3), "a", true},
+		{values.Time(10), nil, int64(0), uint64(0), "cpu", false},
+		{values.Time(20), -2.0, int64(-1), uint64(7), "cpu0", nil},
+		{nil, nil, nil, nil, nil, nil},
+	}
+
+	testCases := []struct {
+		name       string
+		fn         string
+		vectorized bool
+	}{
+		{name: "comparison", fn: `(r) => r._value > 1.0`, vectorized: true},
+		{name: "scope value", fn: `(r) => r._value > x`, vectorized: true},
+		{name: "arithmetic", fn: `(r) => r.n * 2 + 1`, vectorized: true},
+		{name: "divide by zero", fn: `(r) => 10 / r.n`, vectorized: true},
+		{name: "power", fn: `(r) => r._value ^ 2.0`, vectorized: true},
+		{name: "mixed comparison", fn: `(r) => r.n < r.u and r.n <= r._value`, vectorized: true},
+		{name: "string concatenation", fn: `(r) => r.host + "-" + r.host`, vectorized: true},
+		{name: "time comparison", fn: `(r) => r._time >= 1970-01-01T00:00:00.00000001Z`, vectorized: true},
+		{name: "logical", fn: `(r) => not r.ok or exists r._value`, vectorized: true},
+		{name: "conditional", fn: `(r) => if r.ok then r.host else "none"`, vectorized: true},
+		{name: "unary", fn: `(r) => -r._value`, vectorized: true},
+		{name: "constant", fn: `(r) => 1 + 2`, vectorized: true},
+		{name: "mixed arithmetic", fn: `(r) => r.u % 2 == 0`},
+		{name: "regexp", fn: `(r) => r.host =~ /^cpu/`},
+		{name: "regexp from scope", fn: `(r) => r.host !~ re`},
+		{name: "missing column", fn: `(r) => r.missing + 1`},
+		{name: "record", fn: `(r) => ({v: r._value * 2.0})`},
+		{name: "string interpolation", fn: `(r) => "${r.host}"`},
+		{name: "function call", fn: `(r) => r._value > float(v: r.n)`},
+		{name: "record parameter", fn: `(r) => r`},
+		{name: "duration", fn: `(r) => 1h`},
+	}
+
+	// The values in scope are also declared in the source
+	// so the functions can be type checked.
+	prelude := "x = 1.0\nre = /^cpu/\n"
+	scope := compiler.NewScope()
+	scope.Set("x", values.NewFloat(1))
+	scope.Set("re", values.NewRegexp(regexp.MustCompile(`^cpu`)))
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := memory.WithAllocator(context.Background(), memory.DefaultAllocator)
+			pkg, err := runtime.AnalyzeSource(ctx, prelude+tc.fn)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			body := pkg.Files[0].Body
+			stmt := body[len(body)-1].(*semantic.ExpressionStatement)
+			fn := stmt.Expression.(*semantic.FunctionExpression)
+			e, ok := fn.GetFunctionBodyExpression()
+			if !ok {
+				t.Fatal("expected function body to be an expression")
+			}
+
+			properties := make([]semantic.PropertyType, len(cols))
+			for i, c := range cols {
+				properties[i] = semantic.PropertyType{Key: []byte(c.Label), Value: flux.SemanticType(c.Type)}
+			}
+			inType := semantic.NewObjectType([]semantic.PropertyType{
+				{Key: []byte("r"), Value: semantic.NewObjectType(properties)},
+			})
+			boxed, err := compiler.Compile(ctx, scope, fn, inType)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			vector, err := compiler.CompileVector(ctx, scope, e, "r", cols)
+			if !tc.vectorized {
+				if err == nil {
+					t.Fatal("expected expression to not be vectorized")
+				} else if code := errors.Code(err); code != codes.Unimplemented {
+					t.Fatalf("unexpected error code: %s", code)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			elemType, err := vector.Type().ElemType()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if want, got := boxed.Type().String(), elemType.String(); want != got {
+				t.Fatalf("unexpected element type -want/+got:\n\t- %s\n\t+ %s", want, got)
+			}
+
+			tbl := &executetest.Table{ColMeta: cols, Data: data}
+			if err := tbl.Do(func(cr flux.ColReader) error {
+				chunk := table.ChunkFromReader(cr)
+				record := make(map[string]values.Value, len(cols))
+				for j, c := range cols {
+					arr := chunk.Values(j)
+					arr.Retain()
+					record[c.Label] = values.NewVectorValue(arr, flux.SemanticType(c.Type))
+				}
+				input := values.NewObjectWithValues(map[string]values.Value{
+					"r": values.NewObjectWithValues(record),
+				})
+				defer input.Release()
+
+				res, gotErr := vector.Eval(ctx, input)
+				var wantErr error
+				for i := 0; i < cr.Len() && wantErr == nil; i++ {
+					row := make(map[string]values.Value, len(cols))
+					for j, c := range cols {
+						row[c.Label] = execute.ValueForRow(cr, i, j)
+					}
+					_, wantErr = boxed.Eval(ctx, values.NewObjectWithValues(map[string]values.Value{
+						"r": values.NewObjectWithValues(row),
+					}))
+				}
+				if (wantErr != nil) != (gotErr != nil) {
+					t.Fatalf("unexpected error -want/+got:\n\t- %v\n\t+ %v", wantErr, gotErr)
+				} else if wantErr != nil {
+					return nil
+				}
+				defer res.Release()
+
+				vs := res.Vector()
+				for i := 0; i < cr.Len(); i++ {
+					row := make(map[string]values.Value, len(cols))
+					for j, c := range cols {
+						row[c.Label] = execute.ValueForRow(cr, i, j)
+					}
+					want, err := boxed.Eval(ctx, values.NewObjectWithValues(map[string]values.Value{
+						"r": values.NewObjectWithValues(row),
+					}))
+					if err != nil {
+						t.Fatalf("row %d: unexpected error: %s", i, err)
+					}
+					got := vectorValueAt(vs, i)
+
+					// The vectorized logical operators treat null with
+					// three-valued logic, so booleans are compared
+					// as the result of a predicate.
+					if elemType.Nature() == semantic.Bool {
+						want := !want.IsNull() && want.Bool()
+						if got := !got.IsNull() && got.Bool(); want != got {
+							t.Errorf("row %d: unexpected predicate result -want/+got:\n\t- %v\n\t+ %v", i, want, got)
+						}
+						continue
+					}
+					if !equalRowValues(want, got) {
+						t.Errorf("row %d: unexpected value -want/+got:\n\t- %v\n\t+ %v", i, want, got)
+					}
+				}
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// vectorValueAt returns the element of the vector for a row.
+func vectorValueAt(v values.Vector, i int) values.Value {
+	if v.IsRepeat() {
+		return v.(*values.VectorRepeatValue).Value()
+	}
+	arr := v.Arr()
+	if arr.IsNull(i) {
+		return values.Null
+	}
+	switch arr := arr.(type) {
+	case *array.Int:
+		return values.NewInt(arr.Value(i))
+	case *array.Uint:
+		return values.NewUInt(arr.Value(i))
+	case *array.Float:
+		return values.NewFloat(arr.Value(i))
+	case *array.String:
+		return values.NewString(arr.Value(i))
+	case *array.Boolean:
+		return values.NewBool(arr.Value(i))
+	default:
+		panic("unexpected array type")
+	}
+}
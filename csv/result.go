@@ -800,6 +800,11 @@ type ResultEncoderConfig struct {
 	// Delimiter is the character to delimite columns.
 	// It must not be \r, \n, or the Unicode replacement character (0xFFFD).
 	Delimiter rune
+
+	// NoMetaColumns omits the annotation, result and table columns
+	// so the tables are written as plain CSV.
+	// Annotations are not written when it is set.
+	NoMetaColumns bool
 }
 
 func (c ResultEncoderConfig) MarshalJSON() ([]byte, error) {
@@ -855,10 +860,10 @@ func NewResultEncoder(c ResultEncoderConfig) *ResultEncoder {
 	}
 }
 
-func (e *ResultEncoder) csvWriter(w io.Writer) *csv.Writer {
+func newCSVWriter(w io.Writer, delimiter rune) *csv.Writer {
 	writer := csv.NewWriter(w)
-	if e.c.Delimiter != 0 {
-		writer.Comma = e.c.Delimiter
+	if delimiter != 0 {
+		writer.Comma = delimiter
 	}
 	writer.UseCRLF = true
 	return writer
@@ -888,106 +893,131 @@ func wrapEncodingError(err error) error {
 }
 
 func (e *ResultEncoder) Encode(w io.Writer, result flux.Result) (int64, error) {
-	tableID := 0
-	tableIDStr := "0"
-	metaCols := []colMeta{
-		{ColMeta: flux.ColMeta{Label: "", Type: flux.TInvalid}},
-		{ColMeta: flux.ColMeta{Label: resultLabel, Type: flux.TString}},
-		{ColMeta: flux.ColMeta{Label: tableLabel, Type: flux.TInt}},
+	enc := NewTableEncoder(w, result.Name(), e.c)
+	err := result.Tables().Do(func(tbl flux.Table) error {
+		e.written = true
+		return enc.Encode(tbl)
+	})
+	return enc.Count(), err
+}
+
+// TableEncoder encodes the tables of a single result one at a time.
+// It is used when the tables are written as they are produced
+// rather than read from a flux.Result.
+type TableEncoder struct {
+	c          ResultEncoderConfig
+	resultName string
+	counter    *iocounter.Writer
+	writer     *csv.Writer
+	metaCols   []colMeta
+
+	tableID       int
+	lastCols      []colMeta
+	lastGroupCols []flux.ColMeta
+	lastEmpty     bool
+}
+
+// NewTableEncoder creates an encoder that writes the tables
+// of the named result to w.
+func NewTableEncoder(w io.Writer, resultName string, c ResultEncoderConfig) *TableEncoder {
+	counter := &iocounter.Writer{Writer: w}
+	e := &TableEncoder{
+		c:          c,
+		resultName: resultName,
+		counter:    counter,
+		writer:     newCSVWriter(counter, c.Delimiter),
+	}
+	if !c.NoMetaColumns {
+		e.metaCols = []colMeta{
+			{ColMeta: flux.ColMeta{Label: "", Type: flux.TInvalid}},
+			{ColMeta: flux.ColMeta{Label: resultLabel, Type: flux.TString}},
+			{ColMeta: flux.ColMeta{Label: tableLabel, Type: flux.TInt}},
+		}
 	}
-	writeCounter := &iocounter.Writer{Writer: w}
-	writer := e.csvWriter(writeCounter)
+	return e
+}
 
-	var lastCols []colMeta
-	var lastGroupCols []flux.ColMeta
-	var lastEmpty bool
+// Count returns the number of bytes that have been written.
+func (e *TableEncoder) Count() int64 {
+	return e.counter.Count()
+}
 
-	resultName := result.Name()
-	err := result.Tables().Do(func(tbl flux.Table) error {
-		e.written = true
-		// Update cols with table cols
-		cols := metaCols
-		for _, c := range tbl.Cols() {
-			cm := colMeta{ColMeta: c}
-			if c.Type == flux.TTime {
-				cm.fmt = time.RFC3339Nano
-			}
-			cols = append(cols, cm)
+// Encode writes the table, preceded by its schema if the schema
+// differs from the one of the previous table.
+func (e *TableEncoder) Encode(tbl flux.Table) error {
+	writer := e.writer
+	tableIDStr := strconv.Itoa(e.tableID)
+	recordStartIdx := len(e.metaCols)
+
+	// Update cols with table cols
+	cols := append([]colMeta(nil), e.metaCols...)
+	for _, c := range tbl.Cols() {
+		cm := colMeta{ColMeta: c}
+		if c.Type == flux.TTime {
+			cm.fmt = time.RFC3339Nano
 		}
-		// pre-allocate row slice
-		row := make([]string, len(cols))
+		cols = append(cols, cm)
+	}
+	// pre-allocate row slice
+	row := make([]string, len(cols))
 
-		if lastEmpty || tbl.Empty() || schemaChanged(cols, lastCols, tbl.Key().Cols(), lastGroupCols) {
-			if len(lastCols) > 0 {
-				// Write out empty line if not first table
-				writer.Write(nil)
-			}
+	if e.lastEmpty || tbl.Empty() || schemaChanged(cols, e.lastCols, tbl.Key().Cols(), e.lastGroupCols) {
+		if len(e.lastCols) > 0 {
+			// Write out empty line if not first table
+			writer.Write(nil)
+		}
 
-			if err := writeSchema(writer, &e.c, row, cols, tbl.Empty(), tbl.Key(), resultName, tableIDStr); err != nil {
-				return wrapEncodingError(err)
+		if e.c.NoMetaColumns {
+			if !e.c.NoHeader {
+				for j, c := range cols {
+					row[j] = c.Label
+				}
+				writer.Write(row)
 			}
+		} else if err := writeSchema(writer, &e.c, row, cols, tbl.Empty(), tbl.Key(), e.resultName, tableIDStr); err != nil {
+			return wrapEncodingError(err)
 		}
+	}
 
+	if !e.c.NoMetaColumns {
+		row[annotationIdx] = ""
+		row[tableIdx] = tableIDStr
 		if execute.ContainsStr(e.c.Annotations, defaultAnnotation) {
-			for j := range cols {
-				switch j {
-				case annotationIdx:
-					row[j] = ""
-				case resultIdx:
-					row[j] = ""
-				case tableIdx:
-					row[j] = tableIDStr
-				default:
-					row[j] = ""
-				}
-			}
+			row[resultIdx] = ""
 		} else {
-			for j := range cols {
-				switch j {
-				case annotationIdx:
-					row[j] = ""
-				case resultIdx:
-					row[j] = resultName
-				case tableIdx:
-					row[j] = tableIDStr
-				default:
-					row[j] = ""
-				}
-			}
+			row[resultIdx] = e.resultName
 		}
+	}
 
-		if err := tbl.Do(func(cr flux.ColReader) error {
-			record := row[defaultRecordStartIdx:]
-			l := cr.Len()
-			for i := 0; i < l; i++ {
-				for j, c := range cols[defaultRecordStartIdx:] {
-					v, err := encodeValueFrom(i, j, c, cr)
-					if err != nil {
-						return wrapEncodingError(err)
-					}
-					record[j] = v
+	if err := tbl.Do(func(cr flux.ColReader) error {
+		record := row[recordStartIdx:]
+		l := cr.Len()
+		for i := 0; i < l; i++ {
+			for j, c := range cols[recordStartIdx:] {
+				v, err := encodeValueFrom(i, j, c, cr)
+				if err != nil {
+					return wrapEncodingError(err)
 				}
-				writer.Write(row)
+				record[j] = v
 			}
-			writer.Flush()
-			return wrapEncodingError(writer.Error())
-		}); err != nil {
-			return err
+			writer.Write(row)
 		}
-
-		tableID++
-		tableIDStr = strconv.Itoa(tableID)
-		lastCols = cols
-		lastGroupCols = tbl.Key().Cols()
-		lastEmpty = tbl.Empty()
 		writer.Flush()
 		return wrapEncodingError(writer.Error())
-	})
-	return writeCounter.Count(), err
+	}); err != nil {
+		return err
+	}
+
+	e.tableID++
+	e.lastCols = cols
+	e.lastGroupCols = tbl.Key().Cols()
+	e.lastEmpty = tbl.Empty()
+	writer.Flush()
+	return wrapEncodingError(writer.Error())
 }
 
 func (e *ResultEncoder) EncodeError(w io.Writer, err error) error {
-	writer := e.csvWriter(w)
+	writer := newCSVWriter(w, e.c.Delimiter)
 	if e.written {
 		// Write out empty line
 		writer.Write(nil)
@@ -7,8 +7,11 @@ import (
 	"time"
 
 	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/dependencies/audit"
+	"github.com/InfluxCommunity/flux/dependencies/dryrun"
 	"github.com/InfluxCommunity/flux/dependencies/filesystem"
 	"github.com/InfluxCommunity/flux/dependencies/http"
+	"github.com/InfluxCommunity/flux/dependencies/quota"
 	"github.com/InfluxCommunity/flux/dependencies/secret"
 	"github.com/InfluxCommunity/flux/dependencies/url"
 	"github.com/InfluxCommunity/flux/dependency"
@@ -43,6 +46,23 @@ type WrappedDeps struct {
 	FilesystemService filesystem.Service
 	SecretService     secret.Service
 	URLValidator      url.Validator
+
+	// CredentialProvider looks up the named credentials that
+	// authenticate HTTP requests. Credentials cannot be used if it is nil.
+	CredentialProvider http.CredentialProvider
+
+	// Auditor records the writes that functions make to external systems.
+	// Writes are not recorded if it is nil.
+	Auditor audit.Auditor
+
+	// Quotas limit the resources used by each query.
+	// The zero value does not limit queries.
+	Quotas quota.Limits
+
+	// DryRun makes output functions capture what they would write
+	// instead of writing it. The captured payloads are returned
+	// as an additional result named _dryrun.
+	DryRun bool
 }
 
 func (d Deps) HTTPClient() (http.Client, error) {
@@ -86,6 +106,18 @@ func (d Deps) Inject(ctx context.Context) context.Context {
 	if d.Deps.FilesystemService != nil {
 		ctx = filesystem.Inject(ctx, d.Deps.FilesystemService)
 	}
+	if d.Deps.CredentialProvider != nil {
+		ctx = http.CredentialDependency{Provider: d.Deps.CredentialProvider}.Inject(ctx)
+	}
+	if d.Deps.Auditor != nil {
+		ctx = audit.Inject(ctx, d.Deps.Auditor)
+	}
+	if !d.Deps.Quotas.IsZero() {
+		ctx = quota.Inject(ctx, d.Deps.Quotas)
+	}
+	if d.Deps.DryRun {
+		ctx = dryrun.Inject(ctx, dryrun.NewSink())
+	}
 	return ctx
 }
 
@@ -97,6 +129,44 @@ func GetDependencies(ctx context.Context) Dependencies {
 	return deps.(Dependencies)
 }
 
+// WithQuotaTracker returns a context whose Dependencies count the
+// outbound HTTP requests with the quota.Tracker. It wraps any
+// implementation of Dependencies found in the context.
+func WithQuotaTracker(ctx context.Context, t *quota.Tracker) context.Context {
+	return context.WithValue(ctx, dependenciesKey, quotaDeps{
+		Dependencies: GetDependencies(ctx),
+		tracker:      t,
+	})
+}
+
+// quotaDeps are Dependencies whose HTTP clients are limited
+// by the quotas of a query.
+type quotaDeps struct {
+	Dependencies
+	tracker *quota.Tracker
+}
+
+func (d quotaDeps) HTTPClient() (http.Client, error) {
+	c, err := d.Dependencies.HTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	return quota.HTTPClient(c, d.tracker), nil
+}
+
+func (d quotaDeps) PrivateHTTPClient() (http.Client, error) {
+	c, err := d.Dependencies.PrivateHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	return quota.HTTPClient(c, d.tracker), nil
+}
+
+func (d quotaDeps) Inject(ctx context.Context) context.Context {
+	ctx = d.Dependencies.Inject(ctx)
+	return context.WithValue(ctx, dependenciesKey, d)
+}
+
 // NewDefaultDependencies produces a set of dependencies.
 // Not all dependencies have valid defaults and will not be set.
 func NewDefaultDependencies() Deps {
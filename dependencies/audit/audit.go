@@ -0,0 +1,201 @@
+// Package audit records the writes that functions make to external systems.
+//
+// Writes are recorded by the builtin functions that make them. Functions
+// written in Flux, such as slack.message, are recorded by the builtin
+// they use to write, such as http.post.
+package audit
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
+)
+
+const (
+	// Success is the outcome of a write that succeeded.
+	Success = "success"
+	// Failure is the outcome of a write that failed.
+	Failure = "failure"
+)
+
+// Event describes a write made by a function to an external system.
+type Event struct {
+	// Time is when the write finished.
+	Time time.Time
+	// Function is the function that made the write,
+	// named by its package path and name, such as http.post.
+	Function string
+	// Destination is where the data was written.
+	// It must not contain credentials.
+	Destination string
+	// Bytes is the number of bytes that were written, if known.
+	Bytes int64
+	// Rows is the number of rows that were written, if known.
+	Rows int64
+	// Err is the reason the write failed, if it did.
+	Err error
+}
+
+// Outcome returns Success or Failure depending on the error of the Event.
+func (e Event) Outcome() string {
+	if e.Err != nil {
+		return Failure
+	}
+	return Success
+}
+
+// Auditor records the writes of functions.
+//
+// Audit must not block the write for long and cannot fail it.
+// Implementations must be safe to use concurrently.
+type Auditor interface {
+	Audit(ctx context.Context, e Event)
+}
+
+// NoopAuditor is an Auditor that discards every Event.
+type NoopAuditor struct{}
+
+func (NoopAuditor) Audit(ctx context.Context, e Event) {}
+
+type key int
+
+const auditorKey key = iota
+
+// Dependency will inject the Auditor into the dependency chain.
+type Dependency struct {
+	Auditor Auditor
+}
+
+// Inject will inject the Auditor into the dependency chain.
+func (d Dependency) Inject(ctx context.Context) context.Context {
+	if d.Auditor != nil {
+		ctx = Inject(ctx, d.Auditor)
+	}
+	return ctx
+}
+
+// Inject will inject the Auditor into the context.
+func Inject(ctx context.Context, a Auditor) context.Context {
+	return context.WithValue(ctx, auditorKey, a)
+}
+
+// Get will retrieve the Auditor from the context.
+// If none was injected, the NoopAuditor is returned.
+func Get(ctx context.Context) Auditor {
+	a, ok := ctx.Value(auditorKey).(Auditor)
+	if !ok {
+		return NoopAuditor{}
+	}
+	return a
+}
+
+// Record records the Event with the Auditor in the context.
+// The time of the Event is set to now if it is not set.
+func Record(ctx context.Context, e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	Get(ctx).Audit(ctx, e)
+}
+
+// Write accumulates a single Event for a function that writes
+// in several steps, such as a transformation that writes each table.
+// A nil *Write does not record anything.
+type Write struct {
+	ctx context.Context
+
+	mu    sync.Mutex
+	event Event
+	done  bool
+}
+
+// NewWrite starts an Event for the function writing to the destination.
+func NewWrite(ctx context.Context, function, destination string) *Write {
+	return &Write{
+		ctx: ctx,
+		event: Event{
+			Function:    function,
+			Destination: destination,
+		},
+	}
+}
+
+// Add counts the rows and bytes that were written.
+func (w *Write) Add(rows, bytes int64) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	w.event.Rows += rows
+	w.event.Bytes += bytes
+	w.mu.Unlock()
+}
+
+// Fail records the error as the reason the write failed.
+// Only the first error is recorded.
+func (w *Write) Fail(err error) {
+	if w == nil || err == nil {
+		return
+	}
+	w.mu.Lock()
+	if w.event.Err == nil {
+		w.event.Err = err
+	}
+	w.mu.Unlock()
+}
+
+// Done records the Event with the error, if any.
+// Only the first call to Done records the Event.
+func (w *Write) Done(err error) {
+	if w == nil {
+		return
+	}
+	w.Fail(err)
+	w.mu.Lock()
+	if w.done {
+		w.mu.Unlock()
+		return
+	}
+	w.done = true
+	e := w.event
+	w.mu.Unlock()
+	Record(w.ctx, e)
+}
+
+// URL returns the URL as a destination for an Event.
+// The password and query parameters are removed since they
+// commonly hold credentials. If the URL cannot be parsed,
+// an empty string is returned.
+func URL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	if u.User != nil {
+		u.User = url.User(u.User.Username())
+	}
+	u.RawQuery = ""
+	u.ForceQuery = false
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String()
+}
+
+// HTTPEvent returns the Event for an HTTP request made by the function.
+// Requests that were answered with a status other than 2xx are failures
+// even though the function may not return an error for them.
+func HTTPEvent(function, rawURL string, size, statusCode int, err error) Event {
+	if err == nil && (statusCode < 200 || statusCode > 299) {
+		err = errors.Newf(codes.Unknown, "server responded with status code %d", statusCode)
+	}
+	return Event{
+		Function:    function,
+		Destination: URL(rawURL),
+		Bytes:       int64(size),
+		Err:         err,
+	}
+}
@@ -0,0 +1,106 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// JSONLinesAuditor writes every Event as a JSON object on its own line.
+//
+// Each line has the fields time, function, destination, bytes,
+// rows and outcome, and an error field if the write failed.
+// Since Audit cannot fail, the first error writing an Event is
+// kept and returned by Err and Close.
+type JSONLinesAuditor struct {
+	mu  sync.Mutex
+	w   io.Writer
+	err error
+}
+
+var _ Auditor = (*JSONLinesAuditor)(nil)
+
+// NewJSONLinesAuditor creates a JSONLinesAuditor that writes to w.
+func NewJSONLinesAuditor(w io.Writer) *JSONLinesAuditor {
+	return &JSONLinesAuditor{w: w}
+}
+
+// OpenJSONLinesFile creates a JSONLinesAuditor that appends to the file.
+// The file is created if it does not exist.
+// It is closed when the JSONLinesAuditor is closed.
+func OpenJSONLinesFile(name string) (*JSONLinesAuditor, error) {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return NewJSONLinesAuditor(f), nil
+}
+
+type jsonEvent struct {
+	Time        time.Time `json:"time"`
+	Function    string    `json:"function"`
+	Destination string    `json:"destination"`
+	Bytes       int64     `json:"bytes"`
+	Rows        int64     `json:"rows"`
+	Outcome     string    `json:"outcome"`
+	Error       string    `json:"error,omitempty"`
+}
+
+func (a *JSONLinesAuditor) Audit(ctx context.Context, e Event) {
+	je := jsonEvent{
+		Time:        e.Time.UTC(),
+		Function:    e.Function,
+		Destination: e.Destination,
+		Bytes:       e.Bytes,
+		Rows:        e.Rows,
+		Outcome:     e.Outcome(),
+	}
+	if e.Err != nil {
+		je.Error = e.Err.Error()
+	}
+	line, err := json.Marshal(je)
+	if err != nil {
+		a.setErr(err)
+		return
+	}
+	line = append(line, '\n')
+
+	// Lines are written with a single call so concurrent
+	// writes to a file in append mode do not interleave.
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := a.w.Write(line); err != nil && a.err == nil {
+		a.err = err
+	}
+}
+
+func (a *JSONLinesAuditor) setErr(err error) {
+	a.mu.Lock()
+	if a.err == nil {
+		a.err = err
+	}
+	a.mu.Unlock()
+}
+
+// Err returns the first error that occurred writing an Event.
+func (a *JSONLinesAuditor) Err() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.err
+}
+
+// Close closes the underlying writer if it is an io.Closer
+// and returns the first error that occurred writing an Event.
+func (a *JSONLinesAuditor) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if c, ok := a.w.(io.Closer); ok {
+		if err := c.Close(); err != nil && a.err == nil {
+			a.err = err
+		}
+	}
+	return a.err
+}
@@ -0,0 +1,80 @@
+package audit_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/dependencies/audit"
+	"github.com/InfluxCommunity/flux/internal/errors"
+)
+
+func TestJSONLinesAuditor(t *testing.T) {
+	var buf bytes.Buffer
+	a := audit.NewJSONLinesAuditor(&buf)
+	ts := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	a.Audit(context.Background(), audit.Event{
+		Time:        ts,
+		Function:    "http.post",
+		Destination: "https://example.com/hook",
+		Bytes:       42,
+	})
+	a.Audit(context.Background(), audit.Event{
+		Time:        ts,
+		Function:    "sql.to",
+		Destination: "postgres:metrics",
+		Rows:        3,
+		Err:         errors.New(codes.Unavailable, "connection refused"),
+	})
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"time":"2022-01-02T03:04:05Z","function":"http.post","destination":"https://example.com/hook","bytes":42,"rows":0,"outcome":"success"}
+{"time":"2022-01-02T03:04:05Z","function":"sql.to","destination":"postgres:metrics","bytes":0,"rows":3,"outcome":"failure","error":"connection refused"}
+`
+	if got := buf.String(); want != got {
+		t.Fatalf("unexpected output -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+}
+
+func TestOpenJSONLinesFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "audit.jsonl")
+	for i := 0; i < 2; i++ {
+		a, err := audit.OpenJSONLinesFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.Audit(context.Background(), audit.Event{
+			Time:     time.Unix(0, 0),
+			Function: "csv.to",
+		})
+		if err := a.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := `{"time":"1970-01-01T00:00:00Z","function":"csv.to","destination":"","bytes":0,"rows":0,"outcome":"success"}` + "\n"
+	if want, got := line+line, string(data); want != got {
+		t.Fatalf("unexpected file contents -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+
+	// Events can no longer be written once the file is closed.
+	a, err := audit.OpenJSONLinesFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = a.Close()
+	a.Audit(context.Background(), audit.Event{Function: "csv.to"})
+	if err := a.Err(); err == nil {
+		t.Fatal("expected error writing to a closed file")
+	}
+}
@@ -0,0 +1,142 @@
+// Package dryrun captures the data that output functions would write
+// so scripts can be run without writing to external systems.
+package dryrun
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"sync"
+)
+
+// Payload is the data an output function would have written.
+type Payload struct {
+	// Function is the function that would have written the payload,
+	// named by its package path and name, such as http.post.
+	Function string
+	// Destination is where the payload would have been written.
+	// It must not contain credentials.
+	Destination string
+	// Rows is the number of rows in the payload, if the function writes rows.
+	Rows int64
+	// Data is the payload in the format the function would have
+	// written it, or a textual representation if that format is binary.
+	Data []byte
+}
+
+// Sink captures the payloads of a query run in dry-run mode.
+// It is safe to use concurrently.
+type Sink struct {
+	mu       sync.Mutex
+	payloads []Payload
+}
+
+// NewSink creates an empty Sink.
+func NewSink() *Sink {
+	return &Sink{}
+}
+
+// Capture records the payload in place of writing it.
+func (s *Sink) Capture(p Payload) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.payloads = append(s.payloads, p)
+}
+
+// Payloads returns the captured payloads in the order they were captured.
+func (s *Sink) Payloads() []Payload {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Payload(nil), s.payloads...)
+}
+
+// NewWriter creates a Writer that captures a single payload
+// for the function writing to the destination.
+func (s *Sink) NewWriter(function, destination string) *Writer {
+	return &Writer{
+		sink:        s,
+		function:    function,
+		destination: destination,
+	}
+}
+
+// Writer is an io.WriteCloser that captures everything
+// written to it as a single payload when it is closed.
+// It is meant for functions that write in several steps.
+type Writer struct {
+	sink        *Sink
+	function    string
+	destination string
+
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	rows   int64
+	closed bool
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	return w.buf.Write(p)
+}
+
+// AddRows counts rows that were written to the payload.
+func (w *Writer) AddRows(n int64) {
+	w.mu.Lock()
+	w.rows += n
+	w.mu.Unlock()
+}
+
+// Close captures the payload.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	p := Payload{
+		Function:    w.function,
+		Destination: w.destination,
+		Rows:        w.rows,
+		Data:        w.buf.Bytes(),
+	}
+	w.mu.Unlock()
+	w.sink.Capture(p)
+	return nil
+}
+
+type key int
+
+const sinkKey key = iota
+
+// Dependency will enable dry-run mode in the dependency chain.
+type Dependency struct {
+	Enabled bool
+}
+
+// Inject will enable dry-run mode in the dependency chain
+// by injecting a new Sink.
+func (d Dependency) Inject(ctx context.Context) context.Context {
+	if d.Enabled {
+		ctx = Inject(ctx, NewSink())
+	}
+	return ctx
+}
+
+// Inject will inject the Sink into the context,
+// which enables dry-run mode for output functions.
+func Inject(ctx context.Context, s *Sink) context.Context {
+	return context.WithValue(ctx, sinkKey, s)
+}
+
+// GetSink will retrieve the Sink from the context.
+// Output functions must capture their payloads with the Sink
+// instead of writing them when it is not nil.
+func GetSink(ctx context.Context) *Sink {
+	s, _ := ctx.Value(sinkKey).(*Sink)
+	return s
+}
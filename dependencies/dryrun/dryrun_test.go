@@ -0,0 +1,70 @@
+package dryrun_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/InfluxCommunity/flux/dependencies/dryrun"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDependency(t *testing.T) {
+	if s := dryrun.GetSink(dryrun.Dependency{}.Inject(context.Background())); s != nil {
+		t.Fatal("expected no sink when dry-run mode is disabled")
+	}
+	if s := dryrun.GetSink(dryrun.Dependency{Enabled: true}.Inject(context.Background())); s == nil {
+		t.Fatal("expected a sink when dry-run mode is enabled")
+	}
+}
+
+func TestSink_Writer(t *testing.T) {
+	s := dryrun.NewSink()
+	s.Capture(dryrun.Payload{
+		Function:    "http.post",
+		Destination: "http://localhost/",
+		Data:        []byte("hello"),
+	})
+
+	w := s.NewWriter("csv.to", "out.csv")
+	if _, err := io.WriteString(w, "a,b\n"); err != nil {
+		t.Fatal(err)
+	}
+	w.AddRows(1)
+	if _, err := io.WriteString(w, "c,d\n"); err != nil {
+		t.Fatal(err)
+	}
+	w.AddRows(1)
+	if got := len(s.Payloads()); got != 1 {
+		t.Fatalf("expected the payload to be captured when the writer is closed, got %d payloads", got)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Closing again does not capture the payload twice.
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(w, "e,f\n"); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("unexpected error writing after close -want/+got:\n\t- %s\n\t+ %v", os.ErrClosed, err)
+	}
+
+	want := []dryrun.Payload{
+		{
+			Function:    "http.post",
+			Destination: "http://localhost/",
+			Data:        []byte("hello"),
+		},
+		{
+			Function:    "csv.to",
+			Destination: "out.csv",
+			Rows:        2,
+			Data:        []byte("a,b\nc,d\n"),
+		},
+	}
+	if got := s.Payloads(); !cmp.Equal(want, got) {
+		t.Errorf("unexpected payloads -want/+got:\n%s", cmp.Diff(want, got))
+	}
+}
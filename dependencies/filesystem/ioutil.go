@@ -43,3 +43,44 @@ func Stat(ctx context.Context, filename string) (os.FileInfo, error) {
 	defer func() { _ = f.Close() }()
 	return f.Stat()
 }
+
+// CreateFile will create or truncate the file in the service.
+func CreateFile(ctx context.Context, filename string) (io.WriteCloser, error) {
+	fs, err := GetWritable(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return fs.Create(filename)
+}
+
+// AppendFile will open the file in the service for appending.
+func AppendFile(ctx context.Context, filename string) (io.WriteCloser, error) {
+	fs, err := GetWritable(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return fs.Append(filename)
+}
+
+// WriteFile will write the data to the file in the service,
+// replacing any existing contents.
+func WriteFile(ctx context.Context, filename string, data []byte) error {
+	f, err := CreateFile(ctx, filename)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// RemoveFile will remove the file from the service.
+func RemoveFile(ctx context.Context, filename string) error {
+	fs, err := GetWritable(ctx)
+	if err != nil {
+		return err
+	}
+	return fs.Remove(filename)
+}
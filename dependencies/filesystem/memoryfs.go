@@ -0,0 +1,168 @@
+package filesystem
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"time"
+)
+
+var _ WritableService = (*MemoryFS)(nil)
+
+// MemoryFS implements the filesystem.WritableService by keeping
+// files in memory. It is intended for tests.
+//
+// Paths are cleaned and interpreted relative to the root of the
+// filesystem, so "data.csv" and "/data.csv" refer to the same file.
+// Directories are implicit and are not tracked.
+type MemoryFS struct {
+	mu    sync.RWMutex
+	files map[string]*memoryFileData
+}
+
+type memoryFileData struct {
+	data    []byte
+	modTime time.Time
+}
+
+// NewMemoryFS creates a MemoryFS that contains the given files.
+func NewMemoryFS(files map[string]string) *MemoryFS {
+	fs := &MemoryFS{files: make(map[string]*memoryFileData, len(files))}
+	for name, contents := range files {
+		fs.files[memoryPath(name)] = &memoryFileData{
+			data:    []byte(contents),
+			modTime: time.Now(),
+		}
+	}
+	return fs
+}
+
+// Files returns the names of the files in the filesystem in sorted order.
+func (fs *MemoryFS) Files() []string {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	names := make([]string, 0, len(fs.files))
+	for name := range fs.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (fs *MemoryFS) Open(fpath string) (File, error) {
+	name := memoryPath(fpath)
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	f, ok := fs.files[name]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: fpath, Err: os.ErrNotExist}
+	}
+	// The file is a snapshot of the contents at the time it was opened.
+	data := append([]byte(nil), f.data...)
+	return &memoryFile{
+		Reader: bytes.NewReader(data),
+		info: memoryFileInfo{
+			name:    path.Base(name),
+			size:    int64(len(data)),
+			modTime: f.modTime,
+		},
+	}, nil
+}
+
+func (fs *MemoryFS) Create(fpath string) (io.WriteCloser, error) {
+	return fs.open(fpath, true), nil
+}
+
+func (fs *MemoryFS) Append(fpath string) (io.WriteCloser, error) {
+	return fs.open(fpath, false), nil
+}
+
+func (fs *MemoryFS) open(fpath string, truncate bool) io.WriteCloser {
+	name := memoryPath(fpath)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.files == nil {
+		fs.files = make(map[string]*memoryFileData)
+	}
+	f, ok := fs.files[name]
+	if !ok {
+		f = &memoryFileData{}
+		fs.files[name] = f
+	}
+	if truncate {
+		f.data = nil
+	}
+	f.modTime = time.Now()
+	return &memoryWriter{fs: fs, f: f}
+}
+
+func (fs *MemoryFS) Remove(fpath string) error {
+	name := memoryPath(fpath)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if _, ok := fs.files[name]; !ok {
+		return &os.PathError{Op: "remove", Path: fpath, Err: os.ErrNotExist}
+	}
+	delete(fs.files, name)
+	return nil
+}
+
+func memoryPath(fpath string) string {
+	return path.Clean("/" + fpath)
+}
+
+type memoryFile struct {
+	*bytes.Reader
+	info memoryFileInfo
+}
+
+func (f *memoryFile) Stat() (os.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *memoryFile) Close() error {
+	return nil
+}
+
+type memoryFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (fi memoryFileInfo) Name() string       { return fi.name }
+func (fi memoryFileInfo) Size() int64        { return fi.size }
+func (fi memoryFileInfo) Mode() os.FileMode  { return 0666 }
+func (fi memoryFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi memoryFileInfo) IsDir() bool        { return false }
+func (fi memoryFileInfo) Sys() interface{}   { return nil }
+
+// memoryWriter appends to a file in the MemoryFS.
+// Writes are visible to files opened after them.
+type memoryWriter struct {
+	fs     *MemoryFS
+	f      *memoryFileData
+	closed bool
+}
+
+func (w *memoryWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	w.fs.mu.Lock()
+	defer w.fs.mu.Unlock()
+	w.f.data = append(w.f.data, p...)
+	w.f.modTime = time.Now()
+	return len(p), nil
+}
+
+func (w *memoryWriter) Close() error {
+	if w.closed {
+		return os.ErrClosed
+	}
+	w.closed = true
+	return nil
+}
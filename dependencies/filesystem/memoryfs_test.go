@@ -0,0 +1,68 @@
+package filesystem_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/InfluxCommunity/flux/dependencies/filesystem"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMemoryFS(t *testing.T) {
+	fs := filesystem.NewMemoryFS(map[string]string{
+		"data/a.csv": "a\n",
+	})
+	ctx := filesystem.Inject(context.Background(), fs)
+
+	data, err := filesystem.ReadFile(ctx, "/data/../data/a.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "a\n"; got != want {
+		t.Fatalf("unexpected file contents -want/+got:\n\t- %q\n\t+ %q", want, got)
+	}
+
+	f, err := filesystem.AppendFile(ctx, "data/a.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(f, "b\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(f, "c\n"); err == nil {
+		t.Fatal("expected error writing to a closed file")
+	}
+
+	fi, err := filesystem.Stat(ctx, "data/a.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fi.Name(), "a.csv"; got != want {
+		t.Fatalf("unexpected file info name -want/+got:\n\t- %q\n\t+ %q", want, got)
+	}
+	if got, want := fi.Size(), int64(4); got != want {
+		t.Fatalf("unexpected file info size -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+
+	if err := filesystem.WriteFile(ctx, "b.csv", []byte("b\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fs.Files(), []string{"/b.csv", "/data/a.csv"}; !cmp.Equal(want, got) {
+		t.Fatalf("unexpected files -want/+got:\n%s", cmp.Diff(want, got))
+	}
+
+	if err := filesystem.RemoveFile(ctx, "data/a.csv"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := filesystem.OpenFile(ctx, "data/a.csv"); !os.IsNotExist(err) {
+		t.Fatalf("expected not exist error, got %v", err)
+	}
+	if err := filesystem.RemoveFile(ctx, "data/a.csv"); !os.IsNotExist(err) {
+		t.Fatalf("expected not exist error, got %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+package filesystem
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
+)
+
+var _ WritableService = (*RootedFS)(nil)
+
+// RootedFS implements the filesystem.WritableService by confining
+// all paths to a base directory.
+//
+// Paths are interpreted relative to the base directory, so both
+// "data.csv" and "/data.csv" refer to the file data.csv inside it,
+// and ".." cannot be used to leave it. Symbolic links are followed
+// only if they resolve to a location inside the base directory.
+type RootedFS struct {
+	root string
+}
+
+// NewRootedFS creates a RootedFS for the base directory.
+func NewRootedFS(root string) (*RootedFS, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := os.Stat(resolved)
+	if err != nil {
+		return nil, err
+	} else if !fi.IsDir() {
+		return nil, errors.Newf(codes.Invalid, "filesystem root %q is not a directory", root)
+	}
+	return &RootedFS{root: resolved}, nil
+}
+
+// Root returns the base directory.
+func (fs *RootedFS) Root() string {
+	return fs.root
+}
+
+func (fs *RootedFS) Open(fpath string) (File, error) {
+	p, err := fs.resolve(fpath, false)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (fs *RootedFS) Create(fpath string) (io.WriteCloser, error) {
+	p, err := fs.resolve(fpath, true)
+	if err != nil {
+		return nil, err
+	}
+	return os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+func (fs *RootedFS) Append(fpath string) (io.WriteCloser, error) {
+	p, err := fs.resolve(fpath, true)
+	if err != nil {
+		return nil, err
+	}
+	return os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+}
+
+func (fs *RootedFS) Remove(fpath string) error {
+	// The link itself is removed rather than its target,
+	// so only the parent directory needs to be resolved.
+	p, err := fs.resolveParent(fpath)
+	if err != nil {
+		return err
+	}
+	if p == fs.root {
+		return errors.New(codes.PermissionDenied, "cannot remove the filesystem root")
+	}
+	return os.Remove(p)
+}
+
+// join returns the path inside the root without resolving symbolic links.
+func (fs *RootedFS) join(fpath string) string {
+	return filepath.Join(fs.root, filepath.Clean(string(filepath.Separator)+fpath))
+}
+
+// resolve returns the path of the file with all symbolic links resolved.
+// If create is set, the file does not need to exist but its parent directory does.
+func (fs *RootedFS) resolve(fpath string, create bool) (string, error) {
+	p := fs.join(fpath)
+	resolved, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		if !create || !os.IsNotExist(err) {
+			return "", err
+		}
+		if fi, lerr := os.Lstat(p); lerr == nil && fi.Mode()&os.ModeSymlink != 0 {
+			// A dangling link would create its target wherever it points.
+			return "", fs.escapeError(fpath)
+		}
+		return fs.resolveParent(fpath)
+	}
+	if !fs.contains(resolved) {
+		return "", fs.escapeError(fpath)
+	}
+	return resolved, nil
+}
+
+// resolveParent returns the path of the file with the symbolic links
+// of its parent directory resolved.
+func (fs *RootedFS) resolveParent(fpath string) (string, error) {
+	p := fs.join(fpath)
+	if p == fs.root {
+		return p, nil
+	}
+	dir, err := filepath.EvalSymlinks(filepath.Dir(p))
+	if err != nil {
+		return "", err
+	}
+	if !fs.contains(dir) {
+		return "", fs.escapeError(fpath)
+	}
+	return filepath.Join(dir, filepath.Base(p)), nil
+}
+
+func (fs *RootedFS) contains(p string) bool {
+	if p == fs.root {
+		return true
+	}
+	root := fs.root
+	if !strings.HasSuffix(root, string(filepath.Separator)) {
+		root += string(filepath.Separator)
+	}
+	return strings.HasPrefix(p, root)
+}
+
+func (fs *RootedFS) escapeError(fpath string) error {
+	return errors.Newf(codes.PermissionDenied, "path %q resolves outside of the filesystem root", fpath)
+}
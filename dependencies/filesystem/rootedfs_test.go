@@ -0,0 +1,149 @@
+package filesystem_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/dependencies/filesystem"
+	"github.com/InfluxCommunity/flux/internal/errors"
+)
+
+func TestRootedFS(t *testing.T) {
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "data"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "data", "a.csv"), []byte("a"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for link, target := range map[string]string{
+		"inside":       filepath.Join(root, "data", "a.csv"),
+		"relative":     "data/a.csv",
+		"escape":       filepath.Join(outside, "secret.txt"),
+		"escapedir":    outside,
+		"dangling":     filepath.Join(outside, "new.txt"),
+		"data/dotdot":  "../../" + filepath.Base(outside) + "/secret.txt",
+		"data/rootdir": "..",
+	} {
+		if err := os.Symlink(target, filepath.Join(root, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fs, err := filesystem.NewRootedFS(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{
+		"data/a.csv",
+		"/data/a.csv",
+		"../../data/a.csv",
+		"inside",
+		"relative",
+		"data/rootdir/data/a.csv",
+	} {
+		f, err := fs.Open(name)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", name, err)
+			continue
+		}
+		data, err := io.ReadAll(f)
+		_ = f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := string(data), "a"; got != want {
+			t.Errorf("%s: unexpected file contents -want/+got:\n\t- %q\n\t+ %q", name, want, got)
+		}
+	}
+
+	for _, name := range []string{
+		"escape",
+		"escapedir/secret.txt",
+		"data/dotdot",
+	} {
+		if _, err := fs.Open(name); err == nil {
+			t.Errorf("%s: expected error", name)
+		} else if got, want := errors.Code(err), codes.PermissionDenied; got != want {
+			t.Errorf("%s: unexpected error code -want/+got:\n\t- %s\n\t+ %s", name, want, got)
+		}
+	}
+
+	for _, name := range []string{"escape", "dangling", "escapedir/new.txt"} {
+		if _, err := fs.Create(name); err == nil {
+			t.Errorf("%s: expected error", name)
+		} else if got, want := errors.Code(err), codes.PermissionDenied; got != want {
+			t.Errorf("%s: unexpected error code -want/+got:\n\t- %s\n\t+ %s", name, want, got)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(outside, "new.txt")); !os.IsNotExist(err) {
+		t.Fatalf("file was created outside of the root: %v", err)
+	}
+
+	// Removing a link removes the link and not its target.
+	if err := fs.Remove("escape"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "secret.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Remove("/"); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestRootedFS_Write(t *testing.T) {
+	root := t.TempDir()
+	fs, err := filesystem.NewRootedFS(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := filesystem.Inject(context.Background(), fs)
+
+	if err := filesystem.WriteFile(ctx, "/out.csv", []byte("a\n")); err != nil {
+		t.Fatal(err)
+	}
+	f, err := filesystem.AppendFile(ctx, "out.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(f, "b\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, "out.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "a\nb\n"; got != want {
+		t.Fatalf("unexpected file contents -want/+got:\n\t- %q\n\t+ %q", want, got)
+	}
+
+	if err := filesystem.RemoveFile(ctx, "out.csv"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := filesystem.ReadFile(ctx, "out.csv"); !os.IsNotExist(err) {
+		t.Fatalf("expected not exist error, got %v", err)
+	}
+
+	// The system filesystem cannot be written to through the service.
+	ctx = filesystem.Inject(context.Background(), filesystem.SystemFS)
+	if err := filesystem.WriteFile(ctx, filepath.Join(root, "out.csv"), nil); err == nil {
+		t.Fatal("expected error")
+	} else if got, want := errors.Code(err), codes.PermissionDenied; got != want {
+		t.Fatalf("unexpected error code -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+}
@@ -20,6 +20,21 @@ type Service interface {
 	Open(fpath string) (File, error)
 }
 
+// WritableService is a Service that can also modify the filesystem.
+type WritableService interface {
+	Service
+
+	// Create creates the file or truncates it if it already exists.
+	Create(fpath string) (io.WriteCloser, error)
+
+	// Append opens the file for writing at the end of the file.
+	// The file is created if it does not exist.
+	Append(fpath string) (io.WriteCloser, error)
+
+	// Remove removes the file.
+	Remove(fpath string) error
+}
+
 type key int
 
 const serviceKey key = iota
@@ -50,3 +65,17 @@ func Get(ctx context.Context) (Service, error) {
 	}
 	return s.(Service), nil
 }
+
+// GetWritable will retrieve a WritableService from the context.Context.
+// It returns an error if the filesystem Service cannot be written to.
+func GetWritable(ctx context.Context) (WritableService, error) {
+	fs, err := Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	wfs, ok := fs.(WritableService)
+	if !ok {
+		return nil, errors.New(codes.PermissionDenied, "filesystem service is read-only")
+	}
+	return wfs, nil
+}
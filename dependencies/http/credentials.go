@@ -0,0 +1,68 @@
+package http
+
+import (
+	"context"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
+)
+
+// Credential authenticates the requests made by a client.
+type Credential interface {
+	// Client returns a client that authenticates its requests with the credential.
+	Client(ctx context.Context, c Client) (Client, error)
+}
+
+// CredentialProvider looks up credentials by name.
+type CredentialProvider interface {
+	Credential(ctx context.Context, name string) (Credential, error)
+}
+
+// WithCredential returns a client that authenticates its requests with the credential.
+func WithCredential(ctx context.Context, c Client, cred Credential) (Client, error) {
+	return cred.Client(ctx, c)
+}
+
+// StaticCredentialProvider provides a fixed set of named credentials.
+type StaticCredentialProvider map[string]Credential
+
+func (p StaticCredentialProvider) Credential(ctx context.Context, name string) (Credential, error) {
+	cred, ok := p[name]
+	if !ok {
+		return nil, errors.Newf(codes.NotFound, "credential %q not found", name)
+	}
+	return cred, nil
+}
+
+// ErrorCredentialProvider is the credential provider used
+// when no provider has been injected into the dependencies.
+type ErrorCredentialProvider struct{}
+
+func (ErrorCredentialProvider) Credential(ctx context.Context, name string) (Credential, error) {
+	return nil, errors.Newf(codes.Unimplemented, "credential %q requested but no credential provider is configured", name)
+}
+
+type key int
+
+const credentialProviderKey key = iota
+
+// CredentialDependency will inject the CredentialProvider into the dependency chain.
+type CredentialDependency struct {
+	Provider CredentialProvider
+}
+
+// Inject will inject the CredentialProvider into the dependency chain.
+func (d CredentialDependency) Inject(ctx context.Context) context.Context {
+	return context.WithValue(ctx, credentialProviderKey, d.Provider)
+}
+
+// GetCredentialProvider will return the CredentialProvider for the current context.
+// If no CredentialProvider has been injected into the dependencies,
+// this will return a provider that fails every lookup.
+func GetCredentialProvider(ctx context.Context) CredentialProvider {
+	p := ctx.Value(credentialProviderKey)
+	if p == nil {
+		return ErrorCredentialProvider{}
+	}
+	return p.(CredentialProvider)
+}
@@ -0,0 +1,391 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/InfluxCommunity/flux/dependencies/url"
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestOAuth2ClientCredentials(t *testing.T) {
+	tokens := 0
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, ok := r.BasicAuth(); !ok || id != "client" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error": "invalid_client"}`))
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("error parsing form: %v", err)
+		}
+		if want, got := "client_credentials", r.PostForm.Get("grant_type"); want != got {
+			t.Errorf("unexpected grant type -want/+got:\n\t- %s\n\t+ %s", want, got)
+		}
+		if want, got := "read write", r.PostForm.Get("scope"); want != got {
+			t.Errorf("unexpected scope -want/+got:\n\t- %s\n\t+ %s", want, got)
+		}
+		tokens++
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "token" + string(rune('0'+tokens)),
+			"token_type":   "bearer",
+			"expires_in":   3600,
+		})
+	}))
+	defer tokenServer.Close()
+
+	var auths []string
+	revoked := ""
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		auths = append(auths, auth)
+		if auth == revoked {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	now := time.Now()
+	cred := &OAuth2ClientCredentials{
+		TokenURL:     tokenServer.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Scopes:       []string{"read", "write"},
+		nowFn:        func() time.Time { return now },
+	}
+	c, err := WithCredential(context.Background(), NewDefaultClient(url.PassValidator{}), cred)
+	if err != nil {
+		t.Fatal(err)
+	}
+	do := func() int {
+		req, err := http.NewRequest("GET", ts.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := c.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// The token is cached between requests.
+	do()
+	do()
+	// The token is refreshed before it expires.
+	now = now.Add(time.Hour)
+	do()
+	// A rejected token is refreshed for the next request.
+	revoked = "Bearer token2"
+	if want, got := http.StatusUnauthorized, do(); want != got {
+		t.Errorf("unexpected status code -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+	do()
+
+	want := []string{"Bearer token1", "Bearer token1", "Bearer token2", "Bearer token2", "Bearer token3"}
+	if strings.Join(want, ",") != strings.Join(auths, ",") {
+		t.Errorf("unexpected authorization headers -want/+got:\n\t- %v\n\t+ %v", want, auths)
+	}
+	if want, got := 3, tokens; want != got {
+		t.Errorf("unexpected number of token requests -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+
+	cred = &OAuth2ClientCredentials{
+		TokenURL:     tokenServer.URL,
+		ClientID:     "client",
+		ClientSecret: "wrong",
+	}
+	c, err = WithCredential(context.Background(), NewDefaultClient(url.PassValidator{}), cred)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest("GET", ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Do(req); err == nil || !strings.Contains(err.Error(), "invalid_client") {
+		t.Errorf("expected invalid client error, got: %v", err)
+	}
+}
+
+func TestOAuth2ClientCredentials_SharedRequest(t *testing.T) {
+	var tokens int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&tokens, 1) == 1 {
+			close(started)
+		}
+		<-release
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "token",
+			"expires_in":   3600,
+		})
+	}))
+	defer tokenServer.Close()
+
+	cred := &OAuth2ClientCredentials{
+		TokenURL:     tokenServer.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+	}
+	client := NewDefaultClient(url.PassValidator{})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tok, err := cred.Token(context.Background(), client)
+			if err == nil && tok != "token" {
+				err = fmt.Errorf("unexpected token %q", tok)
+			}
+			errs <- err
+		}()
+	}
+	<-started
+
+	// A caller waiting for the token request can give up
+	// without waiting for the token endpoint.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := cred.Token(ctx, client); err != context.Canceled {
+		t.Errorf("expected context canceled error, got: %v", err)
+	}
+
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if want, got := int32(1), atomic.LoadInt32(&tokens); want != got {
+		t.Errorf("unexpected number of token requests -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+}
+
+func TestOAuth2ClientCredentials_CanceledRequester(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "token",
+			"expires_in":   3600,
+		})
+	}))
+	defer tokenServer.Close()
+
+	cred := &OAuth2ClientCredentials{
+		TokenURL:     tokenServer.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+	}
+	client := NewDefaultClient(url.PassValidator{})
+
+	// The first caller starts the token request and is then canceled.
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := cred.Token(ctx, client)
+		errc <- err
+	}()
+	<-started
+
+	tokc := make(chan string, 1)
+	go func() {
+		tok, err := cred.Token(context.Background(), client)
+		if err != nil {
+			t.Error(err)
+		}
+		tokc <- tok
+	}()
+
+	cancel()
+	if err := <-errc; err != context.Canceled {
+		t.Errorf("expected context canceled error, got: %v", err)
+	}
+
+	// The other caller still receives the token.
+	close(release)
+	if want, got := "token", <-tokc; want != got {
+		t.Errorf("unexpected token -want/+got:\n\t- %q\n\t+ %q", want, got)
+	}
+}
+
+func TestSigV4(t *testing.T) {
+	var req *http.Request
+	var body []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	cred := &SigV4{
+		Region:  "us-east-1",
+		Service: "execute-api",
+		Credentials: aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET", SessionToken: "SESSION"}, nil
+		}),
+	}
+	c, err := WithCredential(context.Background(), NewDefaultClient(url.PassValidator{}), cred)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := http.NewRequest("POST", ts.URL+"/path", bytes.NewReader([]byte("body")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	auth := req.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(auth, "/us-east-1/execute-api/aws4_request") {
+		t.Errorf("unexpected authorization header: %q", auth)
+	}
+	if want, got := "SESSION", req.Header.Get("X-Amz-Security-Token"); want != got {
+		t.Errorf("unexpected security token -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+	if want, got := "body", string(body); want != got {
+		t.Errorf("unexpected body -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+	if r.Header.Get("Authorization") != "" {
+		t.Error("original request must not be modified")
+	}
+}
+
+type mapSecretService map[string]string
+
+func (s mapSecretService) LoadSecret(ctx context.Context, k string) (string, error) {
+	return s[k], nil
+}
+
+func TestClientCertificate(t *testing.T) {
+	certPEM, keyPEM := generateCertificate(t)
+	clientCert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(clientCert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	ts.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  pool,
+	}
+	ts.StartTLS()
+	defer ts.Close()
+
+	serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	secrets := mapSecretService{
+		"cert": string(certPEM),
+		"key":  string(keyPEM),
+		"ca":   string(serverCA),
+	}
+
+	for _, tc := range []struct {
+		name    string
+		cred    *ClientCertificate
+		wantErr bool
+	}{
+		{
+			name: "with client certificate",
+			cred: &ClientCertificate{Secrets: secrets, CertKey: "cert", KeyKey: "key", CAKey: "ca"},
+		},
+		{
+			name:    "invalid certificate",
+			cred:    &ClientCertificate{Secrets: secrets, CertKey: "key", KeyKey: "key", CAKey: "ca"},
+			wantErr: true,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := WithCredential(context.Background(), NewLimitedDefaultClient(url.PassValidator{}), tc.cred)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			req, err := http.NewRequest("GET", ts.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := c.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = resp.Body.Close()
+			if want, got := http.StatusNoContent, resp.StatusCode; want != got {
+				t.Errorf("unexpected status code -want/+got:\n\t- %d\n\t+ %d", want, got)
+			}
+		})
+	}
+}
+
+func generateCertificate(t *testing.T) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "flux-client"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
@@ -0,0 +1,79 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/dependencies/secret"
+	"github.com/InfluxCommunity/flux/internal/errors"
+)
+
+// ClientCertificate is a credential that authenticates the client
+// with a TLS client certificate. The PEM encoded certificate, key and
+// optional certificate authority are loaded from the secret service.
+type ClientCertificate struct {
+	Secrets secret.Service
+	// CertKey is the secret key of the PEM encoded client certificate.
+	CertKey string
+	// KeyKey is the secret key of the PEM encoded private key.
+	KeyKey string
+	// CAKey is the secret key of the PEM encoded certificate authority
+	// used to verify the server. When empty, the system roots are used.
+	CAKey string
+}
+
+func (cc *ClientCertificate) Client(ctx context.Context, c Client) (Client, error) {
+	if cc.Secrets == nil {
+		return nil, errors.New(codes.Invalid, "client certificate credential requires a secret service")
+	}
+	certPEM, err := cc.Secrets.LoadSecret(ctx, cc.CertKey)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := cc.Secrets.LoadSecret(ctx, cc.KeyKey)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	if err != nil {
+		return nil, errors.Wrap(err, codes.Invalid, "invalid client certificate")
+	}
+
+	config := transportTLSConfig(c)
+	config.Certificates = []tls.Certificate{cert}
+	if cc.CAKey != "" {
+		caPEM, err := cc.Secrets.LoadSecret(ctx, cc.CAKey)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(caPEM)) {
+			return nil, errors.New(codes.Invalid, "invalid certificate authority")
+		}
+		config.RootCAs = pool
+	}
+	return WithTLSConfig(c, config)
+}
+
+// transportTLSConfig returns a copy of the TLS config of the client transport
+// so that settings such as InsecureSkipVerify are preserved.
+func transportTLSConfig(c Client) *tls.Config {
+	cli, ok := c.(*http.Client)
+	if !ok {
+		return &tls.Config{}
+	}
+	var transport *http.Transport
+	switch t := cli.Transport.(type) {
+	case *http.Transport:
+		transport = t
+	case roundTripLimiter:
+		transport, _ = t.RoundTripper.(*http.Transport)
+	}
+	if transport == nil || transport.TLSClientConfig == nil {
+		return &tls.Config{}
+	}
+	return transport.TLSClientConfig.Clone()
+}
@@ -0,0 +1,234 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
+)
+
+// tokenExpirySkew is how long before its expiry a cached token is refreshed.
+const tokenExpirySkew = 30 * time.Second
+
+// tokenRequestTimeout limits how long a shared token request may take.
+// The request is not canceled with the caller that started it, since
+// other callers may be waiting for it.
+const tokenRequestTimeout = time.Minute
+
+// OAuth2ClientCredentials is a credential that authorizes requests with
+// an access token obtained through the OAuth2 client credentials flow.
+// The token is cached and shared by every client created from the credential.
+// It is refreshed shortly before it expires, or after a request using it
+// is rejected with a 401 response.
+type OAuth2ClientCredentials struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// EndpointParams are additional parameters sent to the token endpoint.
+	EndpointParams url.Values
+
+	mu    sync.Mutex
+	token *oauth2Token
+	// fetch is the token request in progress, if any.
+	// Callers that need a new token share it.
+	fetch *tokenFetch
+	nowFn func() time.Time
+}
+
+type tokenFetch struct {
+	done  chan struct{}
+	token *oauth2Token
+	err   error
+}
+
+type oauth2Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+
+	expiry time.Time
+}
+
+func (t *oauth2Token) valid(now time.Time) bool {
+	return t != nil && t.AccessToken != "" &&
+		(t.expiry.IsZero() || now.Add(tokenExpirySkew).Before(t.expiry))
+}
+
+func (t *oauth2Token) header() string {
+	typ := t.TokenType
+	if typ == "" || strings.EqualFold(typ, "bearer") {
+		typ = "Bearer"
+	}
+	return typ + " " + t.AccessToken
+}
+
+func (o *OAuth2ClientCredentials) Client(ctx context.Context, c Client) (Client, error) {
+	if o.TokenURL == "" {
+		return nil, errors.New(codes.Invalid, "oauth2 credential requires a token url")
+	}
+	return &oauth2Client{client: c, cred: o}, nil
+}
+
+func (o *OAuth2ClientCredentials) now() time.Time {
+	if o.nowFn != nil {
+		return o.nowFn()
+	}
+	return time.Now()
+}
+
+// Token returns the cached access token, requesting a new one
+// from the token endpoint with the client if it is missing or expired.
+func (o *OAuth2ClientCredentials) Token(ctx context.Context, c Client) (string, error) {
+	tok, err := o.cachedToken(ctx, c)
+	if err != nil {
+		return "", err
+	}
+	return tok.AccessToken, nil
+}
+
+// cachedToken returns the cached token or waits for a new one.
+// Only one token request is made at a time and the lock is not held
+// while it is in progress, so a slow token endpoint only delays the
+// callers that need a new token. The request runs separately from
+// the caller that started it so each caller can give up on its own
+// without failing the others.
+func (o *OAuth2ClientCredentials) cachedToken(ctx context.Context, c Client) (*oauth2Token, error) {
+	o.mu.Lock()
+	if tok := o.token; tok.valid(o.now()) {
+		o.mu.Unlock()
+		return tok, nil
+	}
+	f := o.fetch
+	if f == nil {
+		f = &tokenFetch{done: make(chan struct{})}
+		o.fetch = f
+		go o.fetchToken(withoutCancel{ctx}, c, f)
+	}
+	o.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.token, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetchToken requests a new token, caches it and
+// then wakes the callers waiting for it.
+func (o *OAuth2ClientCredentials) fetchToken(ctx context.Context, c Client, f *tokenFetch) {
+	ctx, cancel := context.WithTimeout(ctx, tokenRequestTimeout)
+	defer cancel()
+	f.token, f.err = o.requestToken(ctx, c)
+
+	o.mu.Lock()
+	if f.err == nil {
+		o.token = f.token
+	}
+	o.fetch = nil
+	o.mu.Unlock()
+	close(f.done)
+}
+
+// withoutCancel keeps the values of its parent context
+// but is never canceled and has no deadline.
+type withoutCancel struct {
+	context.Context
+}
+
+func (withoutCancel) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (withoutCancel) Done() <-chan struct{}       { return nil }
+func (withoutCancel) Err() error                  { return nil }
+
+// invalidate drops the cached token if it is still the given token.
+func (o *OAuth2ClientCredentials) invalidate(tok *oauth2Token) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.token == tok {
+		o.token = nil
+	}
+}
+
+func (o *OAuth2ClientCredentials) requestToken(ctx context.Context, c Client) (*oauth2Token, error) {
+	form := url.Values{}
+	for k, vs := range o.EndpointParams {
+		form[k] = append([]string(nil), vs...)
+	}
+	form.Set("grant_type", "client_credentials")
+	if len(o.Scopes) > 0 {
+		form.Set("scope", strings.Join(o.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(o.ClientID), url.QueryEscape(o.ClientSecret))
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, codes.Unavailable, "failed to request oauth2 token")
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, errors.Wrap(err, codes.Unavailable, "failed to read oauth2 token response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		var oerr struct {
+			Error string `json:"error"`
+		}
+		_ = json.Unmarshal(body, &oerr)
+		if oerr.Error == "" {
+			oerr.Error = resp.Status
+		}
+		return nil, errors.Newf(codes.Unauthenticated, "failed to request oauth2 token: %s", oerr.Error)
+	}
+
+	tok := new(oauth2Token)
+	if err := json.Unmarshal(body, tok); err != nil {
+		return nil, errors.Wrap(err, codes.Internal, "failed to decode oauth2 token response")
+	}
+	if tok.AccessToken == "" {
+		return nil, errors.New(codes.Internal, "oauth2 token response is missing the access token")
+	}
+	if tok.ExpiresIn > 0 {
+		tok.expiry = o.now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+	}
+	return tok, nil
+}
+
+type oauth2Client struct {
+	client Client
+	cred   *OAuth2ClientCredentials
+}
+
+func (c *oauth2Client) Do(req *http.Request) (*http.Response, error) {
+	tok, err := c.cred.cachedToken(req.Context(), c.client)
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", tok.header())
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		// The token may have been revoked, so request
+		// a new one for the next request.
+		c.cred.invalidate(tok)
+	}
+	return resp, nil
+}
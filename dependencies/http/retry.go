@@ -0,0 +1,134 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
+)
+
+// maxRetryDrain is the maximum amount of a response body that is read
+// before retrying so the connection can be reused.
+const maxRetryDrain = 64 * 1024
+
+// maxRetryAfter caps the delay requested by a Retry-After header
+// when the retry policy does not cap the delay itself.
+const maxRetryAfter = 5 * time.Minute
+
+// RetryPolicy controls how requests are retried.
+type RetryPolicy struct {
+	// MaxRetries is the maximum number of times a request is retried.
+	MaxRetries int
+	// Backoff is the delay before the first retry.
+	// The delay doubles after every retry.
+	Backoff time.Duration
+	// MaxBackoff caps the delay between retries, including the delay
+	// requested by a Retry-After header. A value of zero means the
+	// backoff is not capped and a Retry-After delay is capped at 5 minutes.
+	MaxBackoff time.Duration
+}
+
+// Delay returns the delay before the given retry attempt, starting at 1.
+// A Retry-After header on the previous response takes precedence over
+// the exponential backoff.
+func (p RetryPolicy) Delay(attempt int, header http.Header) time.Duration {
+	if d, ok := retryAfter(header, time.Now()); ok {
+		max := p.MaxBackoff
+		if max <= 0 {
+			max = maxRetryAfter
+		}
+		if d > max {
+			d = max
+		}
+		return d
+	}
+	d := p.Backoff
+	for i := 1; i < attempt; i++ {
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			break
+		}
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+// retryAfter parses the Retry-After header which is either
+// a number of seconds or an HTTP date.
+func retryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	v := header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// IsRetryable reports whether a response with the given status code should be retried.
+func IsRetryable(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+// WithRetry wraps a client so that requests that receive a 5xx or 429
+// response are retried according to the policy.
+// The response of the last attempt is returned when the retries are exhausted.
+// Requests with a body must be replayable through GetBody.
+func WithRetry(c Client, policy RetryPolicy) Client {
+	if policy.MaxRetries <= 0 {
+		return c
+	}
+	return &retryClient{client: c, policy: policy}
+}
+
+type retryClient struct {
+	client Client
+	policy RetryPolicy
+}
+
+func (c *retryClient) Do(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return nil, errors.New(codes.Internal, "cannot retry request with a body that cannot be replayed")
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if !IsRetryable(resp.StatusCode) || attempt >= c.policy.MaxRetries {
+			return resp, nil
+		}
+
+		delay := c.policy.Delay(attempt+1, resp.Header)
+		_, _ = io.CopyN(io.Discard, resp.Body, maxRetryDrain)
+		_ = resp.Body.Close()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package http
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	depsUrl "github.com/InfluxCommunity/flux/dependencies/url"
+)
+
+func TestRetryClient(t *testing.T) {
+	testCases := []struct {
+		name       string
+		statuses   []int
+		retryAfter string
+		maxRetries int
+		wantStatus int
+		wantCalls  int
+	}{
+		{
+			name:       "success without retry",
+			statuses:   []int{200},
+			maxRetries: 3,
+			wantStatus: 200,
+			wantCalls:  1,
+		},
+		{
+			name:       "retry server errors",
+			statuses:   []int{500, 503, 200},
+			maxRetries: 3,
+			wantStatus: 200,
+			wantCalls:  3,
+		},
+		{
+			name:       "retry too many requests with retry after",
+			statuses:   []int{429, 200},
+			retryAfter: "0",
+			maxRetries: 1,
+			wantStatus: 200,
+			wantCalls:  2,
+		},
+		{
+			name:       "retries exhausted",
+			statuses:   []int{502, 502, 502},
+			maxRetries: 2,
+			wantStatus: 502,
+			wantCalls:  3,
+		},
+		{
+			name:       "client errors are not retried",
+			statuses:   []int{404, 200},
+			maxRetries: 3,
+			wantStatus: 404,
+			wantCalls:  1,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				if err != nil {
+					t.Errorf("error reading body: %v", err)
+				}
+				if want, got := "body", string(body); want != got {
+					t.Errorf("unexpected body on attempt %d -want/+got:\n\t- %s\n\t+ %s", calls, want, got)
+				}
+				status := tc.statuses[calls]
+				calls++
+				if tc.retryAfter != "" {
+					w.Header().Set("Retry-After", tc.retryAfter)
+				}
+				w.WriteHeader(status)
+			}))
+			defer ts.Close()
+
+			c := WithRetry(NewDefaultClient(depsUrl.PassValidator{}), RetryPolicy{
+				MaxRetries: tc.maxRetries,
+				Backoff:    time.Millisecond,
+			})
+			req, err := http.NewRequest("POST", ts.URL, bytes.NewReader([]byte("body")))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := c.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = resp.Body.Close()
+
+			if want, got := tc.wantStatus, resp.StatusCode; want != got {
+				t.Errorf("unexpected status code -want/+got:\n\t- %d\n\t+ %d", want, got)
+			}
+			if want, got := tc.wantCalls, calls; want != got {
+				t.Errorf("unexpected number of calls -want/+got:\n\t- %d\n\t+ %d", want, got)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := RetryPolicy{
+		Backoff:    100 * time.Millisecond,
+		MaxBackoff: time.Second,
+	}
+	for attempt, want := range []time.Duration{
+		1: 100 * time.Millisecond,
+		2: 200 * time.Millisecond,
+		3: 400 * time.Millisecond,
+		4: 800 * time.Millisecond,
+		5: time.Second,
+		6: time.Second,
+	} {
+		if attempt == 0 {
+			continue
+		}
+		if got := policy.Delay(attempt, http.Header{}); want != got {
+			t.Errorf("unexpected delay for attempt %d -want/+got:\n\t- %v\n\t+ %v", attempt, want, got)
+		}
+	}
+
+	header := http.Header{}
+	header.Set("Retry-After", "5")
+	if want, got := time.Second, policy.Delay(1, header); want != got {
+		t.Errorf("unexpected delay with Retry-After -want/+got:\n\t- %v\n\t+ %v", want, got)
+	}
+	if want, got := 5*time.Second, (RetryPolicy{}).Delay(1, header); want != got {
+		t.Errorf("unexpected delay with Retry-After and no max backoff -want/+got:\n\t- %v\n\t+ %v", want, got)
+	}
+
+	header.Set("Retry-After", "86400")
+	if want, got := maxRetryAfter, (RetryPolicy{}).Delay(1, header); want != got {
+		t.Errorf("unexpected delay with long Retry-After -want/+got:\n\t- %v\n\t+ %v", want, got)
+	}
+
+	header.Set("Retry-After", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat))
+	if want, got := time.Duration(0), policy.Delay(1, header); want != got {
+		t.Errorf("unexpected delay with past Retry-After date -want/+got:\n\t- %v\n\t+ %v", want, got)
+	}
+}
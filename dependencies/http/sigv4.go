@@ -0,0 +1,83 @@
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// SigV4 is a credential that signs requests with AWS Signature Version 4.
+type SigV4 struct {
+	Region  string
+	Service string
+	// Credentials retrieves the AWS access keys used to sign requests.
+	Credentials aws.CredentialsProvider
+}
+
+func (s *SigV4) Client(ctx context.Context, c Client) (Client, error) {
+	if s.Region == "" || s.Service == "" {
+		return nil, errors.New(codes.Invalid, "sigv4 credential requires a region and a service")
+	}
+	if s.Credentials == nil {
+		return nil, errors.New(codes.Invalid, "sigv4 credential requires aws credentials")
+	}
+	return &sigV4Client{
+		client: c,
+		cred:   s,
+		signer: v4.NewSigner(),
+	}, nil
+}
+
+type sigV4Client struct {
+	client Client
+	cred   *SigV4
+	signer *v4.Signer
+}
+
+func (c *sigV4Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	creds, err := c.cred.Credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, codes.Unauthenticated, "failed to retrieve aws credentials")
+	}
+
+	payloadHash, err := hashBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(ctx)
+	if err := c.signer.SignHTTP(ctx, creds, req, payloadHash, c.cred.Service, c.cred.Region, time.Now()); err != nil {
+		return nil, errors.Wrap(err, codes.Internal, "failed to sign request")
+	}
+	return c.client.Do(req)
+}
+
+// hashBody returns the hex encoded SHA-256 hash of the request body.
+// The body is read through GetBody so the request can still be sent.
+func hashBody(req *http.Request) (string, error) {
+	h := sha256.New()
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return "", errors.New(codes.Internal, "cannot sign request with a body that cannot be replayed")
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(h, body)
+		_ = body.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
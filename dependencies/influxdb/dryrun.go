@@ -0,0 +1,47 @@
+package influxdb
+
+import (
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/dependencies/dryrun"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/influxdata/line-protocol/v2/lineprotocol"
+)
+
+// DryRunWriter is a Writer that writes the metrics as line protocol
+// to a dryrun.Writer instead of writing them to InfluxDB.
+type DryRunWriter struct {
+	w   *dryrun.Writer
+	enc lineprotocol.Encoder
+}
+
+var _ Writer = (*DryRunWriter)(nil)
+
+// NewDryRunWriter creates a DryRunWriter that captures the metrics with w.
+func NewDryRunWriter(w *dryrun.Writer) *DryRunWriter {
+	return &DryRunWriter{w: w}
+}
+
+func (d *DryRunWriter) Write(metric ...Metric) error {
+	for _, m := range metric {
+		d.enc.Reset()
+		record, err := encodeMetric(&d.enc, m)
+		if err != nil {
+			// Wrap the error with failed precondition because
+			// the error was caused by user data.
+			return errors.Wrap(err, codes.FailedPrecondition)
+		}
+		if len(record) == 0 {
+			continue
+		}
+		if _, err := d.w.Write(record); err != nil {
+			return err
+		}
+		d.w.AddRows(1)
+	}
+	return nil
+}
+
+// Close captures the metrics that were written.
+func (d *DryRunWriter) Close() error {
+	return d.w.Close()
+}
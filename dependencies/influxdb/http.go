@@ -17,6 +17,7 @@ import (
 	"github.com/InfluxCommunity/flux/codes"
 	"github.com/InfluxCommunity/flux/csv"
 	"github.com/InfluxCommunity/flux/dependencies/http"
+	furl "github.com/InfluxCommunity/flux/dependencies/url"
 	"github.com/InfluxCommunity/flux/internal/errors"
 	"github.com/InfluxCommunity/flux/semantic"
 	"github.com/InfluxCommunity/flux/values"
@@ -34,10 +35,17 @@ type HttpProvider struct {
 	DefaultConfig Config
 }
 
-var _ Provider = HttpProvider{}
+var _ AggregateProvider = HttpProvider{}
+
+// The functions that connections made by the provider are scoped to
+// by the URL validator.
+const (
+	readFunction  = "influxdata/influxdb.from"
+	writeFunction = "influxdata/influxdb.to"
+)
 
 func (h HttpProvider) ReaderFor(ctx context.Context, conf Config, bounds flux.Bounds, predicateSet PredicateSet) (Reader, error) {
-	c, err := h.clientFor(ctx, conf)
+	c, err := h.clientFor(ctx, conf, readFunction)
 	if err != nil {
 		return nil, err
 	}
@@ -58,7 +66,7 @@ func (h HttpProvider) SeriesCardinalityReaderFor(ctx context.Context, conf Confi
 	}
 
 	// Retrieve the client and create the http reader.
-	c, err := h.clientFor(ctx, conf)
+	c, err := h.clientFor(ctx, conf, readFunction)
 	if err != nil {
 		return nil, err
 	}
@@ -69,8 +77,50 @@ func (h HttpProvider) SeriesCardinalityReaderFor(ctx context.Context, conf Confi
 	}, nil
 }
 
+// SupportsWindowAggregate reports that every WindowAggregate is
+// supported since it is evaluated by the query of the read.
+func (h HttpProvider) SupportsWindowAggregate(spec WindowAggregate) bool {
+	return true
+}
+
+func (h HttpProvider) WindowAggregateReaderFor(ctx context.Context, conf Config, bounds flux.Bounds, predicateSet PredicateSet, spec WindowAggregate) (Reader, error) {
+	c, err := h.clientFor(ctx, conf, readFunction)
+	if err != nil {
+		return nil, err
+	}
+	return windowAggregateHttpReader{
+		filteredHttpReader: filteredHttpReader{
+			HttpClient:   c,
+			Bounds:       bounds,
+			PredicateSet: predicateSet,
+		},
+		Spec: spec,
+	}, nil
+}
+
+// SupportsGroupAggregate reports that every GroupAggregate is
+// supported since it is evaluated by the query of the read.
+func (h HttpProvider) SupportsGroupAggregate(spec GroupAggregate) bool {
+	return true
+}
+
+func (h HttpProvider) GroupAggregateReaderFor(ctx context.Context, conf Config, bounds flux.Bounds, predicateSet PredicateSet, spec GroupAggregate) (Reader, error) {
+	c, err := h.clientFor(ctx, conf, readFunction)
+	if err != nil {
+		return nil, err
+	}
+	return groupAggregateHttpReader{
+		filteredHttpReader: filteredHttpReader{
+			HttpClient:   c,
+			Bounds:       bounds,
+			PredicateSet: predicateSet,
+		},
+		Spec: spec,
+	}, nil
+}
+
 func (h HttpProvider) WriterFor(ctx context.Context, conf Config) (Writer, error) {
-	httpClient, err := h.clientFor(ctx, conf)
+	httpClient, err := h.clientFor(ctx, conf, writeFunction)
 	if err != nil {
 		return nil, err
 	}
@@ -84,7 +134,9 @@ func (h HttpProvider) WriterFor(ctx context.Context, conf Config) (Writer, error
 	return newHttpWriter(writer)
 }
 
-func (h HttpProvider) clientFor(ctx context.Context, conf Config) (*HttpClient, error) {
+// clientFor returns a client for the configuration.
+// The host is validated for connections made by the function.
+func (h HttpProvider) clientFor(ctx context.Context, conf Config, function string) (*HttpClient, error) {
 	deps := flux.GetDependencies(ctx)
 	var (
 		httpc http.Client
@@ -109,7 +161,7 @@ func (h HttpProvider) clientFor(ctx context.Context, conf Config) (*HttpClient,
 	if conf.Bucket.IsZero() {
 		conf.Bucket = h.DefaultConfig.Bucket
 	}
-	if err := h.validateHost(deps, conf.Host); err != nil {
+	if err := h.validateHost(deps, conf.Host, function); err != nil {
 		return nil, err
 	}
 	if conf.Token == "" {
@@ -121,7 +173,7 @@ func (h HttpProvider) clientFor(ctx context.Context, conf Config) (*HttpClient,
 	}, nil
 }
 
-func (h HttpProvider) validateHost(deps flux.Dependencies, host string) error {
+func (h HttpProvider) validateHost(deps flux.Dependencies, host, function string) error {
 	if host == "" {
 		return errors.New(codes.Invalid, "influxdb provider requires a host to be specified")
 	}
@@ -135,7 +187,7 @@ func (h HttpProvider) validateHost(deps flux.Dependencies, host string) error {
 	if err != nil {
 		return err
 	}
-	return validator.Validate(u)
+	return furl.ForFunction(validator, function).Validate(u)
 }
 
 // HttpClient is an http client for reading from an influxdb instance.
@@ -359,7 +411,19 @@ type filteredHttpReader struct {
 
 func (h filteredHttpReader) Read(ctx context.Context, f func(flux.Table) error, mem memory.Allocator) error {
 	imports := make(map[string]*ast.ImportDeclaration)
-	query := &ast.PipeExpression{
+	query := h.readExpression(imports)
+
+	file := h.newFile(imports)
+	file.Body = []ast.Statement{
+		&ast.ExpressionStatement{Expression: query},
+	}
+	return h.Query(ctx, f, &file, h.Bounds.Now, mem)
+}
+
+// readExpression constructs the expression that reads
+// the bounds from the bucket and filters it with the predicates.
+func (h filteredHttpReader) readExpression(imports map[string]*ast.ImportDeclaration) ast.Expression {
+	var query ast.Expression = &ast.PipeExpression{
 		Argument: &ast.CallExpression{
 			Callee: &ast.Identifier{Name: "from"},
 			Arguments: []ast.Expression{
@@ -388,17 +452,91 @@ func (h filteredHttpReader) Read(ctx context.Context, f func(flux.Table) error,
 				Value: ast.StringLiteralFromValue("keep"),
 			})
 		}
-		query = &ast.PipeExpression{
-			Argument: query,
-			Call: &ast.CallExpression{
-				Callee: &ast.Identifier{Name: "filter"},
-				Arguments: []ast.Expression{
-					&ast.ObjectExpression{
-						Properties: params,
-					},
-				},
+		query = pipeCall(query, "filter", params)
+	}
+	return query
+}
+
+// pipeCall pipes the expression into a call to the function
+// with the properties as its arguments.
+func pipeCall(argument ast.Expression, name string, properties []*ast.Property) *ast.PipeExpression {
+	call := &ast.CallExpression{
+		Callee: &ast.Identifier{Name: name},
+	}
+	if len(properties) > 0 {
+		call.Arguments = []ast.Expression{
+			&ast.ObjectExpression{Properties: properties},
+		}
+	}
+	return &ast.PipeExpression{
+		Argument: argument,
+		Call:     call,
+	}
+}
+
+// durationToAST converts the duration to its ast representation.
+func durationToAST(d flux.Duration) ast.Expression {
+	if d.IsNegative() {
+		return &ast.UnaryExpression{
+			Operator: ast.SubtractionOperator,
+			Argument: &ast.DurationLiteral{Values: d.Mul(-1).AsValues()},
+		}
+	}
+	return &ast.DurationLiteral{Values: d.AsValues()}
+}
+
+type windowAggregateHttpReader struct {
+	filteredHttpReader
+	Spec WindowAggregate
+}
+
+func (h windowAggregateHttpReader) Read(ctx context.Context, f func(flux.Table) error, mem memory.Allocator) error {
+	imports := make(map[string]*ast.ImportDeclaration)
+	query := h.readExpression(imports)
+
+	params := []*ast.Property{
+		{
+			Key:   &ast.Identifier{Name: "every"},
+			Value: durationToAST(h.Spec.Every),
+		},
+		{
+			Key:   &ast.Identifier{Name: "period"},
+			Value: durationToAST(h.Spec.Period),
+		},
+	}
+	if !h.Spec.Offset.IsZero() {
+		params = append(params, &ast.Property{
+			Key:   &ast.Identifier{Name: "offset"},
+			Value: durationToAST(h.Spec.Offset),
+		})
+	}
+	if h.Spec.TimeSrc != "" {
+		// The aggregate and the combination of the windows
+		// are done by aggregateWindow.
+		params = append(params,
+			&ast.Property{
+				Key:   &ast.Identifier{Name: "fn"},
+				Value: &ast.Identifier{Name: h.Spec.Aggregate},
+			},
+			&ast.Property{
+				Key:   &ast.Identifier{Name: "timeSrc"},
+				Value: ast.StringLiteralFromValue(h.Spec.TimeSrc),
 			},
+			&ast.Property{
+				Key:   &ast.Identifier{Name: "createEmpty"},
+				Value: ast.BooleanLiteralFromValue(h.Spec.CreateEmpty),
+			},
+		)
+		query = pipeCall(query, "aggregateWindow", params)
+	} else {
+		if h.Spec.CreateEmpty {
+			params = append(params, &ast.Property{
+				Key:   &ast.Identifier{Name: "createEmpty"},
+				Value: ast.BooleanLiteralFromValue(true),
+			})
 		}
+		query = pipeCall(query, "window", params)
+		query = pipeCall(query, h.Spec.Aggregate, nil)
 	}
 
 	file := h.newFile(imports)
@@ -408,6 +546,44 @@ func (h filteredHttpReader) Read(ctx context.Context, f func(flux.Table) error,
 	return h.Query(ctx, f, &file, h.Bounds.Now, mem)
 }
 
+type groupAggregateHttpReader struct {
+	filteredHttpReader
+	Spec GroupAggregate
+}
+
+func (h groupAggregateHttpReader) Read(ctx context.Context, f func(flux.Table) error, mem memory.Allocator) error {
+	imports := make(map[string]*ast.ImportDeclaration)
+	query := h.readExpression(imports)
+
+	columns := &ast.ArrayExpression{
+		Elements: make([]ast.Expression, len(h.Spec.Columns)),
+	}
+	for i, c := range h.Spec.Columns {
+		columns.Elements[i] = ast.StringLiteralFromValue(c)
+	}
+	mode := "by"
+	if h.Spec.Mode == flux.GroupModeExcept {
+		mode = "except"
+	}
+	query = pipeCall(query, "group", []*ast.Property{
+		{
+			Key:   &ast.Identifier{Name: "columns"},
+			Value: columns,
+		},
+		{
+			Key:   &ast.Identifier{Name: "mode"},
+			Value: ast.StringLiteralFromValue(mode),
+		},
+	})
+	query = pipeCall(query, h.Spec.Aggregate, nil)
+
+	file := h.newFile(imports)
+	file.Body = []ast.Statement{
+		&ast.ExpressionStatement{Expression: query},
+	}
+	return h.Query(ctx, f, &file, h.Bounds.Now, mem)
+}
+
 type seriesCardinalityHttpReader struct {
 	*HttpClient
 	Bounds       flux.Bounds
@@ -507,7 +683,7 @@ var _ Writer = &httpWriter{}
 func (h *httpWriter) Write(metric ...Metric) error {
 	var enc lineprotocol.Encoder
 	for _, m := range metric {
-		record, err := h.encodeMetric(&enc, m)
+		record, err := encodeMetric(&enc, m)
 		if err != nil {
 			h.writer.Flush()
 
@@ -530,7 +706,9 @@ func (h *httpWriter) Write(metric ...Metric) error {
 	return nil
 }
 
-func (h *httpWriter) encodeMetric(enc *lineprotocol.Encoder, m Metric) ([]byte, error) {
+// encodeMetric encodes the metric as a line of line protocol.
+// If the metric has no field that can be encoded, no line is returned.
+func encodeMetric(enc *lineprotocol.Encoder, m Metric) ([]byte, error) {
 	enc.StartLine(m.Name())
 
 	// The line protocol encoder checks for ordering so we need to ensure that
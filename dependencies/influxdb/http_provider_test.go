@@ -32,7 +32,7 @@ func TestPrivateClient(t *testing.T) {
 		URLValidator: fluxurl.PrivateIPValidator{},
 	}}
 	ctx, _ := dependency.Inject(context.Background(), deps)
-	c, err := h.clientFor(ctx, Config{})
+	c, err := h.clientFor(ctx, Config{}, readFunction)
 	if err != nil {
 		t.Error(err)
 	}
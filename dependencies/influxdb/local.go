@@ -0,0 +1,380 @@
+package influxdb
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/influxdata/line-protocol/v2/lineprotocol"
+)
+
+// LocalProvider is an implementation of the Provider that reads
+// and writes buckets stored in a directory on the local disk
+// instead of an influxdb instance.
+//
+// Each bucket is a file of line protocol at <dir>/<org>/<bucket>.lp
+// and writes are appended to it. Buckets are created on their first write.
+// When a series has more than one value for a field at the same time,
+// the last value written is read.
+//
+// The host and token of the configuration are ignored.
+type LocalProvider struct {
+	// Dir is the directory that holds the buckets of every organization.
+	Dir string
+
+	// DefaultConfig holds the organization and bucket
+	// that are used when a configuration does not set them.
+	DefaultConfig Config
+}
+
+var _ Provider = LocalProvider{}
+
+func (l LocalProvider) ReaderFor(ctx context.Context, conf Config, bounds flux.Bounds, predicateSet PredicateSet) (Reader, error) {
+	path, err := l.bucketPath(conf)
+	if err != nil {
+		return nil, err
+	}
+	return localReader{
+		path:         path,
+		bounds:       bounds,
+		predicateSet: predicateSet,
+	}, nil
+}
+
+func (l LocalProvider) SeriesCardinalityReaderFor(ctx context.Context, conf Config, bounds flux.Bounds, predicateSet PredicateSet) (Reader, error) {
+	// If any of the predicates use keep empty then they are not
+	// valid for series cardinality reader.
+	for _, p := range predicateSet {
+		if p.KeepEmpty {
+			return nil, errors.New(codes.Unimplemented, "keep empty filter option is not allowed for the series cardinality reader")
+		}
+	}
+
+	path, err := l.bucketPath(conf)
+	if err != nil {
+		return nil, err
+	}
+	return localSeriesCardinalityReader{
+		path:         path,
+		bounds:       bounds,
+		predicateSet: predicateSet,
+	}, nil
+}
+
+func (l LocalProvider) WriterFor(ctx context.Context, conf Config) (Writer, error) {
+	path, err := l.bucketPath(conf)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, errors.Wrap(err, codes.Internal, "failed to create bucket")
+	}
+
+	// Read the type of every field so writes
+	// that conflict with them can be rejected.
+	fieldTypes, err := localFieldTypes.get(path)
+	if err != nil {
+		return nil, err
+	}
+	return &localWriter{
+		path:       path,
+		fieldTypes: fieldTypes,
+	}, nil
+}
+
+// bucketPath returns the path of the file that stores
+// the bucket of the configuration.
+func (l LocalProvider) bucketPath(conf Config) (string, error) {
+	if l.Dir == "" {
+		return "", errors.New(codes.Invalid, "local influxdb provider requires a directory")
+	}
+	org, bucket := conf.Org, conf.Bucket
+	if org.IsZero() {
+		org = l.DefaultConfig.Org
+	}
+	if bucket.IsZero() {
+		bucket = l.DefaultConfig.Bucket
+	}
+	if org.IsZero() {
+		return "", errors.New(codes.Invalid, "organization is required")
+	}
+	if bucket.IsZero() {
+		return "", errors.New(codes.Invalid, "bucket is required")
+	}
+
+	orgDir, err := localPathElem(org.IdOrName())
+	if err != nil {
+		return "", err
+	}
+	bucketName, err := localPathElem(bucket.IdOrName())
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(l.Dir, orgDir, bucketName+".lp"), nil
+}
+
+// localPathElem escapes the name of an organization or bucket
+// so it can be used as a single element of a path.
+func localPathElem(name string) (string, error) {
+	if name == "." || name == ".." {
+		return "", errors.Newf(codes.Invalid, "invalid name %q", name)
+	}
+	return url.PathEscape(name), nil
+}
+
+// localFieldTypes are the types of the fields of the buckets
+// that have been written to by this process.
+var localFieldTypes = &localFieldTypeCache{
+	buckets: make(map[string]*localBucketFieldTypes),
+}
+
+// localFieldTypeCache remembers the types of the fields of buckets
+// so each write only reads the lines that were appended to the
+// bucket since the previous write, instead of the whole bucket.
+type localFieldTypeCache struct {
+	mu      sync.Mutex
+	buckets map[string]*localBucketFieldTypes
+}
+
+// localBucketFieldTypes are the types of the fields of a bucket
+// up to the offset of the file that has been read.
+type localBucketFieldTypes struct {
+	mu     sync.Mutex
+	file   os.FileInfo
+	offset int64
+	types  map[string]lineprotocol.ValueKind
+}
+
+// get returns a copy of the types of the fields of the bucket at the path,
+// keyed by the measurement and the field separated by a null byte.
+func (c *localFieldTypeCache) get(path string) (map[string]lineprotocol.ValueKind, error) {
+	c.mu.Lock()
+	b, ok := c.buckets[path]
+	if !ok {
+		b = &localBucketFieldTypes{}
+		c.buckets[path] = b
+	}
+	c.mu.Unlock()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.update(path); err != nil {
+		return nil, err
+	}
+	types := make(map[string]lineprotocol.ValueKind, len(b.types))
+	for k, v := range b.types {
+		types[k] = v
+	}
+	return types, nil
+}
+
+// update reads the lines that were appended to the bucket since it
+// was last read. The bucket is read again from the start if the file
+// was replaced or truncated.
+func (b *localBucketFieldTypes) update(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			b.file, b.offset, b.types = nil, 0, nil
+			return nil
+		}
+		return errors.Wrap(err, codes.Internal, "failed to open bucket")
+	}
+	defer func() { _ = f.Close() }()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return errors.Wrap(err, codes.Internal, "failed to open bucket")
+	}
+	if b.file == nil || !os.SameFile(b.file, fi) || fi.Size() < b.offset {
+		b.offset, b.types = 0, nil
+	}
+	b.file = fi
+	if b.types == nil {
+		b.types = make(map[string]lineprotocol.ValueKind)
+	}
+	if fi.Size() == b.offset {
+		return nil
+	}
+	if _, err := f.Seek(b.offset, io.SeekStart); err != nil {
+		return errors.Wrap(err, codes.Internal, "failed to read bucket")
+	}
+	r := io.LimitReader(f, fi.Size()-b.offset)
+	if err := decodeLocalBucket(r, func(measurement string, tags []Tag, field string, t int64, v lineprotocol.Value) error {
+		b.types[measurement+"\x00"+field] = v.Kind()
+		return nil
+	}); err != nil {
+		// Read the whole bucket again the next time.
+		b.offset, b.types = 0, nil
+		return err
+	}
+	b.offset = fi.Size()
+	return nil
+}
+
+// readLocalBucket calls fn with every field of every line in the bucket.
+// If the bucket does not exist, an error with the code codes.NotFound is returned.
+func readLocalBucket(path string, fn func(measurement string, tags []Tag, field string, t int64, v lineprotocol.Value) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			name := strings.TrimSuffix(filepath.Base(path), ".lp")
+			if unescaped, err := url.PathUnescape(name); err == nil {
+				name = unescaped
+			}
+			return errors.Newf(codes.NotFound, "bucket %q not found", name)
+		}
+		return errors.Wrap(err, codes.Internal, "failed to open bucket")
+	}
+	defer func() { _ = f.Close() }()
+	return decodeLocalBucket(f, fn)
+}
+
+// decodeLocalBucket calls fn with every field of every line of line protocol.
+func decodeLocalBucket(r io.Reader, fn func(measurement string, tags []Tag, field string, t int64, v lineprotocol.Value) error) error {
+	type field struct {
+		key   string
+		value lineprotocol.Value
+	}
+	var fields []field
+	dec := lineprotocol.NewDecoder(r)
+	for dec.Next() {
+		measurement, err := dec.Measurement()
+		if err != nil {
+			return errors.Wrap(err, codes.Internal, "failed to read bucket")
+		}
+		m := string(measurement)
+
+		var tags []Tag
+		for {
+			key, value, err := dec.NextTag()
+			if err != nil {
+				return errors.Wrap(err, codes.Internal, "failed to read bucket")
+			} else if key == nil {
+				break
+			}
+			tags = append(tags, Tag{Key: string(key), Value: string(value)})
+		}
+		sort.Slice(tags, func(i, j int) bool {
+			return tags[i].Key < tags[j].Key
+		})
+
+		fields = fields[:0]
+		for {
+			key, value, err := dec.NextField()
+			if err != nil {
+				return errors.Wrap(err, codes.Internal, "failed to read bucket")
+			} else if key == nil {
+				break
+			}
+			fields = append(fields, field{key: string(key), value: value})
+		}
+
+		ts, err := dec.Time(lineprotocol.Nanosecond, time.Time{})
+		if err != nil {
+			return errors.Wrap(err, codes.Internal, "failed to read bucket")
+		} else if ts.IsZero() {
+			return errors.Newf(codes.Internal, "failed to read bucket: line for measurement %q has no timestamp", m)
+		}
+		for _, f := range fields {
+			if err := fn(m, tags, f.key, ts.UnixNano(), f.value); err != nil {
+				return err
+			}
+		}
+	}
+	if err := dec.Err(); err != nil {
+		return errors.Wrap(err, codes.Internal, "failed to read bucket")
+	}
+	return nil
+}
+
+// localWriter appends the metrics to the file of a bucket.
+// The metrics are buffered and written when the buffer is full
+// or the writer is closed, with a single write each time.
+type localWriter struct {
+	path string
+
+	mu         sync.Mutex
+	buf        bytes.Buffer
+	enc        lineprotocol.Encoder
+	fieldTypes map[string]lineprotocol.ValueKind
+}
+
+// localWriterBufferSize is the size of the buffer
+// after which the metrics are written to the file.
+const localWriterBufferSize = 1 << 20
+
+func (w *localWriter) Write(metric ...Metric) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, m := range metric {
+		for _, field := range m.FieldList() {
+			v, ok := lineprotocol.NewValue(field.Value)
+			if !ok {
+				continue
+			}
+			key := m.Name() + "\x00" + field.Key
+			if kind, ok := w.fieldTypes[key]; ok && kind != v.Kind() {
+				return errors.Newf(codes.FailedPrecondition,
+					"field type conflict: input field %q on measurement %q is type %s, already exists as type %s",
+					field.Key, m.Name(), v.Kind(), kind)
+			}
+		}
+
+		w.enc.Reset()
+		record, err := encodeMetric(&w.enc, m)
+		if err != nil {
+			// Wrap the error with failed precondition because
+			// the error was caused by user data.
+			return errors.Wrap(err, codes.FailedPrecondition)
+		}
+		if len(record) == 0 {
+			continue
+		}
+		for _, field := range m.FieldList() {
+			if v, ok := lineprotocol.NewValue(field.Value); ok {
+				w.fieldTypes[m.Name()+"\x00"+field.Key] = v.Kind()
+			}
+		}
+		w.buf.Write(record)
+	}
+	if w.buf.Len() >= localWriterBufferSize {
+		return w.flush()
+	}
+	return nil
+}
+
+func (w *localWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.flush()
+}
+
+func (w *localWriter) flush() error {
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	f, err := os.OpenFile(w.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return errors.Wrap(err, codes.Internal, "failed to write to bucket")
+	}
+	if _, err := f.Write(w.buf.Bytes()); err != nil {
+		_ = f.Close()
+		return errors.Wrap(err, codes.Internal, "failed to write to bucket")
+	}
+	w.buf.Reset()
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, codes.Internal, "failed to write to bucket")
+	}
+	return nil
+}
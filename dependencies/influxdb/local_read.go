@@ -0,0 +1,375 @@
+package influxdb
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/array"
+	"github.com/InfluxCommunity/flux/arrow"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/compiler"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/table"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	fluxmemory "github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/values"
+	"github.com/apache/arrow/go/v7/arrow/memory"
+	"github.com/influxdata/line-protocol/v2/lineprotocol"
+)
+
+// localSeries is a field of a series with its points within the bounds of a read.
+type localSeries struct {
+	measurement string
+	tags        []Tag
+	field       string
+	kind        lineprotocol.ValueKind
+	points      map[int64]lineprotocol.Value
+}
+
+// The approximate sizes of a series and of a point of a series
+// that are accounted for while the series are held in memory.
+const (
+	localSeriesSize = 128
+	localPointSize  = 48
+)
+
+// readLocalSeries reads the series of the bucket that have at least
+// one point within the bounds, sorted by their series key.
+//
+// The series are held in memory until they are turned into tables,
+// so their size is accounted with the allocator. The size that
+// was accounted is returned and must be released by the caller
+// once the series are no longer used, even if an error is returned.
+func readLocalSeries(path string, bounds flux.Bounds, mem fluxmemory.Allocator) ([]*localSeries, int, error) {
+	start := bounds.Start.Time(bounds.Now).UnixNano()
+	stop := bounds.Stop.Time(bounds.Now).UnixNano()
+
+	bySeries := make(map[string]*localSeries)
+	var (
+		sb   strings.Builder
+		size int
+	)
+	account := func(n int) error {
+		if err := mem.Account(n); err != nil {
+			return err
+		}
+		size += n
+		return nil
+	}
+	if err := readLocalBucket(path, func(measurement string, tags []Tag, field string, t int64, v lineprotocol.Value) error {
+		if t < start || t >= stop {
+			return nil
+		}
+
+		sb.Reset()
+		sb.WriteString(measurement)
+		for _, tag := range tags {
+			sb.WriteByte(0)
+			sb.WriteString(tag.Key)
+			sb.WriteByte(0)
+			sb.WriteString(tag.Value)
+		}
+		sb.WriteByte(0)
+		sb.WriteString(field)
+
+		s, ok := bySeries[sb.String()]
+		if !ok {
+			if err := account(localSeriesSize + 2*sb.Len()); err != nil {
+				return err
+			}
+			s = &localSeries{
+				measurement: measurement,
+				tags:        tags,
+				field:       field,
+				kind:        v.Kind(),
+				points:      make(map[int64]lineprotocol.Value),
+			}
+			bySeries[sb.String()] = s
+		} else if s.kind != v.Kind() {
+			return errors.Newf(codes.FailedPrecondition,
+				"field type conflict: field %q on measurement %q is type %s and %s",
+				field, measurement, s.kind, v.Kind())
+		}
+		if _, ok := s.points[t]; !ok {
+			n := localPointSize
+			if v.Kind() == lineprotocol.String {
+				n += len(v.StringV())
+			}
+			if err := account(n); err != nil {
+				return err
+			}
+		}
+		s.points[t] = v
+		return nil
+	}); err != nil {
+		return nil, size, err
+	}
+
+	series := make([]*localSeries, 0, len(bySeries))
+	for _, s := range bySeries {
+		series = append(series, s)
+	}
+	sort.Slice(series, func(i, j int) bool {
+		return series[i].less(series[j])
+	})
+	return series, size, nil
+}
+
+func (s *localSeries) less(o *localSeries) bool {
+	if s.measurement != o.measurement {
+		return s.measurement < o.measurement
+	}
+	for i := 0; i < len(s.tags) && i < len(o.tags); i++ {
+		if s.tags[i].Key != o.tags[i].Key {
+			return s.tags[i].Key < o.tags[i].Key
+		}
+		if s.tags[i].Value != o.tags[i].Value {
+			return s.tags[i].Value < o.tags[i].Value
+		}
+	}
+	if len(s.tags) != len(o.tags) {
+		return len(s.tags) < len(o.tags)
+	}
+	return s.field < o.field
+}
+
+// localColType returns the column type for the values of a field.
+func localColType(kind lineprotocol.ValueKind) flux.ColType {
+	switch kind {
+	case lineprotocol.Int:
+		return flux.TInt
+	case lineprotocol.Uint:
+		return flux.TUInt
+	case lineprotocol.Float:
+		return flux.TFloat
+	case lineprotocol.String:
+		return flux.TString
+	case lineprotocol.Bool:
+		return flux.TBool
+	default:
+		return flux.TInvalid
+	}
+}
+
+// keyColumns returns the columns and values of the group key
+// of the series, in the order they are read by influxdb.
+func (s *localSeries) keyColumns(bounds flux.Bounds) ([]flux.ColMeta, []values.Value) {
+	cols := make([]flux.ColMeta, 0, 4+len(s.tags))
+	vs := make([]values.Value, 0, 4+len(s.tags))
+	cols = append(cols,
+		flux.ColMeta{Label: execute.DefaultStartColLabel, Type: flux.TTime},
+		flux.ColMeta{Label: execute.DefaultStopColLabel, Type: flux.TTime},
+		flux.ColMeta{Label: "_field", Type: flux.TString},
+		flux.ColMeta{Label: "_measurement", Type: flux.TString},
+	)
+	vs = append(vs,
+		values.NewTime(values.ConvertTime(bounds.Start.Time(bounds.Now))),
+		values.NewTime(values.ConvertTime(bounds.Stop.Time(bounds.Now))),
+		values.NewString(s.field),
+		values.NewString(s.measurement),
+	)
+	for _, tag := range s.tags {
+		cols = append(cols, flux.ColMeta{Label: tag.Key, Type: flux.TString})
+		vs = append(vs, values.NewString(tag.Value))
+	}
+	return cols, vs
+}
+
+// buffer creates a table buffer with the points of the series at the times.
+func (s *localSeries) buffer(bounds flux.Bounds, times []int64, mem fluxmemory.Allocator) (arrow.TableBuffer, error) {
+	keyCols, keyValues := s.keyColumns(bounds)
+	key := execute.NewGroupKey(keyCols, keyValues)
+
+	cols := make([]flux.ColMeta, 0, len(keyCols)+2)
+	cols = append(cols, keyCols[:2]...)
+	cols = append(cols,
+		flux.ColMeta{Label: execute.DefaultTimeColLabel, Type: flux.TTime},
+		flux.ColMeta{Label: execute.DefaultValueColLabel, Type: localColType(s.kind)},
+	)
+	cols = append(cols, keyCols[2:]...)
+
+	buf := arrow.TableBuffer{
+		GroupKey: key,
+		Columns:  cols,
+		Values:   make([]array.Array, 0, len(cols)),
+	}
+	for _, col := range cols {
+		switch col.Label {
+		case execute.DefaultTimeColLabel:
+			buf.Values = append(buf.Values, arrow.NewInt(times, mem))
+		case execute.DefaultValueColLabel:
+			b := arrow.NewBuilder(col.Type, mem)
+			b.Resize(len(times))
+			for _, t := range times {
+				if err := arrow.AppendValue(b, values.New(s.points[t].Interface())); err != nil {
+					b.Release()
+					buf.Release()
+					return arrow.TableBuffer{}, err
+				}
+			}
+			buf.Values = append(buf.Values, b.NewArray())
+		default:
+			buf.Values = append(buf.Values, arrow.Repeat(col.Type, key.LabelValue(col.Label), len(times), mem))
+		}
+	}
+	return buf, nil
+}
+
+// times returns the times of the points of the series in order.
+func (s *localSeries) times() []int64 {
+	times := make([]int64, 0, len(s.points))
+	for t := range s.points {
+		times = append(times, t)
+	}
+	sort.Slice(times, func(i, j int) bool {
+		return times[i] < times[j]
+	})
+	return times
+}
+
+// localPredicates evaluates a PredicateSet on the rows of a table buffer.
+type localPredicates struct {
+	fns       []*execute.RowPredicateFn
+	keepEmpty []bool
+}
+
+func newLocalPredicates(predicateSet PredicateSet) *localPredicates {
+	p := &localPredicates{
+		fns:       make([]*execute.RowPredicateFn, len(predicateSet)),
+		keepEmpty: make([]bool, len(predicateSet)),
+	}
+	for i, pred := range predicateSet {
+		p.fns[i] = execute.NewRowPredicateFn(pred.Fn, compiler.ToScope(pred.Scope))
+		p.keepEmpty[i] = pred.KeepEmpty
+	}
+	return p
+}
+
+// filter applies the predicates in order, like a filter for each of them,
+// and returns the times of the rows that pass every predicate.
+// If the rows of the series are filtered out by a predicate
+// that does not keep empty tables, false is returned.
+func (p *localPredicates) filter(ctx context.Context, s *localSeries, bounds flux.Bounds, times []int64, mem fluxmemory.Allocator) ([]int64, bool, error) {
+	for i, fn := range p.fns {
+		buf, err := s.buffer(bounds, times, mem)
+		if err != nil {
+			return nil, false, err
+		}
+		prepared, err := fn.Prepare(ctx, buf.Columns)
+		if err != nil {
+			buf.Release()
+			return nil, false, err
+		}
+		passed := times[:0:0]
+		for row := range times {
+			ok, err := prepared.EvalRow(ctx, row, &buf)
+			if err != nil {
+				buf.Release()
+				return nil, false, errors.Wrap(err, codes.Inherit, "failed to evaluate filter function")
+			}
+			if ok {
+				passed = append(passed, times[row])
+			}
+		}
+		buf.Release()
+
+		times = passed
+		if len(times) == 0 && !p.keepEmpty[i] {
+			return nil, false, nil
+		}
+	}
+	return times, true, nil
+}
+
+// localAllocator returns the allocator for the arrays of the tables that are read.
+func localAllocator(mem memory.Allocator) fluxmemory.Allocator {
+	if alloc, ok := mem.(fluxmemory.Allocator); ok {
+		return alloc
+	}
+	return fluxmemory.NewResourceAllocator(mem)
+}
+
+type localReader struct {
+	path         string
+	bounds       flux.Bounds
+	predicateSet PredicateSet
+}
+
+func (l localReader) Read(ctx context.Context, f func(flux.Table) error, alloc memory.Allocator) error {
+	mem := localAllocator(alloc)
+	series, size, err := readLocalSeries(l.path, l.bounds, mem)
+	defer func() { _ = mem.Account(-size) }()
+	if err != nil {
+		return err
+	}
+	predicates := newLocalPredicates(l.predicateSet)
+	for _, s := range series {
+		times, ok, err := predicates.filter(ctx, s, l.bounds, s.times(), mem)
+		if err != nil {
+			return err
+		} else if !ok {
+			continue
+		}
+		buf, err := s.buffer(l.bounds, times, mem)
+		if err != nil {
+			return err
+		}
+		if err := f(table.FromBuffer(&buf)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type localSeriesCardinalityReader struct {
+	path         string
+	bounds       flux.Bounds
+	predicateSet PredicateSet
+}
+
+func (l localSeriesCardinalityReader) Read(ctx context.Context, f func(flux.Table) error, alloc memory.Allocator) error {
+	mem := localAllocator(alloc)
+	series, size, err := readLocalSeries(l.path, l.bounds, mem)
+	defer func() { _ = mem.Account(-size) }()
+	if err != nil {
+		return err
+	}
+
+	// The predicates of the series cardinality are evaluated on the
+	// series key, so they are evaluated on a single row of each series.
+	var n int64
+	predicates := newLocalPredicates(l.predicateSet)
+	for _, s := range series {
+		times := s.times()
+		_, ok, err := predicates.filter(ctx, s, l.bounds, times[:1], mem)
+		if err != nil {
+			return err
+		} else if ok {
+			n++
+		}
+	}
+
+	keyCols := []flux.ColMeta{
+		{Label: execute.DefaultStartColLabel, Type: flux.TTime},
+		{Label: execute.DefaultStopColLabel, Type: flux.TTime},
+	}
+	key := execute.NewGroupKey(keyCols, []values.Value{
+		values.NewTime(values.ConvertTime(l.bounds.Start.Time(l.bounds.Now))),
+		values.NewTime(values.ConvertTime(l.bounds.Stop.Time(l.bounds.Now))),
+	})
+	buf := arrow.TableBuffer{
+		GroupKey: key,
+		Columns: append(keyCols, flux.ColMeta{
+			Label: execute.DefaultValueColLabel,
+			Type:  flux.TInt,
+		}),
+		Values: []array.Array{
+			arrow.Repeat(flux.TTime, key.Value(0), 1, mem),
+			arrow.Repeat(flux.TTime, key.Value(1), 1, mem),
+			arrow.NewInt([]int64{n}, mem),
+		},
+	}
+	return f(table.FromBuffer(&buf))
+}
@@ -0,0 +1,400 @@
+package influxdb_test
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/dependencies/influxdb"
+	"github.com/InfluxCommunity/flux/execute/executetest"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/interpreter"
+	"github.com/InfluxCommunity/flux/memory"
+	influxdb2 "github.com/InfluxCommunity/flux/stdlib/influxdata/influxdb"
+	"github.com/InfluxCommunity/flux/values"
+	"github.com/google/go-cmp/cmp"
+
+	_ "github.com/InfluxCommunity/flux/fluxinit/static"
+)
+
+var localConfig = influxdb.Config{
+	Org:    influxdb.NameOrID{Name: "myorg"},
+	Bucket: influxdb.NameOrID{Name: "mybucket"},
+}
+
+func localMetric(m, host string, field string, value interface{}, sec int64) influxdb.Metric {
+	return &influxdb2.RowMetric{
+		NameStr: m,
+		Tags: []*influxdb.Tag{
+			{Key: "host", Value: host},
+		},
+		Fields: []*influxdb.Field{
+			{Key: field, Value: value},
+		},
+		TS: time.Unix(sec, 0),
+	}
+}
+
+func localWrite(t *testing.T, p influxdb.LocalProvider, metrics ...influxdb.Metric) {
+	t.Helper()
+	w, err := p.WriterFor(context.Background(), localConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(metrics...); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func localRead(t *testing.T, r influxdb.Reader) []*executetest.Table {
+	t.Helper()
+	var tables []*executetest.Table
+	if err := r.Read(context.Background(), func(tbl flux.Table) error {
+		et, err := executetest.ConvertTable(tbl)
+		if err != nil {
+			return err
+		}
+		tables = append(tables, et)
+		return nil
+	}, memory.DefaultAllocator); err != nil {
+		t.Fatal(err)
+	}
+	executetest.NormalizeTables(tables)
+	return tables
+}
+
+func localBounds(start, stop int64) flux.Bounds {
+	return flux.Bounds{
+		Start: flux.Time{Absolute: time.Unix(start, 0)},
+		Stop:  flux.Time{Absolute: time.Unix(stop, 0)},
+	}
+}
+
+func localTable(m, host, field string, typ flux.ColType, data ...[2]interface{}) *executetest.Table {
+	tbl := &executetest.Table{
+		KeyCols: []string{"_start", "_stop", "_field", "_measurement", "host"},
+		ColMeta: []flux.ColMeta{
+			{Label: "_start", Type: flux.TTime},
+			{Label: "_stop", Type: flux.TTime},
+			{Label: "_time", Type: flux.TTime},
+			{Label: "_value", Type: typ},
+			{Label: "_field", Type: flux.TString},
+			{Label: "_measurement", Type: flux.TString},
+			{Label: "host", Type: flux.TString},
+		},
+	}
+	for _, d := range data {
+		tbl.Data = append(tbl.Data, []interface{}{
+			values.ConvertTime(time.Unix(0, 0)),
+			values.ConvertTime(time.Unix(100, 0)),
+			values.ConvertTime(time.Unix(d[0].(int64), 0)),
+			d[1],
+			field,
+			m,
+			host,
+		})
+	}
+	return tbl
+}
+
+func TestLocalProvider_ReadWrite(t *testing.T) {
+	p := influxdb.LocalProvider{Dir: t.TempDir()}
+	localWrite(t, p,
+		localMetric("cpu", "b", "usage", 2.0, 10),
+		localMetric("cpu", "a", "usage", 1.0, 20),
+		localMetric("cpu", "a", "usage", 1.5, 10),
+		localMetric("mem", "a", "used", int64(7), 30),
+		// Outside of the bounds.
+		localMetric("cpu", "a", "usage", 3.0, 100),
+		// Not written since the value is not valid.
+		localMetric("cpu", "a", "usage", math.NaN(), 40),
+	)
+	// The last value written for a time is read.
+	localWrite(t, p, localMetric("cpu", "b", "usage", 2.5, 10))
+
+	r, err := p.ReaderFor(context.Background(), localConfig, localBounds(0, 100), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := localRead(t, r)
+	want := []*executetest.Table{
+		localTable("cpu", "a", "usage", flux.TFloat,
+			[2]interface{}{int64(10), 1.5},
+			[2]interface{}{int64(20), 1.0},
+		),
+		localTable("cpu", "b", "usage", flux.TFloat,
+			[2]interface{}{int64(10), 2.5},
+		),
+		localTable("mem", "a", "used", flux.TInt,
+			[2]interface{}{int64(30), int64(7)},
+		),
+	}
+	executetest.NormalizeTables(want)
+	if !cmp.Equal(want, got) {
+		t.Errorf("unexpected tables -want/+got:\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestLocalProvider_SeriesCardinality(t *testing.T) {
+	p := influxdb.LocalProvider{Dir: t.TempDir()}
+	localWrite(t, p,
+		localMetric("cpu", "a", "usage", 1.0, 10),
+		localMetric("cpu", "a", "usage", 2.0, 20),
+		localMetric("cpu", "b", "usage", 1.0, 10),
+		localMetric("cpu", "c", "usage", 1.0, 200),
+	)
+
+	r, err := p.SeriesCardinalityReaderFor(context.Background(), localConfig, localBounds(0, 100), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := localRead(t, r)
+	want := []*executetest.Table{{
+		KeyCols: []string{"_start", "_stop"},
+		ColMeta: []flux.ColMeta{
+			{Label: "_start", Type: flux.TTime},
+			{Label: "_stop", Type: flux.TTime},
+			{Label: "_value", Type: flux.TInt},
+		},
+		Data: [][]interface{}{
+			{values.ConvertTime(time.Unix(0, 0)), values.ConvertTime(time.Unix(100, 0)), int64(2)},
+		},
+	}}
+	executetest.NormalizeTables(want)
+	if !cmp.Equal(want, got) {
+		t.Errorf("unexpected tables -want/+got:\n%s", cmp.Diff(want, got))
+	}
+
+	if _, err := p.SeriesCardinalityReaderFor(context.Background(), localConfig, localBounds(0, 100), influxdb.PredicateSet{
+		{KeepEmpty: true},
+	}); err == nil {
+		t.Error("expected error")
+	} else if want, got := codes.Unimplemented, errors.Code(err); want != got {
+		t.Errorf("unexpected error code -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+}
+
+func TestLocalProvider_Predicates(t *testing.T) {
+	p := influxdb.LocalProvider{Dir: t.TempDir()}
+	localWrite(t, p,
+		localMetric("cpu", "a", "usage", 1.0, 10),
+		localMetric("cpu", "a", "usage", 5.0, 20),
+		localMetric("cpu", "b", "usage", 2.0, 10),
+	)
+
+	predicate := func(source string, keepEmpty bool) influxdb.Predicate {
+		return influxdb.Predicate{
+			ResolvedFunction: interpreter.ResolvedFunction{
+				Fn:    executetest.FunctionExpression(t, source),
+				Scope: values.NewScope(),
+			},
+			KeepEmpty: keepEmpty,
+		}
+	}
+	for _, tc := range []struct {
+		name         string
+		predicateSet influxdb.PredicateSet
+		want         []*executetest.Table
+	}{
+		{
+			name: "tag",
+			predicateSet: influxdb.PredicateSet{
+				predicate(`(r) => r.host == "a"`, false),
+			},
+			want: []*executetest.Table{
+				localTable("cpu", "a", "usage", flux.TFloat,
+					[2]interface{}{int64(10), 1.0},
+					[2]interface{}{int64(20), 5.0},
+				),
+			},
+		},
+		{
+			name: "value",
+			predicateSet: influxdb.PredicateSet{
+				predicate(`(r) => r._measurement == "cpu"`, false),
+				predicate(`(r) => r._value > 1.5`, false),
+			},
+			want: []*executetest.Table{
+				localTable("cpu", "a", "usage", flux.TFloat,
+					[2]interface{}{int64(20), 5.0},
+				),
+				localTable("cpu", "b", "usage", flux.TFloat,
+					[2]interface{}{int64(10), 2.0},
+				),
+			},
+		},
+		{
+			name: "keep empty",
+			predicateSet: influxdb.PredicateSet{
+				predicate(`(r) => r._value > 3.0`, true),
+			},
+			want: []*executetest.Table{
+				localTable("cpu", "a", "usage", flux.TFloat,
+					[2]interface{}{int64(20), 5.0},
+				),
+				localTable("cpu", "b", "usage", flux.TFloat),
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := p.ReaderFor(context.Background(), localConfig, localBounds(0, 100), tc.predicateSet)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := localRead(t, r)
+			executetest.NormalizeTables(tc.want)
+			if !cmp.Equal(tc.want, got) {
+				t.Errorf("unexpected tables -want/+got:\n%s", cmp.Diff(tc.want, got))
+			}
+		})
+	}
+}
+
+func TestLocalProvider_Memory(t *testing.T) {
+	p := influxdb.LocalProvider{Dir: t.TempDir()}
+	var metrics []influxdb.Metric
+	for i := int64(0); i < 50; i++ {
+		metrics = append(metrics, localMetric("cpu", "a", "usage", float64(i), i))
+	}
+	localWrite(t, p, metrics...)
+
+	r, err := p.ReaderFor(context.Background(), localConfig, localBounds(0, 100), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The points that are read are accounted for until they are released.
+	mem := memory.NewResourceAllocator(nil)
+	if err := r.Read(context.Background(), func(tbl flux.Table) error {
+		tbl.Done()
+		return nil
+	}, mem); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := int64(0), mem.Allocated(); want != got {
+		t.Errorf("unexpected allocated memory -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+
+	limit := int64(1024)
+	mem = &memory.ResourceAllocator{Limit: &limit}
+	err = r.Read(context.Background(), func(tbl flux.Table) error {
+		tbl.Done()
+		return nil
+	}, mem)
+	if err == nil {
+		t.Fatal("expected error")
+	} else if want, got := codes.ResourceExhausted, errors.Code(err); want != got {
+		t.Errorf("unexpected error code -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+	if want, got := int64(0), mem.Allocated(); want != got {
+		t.Errorf("unexpected allocated memory -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+}
+
+func TestLocalProvider_Errors(t *testing.T) {
+	dir := t.TempDir()
+	p := influxdb.LocalProvider{Dir: dir}
+	localWrite(t, p, localMetric("cpu", "a", "usage", 1.0, 10))
+
+	ctx := context.Background()
+	w, err := p.WriterFor(ctx, localConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(localMetric("cpu", "b", "usage", int64(1), 10)); err == nil {
+		t.Error("expected error writing a field with a different type")
+	} else if want, got := codes.FailedPrecondition, errors.Code(err); want != got {
+		t.Errorf("unexpected error code -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Fields appended since the previous writer are known as well.
+	localWrite(t, p, localMetric("mem", "a", "used", int64(7), 10))
+	w, err = p.WriterFor(ctx, localConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(localMetric("mem", "b", "used", 7.5, 10)); err == nil {
+		t.Error("expected error writing a field with a different type")
+	} else if want, got := codes.FailedPrecondition, errors.Code(err); want != got {
+		t.Errorf("unexpected error code -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		conf influxdb.Config
+		want codes.Code
+	}{
+		{
+			name: "missing bucket",
+			conf: influxdb.Config{
+				Org:    influxdb.NameOrID{Name: "myorg"},
+				Bucket: influxdb.NameOrID{Name: "other"},
+			},
+			want: codes.NotFound,
+		},
+		{
+			name: "no org",
+			conf: influxdb.Config{
+				Bucket: influxdb.NameOrID{Name: "mybucket"},
+			},
+			want: codes.Invalid,
+		},
+		{
+			name: "parent directory",
+			conf: influxdb.Config{
+				Org:    influxdb.NameOrID{Name: ".."},
+				Bucket: influxdb.NameOrID{Name: "mybucket"},
+			},
+			want: codes.Invalid,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var err error
+			r, err := p.ReaderFor(ctx, tc.conf, localBounds(0, 100), nil)
+			if err == nil {
+				err = r.Read(ctx, func(flux.Table) error { return nil }, memory.DefaultAllocator)
+			}
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if want, got := tc.want, errors.Code(err); want != got {
+				t.Errorf("unexpected error code -want/+got:\n\t- %s\n\t+ %s", want, got)
+			}
+		})
+	}
+
+	// Names are escaped so they cannot refer to other directories.
+	conf := influxdb.Config{
+		Org:    influxdb.NameOrID{Name: "my/org"},
+		Bucket: influxdb.NameOrID{Name: "../bucket"},
+	}
+	w, err = p.WriterFor(ctx, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(localMetric("cpu", "a", "usage", 1.0, 10)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "my%2Forg", "..%2Fbucket.lp")); err != nil {
+		t.Errorf("expected the bucket to be stored in its organization: %s", err)
+	}
+}
@@ -100,6 +100,71 @@ type Provider interface {
 	WriterFor(ctx context.Context, conf Config) (Writer, error)
 }
 
+// AggregateProvider is implemented by a Provider that can evaluate
+// aggregates as part of a read. The aggregates that follow a read
+// are only pushed down into it when the Provider implements this
+// interface and reports that it supports the aggregate.
+type AggregateProvider interface {
+	Provider
+
+	// SupportsWindowAggregate reports whether the provider can
+	// evaluate the WindowAggregate as part of a read.
+	// It is called when the query is planned, so it must not
+	// connect to the influxdb instance.
+	SupportsWindowAggregate(spec WindowAggregate) bool
+
+	// WindowAggregateReaderFor will return a Reader for the tables
+	// that result from applying the WindowAggregate to the read.
+	WindowAggregateReaderFor(ctx context.Context, conf Config, bounds flux.Bounds, predicateSet PredicateSet, spec WindowAggregate) (Reader, error)
+
+	// SupportsGroupAggregate reports whether the provider can
+	// evaluate the GroupAggregate as part of a read.
+	// It is called when the query is planned, so it must not
+	// connect to the influxdb instance.
+	SupportsGroupAggregate(spec GroupAggregate) bool
+
+	// GroupAggregateReaderFor will return a Reader for the tables
+	// that result from applying the GroupAggregate to the read.
+	GroupAggregateReaderFor(ctx context.Context, conf Config, bounds flux.Bounds, predicateSet PredicateSet, spec GroupAggregate) (Reader, error)
+}
+
+// WindowAggregate describes a window of the read followed by
+// an aggregate of the _value column in each of the windows.
+type WindowAggregate struct {
+	Every       flux.Duration
+	Period      flux.Duration
+	Offset      flux.Duration
+	CreateEmpty bool
+
+	// Aggregate is the name of the aggregate function, such as mean.
+	Aggregate string
+
+	// TimeSrc is set when the windows are combined into a single table
+	// after the aggregate, as aggregateWindow does. It is the column,
+	// either _start or _stop, that is used for the time of each window.
+	TimeSrc string
+}
+
+// GroupAggregate describes a group of the read followed by
+// an aggregate of the _value column in each of the groups.
+type GroupAggregate struct {
+	Mode    flux.GroupMode
+	Columns []string
+
+	// Aggregate is the name of the aggregate function, such as count.
+	Aggregate string
+}
+
+// Copy produces a deep copy of the GroupAggregate.
+func (g *GroupAggregate) Copy() *GroupAggregate {
+	ng := *g
+	if g.Columns != nil {
+		ng.Columns = make([]string, len(g.Columns))
+		copy(ng.Columns, g.Columns)
+	}
+	return &ng
+}
+
 // Reader reads tables from an influxdb instance.
 type Reader interface {
 	// Read will produce flux.Table values using the memory.Allocator
@@ -9,6 +9,7 @@ import (
 
 	"github.com/InfluxCommunity/flux"
 	"github.com/InfluxCommunity/flux/codes"
+	furl "github.com/InfluxCommunity/flux/dependencies/url"
 	"github.com/InfluxCommunity/flux/dependency"
 	"github.com/InfluxCommunity/flux/internal/errors"
 	mqtt "github.com/eclipse/paho.mqtt.golang"
@@ -58,6 +59,9 @@ type Options struct {
 	Username string
 	Password string
 	Timeout  time.Duration
+	// Function is the name of the function that connects to the brokers.
+	// It is used to scope the validation of the broker urls.
+	Function string
 }
 
 // Dialer provides a method to connect a client to one or more mqtt brokers.
@@ -66,11 +70,22 @@ type Dialer interface {
 	Dial(ctx context.Context, brokers []string, options Options) (Client, error)
 }
 
-// Client is an mqtt client that can publish to an mqtt broker.
+// MessageHandler is called for every message received on a subscribed topic.
+// It may be invoked concurrently from the goroutine owned by the client.
+type MessageHandler func(topic string, payload []byte)
+
+// Client is an mqtt client that can publish to and subscribe to an mqtt broker.
 type Client interface {
 	// Publish will publish the payload to a particular topic.
 	Publish(ctx context.Context, topic string, qos byte, retain bool, payload interface{}) error
 
+	// Subscribe will subscribe to a topic and invoke the handler for every
+	// message that is received until the topic is unsubscribed.
+	Subscribe(ctx context.Context, topic string, qos byte, handler MessageHandler) error
+
+	// Unsubscribe will stop receiving messages for the given topics.
+	Unsubscribe(ctx context.Context, topics ...string) error
+
 	io.Closer
 }
 
@@ -91,7 +106,7 @@ func (d DefaultDialer) Dial(ctx context.Context, brokers []string, options Optio
 		return nil, err
 	} else {
 		for _, broker := range opts.Servers {
-			if err := url.Validate(broker); err != nil {
+			if err := furl.ForFunction(url, options.Function).Validate(broker); err != nil {
 				return nil, err
 			}
 		}
@@ -141,6 +156,29 @@ func (d *defaultClient) Publish(ctx context.Context, topic string, qos byte, ret
 	return nil
 }
 
+func (d *defaultClient) Subscribe(ctx context.Context, topic string, qos byte, handler MessageHandler) error {
+	token := d.client.Subscribe(topic, qos, func(_ mqtt.Client, msg mqtt.Message) {
+		handler(msg.Topic(), msg.Payload())
+	})
+	return d.wait(token, "subscribe")
+}
+
+func (d *defaultClient) Unsubscribe(ctx context.Context, topics ...string) error {
+	token := d.client.Unsubscribe(topics...)
+	return d.wait(token, "unsubscribe")
+}
+
+func (d *defaultClient) wait(token mqtt.Token, op string) error {
+	timeout := d.timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	if !token.WaitTimeout(timeout) {
+		return errors.Newf(codes.Canceled, "mqtt %s: timeout reached", op)
+	}
+	return token.Error()
+}
+
 func (d *defaultClient) Close() error {
 	d.client.Disconnect(250)
 	return nil
@@ -0,0 +1,99 @@
+package quota
+
+import (
+	"io"
+	"net/http"
+
+	fhttp "github.com/InfluxCommunity/flux/dependencies/http"
+)
+
+// HTTPClient returns a client that records the requests it sends
+// with the Tracker and fails them once a quota is exceeded.
+//
+// If the client is an *http.Client, the copy that is returned
+// is an *http.Client as well, so it can still be configured
+// with functions such as http.WithTimeout.
+func HTTPClient(c fhttp.Client, t *Tracker) fhttp.Client {
+	if t == nil {
+		return c
+	}
+	if cli, ok := c.(*http.Client); ok {
+		// make shallow copy
+		newClient := *cli
+		transport := newClient.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+		newClient.Transport = roundTripper{RoundTripper: transport, tracker: t}
+		return &newClient
+	}
+	return client{Client: c, tracker: t}
+}
+
+type client struct {
+	fhttp.Client
+	tracker *Tracker
+}
+
+func (c client) Do(req *http.Request) (*http.Response, error) {
+	req, err := track(req, c.tracker)
+	if err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+type roundTripper struct {
+	http.RoundTripper
+	tracker *Tracker
+}
+
+func (rt roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	tracked, err := track(req, rt.tracker)
+	if err != nil {
+		// A RoundTripper must always close the body.
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, err
+	}
+	return rt.RoundTripper.RoundTrip(tracked)
+}
+
+// track records the request with the Tracker.
+// The size of the body is recorded up front when it is known.
+// Otherwise, the returned copy of the request counts the bytes
+// of the body as they are sent.
+func track(req *http.Request, t *Tracker) (*http.Request, error) {
+	if err := t.AddHTTPRequest(); err != nil {
+		return nil, err
+	}
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	if req.ContentLength > 0 {
+		if err := t.AddHTTPBytes(req.ContentLength); err != nil {
+			return nil, err
+		}
+		return req, nil
+	}
+	tracked := new(http.Request)
+	*tracked = *req
+	tracked.Body = &countingBody{ReadCloser: req.Body, tracker: t}
+	return tracked, nil
+}
+
+type countingBody struct {
+	io.ReadCloser
+	tracker *Tracker
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		if qerr := b.tracker.AddHTTPBytes(int64(n)); qerr != nil {
+			return 0, qerr
+		}
+	}
+	return n, err
+}
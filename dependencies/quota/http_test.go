@@ -0,0 +1,112 @@
+package quota_test
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/dependencies/quota"
+	"github.com/InfluxCommunity/flux/internal/errors"
+)
+
+func TestHTTPClient(t *testing.T) {
+	var received int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received += len(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	_, tracker, stop := quota.Start(context.Background(), quota.Limits{
+		MaxHTTPRequests: 2,
+		MaxHTTPBytes:    10,
+	})
+	defer stop()
+
+	c := quota.HTTPClient(&http.Client{}, tracker)
+	if _, ok := c.(*http.Client); !ok {
+		t.Fatalf("expected *http.Client, got %T", c)
+	}
+
+	post := func(body io.Reader) error {
+		req, err := http.NewRequest(http.MethodPost, ts.URL, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := c.Do(req)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	if err := post(strings.NewReader("0123456789")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	// The body has an unknown length so it is counted as it is sent.
+	if err := post(ioutil.NopCloser(strings.NewReader("x"))); err == nil {
+		t.Fatal("expected error")
+	}
+	if want, got := 10, received; want != got {
+		t.Fatalf("unexpected bytes received -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+
+	err := tracker.Err()
+	if want, got := codes.ResourceExhausted, errors.Code(err); want != got {
+		t.Fatalf("unexpected error code -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+	if want, got := "query exceeded the maximum of 10 bytes sent over http", err.Error(); want != got {
+		t.Fatalf("unexpected error -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+}
+
+func TestHTTPClient_Requests(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer ts.Close()
+
+	_, tracker, stop := quota.Start(context.Background(), quota.Limits{
+		MaxHTTPRequests: 1,
+	})
+	defer stop()
+
+	// Clients that are not an *http.Client are wrapped.
+	c := quota.HTTPClient(clientFunc(http.DefaultClient.Do), tracker)
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := c.Do(req)
+		if i == 0 {
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			_ = resp.Body.Close()
+			continue
+		}
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if want, got := codes.ResourceExhausted, errors.Code(err); want != got {
+			t.Fatalf("unexpected error code -want/+got:\n\t- %s\n\t+ %s", want, got)
+		}
+	}
+	if want, got := 1, requests; want != got {
+		t.Fatalf("unexpected number of requests -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+}
+
+type clientFunc func(*http.Request) (*http.Response, error)
+
+func (f clientFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
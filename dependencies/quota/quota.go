@@ -0,0 +1,231 @@
+// Package quota limits the resources a single query may use
+// apart from memory, which is limited by the memory.ResourceAllocator.
+package quota
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
+)
+
+// Quota names a limit in Limits.
+type Quota string
+
+const (
+	Duration     Quota = "max_duration"
+	ResultRows   Quota = "max_result_rows"
+	ResultBytes  Quota = "max_result_bytes"
+	HTTPRequests Quota = "max_http_requests"
+	HTTPBytes    Quota = "max_http_bytes"
+)
+
+// Limits are the quotas of a query.
+// A zero value for a limit means it is unlimited.
+type Limits struct {
+	// MaxDuration is the maximum time the query may run,
+	// including the evaluation of the script.
+	MaxDuration time.Duration
+	// MaxResultRows is the maximum number of rows in the results of the query.
+	MaxResultRows int64
+	// MaxResultBytes is the maximum size of the values in the results of the query.
+	MaxResultBytes int64
+	// MaxHTTPRequests is the maximum number of outbound HTTP requests.
+	MaxHTTPRequests int64
+	// MaxHTTPBytes is the maximum number of bytes sent in outbound HTTP request bodies.
+	MaxHTTPBytes int64
+}
+
+// IsZero reports whether no limits are set.
+func (l Limits) IsZero() bool {
+	return l == Limits{}
+}
+
+type key int
+
+const (
+	limitsKey key = iota
+	trackerKey
+)
+
+// Dependency will inject the Limits into the dependency chain.
+type Dependency struct {
+	Limits Limits
+}
+
+// Inject will inject the Limits into the dependency chain.
+func (d Dependency) Inject(ctx context.Context) context.Context {
+	if !d.Limits.IsZero() {
+		ctx = Inject(ctx, d.Limits)
+	}
+	return ctx
+}
+
+// Inject will inject the Limits into the context.
+func Inject(ctx context.Context, limits Limits) context.Context {
+	return context.WithValue(ctx, limitsKey, limits)
+}
+
+// GetLimits will retrieve the Limits from the context.
+// If none were injected, the query is unlimited.
+func GetLimits(ctx context.Context) Limits {
+	l, _ := ctx.Value(limitsKey).(Limits)
+	return l
+}
+
+// GetTracker will retrieve the Tracker of the running query from the context.
+// It returns nil if the quotas of the query are not tracked,
+// which is safe to use and never reports a quota as exceeded.
+func GetTracker(ctx context.Context) *Tracker {
+	t, _ := ctx.Value(trackerKey).(*Tracker)
+	return t
+}
+
+// Start begins tracking the usage of a query against the limits.
+//
+// The returned context is canceled when any quota is exceeded and
+// carries the Tracker for the query. The returned function stops
+// tracking the duration of the query and must be called once
+// the query is done.
+func Start(ctx context.Context, limits Limits) (context.Context, *Tracker, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	t := &Tracker{
+		limits: limits,
+		cancel: cancel,
+	}
+	if limits.MaxDuration > 0 {
+		timer := time.AfterFunc(limits.MaxDuration, func() {
+			t.exceed(Duration, errors.Newf(codes.ResourceExhausted,
+				"query exceeded the maximum execution time of %v", limits.MaxDuration))
+		})
+		stop := cancel
+		cancel = func() {
+			timer.Stop()
+			stop()
+		}
+	}
+	return context.WithValue(ctx, trackerKey, t), t, cancel
+}
+
+// Tracker tracks the usage of a single query.
+// It is safe to use concurrently.
+type Tracker struct {
+	limits Limits
+	cancel func()
+
+	resultRows   int64
+	resultBytes  int64
+	httpRequests int64
+	httpBytes    int64
+
+	mu       sync.Mutex
+	err      error
+	exceeded []string
+}
+
+// Limits returns the limits tracked by the Tracker.
+func (t *Tracker) Limits() Limits {
+	if t == nil {
+		return Limits{}
+	}
+	return t.limits
+}
+
+// AddResultRows records rows read from the results of the query.
+func (t *Tracker) AddResultRows(n int64) error {
+	if t == nil {
+		return nil
+	}
+	return t.add(&t.resultRows, n, t.limits.MaxResultRows, ResultRows,
+		"query exceeded the maximum number of result rows of %d")
+}
+
+// AddResultBytes records bytes read from the results of the query.
+func (t *Tracker) AddResultBytes(n int64) error {
+	if t == nil {
+		return nil
+	}
+	return t.add(&t.resultBytes, n, t.limits.MaxResultBytes, ResultBytes,
+		"query exceeded the maximum result size of %d bytes")
+}
+
+// AddHTTPRequest records an outbound HTTP request.
+func (t *Tracker) AddHTTPRequest() error {
+	if t == nil {
+		return nil
+	}
+	return t.add(&t.httpRequests, 1, t.limits.MaxHTTPRequests, HTTPRequests,
+		"query exceeded the maximum number of http requests of %d")
+}
+
+// AddHTTPBytes records bytes sent in an outbound HTTP request.
+func (t *Tracker) AddHTTPBytes(n int64) error {
+	if t == nil {
+		return nil
+	}
+	return t.add(&t.httpBytes, n, t.limits.MaxHTTPBytes, HTTPBytes,
+		"query exceeded the maximum of %d bytes sent over http")
+}
+
+func (t *Tracker) add(counter *int64, n, limit int64, q Quota, format string) error {
+	if err := t.Err(); err != nil {
+		return err
+	}
+	if total := atomic.AddInt64(counter, n); limit > 0 && total > limit {
+		return t.exceed(q, errors.Newf(codes.ResourceExhausted, format, limit))
+	}
+	return nil
+}
+
+// exceed records that the quota was exceeded and cancels the query.
+// It returns the error for the first quota that was exceeded.
+func (t *Tracker) exceed(q Quota, err error) error {
+	t.mu.Lock()
+	if t.err == nil {
+		t.err = err
+	}
+	// A quota can be exceeded by concurrent calls.
+	if !contains(t.exceeded, string(q)) {
+		t.exceeded = append(t.exceeded, string(q))
+	}
+	err = t.err
+	t.mu.Unlock()
+	t.cancel()
+	return err
+}
+
+// Err returns the error for the first quota the query exceeded, if any.
+func (t *Tracker) Err() error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+// Exceeded returns the names of the quotas the query exceeded
+// in the order they were exceeded.
+func (t *Tracker) Exceeded() []string {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.exceeded) == 0 {
+		return nil
+	}
+	return append([]string(nil), t.exceeded...)
+}
+
+func contains(l []string, s string) bool {
+	for _, v := range l {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,197 @@
+package quota_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/dependencies/quota"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTracker(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		limits  quota.Limits
+		add     func(t *quota.Tracker) error
+		wantErr string
+		want    []string
+	}{
+		{
+			name:   "result rows",
+			limits: quota.Limits{MaxResultRows: 10},
+			add: func(t *quota.Tracker) error {
+				if err := t.AddResultRows(10); err != nil {
+					return err
+				}
+				return t.AddResultRows(1)
+			},
+			wantErr: "query exceeded the maximum number of result rows of 10",
+			want:    []string{"max_result_rows"},
+		},
+		{
+			name:   "result bytes",
+			limits: quota.Limits{MaxResultBytes: 100},
+			add: func(t *quota.Tracker) error {
+				return t.AddResultBytes(101)
+			},
+			wantErr: "query exceeded the maximum result size of 100 bytes",
+			want:    []string{"max_result_bytes"},
+		},
+		{
+			name:   "http requests",
+			limits: quota.Limits{MaxHTTPRequests: 1},
+			add: func(t *quota.Tracker) error {
+				if err := t.AddHTTPRequest(); err != nil {
+					return err
+				}
+				return t.AddHTTPRequest()
+			},
+			wantErr: "query exceeded the maximum number of http requests of 1",
+			want:    []string{"max_http_requests"},
+		},
+		{
+			name:   "http bytes",
+			limits: quota.Limits{MaxHTTPBytes: 5},
+			add: func(t *quota.Tracker) error {
+				return t.AddHTTPBytes(6)
+			},
+			wantErr: "query exceeded the maximum of 5 bytes sent over http",
+			want:    []string{"max_http_bytes"},
+		},
+		{
+			name:   "within limits",
+			limits: quota.Limits{MaxResultRows: 10, MaxHTTPRequests: 1},
+			add: func(t *quota.Tracker) error {
+				if err := t.AddResultRows(10); err != nil {
+					return err
+				}
+				// Unlimited quotas are never exceeded.
+				if err := t.AddResultBytes(1 << 40); err != nil {
+					return err
+				}
+				return t.AddHTTPRequest()
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, tracker, stop := quota.Start(context.Background(), tc.limits)
+			defer stop()
+
+			err := tc.add(tracker)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if ctx.Err() != nil {
+					t.Fatal("expected context to not be canceled")
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if want, got := codes.ResourceExhausted, errors.Code(err); want != got {
+				t.Errorf("unexpected error code -want/+got:\n\t- %s\n\t+ %s", want, got)
+			}
+			if want, got := tc.wantErr, err.Error(); want != got {
+				t.Errorf("unexpected error -want/+got:\n\t- %s\n\t+ %s", want, got)
+			}
+			if got := tracker.Err(); got != err {
+				t.Errorf("unexpected tracker error -want/+got:\n\t- %s\n\t+ %s", err, got)
+			}
+			if !cmp.Equal(tc.want, tracker.Exceeded()) {
+				t.Errorf("unexpected exceeded quotas -want/+got:\n%s", cmp.Diff(tc.want, tracker.Exceeded()))
+			}
+			if ctx.Err() == nil {
+				t.Error("expected context to be canceled")
+			}
+		})
+	}
+}
+
+func TestTracker_FirstError(t *testing.T) {
+	_, tracker, stop := quota.Start(context.Background(), quota.Limits{
+		MaxResultRows:   1,
+		MaxHTTPRequests: 1,
+	})
+	defer stop()
+
+	first := tracker.AddResultRows(2)
+	if first == nil {
+		t.Fatal("expected error")
+	}
+	// The query is canceled once a quota is exceeded,
+	// so later calls report the first error.
+	if err := tracker.AddHTTPRequest(); err != first {
+		t.Fatalf("unexpected error -want/+got:\n\t- %s\n\t+ %s", first, err)
+	}
+	if want, got := []string{"max_result_rows"}, tracker.Exceeded(); !cmp.Equal(want, got) {
+		t.Fatalf("unexpected exceeded quotas -want/+got:\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestTracker_Duration(t *testing.T) {
+	ctx, tracker, stop := quota.Start(context.Background(), quota.Limits{
+		MaxDuration: 10 * time.Millisecond,
+	})
+	defer stop()
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected context to be canceled")
+	}
+	err := tracker.Err()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if want, got := codes.ResourceExhausted, errors.Code(err); want != got {
+		t.Errorf("unexpected error code -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+	if want, got := "query exceeded the maximum execution time of 10ms", err.Error(); want != got {
+		t.Errorf("unexpected error -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+	if want, got := []string{"max_duration"}, tracker.Exceeded(); !cmp.Equal(want, got) {
+		t.Errorf("unexpected exceeded quotas -want/+got:\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestTracker_Stop(t *testing.T) {
+	_, tracker, stop := quota.Start(context.Background(), quota.Limits{
+		MaxDuration: 10 * time.Millisecond,
+	})
+	stop()
+	time.Sleep(50 * time.Millisecond)
+	if err := tracker.Err(); err != nil {
+		t.Fatalf("unexpected error after stop: %s", err)
+	}
+}
+
+func TestGetTracker(t *testing.T) {
+	ctx := quota.Inject(context.Background(), quota.Limits{MaxResultRows: 1})
+	if want, got := (quota.Limits{MaxResultRows: 1}), quota.GetLimits(ctx); want != got {
+		t.Fatalf("unexpected limits -want/+got:\n\t- %v\n\t+ %v", want, got)
+	}
+
+	// A query without a tracker is never limited.
+	tracker := quota.GetTracker(ctx)
+	if tracker != nil {
+		t.Fatal("expected no tracker")
+	}
+	if err := tracker.AddResultRows(2); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if exceeded := tracker.Exceeded(); exceeded != nil {
+		t.Fatalf("unexpected exceeded quotas: %v", exceeded)
+	}
+
+	ctx, tracker, stop := quota.Start(ctx, quota.GetLimits(ctx))
+	defer stop()
+	if got := quota.GetTracker(ctx); got != tracker {
+		t.Fatal("expected tracker in context")
+	}
+}
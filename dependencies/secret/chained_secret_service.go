@@ -0,0 +1,25 @@
+package secret
+
+import (
+	"context"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
+)
+
+func (css ChainedSecretService) LoadSecret(ctx context.Context, k string) (string, error) {
+	for _, s := range css {
+		v, err := s.LoadSecret(ctx, k)
+		if err == nil {
+			return v, nil
+		} else if errors.Code(err) != codes.NotFound {
+			return "", err
+		}
+	}
+	return "", errors.Newf(codes.NotFound, "secret key %q not found", k)
+}
+
+// Secret service that looks up a secret in each service in order.
+// The first service that has the key is used. Any error other than
+// not found stops the lookup and is returned.
+type ChainedSecretService []Service
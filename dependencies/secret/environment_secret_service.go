@@ -3,6 +3,9 @@ package secret
 import (
 	"context"
 	"os"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
 )
 
 func (ess EnvironmentSecretService) LoadSecret(ctx context.Context, k string) (string, error) {
@@ -12,3 +15,40 @@ func (ess EnvironmentSecretService) LoadSecret(ctx context.Context, k string) (s
 // Secret service that retrieve the system environment variables.
 type EnvironmentSecretService struct {
 }
+
+func (sess ScopedEnvironmentSecretService) LoadSecret(ctx context.Context, k string) (string, error) {
+	if !sess.allowed(k) {
+		// A key outside of the scope is reported as not found
+		// so a chained secret service moves on to the next one.
+		return "", errors.Newf(codes.NotFound, "secret key %q not found", k)
+	}
+	v, ok := os.LookupEnv(sess.Prefix + k)
+	if !ok {
+		return "", errors.Newf(codes.NotFound, "secret key %q not found", k)
+	}
+	return v, nil
+}
+
+func (sess ScopedEnvironmentSecretService) allowed(k string) bool {
+	if len(sess.Allow) == 0 {
+		return sess.Prefix != ""
+	}
+	for _, a := range sess.Allow {
+		if a == k {
+			return true
+		}
+	}
+	return false
+}
+
+// Secret service that retrieves a restricted set of system environment variables.
+// The secret key is prefixed with Prefix to form the name of the variable,
+// so a prefix of FLUX_SECRET_ maps the key DB_PASSWORD to FLUX_SECRET_DB_PASSWORD.
+// If Allow is set, only the listed keys can be retrieved.
+// If neither is set, no key can be retrieved.
+// Unlike EnvironmentSecretService, a variable that is not set or
+// a key that cannot be retrieved is reported as not found.
+type ScopedEnvironmentSecretService struct {
+	Prefix string
+	Allow  []string
+}
@@ -0,0 +1,39 @@
+package secret
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
+)
+
+// secretKeyRegexp matches the keys that are valid in a Kubernetes secret.
+var secretKeyRegexp = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+func (fss FileSecretService) LoadSecret(ctx context.Context, k string) (string, error) {
+	// Keys starting with .. are used by Kubernetes for the
+	// internal links of the mount and are never secrets.
+	if !secretKeyRegexp.MatchString(k) || strings.HasPrefix(k, "..") || k == "." {
+		return "", errors.Newf(codes.Invalid, "invalid secret key %q", k)
+	}
+	data, err := os.ReadFile(filepath.Join(fss.Dir, k))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", errors.Newf(codes.NotFound, "secret key %q not found", k)
+		}
+		return "", errors.Wrapf(err, codes.Internal, "cannot read secret key %q", k)
+	}
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
+
+// Secret service that reads secrets from files in a directory.
+// This follows the layout of a mounted Kubernetes secret, where
+// every key is a file in the directory that contains the secret value.
+// A single trailing newline is removed from the value.
+type FileSecretService struct {
+	Dir string
+}
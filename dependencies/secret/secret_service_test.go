@@ -2,8 +2,16 @@ package secret_test
 
 import (
 	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/dependencies/secret"
+	"github.com/InfluxCommunity/flux/internal/errors"
 	"github.com/InfluxCommunity/flux/mock"
 )
 
@@ -21,3 +29,176 @@ func TestSecret_Service(t *testing.T) {
 		t.Error("secret service should have errored on key lookup")
 	}
 }
+
+func TestFileSecretService(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "password"), []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// Kubernetes mounts keep the data behind links named ..data.
+	if err := os.Mkdir(filepath.Join(dir, "..data"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	ss := secret.FileSecretService{Dir: dir}
+	val, err := ss.LoadSecret(context.Background(), "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "s3cret", val; want != got {
+		t.Errorf("unexpected secret -want/+got:\n\t- %q\n\t+ %q", want, got)
+	}
+
+	for k, code := range map[string]codes.Code{
+		"missing":         codes.NotFound,
+		"../password":     codes.Invalid,
+		"..data":          codes.Invalid,
+		"/etc/passwd":     codes.Invalid,
+		"dir/password":    codes.Invalid,
+		"":                codes.Invalid,
+		".":               codes.Invalid,
+		"pass\x00word":    codes.Invalid,
+		"..2024_01_01.00": codes.Invalid,
+	} {
+		if _, err := ss.LoadSecret(context.Background(), k); err == nil {
+			t.Errorf("%q: expected error", k)
+		} else if want, got := code, errors.Code(err); want != got {
+			t.Errorf("%q: unexpected error code -want/+got:\n\t- %s\n\t+ %s", k, want, got)
+		}
+	}
+}
+
+func TestScopedEnvironmentSecretService(t *testing.T) {
+	t.Setenv("FLUX_SECRET_TOKEN", "abc")
+	t.Setenv("TOKEN", "unscoped")
+	t.Setenv("API_KEY", "xyz")
+
+	for _, tt := range []struct {
+		name string
+		ss   secret.ScopedEnvironmentSecretService
+		key  string
+		want string
+		code codes.Code
+	}{
+		{name: "prefix", ss: secret.ScopedEnvironmentSecretService{Prefix: "FLUX_SECRET_"}, key: "TOKEN", want: "abc"},
+		{name: "prefix missing", ss: secret.ScopedEnvironmentSecretService{Prefix: "FLUX_SECRET_"}, key: "API_KEY", code: codes.NotFound},
+		{name: "allow", ss: secret.ScopedEnvironmentSecretService{Allow: []string{"API_KEY"}}, key: "API_KEY", want: "xyz"},
+		{name: "not allowed", ss: secret.ScopedEnvironmentSecretService{Allow: []string{"API_KEY"}}, key: "TOKEN", code: codes.NotFound},
+		{name: "prefix and allow", ss: secret.ScopedEnvironmentSecretService{Prefix: "FLUX_SECRET_", Allow: []string{"TOKEN"}}, key: "TOKEN", want: "abc"},
+		{name: "unconfigured", ss: secret.ScopedEnvironmentSecretService{}, key: "TOKEN", code: codes.NotFound},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			val, err := tt.ss.LoadSecret(context.Background(), tt.key)
+			if tt.code != codes.Inherit {
+				if err == nil {
+					t.Fatal("expected error")
+				} else if want, got := tt.code, errors.Code(err); want != got {
+					t.Fatalf("unexpected error code -want/+got:\n\t- %s\n\t+ %s", want, got)
+				}
+				return
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if want, got := tt.want, val; want != got {
+				t.Errorf("unexpected secret -want/+got:\n\t- %q\n\t+ %q", want, got)
+			}
+		})
+	}
+}
+
+func TestChainedSecretService(t *testing.T) {
+	ss := secret.ChainedSecretService{
+		mock.SecretService{"a": "first"},
+		mock.SecretService{"a": "second", "b": "second"},
+		secret.EmptySecretService{},
+	}
+	for k, want := range map[string]string{"a": "first", "b": "second"} {
+		val, err := ss.LoadSecret(context.Background(), k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if val != want {
+			t.Errorf("unexpected secret -want/+got:\n\t- %q\n\t+ %q", want, val)
+		}
+	}
+	if _, err := ss.LoadSecret(context.Background(), "c"); errors.Code(err) != codes.NotFound {
+		t.Errorf("expected not found error, got %v", err)
+	}
+
+	// Keys outside of the scope of a scoped environment
+	// fall through to the next secret service.
+	ss = secret.ChainedSecretService{
+		secret.ScopedEnvironmentSecretService{},
+		mock.SecretService{"a": "value"},
+	}
+	if val, err := ss.LoadSecret(context.Background(), "a"); err != nil {
+		t.Fatal(err)
+	} else if val != "value" {
+		t.Errorf("unexpected secret -want/+got:\n\t- %q\n\t+ %q", "value", val)
+	}
+
+	// Errors other than not found stop the lookup.
+	ss = secret.ChainedSecretService{
+		&secret.VaultSecretService{Address: "http://%zz"},
+		mock.SecretService{"a": "value"},
+	}
+	if _, err := ss.LoadSecret(context.Background(), "a"); errors.Code(err) != codes.Invalid {
+		t.Errorf("expected invalid error, got %v", err)
+	}
+}
+
+func TestVaultSecretService(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/db/postgres":
+			_, _ = io.WriteString(w, `{"data":{"data":{"password":"pg","value":"default","port":5432},"metadata":{"version":1}}}`)
+		case "/v1/kv/db/postgres":
+			_, _ = io.WriteString(w, `{"data":{"password":"v1"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"errors":[]}`)
+		}
+	}))
+	defer ts.Close()
+
+	ss := &secret.VaultSecretService{Address: ts.URL, Token: "root"}
+	for k, want := range map[string]string{
+		"db/postgres#password": "pg",
+		"db/postgres":          "default",
+		"db/postgres#port":     "5432",
+	} {
+		val, err := ss.LoadSecret(context.Background(), k)
+		if err != nil {
+			t.Fatalf("%s: %s", k, err)
+		}
+		if val != want {
+			t.Errorf("%s: unexpected secret -want/+got:\n\t- %q\n\t+ %q", k, want, val)
+		}
+	}
+	for k, code := range map[string]codes.Code{
+		"db/postgres#user": codes.NotFound,
+		"db/mysql#user":    codes.NotFound,
+		"db/../sys#x":      codes.Invalid,
+		"#password":        codes.Invalid,
+	} {
+		if _, err := ss.LoadSecret(context.Background(), k); errors.Code(err) != code {
+			t.Errorf("%s: expected %s error, got %v", k, code, err)
+		}
+	}
+
+	v1 := &secret.VaultSecretService{Address: ts.URL, Token: "root", Mount: "kv", KVVersion: 1}
+	if val, err := v1.LoadSecret(context.Background(), "db/postgres#password"); err != nil {
+		t.Fatal(err)
+	} else if val != "v1" {
+		t.Errorf("unexpected secret -want/+got:\n\t- %q\n\t+ %q", "v1", val)
+	}
+
+	denied := &secret.VaultSecretService{Address: ts.URL, Token: "wrong"}
+	if _, err := denied.LoadSecret(context.Background(), "db/postgres"); errors.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected permission denied error, got %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
+)
+
+// DefaultVaultField is the field of a Vault secret that is
+// returned when the key does not name a field.
+const DefaultVaultField = "value"
+
+// DefaultVaultTimeout is the timeout of a request to Vault
+// when no client is set.
+const DefaultVaultTimeout = 10 * time.Second
+
+var defaultVaultClient = &http.Client{Timeout: DefaultVaultTimeout}
+
+func (vss *VaultSecretService) LoadSecret(ctx context.Context, k string) (string, error) {
+	path, field := k, DefaultVaultField
+	if i := strings.LastIndexByte(k, '#'); i >= 0 {
+		path, field = k[:i], k[i+1:]
+	}
+	path = strings.Trim(path, "/")
+	if path == "" || field == "" {
+		return "", errors.Newf(codes.Invalid, "invalid secret key %q", k)
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "." || segment == ".." {
+			return "", errors.Newf(codes.Invalid, "invalid secret key %q", k)
+		}
+	}
+
+	data, err := vss.read(ctx, path)
+	if err != nil {
+		return "", errors.Wrapf(err, codes.Inherit, "cannot retrieve secret key %q", k)
+	}
+	raw, ok := data[field]
+	if !ok {
+		return "", errors.Newf(codes.NotFound, "secret key %q not found", k)
+	}
+	var v string
+	if err := json.Unmarshal(raw, &v); err != nil {
+		// Secrets that are not strings are returned as JSON.
+		return string(raw), nil
+	}
+	return v, nil
+}
+
+// read returns the fields of the secret at the path.
+func (vss *VaultSecretService) read(ctx context.Context, path string) (map[string]json.RawMessage, error) {
+	mount := strings.Trim(vss.Mount, "/")
+	if mount == "" {
+		mount = "secret"
+	}
+	var u string
+	if vss.KVVersion == 1 {
+		u = fmt.Sprintf("%s/v1/%s/%s", strings.TrimRight(vss.Address, "/"), mount, escapePath(path))
+	} else {
+		u = fmt.Sprintf("%s/v1/%s/data/%s", strings.TrimRight(vss.Address, "/"), mount, escapePath(path))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, codes.Invalid, "invalid secret store address")
+	}
+	if vss.Token != "" {
+		req.Header.Set("X-Vault-Token", vss.Token)
+	}
+	if vss.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", vss.Namespace)
+	}
+
+	client := vss.Client
+	if client == nil {
+		client = defaultVaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, codes.Unavailable, "secret store request failed")
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, errors.Wrap(err, codes.Unavailable, "cannot read secret store response")
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, errors.New(codes.NotFound, "not found")
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, errors.Newf(codes.PermissionDenied, "secret store denied access: %s", resp.Status)
+	case resp.StatusCode/100 != 2:
+		return nil, errors.Newf(codes.Unavailable, "secret store request failed with status %s", resp.Status)
+	}
+
+	var secret struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return nil, errors.Wrap(err, codes.Internal, "cannot decode secret store response")
+	}
+	data := secret.Data
+	if vss.KVVersion != 1 {
+		// Version 2 of the KV engine wraps the fields
+		// together with the metadata of the secret.
+		var versioned struct {
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(data, &versioned); err != nil {
+			return nil, errors.Wrap(err, codes.Internal, "cannot decode secret store response")
+		}
+		data = versioned.Data
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, errors.Wrap(err, codes.Internal, "cannot decode secret store response")
+	}
+	if fields == nil {
+		// A deleted secret in version 2 has no data.
+		return nil, errors.New(codes.NotFound, "not found")
+	}
+	return fields, nil
+}
+
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// Secret service that reads secrets from a Vault KV secrets engine over HTTP.
+// A key has the form path#field, such as database/postgres#password,
+// and the field defaults to DefaultVaultField when it is omitted.
+type VaultSecretService struct {
+	// Address is the address of the server, such as http://127.0.0.1:8200.
+	Address string
+	// Token is sent in the X-Vault-Token header.
+	Token string
+	// Namespace is sent in the X-Vault-Namespace header if it is set.
+	Namespace string
+	// Mount is the path the KV engine is mounted at. It defaults to secret.
+	Mount string
+	// KVVersion is the version of the KV engine, either 1 or 2.
+	// It defaults to 2.
+	KVVersion int
+	// Client is used to make requests.
+	// If it is nil, a client with a timeout of DefaultVaultTimeout is used.
+	Client *http.Client
+}
@@ -0,0 +1,430 @@
+package url
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"go.uber.org/zap"
+)
+
+// ScopedValidator is a Validator that can apply different
+// rules depending on the function that makes the connection.
+type ScopedValidator interface {
+	Validator
+
+	// Scope returns a Validator for connections made by the function.
+	// Functions are named by their package path and name, such as
+	// http.post or sql.from.
+	Scope(function string) Validator
+}
+
+// ForFunction returns the Validator for connections made by the function.
+// If the Validator does not support scopes, it is returned unchanged.
+func ForFunction(v Validator, function string) Validator {
+	if s, ok := v.(ScopedValidator); ok {
+		return s.Scope(function)
+	}
+	return v
+}
+
+// EgressRule matches outbound connections.
+// Every field that is set must match for the rule to match a connection.
+// A rule without any fields set matches every connection.
+type EgressRule struct {
+	// Functions limits the rule to connections made by these functions.
+	Functions []string
+	// Schemes limits the rule to URLs with these schemes.
+	Schemes []string
+	// Ports limits the rule to these ports.
+	// URLs without a port use the default port of their scheme.
+	Ports []int
+	// Hosts limits the rule to these host names.
+	// A name that starts with *. matches any subdomain of the name.
+	Hosts []string
+	// CIDRs limits the rule to the IP addresses in these ranges.
+	// Host names are resolved to check them against the ranges.
+	CIDRs []*net.IPNet
+}
+
+// addressOnly reports whether the rule only limits the addresses,
+// so it can be checked when only the address of a connection is known.
+func (r *EgressRule) addressOnly() bool {
+	return len(r.Functions) == 0 && len(r.Schemes) == 0 && len(r.Ports) == 0 && len(r.Hosts) == 0
+}
+
+// hasDestination reports whether the rule limits the hosts or addresses.
+func (r *EgressRule) hasDestination() bool {
+	return len(r.Hosts) > 0 || len(r.CIDRs) > 0
+}
+
+func (r *EgressRule) matchFunction(function string) bool {
+	if len(r.Functions) == 0 {
+		return true
+	}
+	for _, f := range r.Functions {
+		if f == function {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *EgressRule) matchScheme(scheme string) bool {
+	if len(r.Schemes) == 0 {
+		return true
+	}
+	for _, s := range r.Schemes {
+		if strings.EqualFold(s, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *EgressRule) matchPort(port int) bool {
+	if len(r.Ports) == 0 {
+		return true
+	}
+	for _, p := range r.Ports {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *EgressRule) matchHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, h := range r.Hosts {
+		h = strings.ToLower(h)
+		if strings.HasPrefix(h, "*.") {
+			if strings.HasSuffix(host, h[1:]) {
+				return true
+			}
+		} else if h == host {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *EgressRule) matchIP(ip net.IP) bool {
+	for _, block := range r.CIDRs {
+		if block.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchDestination reports whether the host or any of its addresses match the rule.
+func (r *EgressRule) matchDestination(host string, ips []net.IP) bool {
+	if !r.hasDestination() {
+		return true
+	}
+	if r.matchHost(host) {
+		return true
+	}
+	for _, ip := range ips {
+		if r.matchIP(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// EgressPolicy is a Validator that allows or denies outbound
+// connections based on lists of rules.
+//
+// A connection is denied if it matches any rule in Deny.
+// Otherwise, it is allowed if Allow is empty or it matches a rule in Allow.
+// If DenyPrivate is set, connections to private addresses are
+// denied in the same way as the PrivateIPValidator.
+//
+// Host names are only resolved when a rule or DenyPrivate
+// needs the addresses of the host, or when the connection is
+// allowed by a rule that ValidateIP cannot check.
+//
+// Addresses are also checked when the connection is established,
+// through ValidateIP, to protect against a host name that resolves
+// to a different address than it did when the URL was validated.
+// At that point only the address is known, so only rules that have
+// no hosts, schemes or ports are applied to it. Rules scoped
+// to functions are only applied if the validator is scoped as well.
+// An address is otherwise only allowed if it is one of the addresses
+// of a host that was allowed by Validate.
+type EgressPolicy struct {
+	Allow       []EgressRule
+	Deny        []EgressRule
+	DenyPrivate bool
+
+	// Logger receives an audit log entry for every denied connection.
+	Logger *zap.Logger
+
+	// function is the function the policy is scoped to.
+	function string
+
+	// allowed is shared by the policy and its scoped copies.
+	allowed     *allowedAddrs
+	allowedOnce sync.Once
+}
+
+var _ ScopedValidator = (*EgressPolicy)(nil)
+
+// Scope returns a copy of the policy that applies the rules for the function.
+func (p *EgressPolicy) Scope(function string) Validator {
+	return &EgressPolicy{
+		Allow:       p.Allow,
+		Deny:        p.Deny,
+		DenyPrivate: p.DenyPrivate,
+		Logger:      p.Logger,
+		function:    function,
+		allowed:     p.allowedAddrs(),
+	}
+}
+
+func (p *EgressPolicy) allowedAddrs() *allowedAddrs {
+	p.allowedOnce.Do(func() {
+		if p.allowed == nil {
+			p.allowed = &allowedAddrs{}
+		}
+	})
+	return p.allowed
+}
+
+func (p *EgressPolicy) Validate(u *url.URL) error {
+	if u == nil {
+		return p.deny("", "", 0, nil, "missing url")
+	}
+	scheme := strings.ToLower(u.Scheme)
+	host := u.Hostname()
+	port := 0
+	if s := u.Port(); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return errors.Newf(codes.Invalid, "invalid port %q", s)
+		}
+		port = n
+	} else {
+		port = defaultPorts[scheme]
+	}
+
+	var ips []net.IP
+	if p.needsAddresses() {
+		if ip := net.ParseIP(host); ip != nil {
+			ips = []net.IP{ip}
+		} else {
+			resolved, err := net.LookupIP(host)
+			if err != nil {
+				// Do not leak the resolver that failed to find the host.
+				return errors.New(codes.Invalid, "no such host")
+			}
+			ips = resolved
+		}
+	}
+
+	if p.DenyPrivate {
+		for _, ip := range ips {
+			if isPrivateIP(ip) {
+				return p.deny(scheme, host, port, ip, "private address")
+			}
+		}
+	}
+	for i := range p.Deny {
+		r := &p.Deny[i]
+		if r.matchFunction(p.function) && r.matchScheme(scheme) && r.matchPort(port) && r.matchDestination(host, ips) {
+			return p.deny(scheme, host, port, nil, "matched deny rule")
+		}
+	}
+	if len(p.Allow) == 0 {
+		return nil
+	}
+	for i := range p.Allow {
+		r := &p.Allow[i]
+		if r.matchFunction(p.function) && r.matchScheme(scheme) && r.matchPort(port) && r.matchDestination(host, ips) {
+			if !r.addressOnly() {
+				p.allowHost(host, ips)
+			}
+			return nil
+		}
+	}
+	return p.deny(scheme, host, port, nil, "no allow rule matched")
+}
+
+// allowHost records the addresses of a host allowed by a rule
+// that ValidateIP cannot check against the address alone.
+// If the host cannot be resolved, no address is recorded and
+// the connection fails when the address is checked.
+func (p *EgressPolicy) allowHost(host string, ips []net.IP) {
+	if ips == nil {
+		if ip := net.ParseIP(host); ip != nil {
+			ips = []net.IP{ip}
+		} else if resolved, err := net.LookupIP(host); err == nil {
+			ips = resolved
+		}
+	}
+	if len(ips) > 0 {
+		p.allowedAddrs().add(host, ips)
+	}
+}
+
+func (p *EgressPolicy) ValidateIP(ip net.IP) error {
+	if p.DenyPrivate && isPrivateIP(ip) {
+		return p.deny("", "", 0, ip, "private address")
+	}
+	for i := range p.Deny {
+		r := &p.Deny[i]
+		if p.appliesToIP(r) && len(r.CIDRs) > 0 && r.matchIP(ip) {
+			return p.deny("", "", 0, ip, "matched deny rule")
+		}
+	}
+	if len(p.Allow) == 0 {
+		return nil
+	}
+	for i := range p.Allow {
+		r := &p.Allow[i]
+		if p.appliesToIP(r) && (len(r.CIDRs) == 0 || r.matchIP(ip)) {
+			return nil
+		}
+	}
+	// Rules that limit the host by name, scheme, port or function
+	// cannot be checked against an address, so the address must
+	// belong to a host that one of them allowed in Validate.
+	// This protects against a host name that is rebound to
+	// another address after it was validated.
+	if p.allowedAddrs().contains(ip) {
+		return nil
+	}
+	return p.deny("", "", 0, ip, "no allow rule matched")
+}
+
+// appliesToIP reports whether the rule can be checked against an address alone.
+// Function scoped rules only apply when the policy is scoped, since the
+// connections of every function share the same dialer otherwise.
+func (p *EgressPolicy) appliesToIP(r *EgressRule) bool {
+	if len(r.Schemes) > 0 || len(r.Ports) > 0 || len(r.Hosts) > 0 {
+		return false
+	}
+	if len(r.Functions) > 0 {
+		return p.function != "" && r.matchFunction(p.function)
+	}
+	return true
+}
+
+// needsAddresses reports whether any rule needs the addresses of the host.
+func (p *EgressPolicy) needsAddresses() bool {
+	if p.DenyPrivate {
+		return true
+	}
+	for _, rules := range [][]EgressRule{p.Deny, p.Allow} {
+		for i := range rules {
+			if len(rules[i].CIDRs) > 0 && rules[i].matchFunction(p.function) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// deny logs the denied connection and returns the error for it.
+// The error intentionally does not explain which rule denied the connection.
+func (p *EgressPolicy) deny(scheme, host string, port int, ip net.IP, reason string) error {
+	if p.Logger != nil {
+		fields := []zap.Field{
+			zap.String("function", p.function),
+			zap.String("reason", reason),
+		}
+		if scheme != "" {
+			fields = append(fields, zap.String("scheme", scheme))
+		}
+		if host != "" {
+			fields = append(fields, zap.String("host", host))
+		}
+		if port != 0 {
+			fields = append(fields, zap.Int("port", port))
+		}
+		if ip != nil {
+			fields = append(fields, zap.String("ip", ip.String()))
+		}
+		p.Logger.Warn("egress denied by policy", fields...)
+	}
+	if host == "" && ip != nil {
+		host = ip.String()
+	}
+	return errors.Newf(codes.PermissionDenied, "connection to %q is not allowed by the egress policy", host)
+}
+
+// maxAllowedHosts is the number of hosts whose addresses are
+// remembered for ValidateIP.
+const maxAllowedHosts = 1024
+
+// allowedAddrs are the addresses of the hosts that were allowed
+// by rules that cannot be checked against an address alone.
+type allowedAddrs struct {
+	mu    sync.RWMutex
+	hosts map[string][]net.IP
+}
+
+func (a *allowedAddrs) add(host string, ips []net.IP) {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.hosts[host]; !ok && len(a.hosts) >= maxAllowedHosts {
+		// Forget the hosts that were seen before rather than
+		// growing without bound. They are added again the next
+		// time they are validated.
+		a.hosts = nil
+	}
+	if a.hosts == nil {
+		a.hosts = make(map[string][]net.IP)
+	}
+	a.hosts[host] = ips
+}
+
+func (a *allowedAddrs) contains(ip net.IP) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	for _, ips := range a.hosts {
+		for _, allowed := range ips {
+			if allowed.Equal(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// defaultPorts are the ports used for URLs without a port.
+var defaultPorts = map[string]int{
+	"http":       80,
+	"https":      443,
+	"ws":         80,
+	"wss":        443,
+	"mqtt":       1883,
+	"mqtts":      8883,
+	"ssl":        8883,
+	"postgres":   5432,
+	"postgresql": 5432,
+	"mysql":      3306,
+	"clickhouse": 9000,
+	"sqlserver":  1433,
+	"iox":        8082,
+}
+
+// ParseCIDRs parses a list of CIDR ranges for use in an EgressRule.
+func ParseCIDRs(cidrs ...string) ([]*net.IPNet, error) {
+	blocks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, block, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, errors.Wrapf(err, codes.Invalid, "invalid cidr %q", cidr)
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
@@ -0,0 +1,215 @@
+package url_test
+
+import (
+	"net"
+	nurl "net/url"
+	"testing"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/dependencies/url"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func mustParseCIDRs(t *testing.T, cidrs ...string) []*net.IPNet {
+	t.Helper()
+	blocks, err := url.ParseCIDRs(cidrs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return blocks
+}
+
+func TestEgressPolicy_Validate(t *testing.T) {
+	policy := &url.EgressPolicy{
+		Allow: []url.EgressRule{
+			{
+				Functions: []string{"http.post"},
+				Schemes:   []string{"https"},
+				Hosts:     []string{"hooks.example.com"},
+			},
+			{
+				Functions: []string{"sql.from", "sql.to"},
+				Schemes:   []string{"postgres"},
+				Ports:     []int{5432},
+				CIDRs:     mustParseCIDRs(t, "10.1.0.0/16"),
+			},
+			{
+				Hosts: []string{"*.internal.example.com"},
+				Ports: []int{443},
+			},
+		},
+		Deny: []url.EgressRule{
+			{Hosts: []string{"admin.internal.example.com"}},
+			// Only sql is scoped to this rule so the host names of the
+			// other functions do not need to be resolved.
+			{
+				Functions: []string{"sql.from", "sql.to"},
+				CIDRs:     mustParseCIDRs(t, "10.1.99.0/24"),
+			},
+		},
+	}
+
+	testCases := []struct {
+		function string
+		url      string
+		valid    bool
+	}{
+		{function: "http.post", url: "https://hooks.example.com/notify", valid: true},
+		{function: "http.post", url: "http://hooks.example.com/notify", valid: false},
+		{function: "http.post", url: "https://evil.example.com/", valid: false},
+		{function: "http/requests.do", url: "https://hooks.example.com/notify", valid: false},
+		{function: "sql.from", url: "postgres://10.1.2.3/warehouse", valid: true},
+		{function: "sql.to", url: "postgres://10.1.2.3:5432/warehouse", valid: true},
+		{function: "sql.from", url: "postgres://10.1.2.3:5433/warehouse", valid: false},
+		{function: "sql.from", url: "postgres://10.2.0.1/warehouse", valid: false},
+		{function: "sql.from", url: "postgres://10.1.99.1/warehouse", valid: false},
+		{function: "http.post", url: "postgres://10.1.2.3/warehouse", valid: false},
+		{function: "socket.from", url: "https://api.internal.example.com", valid: true},
+		{function: "socket.from", url: "https://API.Internal.Example.com.", valid: true},
+		{function: "socket.from", url: "http://api.internal.example.com", valid: false},
+		{function: "socket.from", url: "https://admin.internal.example.com", valid: false},
+		{function: "socket.from", url: "https://internal.example.com", valid: false},
+		{url: "https://api.internal.example.com", valid: true},
+		{url: "https://hooks.example.com/notify", valid: false},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.function+" "+tc.url, func(t *testing.T) {
+			u, err := nurl.Parse(tc.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = url.ForFunction(policy, tc.function).Validate(u)
+			if tc.valid && err != nil {
+				t.Errorf("unexpected validation error: %v", err)
+			} else if !tc.valid {
+				if err == nil {
+					t.Error("expected validation error got nil")
+				} else if want, got := codes.PermissionDenied, errors.Code(err); want != got {
+					t.Errorf("unexpected error code -want/+got:\n\t- %s\n\t+ %s", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestEgressPolicy_ValidateIP(t *testing.T) {
+	policy := &url.EgressPolicy{
+		Allow: []url.EgressRule{
+			{
+				Functions: []string{"sql.from"},
+				CIDRs:     mustParseCIDRs(t, "10.1.0.0/16"),
+			},
+			{CIDRs: mustParseCIDRs(t, "192.0.2.0/24")},
+		},
+		Deny: []url.EgressRule{
+			{CIDRs: mustParseCIDRs(t, "192.0.2.128/25")},
+		},
+		DenyPrivate: true,
+	}
+
+	testCases := []struct {
+		function string
+		ip       string
+		valid    bool
+	}{
+		{ip: "192.0.2.1", valid: true},
+		{ip: "192.0.2.200", valid: false},
+		{ip: "127.0.0.1", valid: false},
+		// Without a function, rules scoped to a function cannot be
+		// applied, and no host was allowed with this address.
+		{ip: "198.51.100.1", valid: false},
+		{function: "sql.from", ip: "198.51.100.1", valid: false},
+		{function: "sql.from", ip: "192.0.2.1", valid: true},
+		{function: "http.post", ip: "192.0.2.1", valid: true},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.function+" "+tc.ip, func(t *testing.T) {
+			err := url.ForFunction(policy, tc.function).ValidateIP(net.ParseIP(tc.ip))
+			if tc.valid && err != nil {
+				t.Errorf("unexpected validation error: %v", err)
+			} else if !tc.valid && err == nil {
+				t.Error("expected validation error got nil")
+			}
+		})
+	}
+}
+
+func TestEgressPolicy_ValidateIP_AllowedHosts(t *testing.T) {
+	policy := &url.EgressPolicy{
+		Allow: []url.EgressRule{
+			{Functions: []string{"http.post"}, Hosts: []string{"localhost", "198.51.100.7"}},
+		},
+	}
+
+	// Addresses are only allowed once a host with them was validated.
+	for _, ip := range []string{"127.0.0.1", "198.51.100.7"} {
+		if err := policy.ValidateIP(net.ParseIP(ip)); err == nil {
+			t.Errorf("%s: expected validation error got nil", ip)
+		}
+	}
+	for _, u := range []string{"http://localhost:8086", "https://198.51.100.7"} {
+		pu, _ := nurl.Parse(u)
+		if err := url.ForFunction(policy, "http.post").Validate(pu); err != nil {
+			t.Fatalf("%s: unexpected validation error: %v", u, err)
+		}
+	}
+	for ip, valid := range map[string]bool{
+		"127.0.0.1":    true,
+		"198.51.100.7": true,
+		"198.51.100.8": false,
+	} {
+		err := policy.ValidateIP(net.ParseIP(ip))
+		if valid && err != nil {
+			t.Errorf("%s: unexpected validation error: %v", ip, err)
+		} else if !valid && err == nil {
+			t.Errorf("%s: expected validation error got nil", ip)
+		}
+	}
+}
+
+func TestEgressPolicy_Audit(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	policy := &url.EgressPolicy{
+		Allow:  []url.EgressRule{{Hosts: []string{"example.com"}}},
+		Logger: zap.New(core),
+	}
+
+	u, _ := nurl.Parse("https://example.org:8443/path")
+	if err := url.ForFunction(policy, "http.post").Validate(u); err == nil {
+		t.Fatal("expected validation error got nil")
+	}
+	u, _ = nurl.Parse("https://example.com/path")
+	if err := url.ForFunction(policy, "http.post").Validate(u); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+
+	entries := logs.AllUntimed()
+	if want, got := 1, len(entries); want != got {
+		t.Fatalf("unexpected number of log entries -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+	fields := entries[0].ContextMap()
+	for k, want := range map[string]interface{}{
+		"function": "http.post",
+		"scheme":   "https",
+		"host":     "example.org",
+		"port":     int64(8443),
+		"reason":   "no allow rule matched",
+	} {
+		if got := fields[k]; got != want {
+			t.Errorf("unexpected log field %s -want/+got:\n\t- %v\n\t+ %v", k, want, got)
+		}
+	}
+}
+
+func TestForFunction(t *testing.T) {
+	// Validators that are not scoped are returned as is.
+	v := url.PrivateIPValidator{}
+	if got := url.ForFunction(v, "http.post"); got != v {
+		t.Errorf("unexpected validator: %v", got)
+	}
+}
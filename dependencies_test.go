@@ -0,0 +1,59 @@
+package flux_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/dependencies/quota"
+	"github.com/InfluxCommunity/flux/internal/errors"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestWithQuotaTracker(t *testing.T) {
+	client := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader("ok")),
+				Request:    r,
+			}, nil
+		}),
+	}
+	deps := flux.NewDefaultDependencies()
+	deps.Deps.HTTPClient = client
+	ctx := deps.Inject(context.Background())
+
+	// The second tracker wraps the dependencies of the first one,
+	// so both quotas apply.
+	ctx, outer, stop := quota.Start(ctx, quota.Limits{MaxHTTPRequests: 10})
+	defer stop()
+	ctx = flux.WithQuotaTracker(ctx, outer)
+	ctx, tracker, stop2 := quota.Start(ctx, quota.Limits{MaxHTTPRequests: 1})
+	defer stop2()
+	ctx = flux.WithQuotaTracker(ctx, tracker)
+
+	c, err := flux.GetDependencies(ctx).HTTPClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, code := range []codes.Code{codes.Inherit, codes.ResourceExhausted} {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com", nil)
+		resp, err := c.Do(req)
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+		if want, got := code, errors.Code(err); want != got {
+			t.Errorf("%d: unexpected error code -want/+got:\n\t- %s\n\t+ %s", i, want, got)
+		}
+	}
+}
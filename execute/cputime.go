@@ -0,0 +1,36 @@
+package execute
+
+import (
+	"runtime"
+	"time"
+)
+
+// cpuTimer measures the CPU time used by the calling goroutine.
+//
+// The goroutine is locked to its thread while the timer runs so the
+// CPU time of the thread is the CPU time of the goroutine. This includes
+// the time the thread spends on garbage collection work assigned to the
+// goroutine, but not the time used by other goroutines it starts or
+// waits on. CPU time is only measured on Linux and is zero elsewhere.
+type cpuTimer struct {
+	start time.Duration
+	ok    bool
+}
+
+// startCPUTimer starts measuring the CPU time of the calling goroutine.
+// Every call must be paired with a call to stop from the same goroutine.
+func startCPUTimer() cpuTimer {
+	runtime.LockOSThread()
+	start, ok := threadCPUTime()
+	return cpuTimer{start: start, ok: ok}
+}
+
+// stop returns the CPU time used since the timer was started.
+func (t cpuTimer) stop() time.Duration {
+	now, ok := threadCPUTime()
+	runtime.UnlockOSThread()
+	if !ok || !t.ok || now < t.start {
+		return 0
+	}
+	return now - t.start
+}
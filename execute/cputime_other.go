@@ -0,0 +1,11 @@
+//go:build !linux
+
+package execute
+
+import "time"
+
+// threadCPUTime reports false because the CPU time
+// of a thread is only measured on Linux.
+func threadCPUTime() (time.Duration, bool) {
+	return 0, false
+}
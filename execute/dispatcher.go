@@ -3,6 +3,8 @@ package execute
 import (
 	"context"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -20,11 +22,22 @@ type Dispatcher interface {
 type ScheduleFunc func(ctx context.Context, throughput int)
 
 // poolDispatcher implements Dispatcher using a pool of goroutines.
+//
+// Each worker goroutine has its own queue of work. Work scheduled after
+// the workers have started is distributed across the worker queues and
+// a worker that runs out of work steals half of the work queued on another
+// worker. Work scheduled before the workers have started is placed on
+// a shared queue that every worker pulls from.
 type poolDispatcher struct {
 	work   *ring
 	ready  chan struct{}
 	workMu sync.Mutex
 
+	workers atomic.Value // []*dispatchWorker
+	next    uint32
+	pending int64
+	cpuTime int64
+
 	throughput int
 
 	mu      sync.Mutex
@@ -37,6 +50,43 @@ type poolDispatcher struct {
 	logger *zap.Logger
 }
 
+// dispatchWorker holds the work queue for a single worker goroutine.
+type dispatchWorker struct {
+	id   int
+	mu   sync.Mutex
+	work *ring
+}
+
+func (w *dispatchWorker) push(fn ScheduleFunc) {
+	w.mu.Lock()
+	w.work.Append(fn)
+	w.mu.Unlock()
+}
+
+func (w *dispatchWorker) pop() ScheduleFunc {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if next := w.work.Next(); next != nil {
+		return next.(ScheduleFunc)
+	}
+	return nil
+}
+
+// stealHalf removes half of the queued work, rounded up, from the worker.
+func (w *dispatchWorker) stealHalf() []ScheduleFunc {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n := (w.work.Len() + 1) / 2
+	if n == 0 {
+		return nil
+	}
+	fns := make([]ScheduleFunc, n)
+	for i := range fns {
+		fns[i] = w.work.Next().(ScheduleFunc)
+	}
+	return fns
+}
+
 func newPoolDispatcher(throughput int, logger *zap.Logger) *poolDispatcher {
 	return &poolDispatcher{
 		throughput: throughput,
@@ -49,34 +99,58 @@ func newPoolDispatcher(throughput int, logger *zap.Logger) *poolDispatcher {
 }
 
 func (d *poolDispatcher) Schedule(fn ScheduleFunc) {
-	d.workMu.Lock()
-	defer d.workMu.Unlock()
+	atomic.AddInt64(&d.pending, 1)
+	if workers, _ := d.workers.Load().([]*dispatchWorker); len(workers) > 0 {
+		i := atomic.AddUint32(&d.next, 1) % uint32(len(workers))
+		workers[i].push(fn)
+	} else {
+		d.workMu.Lock()
+		d.work.Append(fn)
+		d.workMu.Unlock()
+	}
+	d.wake()
+}
 
-	// Schedule the work and then report to the channel that there
-	// is available work to unblock the worker scheduler thread.
-	d.work.Append(fn)
+// wake reports to the workers that there is available work.
+func (d *poolDispatcher) wake() {
 	select {
 	case d.ready <- struct{}{}:
 		// The ready channel should have a buffer of 1.
 		// Work being present is a binary yes or no.
 		// If we say yes multiple times, we only need to read it once
-		// in the outermost run loop.
+		// in the outermost run loop. A worker that finds more work
+		// than it takes will wake another worker.
 	default:
 	}
 }
 
 func (d *poolDispatcher) Start(n int, ctx context.Context) {
+	workers := make([]*dispatchWorker, n)
+	for i := range workers {
+		workers[i] = &dispatchWorker{
+			id:   i,
+			work: newRing(16),
+		}
+	}
+	d.workers.Store(workers)
+
 	d.wg.Add(n)
-	for i := 0; i < n; i++ {
-		go func() {
+	for _, w := range workers {
+		go func(w *dispatchWorker) {
 			defer d.wg.Done()
 			// Setup panic handling on the worker goroutines
 			defer d.recover()
-			d.run(ctx)
-		}()
+			d.run(ctx, w)
+		}(w)
 	}
 }
 
+// CPUDuration returns the CPU time the workers have spent
+// running scheduled work. See cpuTimer for what it includes.
+func (d *poolDispatcher) CPUDuration() time.Duration {
+	return time.Duration(atomic.LoadInt64(&d.cpuTime))
+}
+
 // Err returns a channel with will produce an error if encountered.
 func (d *poolDispatcher) Err() <-chan error {
 	d.mu.Lock()
@@ -116,7 +190,7 @@ func (d *poolDispatcher) Stop() error {
 }
 
 // run is the logic executed by each worker goroutine in the pool.
-func (d *poolDispatcher) run(ctx context.Context) {
+func (d *poolDispatcher) run(ctx context.Context, w *dispatchWorker) {
 	for {
 		// This loop waits for any work to be present in the queue
 		// or for the dispatcher to be closed or the context canceled.
@@ -128,33 +202,37 @@ func (d *poolDispatcher) run(ctx context.Context) {
 			// We are done, nothing left to do.
 			return
 		case <-d.ready:
-			// Work is in the queue. Continue to pull work
-			// from the queue until there is none left or
+			// Work is in the queues. Continue to pull work
+			// from the queues until there is none left or
 			// we are supposed to stop for one of the other
 			// reasons stated above.
-			d.doWork(ctx)
+			d.doWork(ctx, w)
 		}
 	}
 }
 
-// doWork will continue pulling work from the work queue
+// doWork will continue pulling work from the work queues
 // and running the scheduled functions until the context is canceled,
-// the dispatcher is closed, or there is no more work in the queue.
-func (d *poolDispatcher) doWork(ctx context.Context) {
+// the dispatcher is closed, or there is no more work in the queues.
+func (d *poolDispatcher) doWork(ctx context.Context, w *dispatchWorker) {
 	for {
-		var fn ScheduleFunc
-		d.workMu.Lock()
-		if next := d.work.Next(); next != nil {
-			fn = next.(ScheduleFunc)
-		}
-		d.workMu.Unlock()
-
+		fn := d.nextWork(w)
 		if fn == nil {
 			// No work anymore. Return to the top level loop
 			// which will wait until new work has been appended.
 			return
 		}
+
+		// If there is more work queued, wake another worker
+		// so it can steal the work instead of waiting for
+		// this worker to finish.
+		if atomic.AddInt64(&d.pending, -1) > 0 {
+			d.wake()
+		}
+
+		timer := startCPUTimer()
 		fn(ctx, d.throughput)
+		atomic.AddInt64(&d.cpuTime, int64(timer.stop()))
 
 		// Check to see if the context was canceled or
 		// the dispatcher was closed. This allows us to exit
@@ -168,3 +246,32 @@ func (d *poolDispatcher) doWork(ctx context.Context) {
 		}
 	}
 }
+
+// nextWork returns the next function for the worker to run.
+// The worker's own queue is checked first followed by the shared queue.
+// If both are empty, the worker attempts to steal work from the other
+// workers. It returns nil if no work was found.
+func (d *poolDispatcher) nextWork(w *dispatchWorker) ScheduleFunc {
+	if fn := w.pop(); fn != nil {
+		return fn
+	}
+
+	d.workMu.Lock()
+	next := d.work.Next()
+	d.workMu.Unlock()
+	if next != nil {
+		return next.(ScheduleFunc)
+	}
+
+	workers := d.workers.Load().([]*dispatchWorker)
+	for i := 1; i < len(workers); i++ {
+		victim := workers[(w.id+i)%len(workers)]
+		if fns := victim.stealHalf(); len(fns) > 0 {
+			for _, fn := range fns[1:] {
+				w.push(fn)
+			}
+			return fns[0]
+		}
+	}
+	return nil
+}
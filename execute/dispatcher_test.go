@@ -81,3 +81,81 @@ func TestDispatcher_ScheduleMany(t *testing.T) {
 	cancel()
 	wg.Wait()
 }
+
+func TestDispatcher_WorkStealing(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	d := newPoolDispatcher(10, zaptest.NewLogger(t))
+	d.Start(2, ctx)
+	defer func() { _ = d.Stop() }()
+
+	// Block one of the workers until all of the other work is done.
+	// Some of the work is queued on the blocked worker so the other
+	// worker must steal it for the work to complete.
+	release := make(chan struct{})
+	started := make(chan struct{})
+	d.Schedule(func(ctx context.Context, throughput int) {
+		close(started)
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+	})
+	<-started
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		d.Schedule(func(ctx context.Context, throughput int) {
+			wg.Done()
+		})
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		t.Fatal("timeout waiting for work to be stolen")
+	}
+	close(release)
+}
+
+func TestDispatcher_CPUDuration(t *testing.T) {
+	if _, ok := threadCPUTime(); !ok {
+		t.Skip("thread CPU time is not supported on this platform")
+	}
+
+	d := newPoolDispatcher(10, zaptest.NewLogger(t))
+	d.Start(2, context.Background())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		// Spin until the thread has used 10ms of CPU time.
+		d.Schedule(func(ctx context.Context, throughput int) {
+			defer wg.Done()
+			start, _ := threadCPUTime()
+			for now := start; now-start < 10*time.Millisecond; now, _ = threadCPUTime() {
+			}
+		})
+		// Sleeping does not use CPU time.
+		d.Schedule(func(ctx context.Context, throughput int) {
+			defer wg.Done()
+			time.Sleep(50 * time.Millisecond)
+		})
+	}
+	wg.Wait()
+
+	if err := d.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if got, min, max := d.CPUDuration(), 40*time.Millisecond, 100*time.Millisecond; got < min || got > max {
+		t.Errorf("unexpected CPU duration: got %v, want between %v and %v", got, min, max)
+	}
+}
@@ -49,6 +49,8 @@ var testFlags = map[string]interface{}{
 	"optimizeStateTracking":     true,
 	"optimizeSetTransformation": true,
 	"strictNullLogicalOps":      true,
+	"typedRowFunctions":         true,
+	"optimizeFunctions":         true,
 }
 
 type TestFlagger map[string]interface{}
@@ -84,6 +84,10 @@ func (e *executor) Execute(ctx context.Context, p *plan.Spec, a memory.Allocator
 
 func (e *executor) createExecutionState(ctx context.Context, p *plan.Spec, a memory.Allocator) (*executionState, error) {
 	ctx, cancel := context.WithCancel(ctx)
+	if a != nil {
+		// Wrap the allocator so it can be canceled along with the query.
+		a = memory.NewCancelableAllocator(a)
+	}
 	es := &executionState{
 		p:         p,
 		ctx:       ctx,
@@ -544,6 +548,20 @@ func (es *executionState) do() {
 		}(src)
 	}
 
+	// Cancel the allocator when the query is canceled so transformations
+	// processing a large table stop at their next allocation or
+	// checkpoint instead of running to completion.
+	done := make(chan struct{})
+	if alloc, ok := es.alloc.(*memory.CancelableAllocator); ok {
+		go func() {
+			select {
+			case <-es.ctx.Done():
+				alloc.Cancel(errors.Wrap(es.ctx.Err(), codes.Canceled, "query canceled"))
+			case <-done:
+			}
+		}()
+	}
+
 	wg.Add(1)
 	es.dispatcher.Start(es.resources.ConcurrencyQuota, es.ctx)
 
@@ -572,11 +590,15 @@ func (es *executionState) do() {
 		if err != nil {
 			es.abort(err)
 		}
+		updateStats(func(stats *flux.Statistics) {
+			stats.CPUDuration = es.dispatcher.CPUDuration()
+		})
 	}()
 
 	go func() {
 		defer close(es.statsCh)
 		wg.Wait()
+		close(done)
 
 		// Merge the transport profiles in with the ones already filled
 		// by the sources.
@@ -21,7 +21,7 @@ func (es *executionState) recover() {
 			err = fmt.Errorf("%v", e)
 		}
 
-		if errors.Code(err) == codes.ResourceExhausted {
+		if code := errors.Code(err); code == codes.ResourceExhausted || code == codes.Canceled {
 			es.abort(err)
 			return
 		}
@@ -42,7 +42,7 @@ func (d *poolDispatcher) recover() {
 			err = fmt.Errorf("%v", e)
 		}
 
-		if errors.Code(err) == codes.ResourceExhausted {
+		if code := errors.Code(err); code == codes.ResourceExhausted || code == codes.Canceled {
 			d.setErr(err)
 			return
 		}
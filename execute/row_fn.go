@@ -7,6 +7,7 @@ import (
 	"github.com/InfluxCommunity/flux/codes"
 	"github.com/InfluxCommunity/flux/compiler"
 	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/internal/feature"
 	"github.com/InfluxCommunity/flux/semantic"
 	"github.com/InfluxCommunity/flux/values"
 )
@@ -22,6 +23,7 @@ type dynamicFn struct {
 
 type compiledFn struct {
 	fn         compiler.Func
+	typed      compiler.RowFunc
 	inType     semantic.MonoType
 	recordType semantic.MonoType
 	cols       []flux.ColMeta
@@ -107,6 +109,7 @@ func (f *dynamicFn) compileFunction(ctx context.Context, cols []flux.ColMeta, ex
 		}
 		f.compiledFn = &compiledFn{
 			fn:         fn,
+			typed:      f.compileRowFunction(ctx, cols, extraTypes, vectorized),
 			inType:     inType,
 			recordType: recordType,
 			cols:       cols,
@@ -117,26 +120,47 @@ func (f *dynamicFn) compileFunction(ctx context.Context, cols []flux.ColMeta, ex
 	return nil
 }
 
+// compileRowFunction specializes the function for the columns when
+// it only takes the record. It returns nil if the function cannot be
+// specialized and the function compiled with compiler.Compile should
+// be used instead.
+func (f *dynamicFn) compileRowFunction(ctx context.Context, cols []flux.ColMeta, extraTypes map[string]semantic.MonoType, vectorized bool) compiler.RowFunc {
+	if vectorized || len(extraTypes) > 0 || !feature.TypedRowFunctions().Enabled(ctx) {
+		return nil
+	}
+	fn, err := compiler.CompileRow(ctx, f.scope, f.fn, cols)
+	if err != nil {
+		return nil
+	}
+	return fn
+}
+
 func (f *dynamicFn) prepare(ctx context.Context, cols []flux.ColMeta, extraTypes map[string]semantic.MonoType, vectorized bool) (preparedFn, error) {
 	err := f.compileFunction(ctx, cols, extraTypes, vectorized)
 	if err != nil {
 		return preparedFn{}, err
 	}
+	return f.newPreparedFn(), nil
+}
 
-	// Construct the arguments that will be used when evaluating the function.
+// newPreparedFn constructs the arguments that will be
+// used when evaluating the compiled function.
+func (f *dynamicFn) newPreparedFn() preparedFn {
 	arg0 := values.NewObject(f.compiledFn.recordType)
 	args := values.NewObject(f.compiledFn.inType)
 	args.Set(f.recordName, arg0)
 	return preparedFn{
 		fn:         f.compiledFn.fn,
+		typed:      f.compiledFn.typed,
 		recordName: f.recordName,
 		arg0:       arg0,
 		args:       args,
-	}, nil
+	}
 }
 
 type preparedFn struct {
 	fn         compiler.Func
+	typed      compiler.RowFunc
 	recordName string
 	arg0       values.Object
 	args       values.Object
@@ -242,6 +266,9 @@ type rowFn struct {
 }
 
 func (f *rowFn) eval(ctx context.Context, row int, cr flux.ColReader, extraParams map[string]values.Value) (values.Value, error) {
+	if f.typed != nil && len(extraParams) == 0 {
+		return f.typed.EvalRow(ctx, row, cr)
+	}
 	for j, col := range cr.Cols() {
 		f.arg0.Set(col.Label, ValueForRow(cr, row, j))
 	}
@@ -290,7 +317,17 @@ func (f *RowPredicatePreparedFn) InputType() semantic.MonoType {
 	return f.arg0.Type()
 }
 
+// Specialized reports whether the function was specialized for the
+// column types with compiler.CompileRow. A specialized function reads
+// the columns directly so EvalRow should be preferred over Eval.
+func (f *RowPredicatePreparedFn) Specialized() bool {
+	return f.typed != nil
+}
+
 func (f *RowPredicatePreparedFn) EvalRow(ctx context.Context, row int, cr flux.ColReader) (bool, error) {
+	if f.typed != nil {
+		return f.typed.EvalRowBool(ctx, row, cr)
+	}
 	v, err := f.eval(ctx, row, cr, nil)
 	if err != nil {
 		return false, err
@@ -1275,7 +1275,12 @@ func (b *ColListTableBuilder) Sort(cols []string, desc bool) {
 			}
 		}
 	}
-	s := colListTableSorter{cols: colIdxs, desc: desc, b: b}
+	s := colListTableSorter{
+		cols:       colIdxs,
+		desc:       desc,
+		b:          b,
+		checkpoint: memory.NewCheckpoint(b.alloc.Allocator),
+	}
 	sort.Sort(s)
 }
 
@@ -1390,9 +1395,10 @@ func (t *ColListTable) Times(j int) *array.Int {
 }
 
 type colListTableSorter struct {
-	cols []int
-	desc bool
-	b    *ColListTableBuilder
+	cols       []int
+	desc       bool
+	b          *ColListTableBuilder
+	checkpoint *memory.Checkpoint
 }
 
 func (c colListTableSorter) Len() int {
@@ -1400,6 +1406,12 @@ func (c colListTableSorter) Len() int {
 }
 
 func (c colListTableSorter) Less(x int, y int) (less bool) {
+	if err := c.checkpoint.Check(); err != nil {
+		// Sort cannot return an error so panic in the
+		// same way the allocator does when it fails.
+		panic(err)
+	}
+
 	var hasNil bool
 	for _, j := range c.cols {
 		if !c.b.cols[j].Equal(x, y) {
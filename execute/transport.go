@@ -62,6 +62,7 @@ type consecutiveTransport struct {
 	schedulerState int32
 	inflight       int32
 	totalMsgs      int32
+	cpuTime        int64
 
 	initSpanOnce sync.Once
 	span         opentracing.Span
@@ -127,7 +128,16 @@ func (t *consecutiveTransport) Finished() <-chan struct{} {
 }
 
 func (t *consecutiveTransport) TransportProfile() flux.TransportProfile {
-	return t.profile
+	profile := t.profile
+	profile.CPUDuration = atomic.LoadInt64(&t.cpuTime)
+	return profile
+}
+
+// addCPUTime records the CPU time used since the timer was started.
+// It must be called before the transport changes its schedule state
+// so the time is recorded before another worker can finish the transport.
+func (t *consecutiveTransport) addCPUTime(timer cpuTimer) {
+	atomic.AddInt64(&t.cpuTime, int64(timer.stop()))
 }
 
 func (t *consecutiveTransport) RetractTable(id DatasetID, key flux.GroupKey) error {
@@ -244,6 +254,7 @@ func (t *consecutiveTransport) processMessages(ctx context.Context, throughput i
 	t.initSpan(ctx)
 
 PROCESS:
+	timer := startCPUTimer()
 	i := 0
 	for m := t.messages.Pop(); m != nil; m = t.messages.Pop() {
 		atomic.AddInt32(&t.inflight, -1)
@@ -263,6 +274,7 @@ PROCESS:
 					_ = t.t.ProcessMessage(m)
 				}
 				// We are finished
+				t.addCPUTime(timer)
 				close(t.finished)
 				t.finishSpan(err)
 				return
@@ -272,12 +284,14 @@ PROCESS:
 		if i >= throughput {
 			// We have done enough work.
 			// Transition to the idle state and reschedule for later.
+			t.addCPUTime(timer)
 			t.transition(idle)
 			t.schedule()
 			return
 		}
 	}
 
+	t.addCPUTime(timer)
 	t.transition(idle)
 	// Check if more messages arrived after the above loop finished.
 	// This check must happen in the idle state.
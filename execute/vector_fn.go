@@ -42,11 +42,130 @@ func (f *VectorMapPreparedFn) Type() semantic.MonoType {
 	return f.fn.Type()
 }
 
+type VectorPredicateFn struct {
+	dynamicFn
+}
+
+func NewVectorPredicateFn(fn *semantic.FunctionExpression, scope compiler.Scope) *VectorPredicateFn {
+	return &VectorPredicateFn{
+		dynamicFn: newDynamicFn(fn, scope),
+	}
+}
+
+func (f *VectorPredicateFn) Prepare(ctx context.Context, cols []flux.ColMeta) (*VectorPredicatePreparedFn, error) {
+	fn, err := f.prepare(ctx, cols, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	if typ := fn.returnType(); typ.Nature() != semantic.Vector {
+		return nil, errors.New(codes.Invalid, "vectorized predicate function does not evaluate to a vector")
+	} else if elem, err := typ.ElemType(); err != nil {
+		return nil, err
+	} else if elem.Nature() != semantic.Bool {
+		return nil, errors.New(codes.Invalid, "vectorized predicate function does not evaluate to a boolean")
+	}
+	return &VectorPredicatePreparedFn{
+		vectorFn: vectorFn{preparedFn: fn},
+	}, nil
+}
+
+type VectorPredicatePreparedFn struct {
+	vectorFn
+}
+
+// Eval evaluates the predicate over every row in the chunk and
+// returns the resulting vector of booleans. The vector may be a
+// repeated constant rather than an array when the predicate
+// does not depend on any of the columns.
+func (f *VectorPredicatePreparedFn) Eval(ctx context.Context, chunk table.Chunk) (values.Vector, error) {
+	res, err := f.eval(ctx, chunk)
+	if err != nil {
+		return nil, err
+	}
+	return res.Vector(), nil
+}
+
+// VectorExprFn evaluates an expression of a record for every row
+// of a chunk at once. It is used to vectorize the parts of a function
+// that only depend on the record when the function itself is not
+// vectorized, such as a reduce function.
+type VectorExprFn struct {
+	dynamicFn
+	expr semantic.Expression
+}
+
+func NewVectorExprFn(expr semantic.Expression, recordName string, scope compiler.Scope) *VectorExprFn {
+	return &VectorExprFn{
+		dynamicFn: dynamicFn{
+			scope:      scope,
+			recordName: recordName,
+		},
+		expr: expr,
+	}
+}
+
+// Prepare compiles the expression for the columns. It returns an error
+// with the code codes.Unimplemented if the expression cannot be vectorized
+// for these columns.
+func (f *VectorExprFn) Prepare(ctx context.Context, cols []flux.ColMeta) (*VectorExprPreparedFn, error) {
+	if f.compiledFn == nil || !f.compiledFn.isCacheHit(cols, nil, true) {
+		fn, err := compiler.CompileVector(ctx, f.scope, f.expr, f.recordName, cols)
+		if err != nil {
+			return nil, err
+		}
+		recordType, err := f.typeof(cols, true)
+		if err != nil {
+			return nil, err
+		}
+		f.compiledFn = &compiledFn{
+			fn: fn,
+			inType: semantic.NewObjectType([]semantic.PropertyType{
+				{Key: []byte(f.recordName), Value: recordType},
+			}),
+			recordType: recordType,
+			cols:       cols,
+			vectorized: true,
+		}
+	}
+	return &VectorExprPreparedFn{
+		vectorFn: vectorFn{preparedFn: f.newPreparedFn()},
+	}, nil
+}
+
+type VectorExprPreparedFn struct {
+	vectorFn
+}
+
+// Type returns the type of the elements of the vector.
+func (f *VectorExprPreparedFn) Type() semantic.MonoType {
+	elem, _ := f.fn.Type().ElemType()
+	return elem
+}
+
+// Eval evaluates the expression for every row in the chunk.
+// The vector may be a repeated constant rather than an array
+// when the expression does not depend on any of the columns.
+func (f *VectorExprPreparedFn) Eval(ctx context.Context, chunk table.Chunk) (values.Vector, error) {
+	res, err := f.eval(ctx, chunk)
+	if err != nil {
+		return nil, err
+	}
+	return res.Vector(), nil
+}
+
 type vectorFn struct {
 	preparedFn
 }
 
 func (f *vectorFn) Eval(ctx context.Context, chunk table.Chunk) (values.Object, error) {
+	res, err := f.eval(ctx, chunk)
+	if err != nil {
+		return nil, err
+	}
+	return res.Object(), nil
+}
+
+func (f *vectorFn) eval(ctx context.Context, chunk table.Chunk) (values.Value, error) {
 	for j, col := range chunk.Cols() {
 		arr := chunk.Values(j)
 		arr.Retain()
@@ -55,9 +174,5 @@ func (f *vectorFn) Eval(ctx context.Context, chunk table.Chunk) (values.Object,
 	}
 	defer f.arg0.Release()
 
-	res, err := f.fn.Eval(ctx, f.args)
-	if err != nil {
-		return nil, err
-	}
-	return res.Object(), nil
+	return f.fn.Eval(ctx, f.args)
 }
@@ -173,6 +173,42 @@ func SalsaDatabase() BoolFlag {
 	return salsaDatabase
 }
 
+var parallelizationFactor = feature.MakeIntFlag(
+	"Parallelization Factor",
+	"parallelizationFactor",
+	"Adrian Thurston",
+	0,
+)
+
+// ParallelizationFactor - Number of partitions that parallel-capable sources split their input into
+func ParallelizationFactor() IntFlag {
+	return parallelizationFactor
+}
+
+var typedRowFunctions = feature.MakeBoolFlag(
+	"Typed Row Functions",
+	"typedRowFunctions",
+	"Jonathan Sternberg",
+	false,
+)
+
+// TypedRowFunctions - Evaluate eligible row functions with evaluators specialized for the column types
+func TypedRowFunctions() BoolFlag {
+	return typedRowFunctions
+}
+
+var optimizeFunctions = feature.MakeBoolFlag(
+	"Optimize Functions",
+	"optimizeFunctions",
+	"Jonathan Sternberg",
+	false,
+)
+
+// OptimizeFunctions - Fold constant expressions and evaluate repeated expressions once when compiling functions
+func OptimizeFunctions() BoolFlag {
+	return optimizeFunctions
+}
+
 // Inject will inject the Flagger into the context.
 func Inject(ctx context.Context, flagger Flagger) context.Context {
 	return feature.Inject(ctx, flagger)
@@ -192,6 +228,9 @@ var all = []Flag{
 	strictNullLogicalOps,
 	prettyError,
 	salsaDatabase,
+	parallelizationFactor,
+	typedRowFunctions,
+	optimizeFunctions,
 }
 
 var byKey = map[string]Flag{
@@ -208,6 +247,9 @@ var byKey = map[string]Flag{
 	"strictNullLogicalOps":             strictNullLogicalOps,
 	"prettyError":                      prettyError,
 	"salsaDatabase":                    salsaDatabase,
+	"parallelizationFactor":            parallelizationFactor,
+	"typedRowFunctions":                typedRowFunctions,
+	"optimizeFunctions":                optimizeFunctions,
 }
 
 // Flags returns all feature flags.
@@ -0,0 +1,97 @@
+// Package ndjson decodes newline-delimited JSON into flux tables.
+package ndjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/values"
+)
+
+// Value converts a raw JSON value into a flux value.
+// Objects and arrays are returned as their JSON encoding.
+func Value(raw json.RawMessage) (values.Value, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return values.Null, nil
+	}
+	switch raw[0] {
+	case '{', '[':
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, raw); err != nil {
+			return nil, errors.Wrap(err, codes.Invalid, "failed to decode json")
+		}
+		return values.NewString(buf.String()), nil
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, errors.Wrap(err, codes.Invalid, "failed to decode json")
+	}
+	switch v := v.(type) {
+	case nil:
+		return values.Null, nil
+	case float64:
+		return values.NewFloat(v), nil
+	case string:
+		return values.NewString(v), nil
+	case bool:
+		return values.NewBool(v), nil
+	default:
+		return nil, errors.Newf(codes.Internal, "unexpected json value %T", v)
+	}
+}
+
+// AppendRow appends a row to the builder. Columns that do not exist yet
+// are added and the existing rows are marked as null. Columns that are
+// missing from the row, or have a null value, are appended as null.
+func AppendRow(b *execute.ColListTableBuilder, row map[string]values.Value) error {
+	labels := make([]string, 0, len(row))
+	for label := range row {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	n := b.NRows()
+	for _, label := range labels {
+		v := row[label]
+		if v.IsNull() {
+			continue
+		}
+		typ := flux.ColumnType(v.Type())
+		j := execute.ColIdx(label, b.Cols())
+		if j < 0 {
+			var err error
+			if j, err = b.AddCol(flux.ColMeta{Label: label, Type: typ}); err != nil {
+				return err
+			}
+			// The builder grows new columns to the current length,
+			// so mark the existing rows as null.
+			for k := 0; k < n; k++ {
+				if err := b.SetNil(k, j); err != nil {
+					return err
+				}
+			}
+		} else if b.Cols()[j].Type != typ {
+			return errors.Newf(codes.FailedPrecondition, "schema collision on column %q: %s and %s", label, b.Cols()[j].Type, typ)
+		}
+	}
+	for j, col := range b.Cols() {
+		v, ok := row[col.Label]
+		if !ok || v.IsNull() {
+			if err := b.AppendNil(j); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := b.AppendValue(j, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package ndjson_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/executetest"
+	"github.com/InfluxCommunity/flux/internal/ndjson"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/values"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAppendRow(t *testing.T) {
+	rows := []string{
+		`{"host": "a", "value": 1.5}`,
+		`{"host": "b", "ok": true, "nested": {"x": [1, 2]}}`,
+		`{"host": null, "value": 2}`,
+	}
+
+	b := execute.NewColListTableBuilder(execute.NewGroupKey(nil, nil), memory.DefaultAllocator)
+	for _, row := range rows {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(row), &obj); err != nil {
+			t.Fatal(err)
+		}
+		vs := make(map[string]values.Value, len(obj))
+		for k, raw := range obj {
+			v, err := ndjson.Value(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			vs[k] = v
+		}
+		if err := ndjson.AppendRow(b, vs); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tbl, err := b.Table()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := executetest.ConvertTable(tbl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &executetest.Table{
+		ColMeta: []flux.ColMeta{
+			{Label: "host", Type: flux.TString},
+			{Label: "value", Type: flux.TFloat},
+			{Label: "nested", Type: flux.TString},
+			{Label: "ok", Type: flux.TBool},
+		},
+		Data: [][]interface{}{
+			{"a", 1.5, nil, nil},
+			{"b", nil, `{"x":[1,2]}`, true},
+			{nil, 2.0, nil, nil},
+		},
+	}
+	got.Normalize()
+	want.Normalize()
+	if !cmp.Equal(want, got) {
+		t.Errorf("unexpected table -want/+got\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestAppendRow_SchemaCollision(t *testing.T) {
+	b := execute.NewColListTableBuilder(execute.NewGroupKey(nil, nil), memory.DefaultAllocator)
+	if err := ndjson.AppendRow(b, map[string]values.Value{"v": values.NewFloat(1)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ndjson.AppendRow(b, map[string]values.Value{"v": values.NewString("one")}); err == nil {
+		t.Fatal("expected error")
+	}
+}
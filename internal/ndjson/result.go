@@ -0,0 +1,167 @@
+package ndjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/internal/line"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/values"
+)
+
+// ResultDecoder decodes a stream of JSON objects from a reader into a flux.Result.
+// Objects may be separated by whitespace, which includes newline-delimited JSON,
+// or wrapped in top-level arrays.
+// Each object becomes a row in a single table with an empty group key.
+// The top-level keys of an object become columns. Numbers are decoded as floats,
+// nested objects and arrays are encoded as JSON strings and null values are left empty.
+// A `_time` key must be an RFC3339 timestamp or an integer number of nanoseconds
+// since the Unix epoch. When an object has no `_time` key or it is null,
+// the `_time` column contains the time the object was read.
+// ResultDecoder outputs one table once the reader reaches EOF
+// or the maximum number of records has been read.
+type ResultDecoder struct {
+	decoder *json.Decoder
+	config  *ResultDecoderConfig
+}
+
+// NewResultDecoder creates a new result decoder from config.
+func NewResultDecoder(config *ResultDecoderConfig) *ResultDecoder {
+	return &ResultDecoder{config: config}
+}
+
+// ResultDecoderConfig is the configuration for a result decoder.
+type ResultDecoderConfig struct {
+	TimeProvider line.TimeProvider
+	// MaxRecords is the maximum number of objects that will be decoded.
+	// A value of zero means no limit.
+	MaxRecords int
+	// Allocator is the memory allocator that will be used during decoding.
+	// The default is to use an unlimited allocator when this is not set.
+	Allocator memory.Allocator
+}
+
+func (rd *ResultDecoder) Do(f func(flux.Table) error) error {
+	key := execute.NewGroupKey(nil, nil)
+	mem := rd.config.Allocator
+	if mem == nil {
+		mem = &memory.ResourceAllocator{}
+	}
+	builder := execute.NewColListTableBuilder(key, mem)
+
+	n := 0
+	for rd.config.MaxRecords <= 0 || n < rd.config.MaxRecords {
+		tok, err := rd.decoder.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return errors.Wrap(err, codes.Invalid, "failed to decode json")
+		}
+
+		switch tok {
+		case json.Delim('{'):
+			if err := rd.decodeObject(builder); err != nil {
+				return err
+			}
+			n++
+		case json.Delim('['), json.Delim(']'):
+			// Arrays of objects are flattened into rows.
+		default:
+			return errors.Newf(codes.Invalid, "expected a json object, got %v", tok)
+		}
+	}
+
+	tbl, err := builder.Table()
+	if err != nil {
+		return err
+	}
+	return f(tbl)
+}
+
+// decodeObject decodes the remainder of an object whose opening
+// delimiter has already been read and appends it as a row.
+func (rd *ResultDecoder) decodeObject(builder *execute.ColListTableBuilder) error {
+	row := make(map[string]values.Value)
+	for rd.decoder.More() {
+		tok, err := rd.decoder.Token()
+		if err != nil {
+			return errors.Wrap(err, codes.Invalid, "failed to decode json")
+		}
+		k, ok := tok.(string)
+		if !ok {
+			return errors.Newf(codes.Invalid, "expected a json object key, got %v", tok)
+		}
+		var raw json.RawMessage
+		if err := rd.decoder.Decode(&raw); err != nil {
+			return errors.Wrap(err, codes.Invalid, "failed to decode json")
+		}
+		var v values.Value
+		if k == execute.DefaultTimeColLabel {
+			v, err = timeValue(raw)
+		} else {
+			v, err = Value(raw)
+		}
+		if err != nil {
+			return err
+		}
+		row[k] = v
+	}
+	// Consume the closing delimiter.
+	if _, err := rd.decoder.Token(); err != nil {
+		return errors.Wrap(err, codes.Invalid, "failed to decode json")
+	}
+
+	if v, ok := row[execute.DefaultTimeColLabel]; !ok || v.IsNull() {
+		row[execute.DefaultTimeColLabel] = values.NewTime(rd.config.TimeProvider.CurrentTime())
+	}
+	return AppendRow(builder, row)
+}
+
+// timeValue converts a raw JSON `_time` value into a time value.
+// Strings are parsed as RFC3339 timestamps and integers are
+// nanoseconds since the Unix epoch.
+func timeValue(raw json.RawMessage) (values.Value, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, errors.Wrap(err, codes.Invalid, "failed to decode json")
+	}
+	switch v := v.(type) {
+	case nil:
+		return values.Null, nil
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return nil, errors.Wrapf(err, codes.Invalid, "invalid value for %q", execute.DefaultTimeColLabel)
+		}
+		return values.NewTime(values.ConvertTime(t)), nil
+	case json.Number:
+		ns, err := v.Int64()
+		if err != nil {
+			return nil, errors.Wrapf(err, codes.Invalid, "invalid value for %q", execute.DefaultTimeColLabel)
+		}
+		return values.NewTime(values.Time(ns)), nil
+	default:
+		return nil, errors.Newf(codes.Invalid, "invalid value for %q: expected a timestamp string or integer", execute.DefaultTimeColLabel)
+	}
+}
+
+func (*ResultDecoder) Name() string {
+	return "_result"
+}
+
+func (rd *ResultDecoder) Tables() flux.TableIterator {
+	return rd
+}
+
+func (rd *ResultDecoder) Decode(r io.Reader) (flux.Result, error) {
+	rd.decoder = json.NewDecoder(r)
+	return rd, nil
+}
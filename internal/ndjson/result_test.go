@@ -0,0 +1,165 @@
+package ndjson_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/executetest"
+	"github.com/InfluxCommunity/flux/internal/ndjson"
+	"github.com/InfluxCommunity/flux/mock"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestResultDecoder(t *testing.T) {
+	tcs := []struct {
+		name       string
+		maxRecords int
+		input      string
+		want       *executetest.Result
+		wantErr    bool
+	}{
+		{
+			name: "newline delimited",
+			input: `{"host": "a", "value": 1.5}
+{"host": "b", "value": 2, "ok": true}
+
+{"host": "c", "nested": {"x": [1, 2]}, "value": null}
+`,
+			want: &executetest.Result{
+				Nm: "_result",
+				Tbls: []*executetest.Table{{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "host", Type: flux.TString},
+						{Label: "value", Type: flux.TFloat},
+						{Label: "ok", Type: flux.TBool},
+						{Label: "nested", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(0), "a", 1.5, nil, nil},
+						{execute.Time(1), "b", 2.0, true, nil},
+						{execute.Time(2), "c", nil, nil, `{"x":[1,2]}`},
+					},
+				}},
+			},
+		},
+		{
+			name:  "array of objects",
+			input: `[{"_time": "2021-01-01T00:00:00Z", "v": "x"}, {"_time": "2021-01-01T00:00:01Z", "v": "y"}]`,
+			want: &executetest.Result{
+				Nm: "_result",
+				Tbls: []*executetest.Table{{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "v", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1609459200000000000), "x"},
+						{execute.Time(1609459201000000000), "y"},
+					},
+				}},
+			},
+		},
+		{
+			name:  "time as nanoseconds",
+			input: `{"_time": 1609459200000000001, "v": 1} {"_time": null, "v": 2}`,
+			want: &executetest.Result{
+				Nm: "_result",
+				Tbls: []*executetest.Table{{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "v", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(1609459200000000001), 1.0},
+						{execute.Time(0), 2.0},
+					},
+				}},
+			},
+		},
+		{
+			name:    "invalid time",
+			input:   `{"_time": "yesterday", "v": 1}`,
+			wantErr: true,
+		},
+		{
+			name:       "max records",
+			maxRecords: 2,
+			input:      `{"v": 1} {"v": 2} {"v": 3}`,
+			want: &executetest.Result{
+				Nm: "_result",
+				Tbls: []*executetest.Table{{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "v", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(0), 1.0},
+						{execute.Time(1), 2.0},
+					},
+				}},
+			},
+		},
+		{
+			name:    "schema collision",
+			input:   `{"v": 1} {"v": "one"}`,
+			wantErr: true,
+		},
+		{
+			name:    "not an object",
+			input:   `{"v": 1} 42`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid json",
+			input:   `{"v": 1`,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			decoder := ndjson.NewResultDecoder(&ndjson.ResultDecoderConfig{
+				TimeProvider: &mock.AscendingTimeProvider{},
+				MaxRecords:   tc.maxRecords,
+			})
+			r, err := decoder.Decode(bytes.NewReader([]byte(tc.input)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := &executetest.Result{
+				Nm: r.Name(),
+			}
+			err = r.Tables().Do(func(table flux.Table) error {
+				ct, err := executetest.ConvertTable(table)
+				if err != nil {
+					return err
+				}
+
+				got.Tbls = append(got.Tbls, ct)
+				return nil
+			})
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			executetest.NormalizeTables(got.Tbls)
+			executetest.NormalizeTables(tc.want.Tbls)
+
+			if !cmp.Equal(tc.want, got) {
+				t.Errorf("unexpected result -want/+got\n%s", cmp.Diff(tc.want, got))
+			}
+		})
+	}
+}
@@ -268,7 +268,13 @@ func (p *Program) SetLogger(logger *zap.Logger) {
 }
 
 func (p *Program) Start(ctx context.Context, alloc memory.Allocator) (flux.Query, error) {
-	ctx, cancel := context.WithCancel(ctx)
+	ctx, tracker, stopQuota := startQuota(ctx)
+	ctx, sink := startDryRun(ctx)
+	ctx, cancelQuery := context.WithCancel(ctx)
+	cancel := func() {
+		cancelQuery()
+		stopQuota()
+	}
 
 	// This span gets closed by the query when it is done.
 	var s opentracing.Span
@@ -290,6 +296,9 @@ func (p *Program) Start(ctx context.Context, alloc memory.Allocator) (flux.Query
 		alloc:   resourceAlloc,
 		span:    s,
 		cancel:  cancel,
+		tracker: tracker,
+		sink:    sink,
+		done:    make(chan struct{}),
 		stats: flux.Statistics{
 			Metadata: make(metadata.Metadata),
 		},
@@ -306,8 +315,9 @@ func (p *Program) Start(ctx context.Context, alloc memory.Allocator) (flux.Query
 	e := execute.NewExecutor(p.Logger)
 	resultMap, statsCh, err := e.Execute(ctx, p.PlanSpec, q.alloc)
 	if err != nil {
+		cancel()
 		s.Finish()
-		return nil, err
+		return nil, quotaError(tracker, err)
 	}
 
 	// There was no error so send the results downstream.
@@ -326,17 +336,35 @@ func (p *Program) processResults(ctx context.Context, q *query, resultMap map[st
 	defer close(q.results)
 
 	for _, res := range resultMap {
+		if q.tracker != nil {
+			res = &quotaResult{Result: res, tracker: q.tracker}
+		}
 		select {
 		case q.results <- res:
 		case <-ctx.Done():
-			q.err = ctx.Err()
+			q.err = quotaError(q.tracker, ctx.Err())
 			return
 		}
 	}
+
+	if q.sink != nil {
+		res := &dryRunResult{
+			ctx:      ctx,
+			sink:     q.sink,
+			executed: q.done,
+			alloc:    q.alloc,
+		}
+		select {
+		case q.results <- res:
+		case <-ctx.Done():
+			q.err = quotaError(q.tracker, ctx.Err())
+		}
+	}
 }
 
 func (p *Program) readStatistics(q *query, statsCh <-chan flux.Statistics) {
 	defer q.wg.Done()
+	defer close(q.done)
 	for stats := range statsCh {
 		q.stats.Merge(stats)
 	}
@@ -477,25 +505,36 @@ func (p *AstProgram) Start(ctx context.Context, alloc memory.Allocator) (flux.Qu
 	deps := execute.NewExecutionDependencies(alloc, &p.Now, p.Logger)
 
 	ctx, span := dependency.Inject(ctx, deps)
+
+	// Quotas are tracked from here, since evaluating
+	// the script can already make http requests.
+	ctx, tracker, stopQuota := startQuota(ctx)
+	// Output functions called during evaluation capture
+	// their payloads in the same Sink as the query.
+	ctx, _ = startDryRun(ctx)
 	nextPlanNodeID := new(int)
 	ctx = context.WithValue(ctx, plan.NextPlanNodeIDKey, nextPlanNodeID)
 
 	// Evaluation.
 	sp, scope, err := p.getSpec(ctx, alloc)
 	if err != nil {
-		return nil, err
+		stopQuota()
+		return nil, quotaError(tracker, err)
 	}
 
 	// Planning.
 	s, cctx := opentracing.StartSpanFromContext(ctx, "plan")
 	if err := p.updateOpts(scope); err != nil {
+		stopQuota()
 		return nil, errors.Wrap(err, codes.Inherit, "error in reading options while starting program")
 	}
 	if err := p.updateProfilers(ctx, scope); err != nil {
+		stopQuota()
 		return nil, errors.Wrap(err, codes.Inherit, "error in reading profiler settings while starting program")
 	}
 	ps, err := buildPlan(cctx, sp, p.opts)
 	if err != nil {
+		stopQuota()
 		return nil, errors.Wrap(err, codes.Inherit, "error in building plan while starting program")
 	}
 	p.PlanSpec = ps
@@ -506,13 +545,15 @@ func (p *AstProgram) Start(ctx context.Context, alloc memory.Allocator) (flux.Qu
 	defer s.Finish()
 	q, err := p.Program.Start(cctx, alloc)
 	if err != nil {
+		stopQuota()
 		span.Finish()
 		return nil, err
 	}
 	return &spanQuery{
-		Query:    q,
-		span:     span,
-		metadata: deps.Metadata,
+		Query:     q,
+		span:      span,
+		metadata:  deps.Metadata,
+		stopQuota: stopQuota,
 	}, nil
 }
 
@@ -548,10 +589,13 @@ type spanQuery struct {
 	span     *dependency.Span
 	stats    flux.Statistics
 	metadata *metadata.SyncMetadata
+
+	stopQuota func()
 }
 
 func (q *spanQuery) Done() {
 	q.Query.Done()
+	q.stopQuota()
 	q.stats.Metadata = make(metadata.Metadata)
 	q.metadata.ReadView(func(meta metadata.Metadata) {
 		q.stats.Metadata.AddAll(meta)
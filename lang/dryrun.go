@@ -0,0 +1,105 @@
+package lang
+
+import (
+	"context"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/dependencies/dryrun"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/values"
+)
+
+// DryRunResultName is the name of the result that holds
+// the payloads captured when a query runs in dry-run mode.
+const DryRunResultName = "_dryrun"
+
+type dryRunKey struct{}
+
+// startDryRun gives the query its own Sink if dry-run mode is enabled,
+// so payloads of other queries that share the dependencies are not mixed in.
+// If the script was evaluated before the program was started,
+// the Sink created for the evaluation is used.
+func startDryRun(ctx context.Context) (context.Context, *dryrun.Sink) {
+	if s, ok := ctx.Value(dryRunKey{}).(*dryrun.Sink); ok {
+		return ctx, s
+	}
+	if dryrun.GetSink(ctx) == nil {
+		return ctx, nil
+	}
+	s := dryrun.NewSink()
+	ctx = dryrun.Inject(ctx, s)
+	return context.WithValue(ctx, dryRunKey{}, s), s
+}
+
+// dryRunResult is the result with the payloads captured during a dry run.
+//
+// Output functions capture their payloads until the query has finished
+// executing, so its table is built only after that. The tables of the other
+// results must be read before the tables of this result.
+type dryRunResult struct {
+	ctx      context.Context
+	sink     *dryrun.Sink
+	executed <-chan struct{}
+	alloc    memory.Allocator
+}
+
+func (r *dryRunResult) Name() string {
+	return DryRunResultName
+}
+
+func (r *dryRunResult) Tables() flux.TableIterator {
+	return r
+}
+
+func (r *dryRunResult) Do(f func(flux.Table) error) error {
+	select {
+	case <-r.executed:
+	case <-r.ctx.Done():
+		return r.ctx.Err()
+	}
+	tbl, err := dryRunTable(r.sink.Payloads(), r.alloc)
+	if err != nil {
+		return err
+	}
+	return f(tbl)
+}
+
+// dryRunTable builds a table with a row for each payload.
+func dryRunTable(payloads []dryrun.Payload, alloc memory.Allocator) (flux.Table, error) {
+	key := execute.NewGroupKey(
+		[]flux.ColMeta{{Label: "_measurement", Type: flux.TString}},
+		[]values.Value{values.NewString("dryrun")},
+	)
+	b := execute.NewColListTableBuilder(key, alloc)
+	cols := []flux.ColMeta{
+		{Label: "_measurement", Type: flux.TString},
+		{Label: "function", Type: flux.TString},
+		{Label: "destination", Type: flux.TString},
+		{Label: "rows", Type: flux.TInt},
+		{Label: "payload", Type: flux.TString},
+	}
+	for _, col := range cols {
+		if _, err := b.AddCol(col); err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range payloads {
+		if err := b.AppendString(0, "dryrun"); err != nil {
+			return nil, err
+		}
+		if err := b.AppendString(1, p.Function); err != nil {
+			return nil, err
+		}
+		if err := b.AppendString(2, p.Destination); err != nil {
+			return nil, err
+		}
+		if err := b.AppendInt(3, p.Rows); err != nil {
+			return nil, err
+		}
+		if err := b.AppendString(4, string(p.Data)); err != nil {
+			return nil, err
+		}
+	}
+	return b.Table()
+}
@@ -0,0 +1,104 @@
+package lang_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/dependencies/dryrun"
+	"github.com/InfluxCommunity/flux/dependency"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/executetest"
+	"github.com/InfluxCommunity/flux/lang"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/plan/plantest"
+	"github.com/InfluxCommunity/flux/stdlib/csv"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestQuery_DryRun(t *testing.T) {
+	spec := &plantest.PlanSpec{
+		Nodes: []plan.Node{
+			plan.CreatePhysicalNode("from-test", executetest.NewFromProcedureSpec(
+				[]*executetest.Table{{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(0), 1.0},
+						{execute.Time(1e9), 2.0},
+					},
+				}},
+			)),
+			plan.CreatePhysicalNode("toCSV", &csv.ToCSVProcedureSpec{
+				Spec: &csv.ToCSVOpSpec{File: "out.csv", Delimiter: ","},
+			}),
+			plan.CreatePhysicalNode("yield", executetest.NewYieldProcedureSpec("_result")),
+		},
+		Edges: [][2]int{
+			{0, 1},
+			{1, 2},
+		},
+		Resources: flux.ResourceManagement{
+			ConcurrencyQuota: 1,
+			MemoryBytesQuota: math.MaxInt64,
+		},
+		Now: time.Unix(0, 0),
+	}
+	program := &lang.Program{
+		PlanSpec: plantest.CreatePlanSpec(spec),
+	}
+
+	ctx, deps := dependency.Inject(context.Background(),
+		executetest.NewTestExecuteDependencies(),
+		dryrun.Dependency{Enabled: true},
+	)
+	defer deps.Finish()
+
+	q, err := program.Start(ctx, memory.DefaultAllocator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	var got [][]interface{}
+	for res := range q.Results() {
+		names = append(names, res.Name())
+		if err := res.Tables().Do(func(tbl flux.Table) error {
+			if res.Name() != lang.DryRunResultName {
+				return tbl.Do(func(flux.ColReader) error { return nil })
+			}
+			rows, err := executetest.ConvertTable(tbl)
+			if err != nil {
+				return err
+			}
+			got = append(got, rows.Data...)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	q.Done()
+	if err := q.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"_result", lang.DryRunResultName}; !cmp.Equal(want, names) {
+		t.Errorf("unexpected results -want/+got:\n%s", cmp.Diff(want, names))
+	}
+	want := [][]interface{}{
+		{
+			"dryrun",
+			"csv.to",
+			"out.csv",
+			int64(2),
+			",_result,0,1970-01-01T00:00:00Z,1\r\n,_result,0,1970-01-01T00:00:01Z,2\r\n",
+		},
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("unexpected payloads -want/+got:\n%s", cmp.Diff(want, got))
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"sync"
 
 	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/dependencies/dryrun"
+	"github.com/InfluxCommunity/flux/dependencies/quota"
 	"github.com/InfluxCommunity/flux/dependencies/testing"
 	"github.com/InfluxCommunity/flux/memory"
 	"github.com/opentracing/opentracing-go"
@@ -18,8 +20,13 @@ type query struct {
 	alloc   *memory.ResourceAllocator
 	span    opentracing.Span
 	cancel  func()
+	tracker *quota.Tracker
+	sink    *dryrun.Sink
 	err     error
 	wg      sync.WaitGroup
+
+	// done is closed once the query has finished executing.
+	done chan struct{}
 }
 
 func (q *query) Results() <-chan flux.Result {
@@ -31,6 +38,7 @@ func (q *query) Done() {
 	q.wg.Wait()
 	q.stats.MaxAllocated = q.alloc.MaxAllocated()
 	q.stats.TotalAllocated = q.alloc.TotalAllocated()
+	q.stats.QuotaExceeded = q.tracker.Exceeded()
 	if q.span != nil {
 		q.span.Finish()
 		q.span = nil
@@ -39,6 +47,9 @@ func (q *query) Done() {
 	// Note: it is safe to read and write to q.err because we have explicitly
 	// waited on the wait group, therefore only a the current goroutine
 	// can access q.err
+	if err := q.tracker.Err(); err != nil {
+		q.err = err
+	}
 	if q.err == nil {
 		// If the testing framework was configured, verify all expectations.
 		q.err = testing.Check(q.ctx)
@@ -0,0 +1,106 @@
+package lang
+
+import (
+	"context"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/dependencies/quota"
+)
+
+// startQuota begins tracking the quotas of a query if any were configured
+// in the dependencies. If the quotas are already being tracked, because the
+// script was evaluated before the program was started, the existing Tracker
+// is used and the returned function does nothing.
+func startQuota(ctx context.Context) (context.Context, *quota.Tracker, func()) {
+	if t := quota.GetTracker(ctx); t != nil {
+		return ctx, t, func() {}
+	}
+	limits := quota.GetLimits(ctx)
+	if limits.IsZero() {
+		return ctx, nil, func() {}
+	}
+	ctx, t, stop := quota.Start(ctx, limits)
+	return flux.WithQuotaTracker(ctx, t), t, stop
+}
+
+// quotaError returns the error of the quota that was exceeded
+// in place of err, which is likely caused by the cancellation
+// of the query when the quota was exceeded.
+func quotaError(t *quota.Tracker, err error) error {
+	if qerr := t.Err(); qerr != nil {
+		return qerr
+	}
+	return err
+}
+
+// quotaResult is a flux.Result that records the rows and bytes
+// that are read from it with the Tracker.
+type quotaResult struct {
+	flux.Result
+	tracker *quota.Tracker
+}
+
+func (r *quotaResult) Tables() flux.TableIterator {
+	return &quotaTableIterator{
+		TableIterator: r.Result.Tables(),
+		tracker:       r.tracker,
+	}
+}
+
+type quotaTableIterator struct {
+	flux.TableIterator
+	tracker *quota.Tracker
+}
+
+func (ti *quotaTableIterator) Do(f func(flux.Table) error) error {
+	err := ti.TableIterator.Do(func(tbl flux.Table) error {
+		return f(&quotaTable{Table: tbl, tracker: ti.tracker})
+	})
+	if err != nil {
+		return quotaError(ti.tracker, err)
+	}
+	return nil
+}
+
+type quotaTable struct {
+	flux.Table
+	tracker *quota.Tracker
+}
+
+func (t *quotaTable) Do(f func(flux.ColReader) error) error {
+	err := t.Table.Do(func(cr flux.ColReader) error {
+		if err := t.tracker.AddResultRows(int64(cr.Len())); err != nil {
+			return err
+		}
+		if err := t.tracker.AddResultBytes(colReaderSize(cr)); err != nil {
+			return err
+		}
+		return f(cr)
+	})
+	if err != nil {
+		return quotaError(t.tracker, err)
+	}
+	return nil
+}
+
+// colReaderSize returns the size of the values in the ColReader.
+// Strings count their length and all other values their fixed width.
+func colReaderSize(cr flux.ColReader) int64 {
+	var size int64
+	for j, col := range cr.Cols() {
+		switch col.Type {
+		case flux.TBool:
+			size += int64(cr.Len())
+		case flux.TInt, flux.TUInt, flux.TFloat, flux.TTime:
+			size += 8 * int64(cr.Len())
+		case flux.TString:
+			vs := cr.Strings(j)
+			for i := 0; i < vs.Len(); i++ {
+				if vs.IsValid(i) {
+					size += int64(vs.ValueLen(i))
+				}
+			}
+		}
+	}
+	return size
+}
@@ -0,0 +1,145 @@
+package lang_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/dependencies/quota"
+	"github.com/InfluxCommunity/flux/dependency"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/executetest"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/lang"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/plan/plantest"
+	"github.com/google/go-cmp/cmp"
+)
+
+func init() {
+	execute.RegisterSource(executetest.FromTestKind, executetest.CreateFromSource)
+}
+
+func quotaProgram() *lang.Program {
+	spec := &plantest.PlanSpec{
+		Nodes: []plan.Node{
+			plan.CreatePhysicalNode("from-test", executetest.NewFromProcedureSpec(
+				[]*executetest.Table{{
+					KeyCols: []string{"tag"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "tag", Type: flux.TString},
+						{Label: "_value", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(0), "a", 1.0},
+						{execute.Time(1), "a", 2.0},
+						{execute.Time(2), "a", 3.0},
+					},
+				}},
+			)),
+			plan.CreatePhysicalNode("yield", executetest.NewYieldProcedureSpec("_result")),
+		},
+		Edges: [][2]int{
+			{0, 1},
+		},
+		Resources: flux.ResourceManagement{
+			ConcurrencyQuota: 1,
+			MemoryBytesQuota: math.MaxInt64,
+		},
+		Now: time.Unix(0, 0),
+	}
+	return &lang.Program{
+		PlanSpec: plantest.CreatePlanSpec(spec),
+	}
+}
+
+func TestQuery_Quota(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		limits       quota.Limits
+		wantErr      string
+		wantExceeded []string
+	}{
+		{
+			name: "unlimited",
+		},
+		{
+			name:   "within limits",
+			limits: quota.Limits{MaxResultRows: 3, MaxResultBytes: 3 * 17},
+		},
+		{
+			name:         "result rows",
+			limits:       quota.Limits{MaxResultRows: 2},
+			wantErr:      "query exceeded the maximum number of result rows of 2",
+			wantExceeded: []string{"max_result_rows"},
+		},
+		{
+			name:         "result bytes",
+			limits:       quota.Limits{MaxResultBytes: 50},
+			wantErr:      "query exceeded the maximum result size of 50 bytes",
+			wantExceeded: []string{"max_result_bytes"},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, deps := dependency.Inject(context.Background(),
+				executetest.NewTestExecuteDependencies(),
+				quota.Dependency{Limits: tc.limits},
+			)
+			defer deps.Finish()
+
+			q, err := quotaProgram().Start(ctx, memory.DefaultAllocator)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var rows int
+			var tablesErr error
+			for res := range q.Results() {
+				if err := res.Tables().Do(func(tbl flux.Table) error {
+					return tbl.Do(func(cr flux.ColReader) error {
+						rows += cr.Len()
+						return nil
+					})
+				}); err != nil && tablesErr == nil {
+					tablesErr = err
+				}
+			}
+			q.Done()
+
+			if tc.wantErr == "" {
+				if tablesErr != nil {
+					t.Fatalf("unexpected error: %s", tablesErr)
+				}
+				if err := q.Err(); err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if want, got := 3, rows; want != got {
+					t.Fatalf("unexpected number of rows -want/+got:\n\t- %d\n\t+ %d", want, got)
+				}
+			} else {
+				for _, err := range []error{tablesErr, q.Err()} {
+					if err == nil {
+						t.Fatal("expected error")
+					}
+					if want, got := codes.ResourceExhausted, errors.Code(err); want != got {
+						t.Errorf("unexpected error code -want/+got:\n\t- %s\n\t+ %s", want, got)
+					}
+					if want, got := tc.wantErr, err.Error(); want != got {
+						t.Errorf("unexpected error -want/+got:\n\t- %s\n\t+ %s", want, got)
+					}
+				}
+				if rows != 0 {
+					t.Errorf("expected no rows to be read, got %d", rows)
+				}
+			}
+			if want, got := tc.wantExceeded, q.Statistics().QuotaExceeded; !cmp.Equal(want, got) {
+				t.Errorf("unexpected exceeded quotas -want/+got:\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
@@ -131,6 +131,31 @@ fn vectorize_non_map_like_function() {
     .assert_eq(&err.to_string());
 }
 
+#[test]
+fn vectorize_predicate() -> anyhow::Result<()> {
+    let pkg = vectorize(r#"(r) => r.a > 1 and r.b == "b""#)?;
+
+    let function = get_vectorized_function(&pkg);
+
+    match &function.typ {
+        MonoType::Fun(f) => assert_eq!(f.retn, MonoType::vector(MonoType::BOOL)),
+        typ => panic!("expected a function type, got {}", typ),
+    }
+    Ok(())
+}
+
+#[test]
+fn vectorize_non_boolean_expression() {
+    let mut pkg = vectorize(r#"(r) => r.a + 1"#).unwrap();
+
+    let err = semantic::vectorize::vectorize(&analyzer_config(), &mut pkg).unwrap_err();
+
+    expect_test::expect![[
+        r#"error @1:8-1:15: can't vectorize function: Vectorization only supports returning a record or a boolean"#
+    ]]
+    .assert_eq(&err.to_string());
+}
+
 #[test]
 fn vectorize_addition_operator() -> anyhow::Result<()> {
     let pkg = vectorize(r#"(r) => ({ x: r.a + r.b })"#)?;
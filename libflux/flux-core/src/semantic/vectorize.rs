@@ -329,6 +329,10 @@ impl FunctionExpr {
                 // a single object expression, the fields of which only reference members of
                 // `r` and do not include any kind of operation, literal, or logical expression.
                 //
+                // A function whose body is a single boolean expression, such as
+                // the predicate of `filter`, is vectorized into a function that
+                // returns a vector of booleans.
+                //
                 // We may support other expression types in the future.
                 Block::Return(e) => {
                     let argument = match &e.argument {
@@ -364,11 +368,15 @@ impl FunctionExpr {
                                 properties,
                             }))
                         }
+                        argument if argument.type_of() == MonoType::BOOL => {
+                            argument.vectorize(&env)?
+                        }
                         _ => {
                             return Err(located(
                                 e.argument.loc().clone(),
                                 ErrorKind::UnableToVectorize(
-                                    "Vectorization only supports returning a record".into(),
+                                    "Vectorization only supports returning a record or a boolean"
+                                        .into(),
                                 ),
                             ));
                         }
@@ -395,7 +403,7 @@ impl FunctionExpr {
                 vectorized: None,
             })
         } else {
-            // Only `map` will get vectorized to start with, so only try to vectorize such functions
+            // Only `map` and `filter` get vectorized, so only try to vectorize such functions
             Err(located(
                 self.loc.clone(),
                 ErrorKind::UnableToVectorize("Does not match the `map` signature".into()),
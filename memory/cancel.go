@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/apache/arrow/go/v7/arrow/memory"
+)
+
+var _ Allocator = (*CancelableAllocator)(nil)
+
+// CancelableAllocator is an Allocator that refuses new allocations once
+// it has been canceled. Memory is allocated from and accounted for by
+// the wrapped Allocator. Memory may still be freed after the allocator
+// has been canceled.
+type CancelableAllocator struct {
+	Allocator
+
+	canceled int32
+	mu       sync.Mutex
+	err      error
+}
+
+// NewCancelableAllocator wraps the allocator so it can be canceled.
+func NewCancelableAllocator(mem Allocator) *CancelableAllocator {
+	return &CancelableAllocator{Allocator: mem}
+}
+
+// Cancel marks the allocator as canceled. Allocations made after the
+// allocator has been canceled fail with err. Only the first call
+// to Cancel has an effect.
+func (a *CancelableAllocator) Cancel(err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.err == nil {
+		a.err = err
+		atomic.StoreInt32(&a.canceled, 1)
+	}
+}
+
+// Err returns the error the allocator was canceled with.
+// It returns nil if the allocator has not been canceled.
+func (a *CancelableAllocator) Err() error {
+	if atomic.LoadInt32(&a.canceled) == 0 {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.err
+}
+
+func (a *CancelableAllocator) Allocate(size int) []byte {
+	if size > 0 {
+		if err := a.Err(); err != nil {
+			panic(err)
+		}
+	}
+	return a.Allocator.Allocate(size)
+}
+
+func (a *CancelableAllocator) Reallocate(size int, b []byte) []byte {
+	if size > cap(b) {
+		if err := a.Err(); err != nil {
+			panic(err)
+		}
+	}
+	return a.Allocator.Reallocate(size, b)
+}
+
+func (a *CancelableAllocator) Account(size int) error {
+	if size > 0 {
+		if err := a.Err(); err != nil {
+			return err
+		}
+	}
+	return a.Allocator.Account(size)
+}
+
+// checkpointInterval is the number of calls to Checkpoint.Check
+// between each check of the allocator.
+const checkpointInterval = 1024
+
+// Checkpoint checks whether an allocator has been canceled from within
+// a long-running loop. Allocations fail as soon as the allocator is
+// canceled, but a loop that does not allocate memory would otherwise
+// run to completion. Loops that allocate on every iteration, such as
+// the ones that copy or append to table buffers, stop at their next
+// allocation and do not need one.
+type Checkpoint struct {
+	mem *CancelableAllocator
+	n   int
+}
+
+// NewCheckpoint creates a Checkpoint for the allocator.
+// If the allocator cannot be canceled, the Checkpoint never reports an error.
+func NewCheckpoint(mem memory.Allocator) *Checkpoint {
+	for {
+		switch a := mem.(type) {
+		case *CancelableAllocator:
+			return &Checkpoint{mem: a}
+		case *ResourceAllocator:
+			// A ResourceAllocator may wrap the allocator
+			// from the query to track a subset of memory.
+			if a == nil || a.Allocator == nil {
+				return &Checkpoint{}
+			}
+			mem = a.Allocator
+		default:
+			return &Checkpoint{}
+		}
+	}
+}
+
+// Check returns the error the allocator was canceled with.
+// The allocator is only checked periodically so Check is cheap
+// enough to call on every iteration of a loop. A nil Checkpoint
+// never reports an error.
+func (c *Checkpoint) Check() error {
+	if c == nil || c.mem == nil {
+		return nil
+	}
+	if c.n++; c.n < checkpointInterval {
+		return nil
+	}
+	c.n = 0
+	return c.mem.Err()
+}
@@ -0,0 +1,88 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/memory"
+	arrowmemory "github.com/apache/arrow/go/v7/arrow/memory"
+)
+
+func TestCancelableAllocator(t *testing.T) {
+	mem := arrowmemory.NewCheckedAllocator(memory.DefaultAllocator)
+	defer mem.AssertSize(t, 0)
+
+	allocator := memory.NewCancelableAllocator(memory.NewResourceAllocator(mem))
+	b := allocator.Allocate(64)
+
+	want := errors.New(codes.Canceled, "query canceled")
+	allocator.Cancel(want)
+	allocator.Cancel(errors.New(codes.Internal, "ignored"))
+	if got := allocator.Err(); got != want {
+		t.Fatalf("unexpected error -want/+got\n\t- %v\n\t+ %v", want, got)
+	}
+
+	func() {
+		defer func() {
+			if got := recover(); got != want {
+				t.Errorf("unexpected panic -want/+got\n\t- %v\n\t+ %v", want, got)
+			}
+		}()
+		allocator.Allocate(64)
+	}()
+
+	func() {
+		defer func() {
+			if got := recover(); got != want {
+				t.Errorf("unexpected panic -want/+got\n\t- %v\n\t+ %v", want, got)
+			}
+		}()
+		b = allocator.Reallocate(128, b)
+	}()
+
+	if got := allocator.Account(64); got != want {
+		t.Errorf("unexpected error -want/+got\n\t- %v\n\t+ %v", want, got)
+	}
+
+	// Memory can still be released after the allocator is canceled.
+	if err := allocator.Account(-64); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	allocator.Free(b)
+}
+
+func TestCheckpoint(t *testing.T) {
+	allocator := memory.NewCancelableAllocator(memory.NewResourceAllocator(nil))
+	checkpoint := memory.NewCheckpoint(&memory.ResourceAllocator{Allocator: allocator})
+
+	want := errors.New(codes.Canceled, "query canceled")
+	allocator.Cancel(want)
+
+	var n int
+	for ; n < 10000; n++ {
+		if err := checkpoint.Check(); err != nil {
+			if err != want {
+				t.Fatalf("unexpected error -want/+got\n\t- %v\n\t+ %v", want, err)
+			}
+			break
+		}
+	}
+	if n == 10000 {
+		t.Fatal("expected checkpoint to report cancellation")
+	}
+
+	// A checkpoint for an allocator that cannot be canceled
+	// never reports an error.
+	checkpoint = memory.NewCheckpoint(memory.DefaultAllocator)
+	for i := 0; i < 10000; i++ {
+		if err := checkpoint.Check(); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	var nilCheckpoint *memory.Checkpoint
+	if err := nilCheckpoint.Check(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,409 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
+)
+
+// Policy determines the order in which a Pool grants memory
+// when more than one query is waiting for it.
+type Policy int
+
+const (
+	// FairSharePolicy grants memory in the order it was requested.
+	// While other queries are waiting, a query cannot grow beyond
+	// its fair share of the pool, which is the pool limit divided by
+	// the number of open managers, or its reservation if that is larger.
+	FairSharePolicy Policy = iota
+
+	// PriorityPolicy grants memory to the query with the highest
+	// priority first. Queries with the same priority are served
+	// in the order they requested memory.
+	PriorityPolicy
+)
+
+// PoolConfig configures a Pool.
+type PoolConfig struct {
+	// Limit is the number of bytes shared by every query using the pool.
+	Limit int64
+
+	// Policy determines how waiting requests are granted.
+	Policy Policy
+
+	// WaitTimeout is how long a request waits for memory to become
+	// available before it is rejected.
+	// If this is zero, requests that cannot be granted immediately are rejected.
+	WaitTimeout time.Duration
+}
+
+// Pool is a global memory budget that is shared by concurrent queries.
+// Each query requests memory from the pool through its own Manager,
+// created with NewManager, which is assigned to the query's ResourceAllocator.
+type Pool struct {
+	config PoolConfig
+
+	mu       sync.Mutex
+	used     int64
+	reserved int64
+	active   int64
+	seq      int64
+	waiters  []*waiter
+
+	rejectedBytes    int64
+	rejectedRequests int64
+}
+
+// NewPool creates a Pool with the given configuration.
+func NewPool(config PoolConfig) (*Pool, error) {
+	if config.Limit <= 0 {
+		return nil, errors.New(codes.Invalid, "memory pool limit must be positive")
+	}
+	if config.WaitTimeout < 0 {
+		return nil, errors.New(codes.Invalid, "memory pool wait timeout cannot be negative")
+	}
+	switch config.Policy {
+	case FairSharePolicy, PriorityPolicy:
+	default:
+		return nil, errors.Newf(codes.Invalid, "unknown memory pool policy %d", config.Policy)
+	}
+	return &Pool{config: config}, nil
+}
+
+// ManagerConfig configures the Manager for a single query.
+type ManagerConfig struct {
+	// Reservation is the number of bytes set aside for the query when
+	// its manager is created. Requests are served from the reservation
+	// before memory is taken from the rest of the pool.
+	Reservation int64
+
+	// Priority is used by the PriorityPolicy to order waiting requests.
+	// Higher values are served first.
+	Priority int
+}
+
+// PoolStats reports the state of a Pool.
+type PoolStats struct {
+	// Limit is the size of the pool in bytes.
+	Limit int64
+	// Reserved is the number of bytes that are reserved by queries
+	// but have not been requested yet.
+	Reserved int64
+	// Used is the number of bytes that have been granted to queries.
+	Used int64
+	// Waiting is the number of requests that are waiting for memory.
+	Waiting int64
+	// RejectedBytes is the total number of bytes in requests that were rejected.
+	RejectedBytes int64
+	// RejectedRequests is the total number of requests that were rejected.
+	RejectedRequests int64
+}
+
+// Stats returns a snapshot of the pool metrics.
+func (p *Pool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return PoolStats{
+		Limit:            p.config.Limit,
+		Reserved:         p.reserved,
+		Used:             p.used,
+		Waiting:          int64(len(p.waiters)),
+		RejectedBytes:    p.rejectedBytes,
+		RejectedRequests: p.rejectedRequests,
+	}
+}
+
+// NewManager creates a Manager for a single query.
+// The context bounds how long the manager waits for memory.
+// If a reservation is configured, NewManager waits for it
+// in the same way as any other request and returns an error
+// if the reservation cannot be satisfied.
+// The manager must be released with Release when the query is done.
+func (p *Pool) NewManager(ctx context.Context, config ManagerConfig) (*PoolManager, error) {
+	if config.Reservation < 0 {
+		return nil, errors.New(codes.Invalid, "memory reservation cannot be negative")
+	}
+	if config.Reservation > p.config.Limit {
+		return nil, errors.Newf(codes.ResourceExhausted, "memory reservation of %d bytes exceeds the pool limit of %d bytes", config.Reservation, p.config.Limit)
+	}
+
+	m := &PoolManager{
+		ctx:         ctx,
+		pool:        p,
+		reservation: config.Reservation,
+		priority:    config.Priority,
+	}
+	p.mu.Lock()
+	p.active++
+	p.mu.Unlock()
+
+	if config.Reservation > 0 {
+		if err := m.acquire(config.Reservation); err != nil {
+			m.Release()
+			return nil, err
+		}
+		// Move the acquired memory into the reservation
+		// so it is handed out by future requests.
+		p.mu.Lock()
+		m.used -= config.Reservation
+		m.reserve += config.Reservation
+		p.used -= config.Reservation
+		p.reserved += config.Reservation
+		p.mu.Unlock()
+	}
+	return m, nil
+}
+
+// waiter is a request that is waiting for memory.
+type waiter struct {
+	m       *PoolManager
+	want    int64
+	seq     int64
+	granted bool
+	ready   chan struct{}
+}
+
+// available returns the number of bytes that are neither used nor reserved.
+// It must be called with the lock held.
+func (p *Pool) available() int64 {
+	return p.config.Limit - p.used - p.reserved
+}
+
+// enqueue adds a waiter to the queue in policy order.
+// It must be called with the lock held.
+func (p *Pool) enqueue(w *waiter) {
+	p.seq++
+	w.seq = p.seq
+	p.waiters = append(p.waiters, w)
+	if p.config.Policy == PriorityPolicy {
+		sort.SliceStable(p.waiters, func(i, j int) bool {
+			return p.waiters[i].m.priority > p.waiters[j].m.priority
+		})
+	}
+}
+
+// remove removes a waiter from the queue.
+// It must be called with the lock held.
+func (p *Pool) remove(w *waiter) {
+	for i, other := range p.waiters {
+		if other == w {
+			p.waiters = append(p.waiters[:i], p.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+// overShare reports whether granting the waiter would take its query
+// beyond its fair share while another query is waiting.
+// It must be called with the lock held.
+func (p *Pool) overShare(w *waiter) bool {
+	if p.config.Policy != FairSharePolicy {
+		return false
+	}
+	share := p.config.Limit / p.active
+	if share < w.m.reservation {
+		share = w.m.reservation
+	}
+	if w.m.used+w.want <= share {
+		return false
+	}
+	for _, other := range p.waiters {
+		if other.m != w.m {
+			return true
+		}
+	}
+	return false
+}
+
+// wake grants memory to the waiters that can be satisfied.
+// Waiters are granted in queue order and a waiter that does not fit
+// blocks those behind it so large requests are not starved.
+// A waiter that is held back by its fair share does not block the others.
+// It must be called with the lock held.
+func (p *Pool) wake() {
+	for i := 0; i < len(p.waiters); {
+		w := p.waiters[i]
+		if p.overShare(w) {
+			i++
+			continue
+		}
+		if w.want > p.available() {
+			return
+		}
+		p.used += w.want
+		w.m.used += w.want
+		w.granted = true
+		close(w.ready)
+		p.waiters = append(p.waiters[:i], p.waiters[i+1:]...)
+	}
+}
+
+// release returns memory to the pool and wakes any waiters.
+// It must be called with the lock held.
+func (p *Pool) release(used, reserved int64) {
+	p.used -= used
+	p.reserved -= reserved
+	p.wake()
+}
+
+var _ Manager = (*PoolManager)(nil)
+
+// PoolManager is the Manager for a single query that requests memory from a Pool.
+type PoolManager struct {
+	ctx         context.Context
+	pool        *Pool
+	reservation int64
+	priority    int
+
+	// used and reserve are guarded by the pool lock.
+	used     int64
+	reserve  int64
+	released bool
+}
+
+// RequestMemory requests memory from the query's reservation and,
+// once that is exhausted, from the pool. If the pool does not have
+// enough memory, the request waits for up to the pool's wait timeout
+// for other queries to free memory before it is rejected.
+func (m *PoolManager) RequestMemory(want int64) (got int64, err error) {
+	if want <= 0 {
+		return 0, nil
+	}
+	p := m.pool
+
+	// Serve as much as possible from the reservation.
+	p.mu.Lock()
+	if m.released {
+		p.mu.Unlock()
+		return 0, errors.New(codes.FailedPrecondition, "memory manager has been released")
+	}
+	n := want
+	if n > m.reserve {
+		n = m.reserve
+	}
+	m.reserve -= n
+	m.used += n
+	p.reserved -= n
+	p.used += n
+	p.mu.Unlock()
+
+	if n == want {
+		return want, nil
+	}
+	if err := m.acquire(want - n); err != nil {
+		// Put the memory taken from the reservation back.
+		p.mu.Lock()
+		if !m.released {
+			m.used -= n
+			m.reserve += n
+			p.used -= n
+			p.reserved += n
+		}
+		p.mu.Unlock()
+		return 0, err
+	}
+	return want, nil
+}
+
+// acquire takes memory from the pool, waiting for it if necessary.
+func (m *PoolManager) acquire(want int64) error {
+	p := m.pool
+	w := &waiter{m: m, want: want, ready: make(chan struct{})}
+
+	p.mu.Lock()
+	p.enqueue(w)
+	p.wake()
+	if w.granted {
+		p.mu.Unlock()
+		return nil
+	}
+	if p.config.WaitTimeout == 0 || want > p.config.Limit {
+		err := m.reject(w)
+		p.mu.Unlock()
+		return err
+	}
+	p.mu.Unlock()
+
+	timer := time.NewTimer(p.config.WaitTimeout)
+	defer timer.Stop()
+
+	var done <-chan struct{}
+	if m.ctx != nil {
+		done = m.ctx.Done()
+	}
+
+	var cause error
+	select {
+	case <-w.ready:
+		return nil
+	case <-timer.C:
+	case <-done:
+		cause = m.ctx.Err()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if w.granted {
+		// The request was granted while the timeout fired.
+		return nil
+	}
+	err := m.reject(w)
+	if cause != nil {
+		return errors.Wrap(cause, codes.Canceled, "memory request canceled")
+	}
+	return err
+}
+
+// reject removes the waiter from the queue and records the rejection.
+// It must be called with the lock held.
+func (m *PoolManager) reject(w *waiter) error {
+	p := m.pool
+	p.remove(w)
+	p.rejectedBytes += w.want
+	p.rejectedRequests++
+	// Removing a waiter may unblock the waiters behind it.
+	p.wake()
+	return errors.Newf(codes.ResourceExhausted, "memory pool exhausted: limit %d bytes, available: %d, wanted: %d", p.config.Limit, p.available(), w.want)
+}
+
+// FreeMemory returns memory that is no longer used by the query to the pool.
+func (m *PoolManager) FreeMemory(bytes int64) {
+	p := m.pool
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if m.released || bytes <= 0 {
+		return
+	}
+	if bytes > m.used {
+		bytes = m.used
+	}
+	m.used -= bytes
+	p.release(bytes, 0)
+}
+
+// Used returns the number of bytes granted to the query.
+func (m *PoolManager) Used() int64 {
+	m.pool.mu.Lock()
+	defer m.pool.mu.Unlock()
+	return m.used
+}
+
+// Release returns all of the memory held by the query to the pool.
+// The manager cannot be used after it has been released.
+func (m *PoolManager) Release() {
+	p := m.pool
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if m.released {
+		return
+	}
+	m.released = true
+	p.active--
+	used, reserve := m.used, m.reserve
+	m.used, m.reserve = 0, 0
+	p.release(used, reserve)
+}
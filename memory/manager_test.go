@@ -0,0 +1,290 @@
+package memory_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/google/go-cmp/cmp"
+)
+
+func newPool(t *testing.T, config memory.PoolConfig) *memory.Pool {
+	t.Helper()
+	p, err := memory.NewPool(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func newManager(t *testing.T, p *memory.Pool, config memory.ManagerConfig) *memory.PoolManager {
+	t.Helper()
+	m, err := p.NewManager(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestPool_RequestMemory(t *testing.T) {
+	p := newPool(t, memory.PoolConfig{Limit: 128})
+	m1 := newManager(t, p, memory.ManagerConfig{Reservation: 32})
+	m2 := newManager(t, p, memory.ManagerConfig{})
+
+	if want, got := (memory.PoolStats{Limit: 128, Reserved: 32}), p.Stats(); !cmp.Equal(want, got) {
+		t.Fatalf("unexpected stats -want/+got:\n%s", cmp.Diff(want, got))
+	}
+
+	// The first request is served from the reservation.
+	if _, err := m1.RequestMemory(16); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := m2.RequestMemory(64); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want, got := (memory.PoolStats{Limit: 128, Reserved: 16, Used: 80}), p.Stats(); !cmp.Equal(want, got) {
+		t.Fatalf("unexpected stats -want/+got:\n%s", cmp.Diff(want, got))
+	}
+
+	// Only 32 bytes are left, so this is rejected and the
+	// reservation that was used for it is restored.
+	if _, err := m1.RequestMemory(64); err == nil {
+		t.Fatal("expected error")
+	} else if want, got := codes.ResourceExhausted, errors.Code(err); want != got {
+		t.Fatalf("unexpected error code -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+	if want, got := (memory.PoolStats{Limit: 128, Reserved: 16, Used: 80, RejectedBytes: 48, RejectedRequests: 1}), p.Stats(); !cmp.Equal(want, got) {
+		t.Fatalf("unexpected stats -want/+got:\n%s", cmp.Diff(want, got))
+	}
+
+	m2.FreeMemory(32)
+	if want, got := int64(32), m2.Used(); want != got {
+		t.Fatalf("unexpected used -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+
+	m1.Release()
+	m2.Release()
+	if want, got := (memory.PoolStats{Limit: 128, RejectedBytes: 48, RejectedRequests: 1}), p.Stats(); !cmp.Equal(want, got) {
+		t.Fatalf("unexpected stats -want/+got:\n%s", cmp.Diff(want, got))
+	}
+	if _, err := m1.RequestMemory(1); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestPool_Reservation(t *testing.T) {
+	p := newPool(t, memory.PoolConfig{Limit: 64})
+	m := newManager(t, p, memory.ManagerConfig{Reservation: 48})
+	defer m.Release()
+
+	if _, err := p.NewManager(context.Background(), memory.ManagerConfig{Reservation: 32}); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := p.NewManager(context.Background(), memory.ManagerConfig{Reservation: 128}); err == nil {
+		t.Fatal("expected error")
+	}
+	if want, got := (memory.PoolStats{Limit: 64, Reserved: 48, RejectedBytes: 32, RejectedRequests: 1}), p.Stats(); !cmp.Equal(want, got) {
+		t.Fatalf("unexpected stats -want/+got:\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestPool_WaitTimeout(t *testing.T) {
+	p := newPool(t, memory.PoolConfig{Limit: 64, WaitTimeout: time.Minute})
+	m1 := newManager(t, p, memory.ManagerConfig{})
+	m2 := newManager(t, p, memory.ManagerConfig{})
+	defer m2.Release()
+
+	if _, err := m1.RequestMemory(64); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := m2.RequestMemory(32)
+		done <- err
+	}()
+	waitForWaiters(t, p, 1)
+
+	// Releasing the first query unblocks the second.
+	m1.Release()
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want, got := int64(32), m2.Used(); want != got {
+		t.Fatalf("unexpected used -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+
+	// A short timeout rejects the request.
+	p = newPool(t, memory.PoolConfig{Limit: 64, WaitTimeout: 10 * time.Millisecond})
+	m := newManager(t, p, memory.ManagerConfig{})
+	defer m.Release()
+	if _, err := m.RequestMemory(32); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := m.RequestMemory(64); err == nil {
+		t.Fatal("expected error")
+	}
+	if want, got := (memory.PoolStats{Limit: 64, Used: 32, RejectedBytes: 64, RejectedRequests: 1}), p.Stats(); !cmp.Equal(want, got) {
+		t.Fatalf("unexpected stats -want/+got:\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestPool_Canceled(t *testing.T) {
+	p := newPool(t, memory.PoolConfig{Limit: 64, WaitTimeout: time.Minute})
+	ctx, cancel := context.WithCancel(context.Background())
+	m, err := p.NewManager(ctx, memory.ManagerConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Release()
+	if _, err := m.RequestMemory(64); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := m.RequestMemory(1)
+		done <- err
+	}()
+	waitForWaiters(t, p, 1)
+	cancel()
+
+	if err := <-done; err == nil {
+		t.Fatal("expected error")
+	} else if want, got := codes.Canceled, errors.Code(err); want != got {
+		t.Fatalf("unexpected error code -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+	if want, got := int64(0), p.Stats().Waiting; want != got {
+		t.Fatalf("unexpected waiting -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+}
+
+func TestPool_PriorityPolicy(t *testing.T) {
+	p := newPool(t, memory.PoolConfig{Limit: 64, Policy: memory.PriorityPolicy, WaitTimeout: time.Minute})
+	holder := newManager(t, p, memory.ManagerConfig{})
+	low := newManager(t, p, memory.ManagerConfig{Priority: 0})
+	high := newManager(t, p, memory.ManagerConfig{Priority: 10})
+	defer low.Release()
+	defer high.Release()
+
+	if _, err := holder.RequestMemory(64); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var (
+		mu    sync.Mutex
+		order []string
+		wg    sync.WaitGroup
+	)
+	request := func(name string, m *memory.PoolManager) {
+		defer wg.Done()
+		if _, err := m.RequestMemory(48); err != nil {
+			t.Errorf("unexpected error: %s", err)
+			return
+		}
+		mu.Lock()
+		order = append(order, name)
+		mu.Unlock()
+		m.FreeMemory(48)
+	}
+
+	// The low priority request waits first, but the
+	// high priority request must be granted before it.
+	wg.Add(2)
+	go request("low", low)
+	waitForWaiters(t, p, 1)
+	go request("high", high)
+	waitForWaiters(t, p, 2)
+
+	holder.Release()
+	wg.Wait()
+
+	if want, got := []string{"high", "low"}, order; !cmp.Equal(want, got) {
+		t.Fatalf("unexpected order -want/+got:\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestPool_FairSharePolicy(t *testing.T) {
+	p := newPool(t, memory.PoolConfig{Limit: 100, WaitTimeout: time.Minute})
+	greedy := newManager(t, p, memory.ManagerConfig{})
+	other := newManager(t, p, memory.ManagerConfig{})
+	defer greedy.Release()
+	defer other.Release()
+
+	// With no other query waiting, a query may use more than its share.
+	if _, err := greedy.RequestMemory(60); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := other.RequestMemory(30); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Both queries now wait. The greedy query is over its share
+	// of 50 bytes, so the other query is granted first even
+	// though it asked later.
+	greedyDone := make(chan error, 1)
+	go func() {
+		_, err := greedy.RequestMemory(20)
+		greedyDone <- err
+	}()
+	waitForWaiters(t, p, 1)
+
+	otherDone := make(chan error, 1)
+	go func() {
+		_, err := other.RequestMemory(20)
+		otherDone <- err
+	}()
+	waitForWaiters(t, p, 2)
+
+	greedy.FreeMemory(20)
+	if err := <-otherDone; err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want, got := int64(50), other.Used(); want != got {
+		t.Fatalf("unexpected used -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+
+	// Once no other query is waiting, the greedy query can be granted.
+	other.FreeMemory(50)
+	if err := <-greedyDone; err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want, got := int64(60), greedy.Used(); want != got {
+		t.Fatalf("unexpected used -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+}
+
+func TestPool_ResourceAllocator(t *testing.T) {
+	p := newPool(t, memory.PoolConfig{Limit: 128})
+	m := newManager(t, p, memory.ManagerConfig{})
+	defer m.Release()
+
+	allocator := &memory.ResourceAllocator{
+		Limit:   func(v int64) *int64 { return &v }(0),
+		Manager: m,
+	}
+	if err := allocator.Account(96); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := allocator.Account(64); err == nil {
+		t.Fatal("expected error")
+	}
+	if want, got := int64(96), p.Stats().Used; want != got {
+		t.Fatalf("unexpected used -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+}
+
+func waitForWaiters(t *testing.T, p *memory.Pool, n int64) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for p.Stats().Waiting != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d waiters", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
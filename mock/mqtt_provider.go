@@ -15,14 +15,27 @@ func (m MqttDialer) Dial(ctx context.Context, brokers []string, options mqtt.Opt
 }
 
 type MqttClient struct {
-	PublishFn func(ctx context.Context, topic string, qos byte, retain bool, payload interface{}) error
-	CloseFn   func() error
+	PublishFn     func(ctx context.Context, topic string, qos byte, retain bool, payload interface{}) error
+	SubscribeFn   func(ctx context.Context, topic string, qos byte, handler mqtt.MessageHandler) error
+	UnsubscribeFn func(ctx context.Context, topics ...string) error
+	CloseFn       func() error
 }
 
 func (m MqttClient) Publish(ctx context.Context, topic string, qos byte, retain bool, payload interface{}) error {
 	return m.PublishFn(ctx, topic, qos, retain, payload)
 }
 
+func (m MqttClient) Subscribe(ctx context.Context, topic string, qos byte, handler mqtt.MessageHandler) error {
+	return m.SubscribeFn(ctx, topic, qos, handler)
+}
+
+func (m MqttClient) Unsubscribe(ctx context.Context, topics ...string) error {
+	if m.UnsubscribeFn == nil {
+		return nil
+	}
+	return m.UnsubscribeFn(ctx, topics...)
+}
+
 func (m MqttClient) Close() error {
 	if m.CloseFn == nil {
 		return nil
@@ -48,6 +48,12 @@ type Statistics struct {
 	// ExecuteDuration is the amount of time in nanoseconds spent in executing the query.
 	ExecuteDuration time.Duration `json:"execute_duration"`
 
+	// CPUDuration is the amount of CPU time in nanoseconds the dispatcher
+	// workers spent running the transformations of the query. It does not
+	// include the CPU time of sources or of other goroutines started by the
+	// transformations, and it is only measured on Linux.
+	CPUDuration time.Duration `json:"cpu_duration"`
+
 	// Concurrency is the number of goroutines allocated to process the query
 	Concurrency int `json:"concurrency"`
 	// MaxAllocated is the maximum number of bytes the query allocated.
@@ -62,6 +68,9 @@ type Statistics struct {
 	// RuntimeErrors contains error messages that happened during the execution of the query.
 	RuntimeErrors []string `json:"runtime_errors"`
 
+	// QuotaExceeded contains the names of the quotas the query exceeded.
+	QuotaExceeded []string `json:"quota_exceeded"`
+
 	// Metadata contains metadata key/value pairs that have been attached during execution.
 	Metadata metadata.Metadata `json:"metadata"`
 }
@@ -77,6 +86,9 @@ func (s Statistics) Add(other Statistics) Statistics {
 	profiles := make([]TransportProfile, 0, len(s.Profiles)+len(other.Profiles))
 	profiles = append(profiles, s.Profiles...)
 	profiles = append(profiles, other.Profiles...)
+	exceeded := make([]string, 0, len(s.QuotaExceeded)+len(other.QuotaExceeded))
+	exceeded = append(exceeded, s.QuotaExceeded...)
+	exceeded = append(exceeded, other.QuotaExceeded...)
 	return Statistics{
 		TotalDuration:   s.TotalDuration + other.TotalDuration,
 		CompileDuration: s.CompileDuration + other.CompileDuration,
@@ -84,11 +96,13 @@ func (s Statistics) Add(other Statistics) Statistics {
 		PlanDuration:    s.PlanDuration + other.PlanDuration,
 		RequeueDuration: s.RequeueDuration + other.RequeueDuration,
 		ExecuteDuration: s.ExecuteDuration + other.ExecuteDuration,
+		CPUDuration:     s.CPUDuration + other.CPUDuration,
 		Concurrency:     s.Concurrency + other.Concurrency,
 		MaxAllocated:    s.MaxAllocated + other.MaxAllocated,
 		TotalAllocated:  s.TotalAllocated + other.TotalAllocated,
 		Profiles:        profiles,
 		RuntimeErrors:   errs,
+		QuotaExceeded:   exceeded,
 		Metadata:        md,
 	}
 }
@@ -101,11 +115,13 @@ func (s *Statistics) Merge(other Statistics) {
 	s.PlanDuration += other.PlanDuration
 	s.RequeueDuration += other.RequeueDuration
 	s.ExecuteDuration += other.ExecuteDuration
+	s.CPUDuration += other.CPUDuration
 	s.Concurrency += other.Concurrency
 	s.MaxAllocated += other.MaxAllocated
 	s.TotalAllocated += other.TotalAllocated
 	s.Profiles = append(s.Profiles, other.Profiles...)
 	s.RuntimeErrors = append(s.RuntimeErrors, other.RuntimeErrors...)
+	s.QuotaExceeded = append(s.QuotaExceeded, other.QuotaExceeded...)
 	s.Metadata.AddAll(other.Metadata)
 }
 
@@ -132,6 +148,10 @@ type TransportProfile struct {
 
 	// Mean is the mean span time of this profile.
 	Mean float64 `json:"mean"`
+
+	// CPUDuration holds the CPU time in nanoseconds the dispatcher
+	// workers spent running this transport. It is only measured on Linux.
+	CPUDuration int64 `json:"cpu_duration"`
 }
 
 // StartSpan will start a profile span to be recorded.
@@ -53,6 +53,10 @@ func (s *FromOpSpec) Kind() flux.OperationKind {
 	return FromKind
 }
 
+// FromProcedureSpec is not split into partitions when a parallelization
+// factor is set. The partitions of a source must not produce tables with the
+// same group key, and array.from produces a single table with an empty group
+// key, so there is nothing to split it by.
 type FromProcedureSpec struct {
 	plan.DefaultCost
 	Rows values.Array
@@ -11,9 +11,11 @@ import (
 	"github.com/InfluxCommunity/flux/dependencies/filesystem"
 	"github.com/InfluxCommunity/flux/execute"
 	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/internal/feature"
 	"github.com/InfluxCommunity/flux/memory"
 	"github.com/InfluxCommunity/flux/plan"
 	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/InfluxCommunity/flux/stdlib/universe"
 )
 
 const FromCSVKind = "fromCSV"
@@ -33,6 +35,7 @@ func init() {
 	fromCSVSignature := runtime.MustLookupBuiltinType("csv", "from")
 	runtime.RegisterPackageValue("csv", "from", flux.MustValue(flux.FunctionValue(FromCSVKind, createFromCSVOpSpec, fromCSVSignature)))
 	plan.RegisterProcedureSpec(FromCSVKind, newFromCSVProcedure, FromCSVKind)
+	plan.RegisterParallelizeRules(ParallelizeFromCSVRule{})
 	execute.RegisterSource(FromCSVKind, createFromCSVSource)
 }
 
@@ -80,6 +83,9 @@ type FromCSVProcedureSpec struct {
 	CSV  string
 	File string
 	Mode string
+
+	// Factor is the number of partitions the file is split into.
+	Factor int
 }
 
 func newFromCSVProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
@@ -104,9 +110,50 @@ func (s *FromCSVProcedureSpec) Copy() plan.ProcedureSpec {
 	ns.CSV = s.CSV
 	ns.File = s.File
 	ns.Mode = s.Mode
+	ns.Factor = s.Factor
 	return ns
 }
 
+func (s *FromCSVProcedureSpec) OutputAttributes() plan.PhysicalAttributes {
+	if s.Factor > 1 {
+		return plan.PhysicalAttributes{
+			plan.ParallelRunKey: plan.ParallelRunAttribute{Factor: s.Factor},
+		}
+	}
+	return nil
+}
+
+// ParallelizeFromCSVRule splits csv.from(file:) into partitions
+// when a parallelization factor is configured. Each partition reads
+// the tables that start within a byte range of the file and the
+// partitions are merged afterwards. Files read in raw mode are a
+// single table and are not split.
+type ParallelizeFromCSVRule struct{}
+
+func (ParallelizeFromCSVRule) Name() string {
+	return "ParallelizeFromCSVRule"
+}
+
+func (ParallelizeFromCSVRule) Pattern() plan.Pattern {
+	return plan.MultiSuccessor(FromCSVKind)
+}
+
+func (ParallelizeFromCSVRule) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
+	spec := node.ProcedureSpec().(*FromCSVProcedureSpec)
+	factor := feature.ParallelizationFactor().Int(ctx)
+	if factor <= 1 || spec.Factor > 0 || spec.File == "" || spec.Mode == rawMode {
+		return node, false, nil
+	}
+
+	newSpec := spec.Copy().(*FromCSVProcedureSpec)
+	newSpec.Factor = factor
+	fromNode := plan.CreateUniquePhysicalNode(ctx, "fromCSV", newSpec)
+	mergeNode := plan.CreateUniquePhysicalNode(ctx, "partitionMerge", &universe.PartitionMergeProcedureSpec{Factor: factor})
+	fromNode.AddSuccessors(mergeNode)
+	mergeNode.AddPredecessors(fromNode)
+	return mergeNode, true, nil
+}
+
 func createFromCSVSource(prSpec plan.ProcedureSpec, dsid execute.DatasetID, a execute.Administration) (execute.Source, error) {
 	spec, ok := prSpec.(*FromCSVProcedureSpec)
 	if !ok {
@@ -117,7 +164,28 @@ func createFromCSVSource(prSpec plan.ProcedureSpec, dsid execute.DatasetID, a ex
 
 func CreateSource(spec *FromCSVProcedureSpec, dsid execute.DatasetID, a execute.Administration) (execute.Source, error) {
 	var getDataStream func() (io.ReadCloser, error)
-	if spec.File != "" {
+	if popts := a.ParallelOpts(); spec.File != "" && spec.Mode != rawMode && popts.Factor > 1 {
+		getDataStream = func() (io.ReadCloser, error) {
+			f, err := filesystem.OpenFile(a.Context(), spec.File)
+			if err != nil {
+				return nil, errors.Wrap(err, codes.Inherit, "csv.from() failed to read file")
+			}
+			fi, err := f.Stat()
+			if err != nil {
+				_ = f.Close()
+				return nil, errors.Wrap(err, codes.Inherit, "csv.from() failed to read file")
+			}
+			r, err := newPartitionReader(f, fi.Size(), popts.Group, popts.Factor)
+			if err != nil {
+				_ = f.Close()
+				return nil, errors.Wrap(err, codes.Inherit, "csv.from() failed to read file")
+			}
+			return struct {
+				io.Reader
+				io.Closer
+			}{r, f}, nil
+		}
+	} else if spec.File != "" {
 		getDataStream = func() (io.ReadCloser, error) {
 			f, err := filesystem.OpenFile(a.Context(), spec.File)
 			if err != nil {
@@ -2,22 +2,32 @@ package csv_test
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/InfluxCommunity/flux"
 	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/dependencies/filesystem"
 	"github.com/InfluxCommunity/flux/execute"
 	"github.com/InfluxCommunity/flux/execute/executetest"
 	_ "github.com/InfluxCommunity/flux/fluxinit/static" // We need to init flux for the tests to work.
 	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/internal/feature"
 	"github.com/InfluxCommunity/flux/internal/operation"
+	"github.com/InfluxCommunity/flux/lang"
+	"github.com/InfluxCommunity/flux/memory"
 	"github.com/InfluxCommunity/flux/mock"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/plan/plantest"
 	"github.com/InfluxCommunity/flux/querytest"
+	"github.com/InfluxCommunity/flux/runtime"
 	"github.com/InfluxCommunity/flux/stdlib/csv"
 	"github.com/InfluxCommunity/flux/stdlib/universe"
 	"github.com/InfluxCommunity/flux/values"
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestFromCSV_ReturnSingleResult(t *testing.T) {
@@ -401,3 +411,289 @@ func (n *noopTransformation) UpdateProcessingTime(id execute.DatasetID, t execut
 	return nil
 }
 func (n *noopTransformation) Finish(id execute.DatasetID, err error) {}
+
+func TestFromCSV_RunParallel(t *testing.T) {
+	// The text has tables that span several rows, rows without
+	// a table column and quoted values with newlines in them.
+	const text = `#datatype,string,long,dateTime:RFC3339,string,string,string
+#group,false,false,false,true,true,false
+#default,_result,,,,,
+,result,table,_time,_measurement,host,_value
+,,0,2018-04-17T00:00:00Z,cpu,A,42
+,,0,2018-04-17T00:00:01Z,cpu,A,43
+,,,2018-04-17T00:00:02Z,cpu,A,44
+,,1,2018-04-17T00:00:00Z,cpu,B,"first
+line"
+,,1,2018-04-17T00:00:01Z,cpu,B,"a ""quoted"" value"
+,,2,2018-04-17T00:00:00Z,log,A,"
+,,5,log,not a row
+"
+,,2,2018-04-17T00:00:01Z,log,A,second
+,,3,2018-04-17T00:00:00Z,event,A,start
+,,,2018-04-17T00:00:01Z,event,A,stop
+,,4,2018-04-17T00:00:00Z,event,B,start
+`
+	fs := filesystem.NewMemoryFS(map[string]string{
+		"/data.csv": text,
+	})
+	ctx := filesystem.Inject(context.Background(), fs)
+	spec := &csv.FromCSVProcedureSpec{
+		File: "/data.csv",
+		Mode: "annotations",
+	}
+
+	want := runPartitions(t, ctx, spec, 1)
+	if len(want) != 5 {
+		t.Fatalf("unexpected number of tables: %d", len(want))
+	}
+	for factor := 2; factor <= 16; factor++ {
+		got := runPartitions(t, ctx, spec, factor)
+		if !cmp.Equal(want, got) {
+			t.Errorf("unexpected tables with %d partitions -want/+got\n%s", factor, cmp.Diff(want, got))
+		}
+	}
+}
+
+func TestFromCSV_RunParallelMultipleAnnotations(t *testing.T) {
+	fs := filesystem.NewMemoryFS(map[string]string{
+		"/data.csv": `#datatype,string,long,string,double
+#group,false,false,true,false
+#default,_result,,,
+,result,table,host,_value
+,,0,A,42
+,,0,A,43
+
+#datatype,string,long,string,string
+#group,false,false,true,false
+#default,_result,,,
+,result,table,host,_value
+,,1,B,first
+,,1,B,second
+`,
+	})
+	ctx := filesystem.Inject(context.Background(), fs)
+	spec := &csv.FromCSVProcedureSpec{
+		File: "/data.csv",
+		Mode: "annotations",
+	}
+
+	var errs int
+	for group := 0; group < 2; group++ {
+		collector := runPartition(t, ctx, spec, group, 2)
+		if collector.err != nil {
+			if got, want := errors.Code(collector.err), codes.Invalid; got != want {
+				t.Errorf("unexpected error code -want/+got:\n\t- %v\n\t+ %v", want, got)
+			}
+			errs++
+		}
+	}
+	if errs == 0 {
+		t.Error("expected an error reading a file with more than one set of annotations")
+	}
+}
+
+// runPartitions reads every partition of the file and returns the tables.
+func runPartitions(t *testing.T, ctx context.Context, spec *csv.FromCSVProcedureSpec, factor int) []*executetest.Table {
+	t.Helper()
+
+	var tables []*executetest.Table
+	for group := 0; group < factor; group++ {
+		collector := runPartition(t, ctx, spec, group, factor)
+		if collector.err != nil {
+			t.Fatalf("unexpected error in partition %d of %d: %s", group, factor, collector.err)
+		}
+		tables = append(tables, collector.tables...)
+	}
+	executetest.NormalizeTables(tables)
+	sort.Sort(executetest.SortedTables(tables))
+	return tables
+}
+
+func runPartition(t *testing.T, ctx context.Context, spec *csv.FromCSVProcedureSpec, group, factor int) *tableCollector {
+	t.Helper()
+
+	a := &parallelAdministration{
+		Administration: mock.AdministrationWithContext(ctx),
+		opts:           execute.ParallelOpts{Group: group, Factor: factor},
+	}
+	s, err := csv.CreateSource(spec, executetest.RandomDatasetID(), a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector := &tableCollector{}
+	s.AddTransformation(collector)
+	s.Run(ctx)
+	return collector
+}
+
+func TestFromCSV_ParallelQuery(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString(`#datatype,string,long,dateTime:RFC3339,string,string,double
+#group,false,false,false,true,true,false
+#default,_result,,,,,
+,result,table,_time,_measurement,host,_value
+`)
+	start := time.Date(2018, 4, 17, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 20; i++ {
+		for j := 0; j < 50; j++ {
+			fmt.Fprintf(&sb, ",,%d,%s,m%d,h%d,%d\n", i, start.Add(time.Duration(j)*time.Second).Format(time.RFC3339), i%5, i/5, i*j)
+		}
+	}
+	fs := filesystem.NewMemoryFS(map[string]string{
+		"/data.csv": sb.String(),
+	})
+
+	// The wide transformations run after the partitions are merged.
+	const query = `import "csv"
+csv.from(file: "/data.csv")
+	|> map(fn: (r) => ({r with _value: r._value * 2.0}))
+	|> group(columns: ["host"])
+	|> sort(columns: ["_measurement", "_time"])
+`
+	run := func(factor int) []*executetest.Table {
+		t.Helper()
+
+		flagger := executetest.TestFlagger{}
+		flagger[feature.ParallelizationFactor().Key()] = factor
+		ctx := feature.Inject(filesystem.Inject(context.Background(), fs), flagger)
+
+		program, err := (&lang.FluxCompiler{Query: query}).Compile(ctx, runtime.Default)
+		if err != nil {
+			t.Fatal(err)
+		}
+		q, err := program.Start(ctx, &memory.ResourceAllocator{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer q.Done()
+
+		var tables []*executetest.Table
+		for res := range q.Results() {
+			if err := res.Tables().Do(func(tbl flux.Table) error {
+				et, err := executetest.ConvertTable(tbl)
+				if err != nil {
+					return err
+				}
+				tables = append(tables, et)
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		q.Done()
+		if err := q.Err(); err != nil {
+			t.Fatalf("unexpected error with %d partitions: %s", factor, err)
+		}
+		executetest.NormalizeTables(tables)
+		sort.Sort(executetest.SortedTables(tables))
+		return tables
+	}
+
+	want := run(0)
+	if len(want) != 4 {
+		t.Fatalf("unexpected number of tables: %d", len(want))
+	}
+	for factor := 2; factor <= 8; factor++ {
+		if got := run(factor); !cmp.Equal(want, got) {
+			t.Errorf("unexpected tables with %d partitions -want/+got\n%s", factor, cmp.Diff(want, got))
+		}
+	}
+}
+
+func TestParallelizeFromCSVRule(t *testing.T) {
+	flagger := executetest.TestFlagger{}
+	flagger[feature.ParallelizationFactor().Key()] = 4
+	ctx := feature.Inject(context.Background(), flagger)
+
+	tests := []plantest.RuleTestCase{
+		{
+			Name:    "file",
+			Context: ctx,
+			Rules:   []plan.Rule{csv.ParallelizeFromCSVRule{}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("fromCSV", &csv.FromCSVProcedureSpec{File: "/data.csv", Mode: "annotations"}),
+					plantest.CreatePhysicalMockNode("mock"),
+				},
+				Edges: [][2]int{{0, 1}},
+			},
+			After: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("fromCSV", &csv.FromCSVProcedureSpec{File: "/data.csv", Mode: "annotations", Factor: 4}),
+					plan.CreatePhysicalNode("partitionMerge", &universe.PartitionMergeProcedureSpec{Factor: 4}),
+					plantest.CreatePhysicalMockNode("mock"),
+				},
+				Edges: [][2]int{{0, 1}, {1, 2}},
+			},
+		},
+		{
+			Name:    "raw file",
+			Context: ctx,
+			Rules:   []plan.Rule{csv.ParallelizeFromCSVRule{}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("fromCSV", &csv.FromCSVProcedureSpec{File: "/data.csv", Mode: "raw"}),
+					plantest.CreatePhysicalMockNode("mock"),
+				},
+				Edges: [][2]int{{0, 1}},
+			},
+			NoChange: true,
+		},
+		{
+			Name:    "inline csv",
+			Context: ctx,
+			Rules:   []plan.Rule{csv.ParallelizeFromCSVRule{}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("fromCSV", &csv.FromCSVProcedureSpec{CSV: "_value\n1\n", Mode: "raw"}),
+					plantest.CreatePhysicalMockNode("mock"),
+				},
+				Edges: [][2]int{{0, 1}},
+			},
+			NoChange: true,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			plantest.PhysicalRuleTestHelper(t, &tc)
+		})
+	}
+}
+
+type parallelAdministration struct {
+	*mock.Administration
+	opts execute.ParallelOpts
+}
+
+func (a *parallelAdministration) ParallelOpts() execute.ParallelOpts {
+	return a.opts
+}
+
+// tableCollector stores the tables read by a partition.
+type tableCollector struct {
+	execute.ExecutionNode
+	tables []*executetest.Table
+	err    error
+}
+
+func (c *tableCollector) RetractTable(id execute.DatasetID, key flux.GroupKey) error { return nil }
+func (c *tableCollector) Process(id execute.DatasetID, tbl flux.Table) error {
+	et, err := executetest.ConvertTable(tbl)
+	if err != nil {
+		return err
+	}
+	c.tables = append(c.tables, et)
+	return nil
+}
+func (c *tableCollector) UpdateWatermark(id execute.DatasetID, t execute.Time) error { return nil }
+func (c *tableCollector) UpdateProcessingTime(id execute.DatasetID, t execute.Time) error {
+	return nil
+}
+func (c *tableCollector) Finish(id execute.DatasetID, err error) {
+	if err != nil {
+		c.err = err
+	}
+}
@@ -0,0 +1,378 @@
+package csv
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
+)
+
+// partitionReader reads a single partition of an annotated csv file.
+//
+// The file is split into byte ranges of roughly equal size. Each partition
+// seeks to the start of its range and moves forward to the first line that
+// looks like a data row. Partitions only begin and end where the table
+// column changes, so every table is read by exactly one partition: the
+// partition that owns the start of a range keeps reading past its end until
+// the table it is in is complete, and the next partition skips those rows.
+//
+// The annotations and header are read from the top of the file and written
+// out before the first data row of the partition. Files that contain more
+// than one set of annotations cannot be split this way and produce an error.
+type partitionReader struct {
+	r *bufio.Reader
+
+	// offset is the offset in the file of the next byte read from r.
+	offset int64
+	// stop is the offset where the next partition begins
+	// or -1 if this is the last partition.
+	stop int64
+
+	header []byte
+	// fields is the number of fields in the header row.
+	fields int
+
+	state partitionState
+	// passed reports whether the start of the
+	// next partition has been read.
+	passed bool
+	// table is the table column of the row where this or the
+	// next partition begins. It is only compared to the rows
+	// that follow that one.
+	table string
+
+	// written reports whether the header has been written to the output.
+	written bool
+	buf     []byte
+}
+
+type partitionState int
+
+const (
+	// skipping reads lines until one looks like a data row
+	// with a table column. That row and the ones before it
+	// belong to the previous partition.
+	skipping partitionState = iota
+	// seeking reads records until the table column changes.
+	// The rows until then belong to the previous partition.
+	seeking
+	// reading passes records through until the start of the
+	// next partition and then until the table column changes.
+	reading
+	// finishing passes records through until the table column changes.
+	finishing
+	done
+)
+
+var bom = []byte{0xef, 0xbb, 0xbf}
+
+func newPartitionReader(r io.Reader, size int64, partition, factor int) (*partitionReader, error) {
+	p := &partitionReader{
+		r:    bufio.NewReader(r),
+		stop: -1,
+	}
+	if err := p.readHeader(); err != nil {
+		return nil, err
+	}
+
+	bound := func(partition int) int64 {
+		offset := size * int64(partition) / int64(factor)
+		if offset < p.offset {
+			return p.offset
+		}
+		return offset
+	}
+	if partition+1 < factor {
+		p.stop = bound(partition + 1)
+	}
+	if partition == 0 {
+		p.state = reading
+		p.buf = append(p.buf, p.header...)
+		p.written = true
+		return p, nil
+	}
+
+	p.state = skipping
+	if start := bound(partition); start > p.offset {
+		// Resume on the byte before the range so a line that begins
+		// exactly at the start of the range is not skipped.
+		if err := p.seek(r, start-1); err != nil {
+			return nil, err
+		}
+		line, err := p.readLine()
+		if err != nil && err != io.EOF {
+			return nil, err
+		} else if err == io.EOF && len(line) == 0 {
+			p.state = done
+		}
+	}
+	return p, nil
+}
+
+// readHeader reads the annotations and header row
+// at the top of the file.
+func (p *partitionReader) readHeader() error {
+	for {
+		rec, err := p.readRecord()
+		if err != nil && err != io.EOF {
+			return err
+		}
+		p.header = append(p.header, rec...)
+		if len(rec) == 0 {
+			return nil
+		}
+
+		n, annotation, _, _ := parseRecord(bytes.TrimPrefix(rec, bom))
+		if n > 0 && annotation == "" && !isBlank(rec) {
+			p.fields = n
+			return nil
+		} else if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// seek moves the reader to the offset in the file. Readers that cannot
+// seek are read up to the offset instead.
+func (p *partitionReader) seek(r io.Reader, offset int64) error {
+	if s, ok := r.(io.Seeker); ok {
+		if _, err := s.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+		p.r.Reset(r)
+	} else if _, err := io.CopyN(io.Discard, p.r, offset-p.offset); err != nil {
+		return err
+	}
+	p.offset = offset
+	return nil
+}
+
+func (p *partitionReader) Read(b []byte) (int, error) {
+	for len(p.buf) == 0 {
+		if p.state == done {
+			return 0, io.EOF
+		}
+		if err := p.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(b, p.buf)
+	p.buf = p.buf[n:]
+	return n, nil
+}
+
+// next reads the next line or record and queues it
+// for output if it belongs to the partition.
+func (p *partitionReader) next() error {
+	if p.state == skipping {
+		// The reader may have started inside of a quoted field
+		// so lines are read until one is a complete data row.
+		offset := p.offset
+		line, err := p.readLine()
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if table, ok := p.dataRow(line); ok {
+			p.table = table
+			p.state = seeking
+			if p.stop >= 0 && offset >= p.stop {
+				// The next partition starts on the same row.
+				p.state = done
+			}
+		} else if err == io.EOF {
+			p.state = done
+		}
+		return nil
+	}
+
+	offset := p.offset
+	rec, err := p.readRecord()
+	if err != nil && err != io.EOF {
+		return err
+	} else if len(rec) == 0 {
+		p.state = done
+		return nil
+	}
+	if err == io.EOF {
+		defer func() {
+			p.state = done
+		}()
+	}
+	if isBlank(rec) {
+		return nil
+	}
+
+	n, annotation, table, _ := parseRecord(rec)
+	if annotation != "" {
+		return errors.New(codes.Invalid, "csv.from() cannot split a file with more than one set of annotations into partitions")
+	} else if n != p.fields {
+		// Let the decoder report the malformed row.
+		table = ""
+	}
+
+	switch p.state {
+	case seeking:
+		next := p.nextPartition(offset, rec)
+		if p.boundary(table) {
+			p.table = table
+			p.state = reading
+			if next {
+				p.state = finishing
+			}
+			p.write(rec)
+		} else if next {
+			// The next partition starts within the table that
+			// the previous partition is reading.
+			p.state = done
+		}
+	case reading:
+		if p.nextPartition(offset, rec) {
+			p.table = table
+			p.state = finishing
+		}
+		p.write(rec)
+	case finishing:
+		if p.boundary(table) {
+			p.state = done
+			return nil
+		}
+		p.write(rec)
+	}
+	return nil
+}
+
+// nextPartition reports whether the record at the offset is where
+// the next partition begins. That is the first line at or after the end
+// of this range that is a complete data row with a table column. Only the
+// first line of the record is checked, just as the next partition sees it.
+func (p *partitionReader) nextPartition(offset int64, rec []byte) bool {
+	if p.stop < 0 || offset < p.stop || p.passed {
+		return false
+	}
+	_, p.passed = p.dataRow(firstLine(rec))
+	return p.passed
+}
+
+// boundary reports whether a data row with the given table column
+// begins a new table. Rows with an empty table column continue
+// the current table.
+func (p *partitionReader) boundary(table string) bool {
+	return table != "" && table != p.table
+}
+
+func (p *partitionReader) write(rec []byte) {
+	if !p.written {
+		p.buf = append(p.buf, p.header...)
+		p.written = true
+	}
+	p.buf = append(p.buf, rec...)
+}
+
+// dataRow reports whether the line is a complete data row with
+// a table column and returns the table column.
+func (p *partitionReader) dataRow(line []byte) (string, bool) {
+	n, annotation, table, ok := parseRecord(line)
+	if !ok || n != p.fields || annotation != "" || table == "" {
+		return "", false
+	}
+	return table, true
+}
+
+// readLine reads a single line including its trailing newline.
+func (p *partitionReader) readLine() ([]byte, error) {
+	var line []byte
+	for {
+		b, err := p.r.ReadSlice('\n')
+		line = append(line, b...)
+		if err != bufio.ErrBufferFull {
+			p.offset += int64(len(line))
+			return line, err
+		}
+	}
+}
+
+// readRecord reads a single csv record including its trailing newline.
+// A record spans multiple lines when a quoted field contains a newline.
+func (p *partitionReader) readRecord() ([]byte, error) {
+	var (
+		rec    []byte
+		quoted bool
+	)
+	for {
+		line, err := p.r.ReadSlice('\n')
+		rec = append(rec, line...)
+		quoted = quoted != (bytes.Count(line, []byte{'"'})%2 == 1)
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil || !quoted {
+			p.offset += int64(len(rec))
+			return rec, err
+		}
+	}
+}
+
+func isBlank(rec []byte) bool {
+	return len(bytes.TrimRight(rec, "\r\n")) == 0
+}
+
+func firstLine(rec []byte) []byte {
+	if i := bytes.IndexByte(rec, '\n'); i >= 0 {
+		return rec[:i+1]
+	}
+	return rec
+}
+
+// parseRecord returns the number of fields in a csv record along with
+// the values of its annotation and table fields, which are the first
+// and third fields. It reports false if
+// the record ends inside of a quoted field.
+func parseRecord(rec []byte) (n int, annotation, table string, ok bool) {
+	rec = bytes.TrimRight(rec, "\r\n")
+	if len(rec) == 0 {
+		return 0, "", "", true
+	}
+
+	var (
+		field  []byte
+		quoted bool
+		start  = true
+	)
+	end := func() {
+		switch n {
+		case 0:
+			annotation = string(field)
+		case 2:
+			table = string(field)
+		}
+		field = field[:0]
+		n++
+		start = true
+	}
+	for i := 0; i < len(rec); i++ {
+		c := rec[i]
+		switch {
+		case quoted:
+			if c != '"' {
+				field = append(field, c)
+			} else if i+1 < len(rec) && rec[i+1] == '"' {
+				field = append(field, c)
+				i++
+			} else {
+				quoted = false
+			}
+		case c == '"' && start:
+			quoted = true
+			start = false
+		case c == ',':
+			end()
+		default:
+			field = append(field, c)
+			start = false
+		}
+	}
+	end()
+	return n, annotation, table, !quoted
+}
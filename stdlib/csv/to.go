@@ -0,0 +1,267 @@
+package csv
+
+import (
+	"context"
+	"io"
+	"os"
+	"unicode/utf8"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/csv"
+	"github.com/InfluxCommunity/flux/dependencies/audit"
+	"github.com/InfluxCommunity/flux/dependencies/dryrun"
+	"github.com/InfluxCommunity/flux/dependencies/filesystem"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/InfluxCommunity/flux/semantic"
+)
+
+const ToCSVKind = "toCSV"
+
+// toCSVResultName is the name written in the result column.
+const toCSVResultName = "_result"
+
+// validAnnotations are the annotations that can be written by csv.to.
+var validAnnotations = map[string]bool{
+	"datatype": true,
+	"group":    true,
+	"default":  true,
+}
+
+type ToCSVOpSpec struct {
+	File        string   `json:"file"`
+	Annotations []string `json:"annotations"`
+	Header      bool     `json:"header"`
+	Delimiter   string   `json:"delimiter"`
+	Append      bool     `json:"append"`
+}
+
+func init() {
+	toCSVSignature := runtime.MustLookupBuiltinType("csv", "to")
+	runtime.RegisterPackageValue("csv", "to", flux.MustValue(flux.FunctionValueWithSideEffect(ToCSVKind, createToCSVOpSpec, toCSVSignature)))
+	plan.RegisterProcedureSpecWithSideEffect(ToCSVKind, newToCSVProcedure, ToCSVKind)
+	execute.RegisterTransformation(ToCSVKind, createToCSVTransformation)
+}
+
+func createToCSVOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
+	if err := a.AddParentFromArgs(args); err != nil {
+		return nil, err
+	}
+	spec := &ToCSVOpSpec{
+		Annotations: []string{"datatype", "group", "default"},
+		Header:      true,
+		Delimiter:   ",",
+	}
+
+	if file, err := args.GetRequiredString("file"); err != nil {
+		return nil, err
+	} else {
+		spec.File = file
+	}
+	if spec.File == "" {
+		return nil, errors.New(codes.Invalid, "file must not be empty")
+	}
+
+	if annotations, ok, err := args.GetArray("annotations", semantic.String); err != nil {
+		return nil, err
+	} else if ok {
+		spec.Annotations = make([]string, annotations.Len())
+		for i := range spec.Annotations {
+			anno := annotations.Get(i).Str()
+			if !validAnnotations[anno] {
+				return nil, errors.Newf(codes.Invalid, "unknown annotation %q, must be one of datatype, group or default", anno)
+			}
+			spec.Annotations[i] = anno
+		}
+	}
+
+	if header, ok, err := args.GetBool("header"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.Header = header
+	}
+
+	if delimiter, ok, err := args.GetString("delimiter"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.Delimiter = delimiter
+	}
+	if r, size := utf8.DecodeRuneInString(spec.Delimiter); size != len(spec.Delimiter) || size == 0 ||
+		r == '\r' || r == '\n' || r == '"' || r == utf8.RuneError {
+		return nil, errors.Newf(codes.Invalid, "invalid delimiter %q, must be a single character", spec.Delimiter)
+	}
+
+	if appendFile, ok, err := args.GetBool("append"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.Append = appendFile
+	}
+	return spec, nil
+}
+
+func (s *ToCSVOpSpec) Kind() flux.OperationKind {
+	return ToCSVKind
+}
+
+type ToCSVProcedureSpec struct {
+	plan.DefaultCost
+	Spec *ToCSVOpSpec
+}
+
+func newToCSVProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
+	spec, ok := qs.(*ToCSVOpSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", qs)
+	}
+	return &ToCSVProcedureSpec{Spec: spec}, nil
+}
+
+func (s *ToCSVProcedureSpec) Kind() plan.ProcedureKind {
+	return ToCSVKind
+}
+
+func (s *ToCSVProcedureSpec) Copy() plan.ProcedureSpec {
+	spec := *s.Spec
+	spec.Annotations = append([]string(nil), s.Spec.Annotations...)
+	return &ToCSVProcedureSpec{Spec: &spec}
+}
+
+// EncoderConfig returns the configuration of the csv.TableEncoder
+// that writes the file.
+// Without annotations, the tables are written as plain CSV
+// without the annotation, result and table columns.
+func (s *ToCSVProcedureSpec) EncoderConfig() csv.ResultEncoderConfig {
+	r, _ := utf8.DecodeRuneInString(s.Spec.Delimiter)
+	return csv.ResultEncoderConfig{
+		Annotations:   s.Spec.Annotations,
+		NoHeader:      !s.Spec.Header,
+		Delimiter:     r,
+		NoMetaColumns: len(s.Spec.Annotations) == 0,
+	}
+}
+
+func createToCSVTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
+	s, ok := spec.(*ToCSVProcedureSpec)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	return NewToCSVTransformation(a.Context(), id, s)
+}
+
+// ToCSVTransformation writes the tables it receives to a file
+// with a csv.TableEncoder and passes them through unchanged.
+type ToCSVTransformation struct {
+	execute.ExecutionNode
+	d       *execute.PassthroughDataset
+	spec    *ToCSVProcedureSpec
+	f       io.WriteCloser
+	encoder *csv.TableEncoder
+	write   *audit.Write
+	// dryRun is the file in dry-run mode.
+	dryRun *dryrun.Writer
+}
+
+// NewToCSVTransformation opens the file with the writable filesystem
+// service and encodes the tables that are processed into it.
+// In dry-run mode, the file is not opened and the tables are captured instead.
+func NewToCSVTransformation(ctx context.Context, id execute.DatasetID, spec *ToCSVProcedureSpec) (*ToCSVTransformation, execute.Dataset, error) {
+	if sink := dryrun.GetSink(ctx); sink != nil {
+		w := sink.NewWriter("csv.to", spec.Spec.File)
+		t := newToCSVTransformation(id, spec, w, nil)
+		t.dryRun = w
+		return t, t.d, nil
+	}
+
+	var (
+		f   io.WriteCloser
+		err error
+	)
+	// Results are separated by an empty line, so one is written before
+	// the new result when appending to a file that already has one.
+	separate := false
+	if spec.Spec.Append {
+		if len(spec.Spec.Annotations) > 0 || spec.Spec.Header {
+			if fi, err := filesystem.Stat(ctx, spec.Spec.File); err == nil {
+				separate = fi.Size() > 0
+			} else if !os.IsNotExist(err) {
+				return nil, nil, errors.Wrap(err, codes.Inherit, "csv.to() failed to open file")
+			}
+		}
+		f, err = filesystem.AppendFile(ctx, spec.Spec.File)
+	} else {
+		f, err = filesystem.CreateFile(ctx, spec.Spec.File)
+	}
+	if err != nil {
+		return nil, nil, errors.Wrap(err, codes.Inherit, "csv.to() failed to open file")
+	}
+	write := audit.NewWrite(ctx, "csv.to", spec.Spec.File)
+	if separate {
+		n, err := io.WriteString(f, "\r\n")
+		if err != nil {
+			_ = f.Close()
+			err = errors.Wrap(err, codes.Inherit, "csv.to() failed to write file")
+			write.Done(err)
+			return nil, nil, err
+		}
+		write.Add(0, int64(n))
+	}
+
+	t := newToCSVTransformation(id, spec, f, write)
+	return t, t.d, nil
+}
+
+func newToCSVTransformation(id execute.DatasetID, spec *ToCSVProcedureSpec, f io.WriteCloser, write *audit.Write) *ToCSVTransformation {
+	return &ToCSVTransformation{
+		d:       execute.NewPassthroughDataset(id),
+		spec:    spec,
+		f:       f,
+		encoder: csv.NewTableEncoder(f, toCSVResultName, spec.EncoderConfig()),
+		write:   write,
+	}
+}
+
+func (t *ToCSVTransformation) RetractTable(id execute.DatasetID, key flux.GroupKey) error {
+	return t.d.RetractTable(key)
+}
+
+func (t *ToCSVTransformation) Process(id execute.DatasetID, tbl flux.Table) error {
+	// The buffered table retains the column buffers of the input
+	// so it can be encoded and passed on without copying the data.
+	buffered, err := execute.CopyTable(tbl)
+	if err != nil {
+		return err
+	}
+	written := t.encoder.Count()
+	if err := t.encoder.Encode(buffered.Copy()); err != nil {
+		buffered.Done()
+		return errors.Wrap(err, codes.Inherit, "csv.to() failed to write file")
+	}
+	var rows int64
+	for i, n := 0, buffered.BufferN(); i < n; i++ {
+		rows += int64(buffered.Buffer(i).Len())
+	}
+	t.write.Add(rows, t.encoder.Count()-written)
+	if t.dryRun != nil {
+		t.dryRun.AddRows(rows)
+	}
+	return t.d.Process(buffered)
+}
+
+func (t *ToCSVTransformation) UpdateWatermark(id execute.DatasetID, pt execute.Time) error {
+	return t.d.UpdateWatermark(pt)
+}
+
+func (t *ToCSVTransformation) UpdateProcessingTime(id execute.DatasetID, pt execute.Time) error {
+	return t.d.UpdateProcessingTime(pt)
+}
+
+func (t *ToCSVTransformation) Finish(id execute.DatasetID, err error) {
+	if closeErr := t.f.Close(); closeErr != nil && err == nil {
+		err = errors.Wrap(closeErr, codes.Inherit, "csv.to() failed to write file")
+	}
+	t.write.Done(err)
+	t.d.Finish(err)
+}
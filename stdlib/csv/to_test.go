@@ -0,0 +1,286 @@
+package csv_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/dependencies/audit"
+	"github.com/InfluxCommunity/flux/dependencies/dryrun"
+	"github.com/InfluxCommunity/flux/dependencies/filesystem"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/executetest"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/stdlib/csv"
+)
+
+func toCSVInput() []*executetest.Table {
+	return []*executetest.Table{
+		{
+			KeyCols: []string{"host"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "host", Type: flux.TString},
+				{Label: "_value", Type: flux.TFloat},
+			},
+			Data: [][]interface{}{
+				{execute.Time(0), "a", 1.5},
+				{execute.Time(1e9), "a", 2.0},
+			},
+		},
+		{
+			KeyCols: []string{"host"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "host", Type: flux.TString},
+				{Label: "_value", Type: flux.TFloat},
+			},
+			Data: [][]interface{}{
+				{execute.Time(0), "b", 3.0},
+			},
+		},
+	}
+}
+
+func TestToCSV_Process(t *testing.T) {
+	testCases := []struct {
+		name     string
+		spec     *csv.ToCSVOpSpec
+		existing string
+		want     string
+	}{
+		{
+			name: "annotated",
+			spec: &csv.ToCSVOpSpec{
+				File:        "out.csv",
+				Annotations: []string{"datatype", "group", "default"},
+				Header:      true,
+				Delimiter:   ",",
+			},
+			existing: "old contents\r\n",
+			want: "#datatype,string,long,dateTime:RFC3339,string,double\r\n" +
+				"#group,false,false,false,true,false\r\n" +
+				"#default,_result,,,,\r\n" +
+				",result,table,_time,host,_value\r\n" +
+				",,0,1970-01-01T00:00:00Z,a,1.5\r\n" +
+				",,0,1970-01-01T00:00:01Z,a,2\r\n" +
+				",,1,1970-01-01T00:00:00Z,b,3\r\n",
+		},
+		{
+			name: "no annotations",
+			spec: &csv.ToCSVOpSpec{
+				File:      "out.csv",
+				Header:    true,
+				Delimiter: ";",
+			},
+			want: "_time;host;_value\r\n" +
+				"1970-01-01T00:00:00Z;a;1.5\r\n" +
+				"1970-01-01T00:00:01Z;a;2\r\n" +
+				"1970-01-01T00:00:00Z;b;3\r\n",
+		},
+		{
+			name: "append",
+			spec: &csv.ToCSVOpSpec{
+				File:        "out.csv",
+				Annotations: []string{"group"},
+				Header:      true,
+				Delimiter:   ",",
+				Append:      true,
+			},
+			existing: "#group,false\r\n,result\r\n,x\r\n",
+			want: "#group,false\r\n,result\r\n,x\r\n" +
+				"\r\n" +
+				"#group,false,false,false,true,false\r\n" +
+				",result,table,_time,host,_value\r\n" +
+				",_result,0,1970-01-01T00:00:00Z,a,1.5\r\n" +
+				",_result,0,1970-01-01T00:00:01Z,a,2\r\n" +
+				",_result,1,1970-01-01T00:00:00Z,b,3\r\n",
+		},
+		{
+			name: "append without header",
+			spec: &csv.ToCSVOpSpec{
+				File:      "out.csv",
+				Delimiter: ",",
+				Append:    true,
+			},
+			existing: "1970-01-01T00:00:00Z,z,0\r\n",
+			want: "1970-01-01T00:00:00Z,z,0\r\n" +
+				"1970-01-01T00:00:00Z,a,1.5\r\n" +
+				"1970-01-01T00:00:01Z,a,2\r\n" +
+				"1970-01-01T00:00:00Z,b,3\r\n",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			files := map[string]string{}
+			if tc.existing != "" {
+				files["out.csv"] = tc.existing
+			}
+			fs := filesystem.NewMemoryFS(files)
+			ctx := filesystem.Inject(context.Background(), fs)
+
+			input := toCSVInput()
+			data := make([]flux.Table, len(input))
+			for i, tbl := range toCSVInput() {
+				data[i] = tbl
+			}
+			executetest.ProcessTestHelper2(
+				t,
+				data,
+				input,
+				nil,
+				func(id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset) {
+					tr, d, err := csv.NewToCSVTransformation(ctx, id, &csv.ToCSVProcedureSpec{Spec: tc.spec})
+					if err != nil {
+						t.Fatal(err)
+					}
+					return tr, d
+				},
+			)
+
+			got, err := filesystem.ReadFile(ctx, "out.csv")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := tc.want; want != string(got) {
+				t.Errorf("unexpected file contents -want/+got:\n\t- %q\n\t+ %q", want, string(got))
+			}
+		})
+	}
+}
+
+func TestToCSV_ReadOnly(t *testing.T) {
+	ctx := filesystem.Inject(context.Background(), filesystem.SystemFS)
+	spec := &csv.ToCSVProcedureSpec{
+		Spec: &csv.ToCSVOpSpec{File: "out.csv", Header: true, Delimiter: ","},
+	}
+	if _, _, err := csv.NewToCSVTransformation(ctx, executetest.RandomDatasetID(), spec); err == nil {
+		t.Fatal("expected error")
+	} else if want, got := codes.PermissionDenied, errors.Code(err); want != got {
+		t.Fatalf("unexpected error code -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+}
+
+type auditRecorder struct {
+	mu     sync.Mutex
+	events []audit.Event
+}
+
+func (r *auditRecorder) Audit(ctx context.Context, e audit.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+func TestToCSV_Audit(t *testing.T) {
+	r := &auditRecorder{}
+	ctx := filesystem.Inject(context.Background(), filesystem.NewMemoryFS(nil))
+	ctx = audit.Inject(ctx, r)
+
+	input := toCSVInput()
+	data := make([]flux.Table, len(input))
+	for i, tbl := range toCSVInput() {
+		data[i] = tbl
+	}
+	spec := &csv.ToCSVProcedureSpec{
+		Spec: &csv.ToCSVOpSpec{File: "out.csv", Header: true, Delimiter: ","},
+	}
+	executetest.ProcessTestHelper2(
+		t,
+		data,
+		input,
+		nil,
+		func(id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset) {
+			tr, d, err := csv.NewToCSVTransformation(ctx, id, spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return tr, d
+		},
+	)
+
+	contents, err := filesystem.ReadFile(ctx, "out.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 1, len(r.events); want != got {
+		t.Fatalf("unexpected number of audit events -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+	e := r.events[0]
+	if want, got := "csv.to", e.Function; want != got {
+		t.Errorf("unexpected function -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+	if want, got := "out.csv", e.Destination; want != got {
+		t.Errorf("unexpected destination -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+	if want, got := int64(3), e.Rows; want != got {
+		t.Errorf("unexpected rows -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+	if want, got := int64(len(contents)), e.Bytes; want != got {
+		t.Errorf("unexpected bytes -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+	if want, got := audit.Success, e.Outcome(); want != got {
+		t.Errorf("unexpected outcome -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+}
+
+func TestToCSV_DryRun(t *testing.T) {
+	r := &auditRecorder{}
+	ctx := filesystem.Inject(context.Background(), filesystem.NewMemoryFS(nil))
+	ctx = audit.Inject(ctx, r)
+	sink := dryrun.NewSink()
+	ctx = dryrun.Inject(ctx, sink)
+
+	input := toCSVInput()
+	data := make([]flux.Table, len(input))
+	for i, tbl := range toCSVInput() {
+		data[i] = tbl
+	}
+	spec := &csv.ToCSVProcedureSpec{
+		Spec: &csv.ToCSVOpSpec{File: "out.csv", Delimiter: ","},
+	}
+	executetest.ProcessTestHelper2(
+		t,
+		data,
+		input,
+		nil,
+		func(id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset) {
+			tr, d, err := csv.NewToCSVTransformation(ctx, id, spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return tr, d
+		},
+	)
+
+	if _, err := filesystem.Stat(ctx, "out.csv"); err == nil {
+		t.Error("expected the file not to be written")
+	}
+	if len(r.events) != 0 {
+		t.Errorf("expected no audit events, got %d", len(r.events))
+	}
+	payloads := sink.Payloads()
+	if want, got := 1, len(payloads); want != got {
+		t.Fatalf("unexpected number of payloads -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+	p := payloads[0]
+	if want, got := "csv.to", p.Function; want != got {
+		t.Errorf("unexpected function -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+	if want, got := "out.csv", p.Destination; want != got {
+		t.Errorf("unexpected destination -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+	if want, got := int64(3), p.Rows; want != got {
+		t.Errorf("unexpected rows -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+	want := "1970-01-01T00:00:00Z,a,1.5\r\n" +
+		"1970-01-01T00:00:01Z,a,2\r\n" +
+		"1970-01-01T00:00:00Z,b,3\r\n"
+	if got := string(p.Data); want != got {
+		t.Errorf("unexpected payload -want/+got:\n\t- %q\n\t+ %q", want, got)
+	}
+}
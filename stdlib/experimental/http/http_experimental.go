@@ -11,6 +11,7 @@ import (
 
 	"github.com/InfluxCommunity/flux"
 	"github.com/InfluxCommunity/flux/codes"
+	furl "github.com/InfluxCommunity/flux/dependencies/url"
 	"github.com/InfluxCommunity/flux/internal/errors"
 	"github.com/InfluxCommunity/flux/runtime"
 	"github.com/InfluxCommunity/flux/semantic"
@@ -38,7 +39,7 @@ var get = values.NewFunction(
 		if err != nil {
 			return nil, err
 		}
-		if err := validator.Validate(u); err != nil {
+		if err := furl.ForFunction(validator, "experimental/http.get").Validate(u); err != nil {
 			return nil, errors.New(codes.Invalid, "no such host")
 		}
 
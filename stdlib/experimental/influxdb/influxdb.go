@@ -125,7 +125,7 @@ func api(ctx context.Context, a values.Object) (values.Value, error) {
 			u.RawQuery = q.Encode()
 		}
 
-		if err := validator.Validate(u); err != nil {
+		if err := fluxurl.ForFunction(validator, "experimental/influxdb.api").Validate(u); err != nil {
 			return nil, err
 		}
 
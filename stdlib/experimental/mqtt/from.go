@@ -0,0 +1,411 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/dependencies/mqtt"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/internal/line"
+	"github.com/InfluxCommunity/flux/internal/ndjson"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/InfluxCommunity/flux/values"
+	protocol "github.com/influxdata/line-protocol"
+)
+
+const (
+	FromMQTTKind = "fromMQTT"
+
+	DefaultFromDuration    = 10 * time.Second
+	DefaultFromMaxMessages = 10000
+	DefaultTopicColLabel   = "topic"
+)
+
+var formats = []string{"line", "json"}
+
+func init() {
+	fromMQTTSignature := runtime.MustLookupBuiltinType("experimental/mqtt", "from")
+
+	runtime.RegisterPackageValue("experimental/mqtt", "from", flux.MustValue(flux.FunctionValue(FromMQTTKind, createFromMQTTOpSpec, fromMQTTSignature)))
+	plan.RegisterProcedureSpec(FromMQTTKind, newFromMQTTProcedure, FromMQTTKind)
+	execute.RegisterSource(FromMQTTKind, createFromMQTTSource)
+}
+
+type FromMQTTOpSpec struct {
+	CommonMQTTOpSpec
+	Topic       string        `json:"topic"`
+	Duration    time.Duration `json:"duration"`
+	MaxMessages int64         `json:"maxMessages"`
+	Format      string        `json:"format"`
+}
+
+// ReadArgs loads a flux.Arguments into FromMQTTOpSpec. It sets several default values.
+// If the duration isn't set, it defaults to DefaultFromDuration.
+// If maxMessages isn't set, it defaults to DefaultFromMaxMessages.
+// If the format isn't set, it defaults to line protocol.
+func (o *FromMQTTOpSpec) ReadArgs(args flux.Arguments) error {
+	if err := o.CommonMQTTOpSpec.ReadArgs(args); err != nil {
+		return err
+	}
+
+	topic, err := args.GetRequiredString("topic")
+	if err != nil {
+		return err
+	}
+	if topic == "" {
+		return errors.New(codes.Invalid, "empty topic")
+	}
+	o.Topic = topic
+
+	duration, ok, err := args.GetDuration("duration")
+	if err != nil {
+		return err
+	}
+	if ok {
+		o.Duration = values.Duration(duration).Duration()
+		if o.Duration <= 0 {
+			return errors.New(codes.Invalid, "duration must be positive")
+		}
+	} else {
+		o.Duration = DefaultFromDuration
+	}
+
+	maxMessages, ok, err := args.GetInt("maxMessages")
+	if err != nil {
+		return err
+	}
+	if ok {
+		if maxMessages <= 0 {
+			return errors.New(codes.Invalid, "maxMessages must be positive")
+		}
+		o.MaxMessages = maxMessages
+	} else {
+		o.MaxMessages = DefaultFromMaxMessages
+	}
+
+	format, ok, err := args.GetString("format")
+	if err != nil {
+		return err
+	}
+	if ok {
+		o.Format = format
+	} else {
+		o.Format = formats[0]
+	}
+	if !contains(formats, o.Format) {
+		return errors.Newf(codes.Invalid, "invalid format %s, must be one of %v", o.Format, formats)
+	}
+	return nil
+}
+
+func contains(ss []string, s string) bool {
+	for _, st := range ss {
+		if st == s {
+			return true
+		}
+	}
+	return false
+}
+
+func createFromMQTTOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
+	s := new(FromMQTTOpSpec)
+	if err := s.ReadArgs(args); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (FromMQTTOpSpec) Kind() flux.OperationKind {
+	return FromMQTTKind
+}
+
+type FromMQTTProcedureSpec struct {
+	plan.DefaultCost
+	Spec *FromMQTTOpSpec
+}
+
+func newFromMQTTProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
+	spec, ok := qs.(*FromMQTTOpSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", qs)
+	}
+	return &FromMQTTProcedureSpec{Spec: spec}, nil
+}
+
+func (s *FromMQTTProcedureSpec) Kind() plan.ProcedureKind {
+	return FromMQTTKind
+}
+
+func (s *FromMQTTProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := *s.Spec
+	return &FromMQTTProcedureSpec{Spec: &ns}
+}
+
+// nowTimeProvider provides wall clock time.
+type nowTimeProvider struct{}
+
+func (nowTimeProvider) CurrentTime() values.Time {
+	return values.ConvertTime(time.Now())
+}
+
+func createFromMQTTSource(s plan.ProcedureSpec, dsid execute.DatasetID, a execute.Administration) (execute.Source, error) {
+	spec, ok := s.(*FromMQTTProcedureSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", s)
+	}
+	return NewFromMQTTSource(spec, nowTimeProvider{}, dsid, a)
+}
+
+// NewFromMQTTSource creates a source that subscribes to the topic in the spec
+// and decodes the messages it receives. The time provider is used to stamp
+// each message with the time it was received.
+func NewFromMQTTSource(spec *FromMQTTProcedureSpec, tp line.TimeProvider, dsid execute.DatasetID, a execute.Administration) (execute.Source, error) {
+	return execute.CreateSourceFromIterator(&mqttIterator{
+		spec:  spec.Spec,
+		tp:    tp,
+		admin: a,
+	}, dsid)
+}
+
+type message struct {
+	topic    string
+	payload  []byte
+	received values.Time
+}
+
+type mqttIterator struct {
+	spec  *FromMQTTOpSpec
+	tp    line.TimeProvider
+	admin execute.Administration
+}
+
+func (d *mqttIterator) Do(ctx context.Context, f func(flux.Table) error) error {
+	messages, err := d.collect(ctx)
+	if err != nil {
+		return err
+	}
+
+	var tables []flux.Table
+	switch d.spec.Format {
+	case "json":
+		tables, err = d.decodeJSON(messages)
+	default:
+		tables, err = d.decodeLine(messages)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, tbl := range tables {
+		if err := f(tbl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collect subscribes to the topic and collects messages until the
+// duration has elapsed or the maximum number of messages has been received.
+func (d *mqttIterator) collect(ctx context.Context) ([]message, error) {
+	options := mqtt.Options{
+		ClientID: d.spec.ClientID,
+		Username: d.spec.Username,
+		Password: d.spec.Password,
+		Timeout:  d.spec.Timeout,
+		Function: "experimental/mqtt.from",
+	}
+	provider := mqtt.GetDialer(ctx)
+	client, err := provider.Dial(ctx, []string{d.spec.Broker}, options)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = client.Close() }()
+
+	var (
+		mu       sync.Mutex
+		messages []message
+		done     = make(chan struct{})
+		closed   bool
+	)
+	handler := func(topic string, payload []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		if closed {
+			return
+		}
+		messages = append(messages, message{
+			topic:    topic,
+			payload:  payload,
+			received: d.tp.CurrentTime(),
+		})
+		if d.spec.MaxMessages > 0 && int64(len(messages)) >= d.spec.MaxMessages {
+			closed = true
+			close(done)
+		}
+	}
+	if err := client.Subscribe(ctx, d.spec.Topic, byte(d.spec.QoS), handler); err != nil {
+		return nil, errors.Wrap(err, codes.Inherit, "mqtt subscribe")
+	}
+
+	timer := time.NewTimer(d.spec.Duration)
+	select {
+	case <-done:
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	timer.Stop()
+
+	mu.Lock()
+	closed = true
+	collected := messages
+	mu.Unlock()
+
+	if err := client.Unsubscribe(ctx, d.spec.Topic); err != nil {
+		return nil, errors.Wrap(err, codes.Inherit, "mqtt unsubscribe")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return collected, nil
+}
+
+// tableSet collects rows into table builders indexed by group key.
+type tableSet struct {
+	admin    execute.Administration
+	builders map[string]*execute.ColListTableBuilder
+	order    []string
+}
+
+func newTableSet(a execute.Administration) *tableSet {
+	return &tableSet{
+		admin:    a,
+		builders: make(map[string]*execute.ColListTableBuilder),
+	}
+}
+
+func (ts *tableSet) builder(key flux.GroupKey) *execute.ColListTableBuilder {
+	id := key.String()
+	b, ok := ts.builders[id]
+	if !ok {
+		b = execute.NewColListTableBuilder(key, ts.admin.Allocator())
+		ts.builders[id] = b
+		ts.order = append(ts.order, id)
+	}
+	return b
+}
+
+func (ts *tableSet) tables() ([]flux.Table, error) {
+	tables := make([]flux.Table, 0, len(ts.order))
+	for _, id := range ts.order {
+		tbl, err := ts.builders[id].Table()
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, tbl)
+	}
+	return tables, nil
+}
+
+// decodeLine decodes each message as line protocol. Each field of a point
+// produces one row grouped by measurement, tags, field and topic.
+func (d *mqttIterator) decodeLine(messages []message) ([]flux.Table, error) {
+	ts := newTableSet(d.admin)
+	for _, m := range messages {
+		handler := protocol.NewMetricHandler()
+		handler.SetTimeFunc(func() time.Time {
+			return m.received.Time()
+		})
+		metrics, err := protocol.NewParser(handler).Parse(m.payload)
+		if err != nil {
+			return nil, errors.Wrapf(err, codes.Invalid, "failed to parse line protocol from topic %q", m.topic)
+		}
+		for _, metric := range metrics {
+			for _, field := range metric.FieldList() {
+				v := values.New(field.Value)
+				if v == values.InvalidValue {
+					return nil, errors.Newf(codes.Invalid, "unsupported field type %T for field %q", field.Value, field.Key)
+				}
+
+				keyCols := []flux.ColMeta{
+					{Label: DefaultNameColLabel, Type: flux.TString},
+					{Label: "_field", Type: flux.TString},
+					{Label: DefaultTopicColLabel, Type: flux.TString},
+				}
+				keyValues := []values.Value{
+					values.NewString(metric.Name()),
+					values.NewString(field.Key),
+					values.NewString(m.topic),
+				}
+				row := map[string]values.Value{
+					execute.DefaultTimeColLabel:  values.NewTime(values.ConvertTime(metric.Time())),
+					execute.DefaultValueColLabel: v,
+					DefaultNameColLabel:          keyValues[0],
+					"_field":                     keyValues[1],
+					DefaultTopicColLabel:         keyValues[2],
+				}
+				for _, tag := range metric.TagList() {
+					tv := values.NewString(tag.Value)
+					keyCols = append(keyCols, flux.ColMeta{Label: tag.Key, Type: flux.TString})
+					keyValues = append(keyValues, tv)
+					row[tag.Key] = tv
+				}
+
+				key := execute.NewGroupKey(keyCols, keyValues)
+				if err := ndjson.AppendRow(ts.builder(key), row); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return ts.tables()
+}
+
+// decodeJSON decodes each message as a JSON object or an array of JSON objects.
+// The top-level keys of each object become columns. Rows are grouped by topic.
+func (d *mqttIterator) decodeJSON(messages []message) ([]flux.Table, error) {
+	ts := newTableSet(d.admin)
+	for _, m := range messages {
+		var objects []map[string]json.RawMessage
+		payload := strings.TrimSpace(string(m.payload))
+		if strings.HasPrefix(payload, "[") {
+			if err := json.Unmarshal([]byte(payload), &objects); err != nil {
+				return nil, errors.Wrapf(err, codes.Invalid, "failed to parse json from topic %q", m.topic)
+			}
+		} else {
+			var obj map[string]json.RawMessage
+			if err := json.Unmarshal([]byte(payload), &obj); err != nil {
+				return nil, errors.Wrapf(err, codes.Invalid, "failed to parse json from topic %q", m.topic)
+			}
+			objects = append(objects, obj)
+		}
+
+		topic := values.NewString(m.topic)
+		key := execute.NewGroupKey(
+			[]flux.ColMeta{{Label: DefaultTopicColLabel, Type: flux.TString}},
+			[]values.Value{topic},
+		)
+		for _, obj := range objects {
+			row := make(map[string]values.Value, len(obj)+2)
+			for k, v := range obj {
+				jv, err := ndjson.Value(v)
+				if err != nil {
+					return nil, err
+				}
+				row[k] = jv
+			}
+			row[execute.DefaultTimeColLabel] = values.NewTime(m.received)
+			row[DefaultTopicColLabel] = topic
+			if err := ndjson.AppendRow(ts.builder(key), row); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ts.tables()
+}
@@ -0,0 +1,269 @@
+package mqtt_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	mqttdep "github.com/InfluxCommunity/flux/dependencies/mqtt"
+	"github.com/InfluxCommunity/flux/dependency"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/executetest"
+	_ "github.com/InfluxCommunity/flux/fluxinit/static"
+	"github.com/InfluxCommunity/flux/internal/operation"
+	"github.com/InfluxCommunity/flux/mock"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/querytest"
+	"github.com/InfluxCommunity/flux/stdlib/experimental/mqtt"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFromMQTT_NewQuery(t *testing.T) {
+	tests := []querytest.NewQueryTestCase{
+		{
+			Name: "from without topic",
+			Raw: `
+import "experimental/mqtt"
+mqtt.from(broker: "tcp://iot.eclipse.org:1883")`,
+			WantErr: true, // topic is required
+		},
+		{
+			Name: "from with invalid format",
+			Raw: `
+import "experimental/mqtt"
+mqtt.from(broker: "tcp://iot.eclipse.org:1883", topic: "sensors", format: "xml")`,
+			WantErr: true,
+		},
+		{
+			Name: "from with negative maxMessages",
+			Raw: `
+import "experimental/mqtt"
+mqtt.from(broker: "tcp://iot.eclipse.org:1883", topic: "sensors", maxMessages: -1)`,
+			WantErr: true,
+		},
+		{
+			Name: "from with defaults",
+			Raw: `
+import "experimental/mqtt"
+mqtt.from(broker: "tcp://iot.eclipse.org:1883", topic: "sensors/#")`,
+			Want: &operation.Spec{
+				Operations: []*operation.Node{
+					{
+						ID: "fromMQTT0",
+						Spec: &mqtt.FromMQTTOpSpec{
+							CommonMQTTOpSpec: mqtt.CommonMQTTOpSpec{
+								Broker:   "tcp://iot.eclipse.org:1883",
+								ClientID: "flux-mqtt",
+								Timeout:  1 * time.Second,
+							},
+							Topic:       "sensors/#",
+							Duration:    mqtt.DefaultFromDuration,
+							MaxMessages: mqtt.DefaultFromMaxMessages,
+							Format:      "line",
+						},
+					},
+				},
+			},
+		},
+		{
+			Name: "from with bounds",
+			Raw: `
+import "experimental/mqtt"
+mqtt.from(broker: "tcp://iot.eclipse.org:1883", topic: "sensors", qos: 1, duration: 1m, maxMessages: 5, format: "json")`,
+			Want: &operation.Spec{
+				Operations: []*operation.Node{
+					{
+						ID: "fromMQTT0",
+						Spec: &mqtt.FromMQTTOpSpec{
+							CommonMQTTOpSpec: mqtt.CommonMQTTOpSpec{
+								Broker:   "tcp://iot.eclipse.org:1883",
+								ClientID: "flux-mqtt",
+								QoS:      1,
+								Timeout:  1 * time.Second,
+							},
+							Topic:       "sensors",
+							Duration:    time.Minute,
+							MaxMessages: 5,
+							Format:      "json",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			querytest.NewQueryTestHelper(t, tc)
+		})
+	}
+}
+
+func TestFromMQTT_Run(t *testing.T) {
+	type msg struct {
+		topic   string
+		payload string
+	}
+	testCases := []struct {
+		name     string
+		spec     *mqtt.FromMQTTOpSpec
+		messages []msg
+		want     []*executetest.Table
+		wantErr  bool
+	}{
+		{
+			name: "line protocol",
+			spec: &mqtt.FromMQTTOpSpec{
+				Topic:       "sensors/#",
+				Duration:    time.Minute,
+				MaxMessages: 2,
+				Format:      "line",
+			},
+			messages: []msg{
+				{topic: "sensors/a", payload: "cpu,host=a usage=1.5 10\ncpu,host=a usage=2.5 20"},
+				{topic: "sensors/b", payload: "cpu,host=b usage=3i"},
+				{topic: "sensors/c", payload: "cpu,host=c usage=4.5 40"},
+			},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"_measurement", "_field", "topic", "host"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_field", Type: flux.TString},
+						{Label: "_measurement", Type: flux.TString},
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TFloat},
+						{Label: "host", Type: flux.TString},
+						{Label: "topic", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{"usage", "cpu", execute.Time(10), 1.5, "a", "sensors/a"},
+						{"usage", "cpu", execute.Time(20), 2.5, "a", "sensors/a"},
+					},
+				},
+				{
+					KeyCols: []string{"_measurement", "_field", "topic", "host"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_field", Type: flux.TString},
+						{Label: "_measurement", Type: flux.TString},
+						{Label: "_time", Type: flux.TTime},
+						{Label: "_value", Type: flux.TInt},
+						{Label: "host", Type: flux.TString},
+						{Label: "topic", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{"usage", "cpu", execute.Time(1), int64(3), "b", "sensors/b"},
+					},
+				},
+			},
+		},
+		{
+			name: "json",
+			spec: &mqtt.FromMQTTOpSpec{
+				Topic:    "sensors",
+				Duration: 10 * time.Millisecond,
+				Format:   "json",
+			},
+			messages: []msg{
+				{topic: "sensors", payload: `{"temp": 21.5, "id": "a"}`},
+				{topic: "sensors", payload: `[{"temp": 22.5, "ok": true}, {"temp": null, "meta": {"v": 1}}]`},
+			},
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"topic"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "id", Type: flux.TString},
+						{Label: "temp", Type: flux.TFloat},
+						{Label: "topic", Type: flux.TString},
+						{Label: "ok", Type: flux.TBool},
+						{Label: "meta", Type: flux.TString},
+					},
+					Data: [][]interface{}{
+						{execute.Time(0), "a", 21.5, "sensors", nil, nil},
+						{execute.Time(1), nil, 22.5, "sensors", true, nil},
+						{execute.Time(1), nil, nil, "sensors", nil, `{"v":1}`},
+					},
+				},
+			},
+		},
+		{
+			name: "invalid json",
+			spec: &mqtt.FromMQTTOpSpec{
+				Topic:    "sensors",
+				Duration: 10 * time.Millisecond,
+				Format:   "json",
+			},
+			messages: []msg{
+				{topic: "sensors", payload: `{"temp": `},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			unsubscribed := false
+			dialer := mock.MqttDialer{
+				DialFn: func(ctx context.Context, brokers []string, options mqttdep.Options) (mqttdep.Client, error) {
+					return mock.MqttClient{
+						SubscribeFn: func(ctx context.Context, topic string, qos byte, handler mqttdep.MessageHandler) error {
+							if want, got := tc.spec.Topic, topic; want != got {
+								t.Errorf("unexpected topic -want/+got:\n\t- %s\n\t+ %s", want, got)
+							}
+							for _, m := range tc.messages {
+								handler(m.topic, []byte(m.payload))
+							}
+							return nil
+						},
+						UnsubscribeFn: func(ctx context.Context, topics ...string) error {
+							unsubscribed = true
+							return nil
+						},
+					}, nil
+				},
+			}
+			ctx, deps := dependency.Inject(context.Background(), mqttdep.Dependency{Dialer: dialer})
+			defer deps.Finish()
+
+			id := executetest.RandomDatasetID()
+			d := executetest.NewDataset(id)
+			c := execute.NewTableBuilderCache(executetest.UnlimitedAllocator)
+			c.SetTriggerSpec(plan.DefaultTriggerSpec)
+
+			spec := &mqtt.FromMQTTProcedureSpec{Spec: tc.spec}
+			src, err := mqtt.NewFromMQTTSource(spec, &mock.AscendingTimeProvider{}, id, mock.AdministrationWithContext(ctx))
+			if err != nil {
+				t.Fatal(err)
+			}
+			src.AddTransformation(executetest.NewYieldTransformation(d, c))
+			src.Run(ctx)
+
+			if !unsubscribed {
+				t.Error("expected topic to be unsubscribed")
+			}
+			if tc.wantErr {
+				if d.FinishedErr == nil {
+					t.Fatal("expected error")
+				}
+				return
+			} else if d.FinishedErr != nil {
+				t.Fatal(d.FinishedErr)
+			}
+
+			got, err := executetest.TablesFromCache(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			executetest.NormalizeTables(got)
+			executetest.NormalizeTables(tc.want)
+
+			if !cmp.Equal(tc.want, got) {
+				t.Errorf("unexpected tables -want/+got\n%s", cmp.Diff(tc.want, got))
+			}
+		})
+	}
+}
@@ -2,11 +2,16 @@ package mqtt
 
 import (
 	"context"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/InfluxCommunity/flux"
 	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/dependencies/audit"
+	"github.com/InfluxCommunity/flux/dependencies/dryrun"
 	"github.com/InfluxCommunity/flux/dependencies/mqtt"
+	furl "github.com/InfluxCommunity/flux/dependencies/url"
 	"github.com/InfluxCommunity/flux/internal/errors"
 	"github.com/InfluxCommunity/flux/values"
 )
@@ -89,12 +94,37 @@ func (o *CommonMQTTOpSpec) ReadArgs(args flux.Arguments) error {
 	return nil
 }
 
-func publish(ctx context.Context, topic, message string, spec *CommonMQTTOpSpec) (bool, error) {
+func publish(ctx context.Context, function, topic, message string, rows int64, spec *CommonMQTTOpSpec) (published bool, err error) {
+	destination := audit.URL(spec.Broker) + "/" + strings.TrimPrefix(topic, "/")
+	if sink := dryrun.GetSink(ctx); sink != nil {
+		if err := validateBroker(ctx, function, spec.Broker); err != nil {
+			return false, err
+		}
+		sink.Capture(dryrun.Payload{
+			Function:    function,
+			Destination: destination,
+			Rows:        rows,
+			Data:        []byte(message),
+		})
+		return true, nil
+	}
+
+	defer func() {
+		audit.Record(ctx, audit.Event{
+			Function:    function,
+			Destination: destination,
+			Bytes:       int64(len(message)),
+			Rows:        rows,
+			Err:         err,
+		})
+	}()
+
 	options := mqtt.Options{
 		ClientID: spec.ClientID,
 		Username: spec.Username,
 		Password: spec.Password,
 		Timeout:  spec.Timeout,
+		Function: function,
 	}
 	provider := mqtt.GetDialer(ctx)
 	client, err := provider.Dial(ctx, []string{spec.Broker}, options)
@@ -108,3 +138,17 @@ func publish(ctx context.Context, topic, message string, spec *CommonMQTTOpSpec)
 	}
 	return true, nil
 }
+
+// validateBroker validates the broker url the way the dialer does,
+// for when the message is not published.
+func validateBroker(ctx context.Context, function, broker string) error {
+	u, err := url.Parse(broker)
+	if err != nil {
+		return errors.Wrap(err, codes.Invalid, "invalid broker url")
+	}
+	validator, err := flux.GetDependencies(ctx).URLValidator()
+	if err != nil {
+		return err
+	}
+	return furl.ForFunction(validator, function).Validate(u)
+}
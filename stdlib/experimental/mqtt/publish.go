@@ -38,7 +38,7 @@ func init() {
 					return nil, errors.New(codes.Invalid, "empty message")
 				}
 
-				published, err := publish(ctx, topic, message, spec)
+				published, err := publish(ctx, "experimental/mqtt.publish", topic, message, 0, spec)
 				if err != nil {
 					return nil, err
 				}
@@ -302,10 +302,14 @@ func (t *ToMQTTTransformation) Process(id execute.DatasetID, tbl flux.Table) err
 	// The whole table content is sent afterwards in a single MQTT message.
 	// Unlike kafka.to(), with very similar code but different in this regard.
 
-	var wg syncutil.WaitGroup
+	var (
+		wg   syncutil.WaitGroup
+		rows int64
+	)
 	wg.Do(func() error {
 		err := tbl.Do(func(er flux.ColReader) error {
 			l := er.Len()
+			rows += int64(l)
 			for i := 0; i < l; i++ {
 				m.truncateTagsAndFields()
 				for j, col := range er.Cols() {
@@ -367,7 +371,7 @@ func (t *ToMQTTTransformation) Process(id execute.DatasetID, tbl flux.Table) err
 			topic = m.createTopic(message)
 		}
 		spec := &t.spec.Spec.CommonMQTTOpSpec
-		publish(t.ctx, topic, message, spec)
+		publish(t.ctx, "experimental/mqtt.to", topic, message, rows, spec)
 	}
 
 	return nil
@@ -0,0 +1,365 @@
+package prometheus
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/golang/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The remote read and write protocols exchange snappy compressed
+// protocol buffers. The messages below mirror the subset of the
+// prompb package that is used by the protocols.
+
+const (
+	remoteReadVersion  = "0.1.0"
+	remoteWriteVersion = "0.1.0"
+
+	// maxRemoteResponse is the maximum size of a remote read response
+	// after it has been decompressed.
+	maxRemoteResponse = 100 * 1024 * 1024
+)
+
+type label struct {
+	Name  string
+	Value string
+}
+
+type sample struct {
+	Value float64
+	// Timestamp is in milliseconds since the epoch.
+	Timestamp int64
+}
+
+type timeSeries struct {
+	Labels  []label
+	Samples []sample
+}
+
+type matcherType int
+
+const (
+	matchEqual matcherType = iota
+	matchNotEqual
+	matchRegexp
+	matchNotRegexp
+)
+
+type labelMatcher struct {
+	Type  matcherType
+	Name  string
+	Value string
+}
+
+type query struct {
+	StartMs  int64
+	EndMs    int64
+	Matchers []labelMatcher
+}
+
+// matcherRegexp parses label matchers in the PromQL syntax, e.g. `job=~"api.*"`.
+var matcherRegexp = regexp.MustCompile(`^\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*(=~|!~|!=|=)\s*"((?:[^"\\]|\\.)*)"\s*$`)
+
+// parseMatcher parses a label matcher such as `__name__="up"`.
+func parseMatcher(s string) (labelMatcher, error) {
+	m := matcherRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return labelMatcher{}, errors.Newf(codes.Invalid, "invalid label matcher %q", s)
+	}
+	value := strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(m[3])
+	var typ matcherType
+	switch m[2] {
+	case "=":
+		typ = matchEqual
+	case "!=":
+		typ = matchNotEqual
+	case "=~":
+		typ = matchRegexp
+	case "!~":
+		typ = matchNotRegexp
+	}
+	if typ == matchRegexp || typ == matchNotRegexp {
+		if _, err := regexp.Compile(value); err != nil {
+			return labelMatcher{}, errors.Wrapf(err, codes.Invalid, "invalid regular expression in label matcher %q", s)
+		}
+	}
+	return labelMatcher{Type: typ, Name: m[1], Value: value}, nil
+}
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
+
+func appendVarint(b []byte, num protowire.Number, v int64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(v))
+}
+
+func (l label) marshal() []byte {
+	var b []byte
+	b = appendString(b, 1, l.Name)
+	return appendString(b, 2, l.Value)
+}
+
+func (s sample) marshal() []byte {
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, math.Float64bits(s.Value))
+	return appendVarint(b, 2, s.Timestamp)
+}
+
+func (ts timeSeries) marshal() []byte {
+	var b []byte
+	for _, l := range ts.Labels {
+		b = appendMessage(b, 1, l.marshal())
+	}
+	for _, s := range ts.Samples {
+		b = appendMessage(b, 2, s.marshal())
+	}
+	return b
+}
+
+func (m labelMatcher) marshal() []byte {
+	var b []byte
+	b = appendVarint(b, 1, int64(m.Type))
+	b = appendString(b, 2, m.Name)
+	return appendString(b, 3, m.Value)
+}
+
+func (q query) marshal() []byte {
+	var b []byte
+	b = appendVarint(b, 1, q.StartMs)
+	b = appendVarint(b, 2, q.EndMs)
+	for _, m := range q.Matchers {
+		b = appendMessage(b, 3, m.marshal())
+	}
+	return b
+}
+
+// marshalWriteRequest encodes a prompb.WriteRequest.
+func marshalWriteRequest(series []timeSeries) []byte {
+	var b []byte
+	for _, ts := range series {
+		b = appendMessage(b, 1, ts.marshal())
+	}
+	return b
+}
+
+// marshalReadRequest encodes a prompb.ReadRequest.
+func marshalReadRequest(queries []query) []byte {
+	var b []byte
+	for _, q := range queries {
+		b = appendMessage(b, 1, q.marshal())
+	}
+	return b
+}
+
+// rangeFields calls fn for every field of a message.
+// For length delimited fields, v contains the field bytes.
+// For varint and fixed fields, n contains the value.
+func rangeFields(b []byte, fn func(num protowire.Number, typ protowire.Type, v []byte, n uint64) error) error {
+	for len(b) > 0 {
+		num, typ, l := protowire.ConsumeTag(b)
+		if l < 0 {
+			return protowire.ParseError(l)
+		}
+		b = b[l:]
+		var (
+			v []byte
+			n uint64
+		)
+		switch typ {
+		case protowire.VarintType:
+			n, l = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			n, l = protowire.ConsumeFixed64(b)
+		case protowire.Fixed32Type:
+			var n32 uint32
+			n32, l = protowire.ConsumeFixed32(b)
+			n = uint64(n32)
+		case protowire.BytesType:
+			v, l = protowire.ConsumeBytes(b)
+		default:
+			l = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if l < 0 {
+			return protowire.ParseError(l)
+		}
+		b = b[l:]
+		if err := fn(num, typ, v, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func unmarshalLabel(b []byte) (label, error) {
+	var l label
+	err := rangeFields(b, func(num protowire.Number, typ protowire.Type, v []byte, n uint64) error {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			l.Name = string(v)
+		case num == 2 && typ == protowire.BytesType:
+			l.Value = string(v)
+		}
+		return nil
+	})
+	return l, err
+}
+
+func unmarshalSample(b []byte) (sample, error) {
+	var s sample
+	err := rangeFields(b, func(num protowire.Number, typ protowire.Type, v []byte, n uint64) error {
+		switch {
+		case num == 1 && typ == protowire.Fixed64Type:
+			s.Value = math.Float64frombits(n)
+		case num == 2 && typ == protowire.VarintType:
+			s.Timestamp = int64(n)
+		}
+		return nil
+	})
+	return s, err
+}
+
+func unmarshalTimeSeries(b []byte) (timeSeries, error) {
+	var ts timeSeries
+	err := rangeFields(b, func(num protowire.Number, typ protowire.Type, v []byte, n uint64) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1:
+			l, err := unmarshalLabel(v)
+			if err != nil {
+				return err
+			}
+			ts.Labels = append(ts.Labels, l)
+		case 2:
+			s, err := unmarshalSample(v)
+			if err != nil {
+				return err
+			}
+			ts.Samples = append(ts.Samples, s)
+		}
+		return nil
+	})
+	return ts, err
+}
+
+// unmarshalReadResponse decodes the time series of all query results of a prompb.ReadResponse.
+func unmarshalReadResponse(b []byte) ([]timeSeries, error) {
+	var series []timeSeries
+	err := rangeFields(b, func(num protowire.Number, typ protowire.Type, v []byte, n uint64) error {
+		if num != 1 || typ != protowire.BytesType {
+			return nil
+		}
+		return rangeFields(v, func(num protowire.Number, typ protowire.Type, v []byte, n uint64) error {
+			if num != 1 || typ != protowire.BytesType {
+				return nil
+			}
+			ts, err := unmarshalTimeSeries(v)
+			if err != nil {
+				return err
+			}
+			series = append(series, ts)
+			return nil
+		})
+	})
+	return series, err
+}
+
+// unmarshalWriteRequest decodes the time series of a prompb.WriteRequest.
+func unmarshalWriteRequest(b []byte) ([]timeSeries, error) {
+	var series []timeSeries
+	err := rangeFields(b, func(num protowire.Number, typ protowire.Type, v []byte, n uint64) error {
+		if num != 1 || typ != protowire.BytesType {
+			return nil
+		}
+		ts, err := unmarshalTimeSeries(v)
+		if err != nil {
+			return err
+		}
+		series = append(series, ts)
+		return nil
+	})
+	return series, err
+}
+
+// sortLabels sorts labels by name as required by the remote write protocol.
+func sortLabels(labels []label) {
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].Name < labels[j].Name
+	})
+}
+
+// doRemoteRequest posts the snappy compressed message to the url and returns
+// the decompressed response body.
+func doRemoteRequest(ctx context.Context, url string, msg []byte, header http.Header) ([]byte, error) {
+	client, err := flux.GetDependencies(ctx).HTTPClient()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(snappy.Encode(nil, msg)))
+	if err != nil {
+		return nil, err
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		code := codes.Invalid
+		if resp.StatusCode/100 == 5 {
+			code = codes.Unavailable
+		}
+		return nil, errors.Newf(code, "remote request failed with status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if len(body) == 0 {
+		return nil, nil
+	}
+
+	n, err := snappy.DecodedLen(body)
+	if err != nil {
+		return nil, errors.Wrap(err, codes.Internal, "invalid snappy response")
+	}
+	if n > maxRemoteResponse {
+		return nil, errors.New(codes.FailedPrecondition, "remote response is too large, reduce the amount of data queried")
+	}
+	decoded, err := snappy.Decode(nil, body)
+	if err != nil {
+		return nil, errors.Wrap(err, codes.Internal, "invalid snappy response")
+	}
+	return decoded, nil
+}
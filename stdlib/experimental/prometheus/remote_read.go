@@ -0,0 +1,226 @@
+package prometheus
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	furl "github.com/InfluxCommunity/flux/dependencies/url"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/InfluxCommunity/flux/semantic"
+	"github.com/InfluxCommunity/flux/values"
+)
+
+const RemoteReadKind = "prometheusRemoteRead"
+
+// metricNameLabel is the label that holds the metric name of a series.
+const metricNameLabel = "__name__"
+
+type RemoteReadOpSpec struct {
+	URL      string    `json:"url"`
+	Matchers []string  `json:"matchers"`
+	Start    flux.Time `json:"start"`
+	Stop     flux.Time `json:"stop"`
+}
+
+func init() {
+	remoteReadSignature := runtime.MustLookupBuiltinType("experimental/prometheus", "remoteRead")
+	runtime.RegisterPackageValue("experimental/prometheus", "remoteRead", flux.MustValue(flux.FunctionValue(RemoteReadKind, createRemoteReadOpSpec, remoteReadSignature)))
+	plan.RegisterProcedureSpec(RemoteReadKind, newRemoteReadProcedure, RemoteReadKind)
+	execute.RegisterSource(RemoteReadKind, createRemoteReadSource)
+}
+
+func createRemoteReadOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
+	spec := new(RemoteReadOpSpec)
+
+	if u, err := args.GetRequiredString("url"); err != nil {
+		return nil, err
+	} else {
+		spec.URL = u
+	}
+
+	matchers, err := args.GetRequiredArray("matchers", semantic.String)
+	if err != nil {
+		return nil, err
+	}
+	if matchers.Len() == 0 {
+		return nil, errors.New(codes.Invalid, "at least one label matcher is required")
+	}
+	spec.Matchers = make([]string, matchers.Len())
+	for i := range spec.Matchers {
+		spec.Matchers[i] = matchers.Get(i).Str()
+		if _, err := parseMatcher(spec.Matchers[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	if spec.Start, err = args.GetRequiredTime("start"); err != nil {
+		return nil, err
+	}
+	if stop, ok, err := args.GetTime("stop"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.Stop = stop
+	} else {
+		spec.Stop = flux.Now
+	}
+	return spec, nil
+}
+
+func (s *RemoteReadOpSpec) Kind() flux.OperationKind {
+	return RemoteReadKind
+}
+
+type RemoteReadProcedureSpec struct {
+	plan.DefaultCost
+	URL      string
+	Matchers []string
+	Bounds   flux.Bounds
+}
+
+func newRemoteReadProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
+	spec, ok := qs.(*RemoteReadOpSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", qs)
+	}
+	bounds := flux.Bounds{
+		Start: spec.Start,
+		Stop:  spec.Stop,
+		Now:   pa.Now(),
+	}
+	if bounds.IsEmpty() {
+		return nil, errors.New(codes.Invalid, "cannot query an empty range")
+	}
+	return &RemoteReadProcedureSpec{
+		URL:      spec.URL,
+		Matchers: spec.Matchers,
+		Bounds:   bounds,
+	}, nil
+}
+
+func (s *RemoteReadProcedureSpec) Kind() plan.ProcedureKind {
+	return RemoteReadKind
+}
+
+func (s *RemoteReadProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := *s
+	ns.Matchers = append([]string(nil), s.Matchers...)
+	return &ns
+}
+
+func createRemoteReadSource(prSpec plan.ProcedureSpec, dsid execute.DatasetID, a execute.Administration) (execute.Source, error) {
+	spec, ok := prSpec.(*RemoteReadProcedureSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", prSpec)
+	}
+	return execute.CreateSourceFromIterator(&remoteReadIterator{spec: spec, a: a}, dsid)
+}
+
+type remoteReadIterator struct {
+	spec *RemoteReadProcedureSpec
+	a    execute.Administration
+}
+
+func (ri *remoteReadIterator) Do(ctx context.Context, f func(flux.Table) error) error {
+	u, err := url.Parse(ri.spec.URL)
+	if err != nil {
+		return errors.Newf(codes.Invalid, "invalid url: %v", err)
+	}
+	validator, err := flux.GetDependencies(ctx).URLValidator()
+	if err != nil {
+		return err
+	}
+	if err := furl.ForFunction(validator, "experimental/prometheus.remoteRead").Validate(u); err != nil {
+		return err
+	}
+
+	q := query{
+		StartMs:  ri.spec.Bounds.Start.Time(ri.spec.Bounds.Now).UnixNano() / 1e6,
+		EndMs:    ri.spec.Bounds.Stop.Time(ri.spec.Bounds.Now).UnixNano() / 1e6,
+		Matchers: make([]labelMatcher, len(ri.spec.Matchers)),
+	}
+	for i, s := range ri.spec.Matchers {
+		if q.Matchers[i], err = parseMatcher(s); err != nil {
+			return err
+		}
+	}
+
+	header := make(http.Header)
+	header.Set("X-Prometheus-Remote-Read-Version", remoteReadVersion)
+	body, err := doRemoteRequest(ctx, ri.spec.URL, marshalReadRequest([]query{q}), header)
+	if err != nil {
+		return err
+	}
+	series, err := unmarshalReadResponse(body)
+	if err != nil {
+		return errors.Wrap(err, codes.Internal, "failed to decode remote read response")
+	}
+
+	for _, ts := range series {
+		tbl, err := ri.seriesTable(ts)
+		if err != nil {
+			return err
+		}
+		if err := f(tbl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seriesTable converts a series into a table in the same shape as scrape.
+// The metric name becomes the _field and every other label
+// becomes a column in the group key.
+func (ri *remoteReadIterator) seriesTable(ts timeSeries) (flux.Table, error) {
+	sortLabels(ts.Labels)
+
+	field := ""
+	keyCols := []flux.ColMeta{
+		{Label: "_measurement", Type: flux.TString},
+		{Label: "_field", Type: flux.TString},
+	}
+	keyValues := []values.Value{values.NewString("prometheus"), nil}
+	for _, l := range ts.Labels {
+		if l.Name == metricNameLabel {
+			field = l.Value
+			continue
+		}
+		keyCols = append(keyCols, flux.ColMeta{Label: l.Name, Type: flux.TString})
+		keyValues = append(keyValues, values.NewString(l.Value))
+	}
+	keyValues[1] = values.NewString(field)
+	key := execute.NewGroupKey(keyCols, keyValues)
+
+	builder := execute.NewColListTableBuilder(key, ri.a.Allocator())
+	if _, err := builder.AddCol(flux.ColMeta{Label: execute.DefaultTimeColLabel, Type: flux.TTime}); err != nil {
+		return nil, err
+	}
+	if _, err := builder.AddCol(flux.ColMeta{Label: execute.DefaultValueColLabel, Type: flux.TFloat}); err != nil {
+		return nil, err
+	}
+	if err := execute.AddTableKeyCols(key, builder); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(ts.Samples, func(i, j int) bool {
+		return ts.Samples[i].Timestamp < ts.Samples[j].Timestamp
+	})
+	for _, s := range ts.Samples {
+		if err := builder.AppendTime(0, values.Time(s.Timestamp*1e6)); err != nil {
+			return nil, err
+		}
+		if err := builder.AppendFloat(1, s.Value); err != nil {
+			return nil, err
+		}
+		if err := execute.AppendKeyValues(key, builder); err != nil {
+			return nil, err
+		}
+	}
+	return builder.Table()
+}
@@ -0,0 +1,264 @@
+package prometheus
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/executetest"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/mock"
+	"github.com/golang/snappy"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestParseMatcher(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		want    labelMatcher
+		wantErr bool
+	}{
+		{in: `__name__="up"`, want: labelMatcher{Type: matchEqual, Name: "__name__", Value: "up"}},
+		{in: `job != "api"`, want: labelMatcher{Type: matchNotEqual, Name: "job", Value: "api"}},
+		{in: `job=~"api.*"`, want: labelMatcher{Type: matchRegexp, Name: "job", Value: "api.*"}},
+		{in: `path!~"/a\"b"`, want: labelMatcher{Type: matchNotRegexp, Name: "path", Value: `/a"b`}},
+		{in: `job=api`, wantErr: true},
+		{in: `job=~"("`, wantErr: true},
+	} {
+		got, err := parseMatcher(tc.in)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected error", tc.in)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.in, err)
+			continue
+		}
+		if !cmp.Equal(tc.want, got) {
+			t.Errorf("%s: unexpected matcher -want/+got\n%s", tc.in, cmp.Diff(tc.want, got))
+		}
+	}
+}
+
+// marshalReadResponse encodes a prompb.ReadResponse with a single query result.
+func marshalReadResponse(series []timeSeries) []byte {
+	var result []byte
+	for _, ts := range series {
+		result = appendMessage(result, 1, ts.marshal())
+	}
+	return appendMessage(nil, 1, result)
+}
+
+// unmarshalReadRequest decodes the first query of a prompb.ReadRequest.
+func unmarshalReadRequest(b []byte) (query, error) {
+	var q query
+	err := rangeFields(b, func(num protowire.Number, typ protowire.Type, v []byte, n uint64) error {
+		if num != 1 {
+			return nil
+		}
+		return rangeFields(v, func(num protowire.Number, typ protowire.Type, v []byte, n uint64) error {
+			switch num {
+			case 1:
+				q.StartMs = int64(n)
+			case 2:
+				q.EndMs = int64(n)
+			case 3:
+				var m labelMatcher
+				if err := rangeFields(v, func(num protowire.Number, typ protowire.Type, v []byte, n uint64) error {
+					switch num {
+					case 1:
+						m.Type = matcherType(n)
+					case 2:
+						m.Name = string(v)
+					case 3:
+						m.Value = string(v)
+					}
+					return nil
+				}); err != nil {
+					return err
+				}
+				q.Matchers = append(q.Matchers, m)
+			}
+			return nil
+		})
+	})
+	return q, err
+}
+
+func readSnappyBody(t *testing.T, r *http.Request) []byte {
+	t.Helper()
+	if want, got := "snappy", r.Header.Get("Content-Encoding"); want != got {
+		t.Errorf("unexpected content encoding -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := snappy.Decode(nil, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+func TestRemoteRead(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if want, got := remoteReadVersion, r.Header.Get("X-Prometheus-Remote-Read-Version"); want != got {
+			t.Errorf("unexpected remote read version -want/+got:\n\t- %s\n\t+ %s", want, got)
+		}
+		q, err := unmarshalReadRequest(readSnappyBody(t, r))
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantQuery := query{
+			StartMs: 1000,
+			EndMs:   5000,
+			Matchers: []labelMatcher{
+				{Type: matchEqual, Name: "__name__", Value: "http_duration_bucket"},
+				{Type: matchRegexp, Name: "job", Value: "api.*"},
+			},
+		}
+		if !cmp.Equal(wantQuery, q) {
+			t.Errorf("unexpected query -want/+got\n%s", cmp.Diff(wantQuery, q))
+		}
+		_, _ = w.Write(snappy.Encode(nil, marshalReadResponse([]timeSeries{
+			{
+				Labels:  []label{{"__name__", "http_duration_bucket"}, {"le", "0.5"}, {"job", "api"}},
+				Samples: []sample{{Value: 2, Timestamp: 2000}, {Value: 1, Timestamp: 1000}},
+			},
+			{
+				Labels:  []label{{"job", "api"}, {"le", "+Inf"}, {"__name__", "http_duration_bucket"}},
+				Samples: []sample{{Value: 3, Timestamp: 1000}},
+			},
+		})))
+	}))
+	defer ts.Close()
+
+	ctx := flux.NewDefaultDependencies().Inject(context.Background())
+	spec := &RemoteReadProcedureSpec{
+		URL:      ts.URL,
+		Matchers: []string{`__name__="http_duration_bucket"`, `job=~"api.*"`},
+		Bounds: flux.Bounds{
+			Start: flux.Time{Absolute: time.Unix(1, 0)},
+			Stop:  flux.Time{Absolute: time.Unix(5, 0)},
+		},
+	}
+	id := executetest.RandomDatasetID()
+	src, err := createRemoteReadSource(spec, id, mock.AdministrationWithContext(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := executetest.NewDataStore()
+	src.AddTransformation(store)
+	src.Run(ctx)
+	if err := store.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := executetest.TablesFromCache(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cols := []flux.ColMeta{
+		{Label: "_time", Type: flux.TTime},
+		{Label: "_value", Type: flux.TFloat},
+		{Label: "_measurement", Type: flux.TString},
+		{Label: "_field", Type: flux.TString},
+		{Label: "job", Type: flux.TString},
+		{Label: "le", Type: flux.TString},
+	}
+	keyCols := []string{"_measurement", "_field", "job", "le"}
+	want := []*executetest.Table{
+		{
+			KeyCols: keyCols,
+			ColMeta: cols,
+			Data: [][]interface{}{
+				{execute.Time(1e9), 1.0, "prometheus", "http_duration_bucket", "api", "0.5"},
+				{execute.Time(2e9), 2.0, "prometheus", "http_duration_bucket", "api", "0.5"},
+			},
+		},
+		{
+			KeyCols: keyCols,
+			ColMeta: cols,
+			Data: [][]interface{}{
+				{execute.Time(1e9), 3.0, "prometheus", "http_duration_bucket", "api", "+Inf"},
+			},
+		},
+	}
+	executetest.NormalizeTables(got)
+	executetest.NormalizeTables(want)
+	if !cmp.Equal(want, got) {
+		t.Errorf("unexpected tables -want/+got\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestRemoteWrite(t *testing.T) {
+	var written []timeSeries
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if want, got := remoteWriteVersion, r.Header.Get("X-Prometheus-Remote-Write-Version"); want != got {
+			t.Errorf("unexpected remote write version -want/+got:\n\t- %s\n\t+ %s", want, got)
+		}
+		series, err := unmarshalWriteRequest(readSnappyBody(t, r))
+		if err != nil {
+			t.Fatal(err)
+		}
+		written = append(written, series...)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	newInput := func() *executetest.Table {
+		return &executetest.Table{
+			KeyCols: []string{"_measurement", "_field"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TInt},
+				{Label: "_measurement", Type: flux.TString},
+				{Label: "_field", Type: flux.TString},
+				{Label: "host", Type: flux.TString},
+			},
+			Data: [][]interface{}{
+				{execute.Time(2e6), int64(2), "prometheus", "requests_total", "a"},
+				{execute.Time(1e6), int64(1), "prometheus", "requests_total", "a"},
+				{execute.Time(1e6), int64(5), "prometheus", "requests_total", "b"},
+				{execute.Time(3e6), nil, "prometheus", "requests_total", "b"},
+			},
+		}
+	}
+
+	ctx := flux.NewDefaultDependencies().Inject(context.Background())
+	executetest.ProcessTestHelper2(
+		t,
+		[]flux.Table{newInput()},
+		[]*executetest.Table{newInput()},
+		nil,
+		func(id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset) {
+			tr, d, err := NewRemoteWriteTransformation(ctx, id, &RemoteWriteProcedureSpec{URL: ts.URL}, alloc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return tr, d
+		},
+	)
+
+	want := []timeSeries{
+		{
+			Labels:  []label{{"__name__", "requests_total"}, {"host", "a"}},
+			Samples: []sample{{Value: 1, Timestamp: 1}, {Value: 2, Timestamp: 2}},
+		},
+		{
+			Labels:  []label{{"__name__", "requests_total"}, {"host", "b"}},
+			Samples: []sample{{Value: 5, Timestamp: 1}},
+		},
+	}
+	if !cmp.Equal(want, written) {
+		t.Errorf("unexpected series -want/+got\n%s", cmp.Diff(want, written))
+	}
+}
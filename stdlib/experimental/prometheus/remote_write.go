@@ -0,0 +1,296 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/dependencies/audit"
+	"github.com/InfluxCommunity/flux/dependencies/dryrun"
+	furl "github.com/InfluxCommunity/flux/dependencies/url"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/table"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/apache/arrow/go/v7/arrow/memory"
+)
+
+const RemoteWriteKind = "prometheusRemoteWrite"
+
+type RemoteWriteOpSpec struct {
+	URL string `json:"url"`
+}
+
+func init() {
+	remoteWriteSignature := runtime.MustLookupBuiltinType("experimental/prometheus", "remoteWrite")
+	runtime.RegisterPackageValue("experimental/prometheus", "remoteWrite", flux.MustValue(flux.FunctionValueWithSideEffect(RemoteWriteKind, createRemoteWriteOpSpec, remoteWriteSignature)))
+	plan.RegisterProcedureSpecWithSideEffect(RemoteWriteKind, newRemoteWriteProcedure, RemoteWriteKind)
+	execute.RegisterTransformation(RemoteWriteKind, createRemoteWriteTransformation)
+}
+
+func createRemoteWriteOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
+	if err := a.AddParentFromArgs(args); err != nil {
+		return nil, err
+	}
+	spec := new(RemoteWriteOpSpec)
+	if u, err := args.GetRequiredString("url"); err != nil {
+		return nil, err
+	} else {
+		spec.URL = u
+	}
+	return spec, nil
+}
+
+func (s *RemoteWriteOpSpec) Kind() flux.OperationKind {
+	return RemoteWriteKind
+}
+
+type RemoteWriteProcedureSpec struct {
+	plan.DefaultCost
+	URL string
+}
+
+func newRemoteWriteProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
+	spec, ok := qs.(*RemoteWriteOpSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", qs)
+	}
+	return &RemoteWriteProcedureSpec{URL: spec.URL}, nil
+}
+
+func (s *RemoteWriteProcedureSpec) Kind() plan.ProcedureKind {
+	return RemoteWriteKind
+}
+
+func (s *RemoteWriteProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := *s
+	return &ns
+}
+
+func createRemoteWriteTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
+	s, ok := spec.(*RemoteWriteProcedureSpec)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	return NewRemoteWriteTransformation(a.Context(), id, s, a.Allocator())
+}
+
+type remoteWriteTransformation struct {
+	ctx   context.Context
+	spec  *RemoteWriteProcedureSpec
+	write *audit.Write
+	// dryRun captures the series in the text format
+	// in place of the endpoint in dry-run mode.
+	dryRun *dryrun.Writer
+}
+
+// NewRemoteWriteTransformation creates a transformation that writes every
+// table to a Prometheus remote write endpoint and passes it through unchanged.
+func NewRemoteWriteTransformation(ctx context.Context, id execute.DatasetID, spec *RemoteWriteProcedureSpec, mem memory.Allocator) (execute.Transformation, execute.Dataset, error) {
+	u, err := url.Parse(spec.URL)
+	if err != nil {
+		return nil, nil, errors.Newf(codes.Invalid, "invalid url: %v", err)
+	}
+	validator, err := flux.GetDependencies(ctx).URLValidator()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := furl.ForFunction(validator, "experimental/prometheus.remoteWrite").Validate(u); err != nil {
+		return nil, nil, err
+	}
+	t := &remoteWriteTransformation{
+		ctx:  ctx,
+		spec: spec,
+	}
+	if sink := dryrun.GetSink(ctx); sink != nil {
+		t.dryRun = sink.NewWriter("experimental/prometheus.remoteWrite", audit.URL(spec.URL))
+	} else {
+		t.write = audit.NewWrite(ctx, "experimental/prometheus.remoteWrite", audit.URL(spec.URL))
+	}
+	return execute.NewNarrowTransformation(id, t, mem)
+}
+
+func (t *remoteWriteTransformation) Process(chunk table.Chunk, d *execute.TransportDataset, mem memory.Allocator) error {
+	series, err := chunkSeries(chunk)
+	if err != nil {
+		return err
+	}
+	if len(series) > 0 && t.dryRun != nil {
+		if err := writeSeriesText(t.dryRun, series); err != nil {
+			return err
+		}
+	} else if len(series) > 0 {
+		header := make(http.Header)
+		header.Set("X-Prometheus-Remote-Write-Version", remoteWriteVersion)
+		body := marshalWriteRequest(series)
+		if _, err := doRemoteRequest(t.ctx, t.spec.URL, body, header); err != nil {
+			t.write.Fail(err)
+			return err
+		}
+		var samples int
+		for _, ts := range series {
+			samples += len(ts.Samples)
+		}
+		t.write.Add(int64(samples), int64(len(body)))
+	}
+	chunk.Retain()
+	return d.Process(chunk)
+}
+
+func (t *remoteWriteTransformation) Close() error {
+	if t.dryRun != nil {
+		return t.dryRun.Close()
+	}
+	t.write.Done(nil)
+	return nil
+}
+
+// writeSeriesText writes every sample of the series as a line in the
+// Prometheus text format, with its timestamp in milliseconds.
+func writeSeriesText(w *dryrun.Writer, series []timeSeries) error {
+	var sb strings.Builder
+	for _, ts := range series {
+		var name string
+		var labels []string
+		for _, l := range ts.Labels {
+			if l.Name == metricNameLabel {
+				name = l.Value
+				continue
+			}
+			labels = append(labels, l.Name+"="+strconv.Quote(l.Value))
+		}
+		for _, s := range ts.Samples {
+			sb.WriteString(name)
+			if len(labels) > 0 {
+				sb.WriteString("{" + strings.Join(labels, ",") + "}")
+			}
+			fmt.Fprintf(&sb, " %s %d\n", strconv.FormatFloat(s.Value, 'g', -1, 64), s.Timestamp)
+		}
+		w.AddRows(int64(len(ts.Samples)))
+	}
+	_, err := w.Write([]byte(sb.String()))
+	return err
+}
+
+// excludedLabelColumns are the columns that are never written as labels.
+var excludedLabelColumns = map[string]bool{
+	"_measurement":               true,
+	"_field":                     true,
+	execute.DefaultStartColLabel: true,
+	execute.DefaultStopColLabel:  true,
+	execute.DefaultTimeColLabel:  true,
+	execute.DefaultValueColLabel: true,
+}
+
+// chunkSeries converts the rows of a chunk into series.
+// It is the inverse of remoteRead: the _field column holds the metric name,
+// the _time and _value columns hold the sample and every other string
+// column is a label. Rows with a null time or value are skipped.
+func chunkSeries(chunk table.Chunk) ([]timeSeries, error) {
+	cols := chunk.Cols()
+	fieldIdx := execute.ColIdx("_field", cols)
+	if fieldIdx < 0 || cols[fieldIdx].Type != flux.TString {
+		return nil, errors.New(codes.FailedPrecondition, "remoteWrite requires a _field column of type string with the metric name")
+	}
+	timeIdx := execute.ColIdx(execute.DefaultTimeColLabel, cols)
+	if timeIdx < 0 || cols[timeIdx].Type != flux.TTime {
+		return nil, errors.New(codes.FailedPrecondition, "remoteWrite requires a _time column of type time")
+	}
+	valueIdx := execute.ColIdx(execute.DefaultValueColLabel, cols)
+	if valueIdx < 0 {
+		return nil, errors.New(codes.FailedPrecondition, "remoteWrite requires a _value column")
+	}
+	switch cols[valueIdx].Type {
+	case flux.TFloat, flux.TInt, flux.TUInt:
+	default:
+		return nil, errors.Newf(codes.FailedPrecondition, "unsupported type %s for _value column, must be numeric", cols[valueIdx].Type)
+	}
+
+	var labelIdxs []int
+	for j, col := range cols {
+		if col.Type == flux.TString && !excludedLabelColumns[col.Label] {
+			labelIdxs = append(labelIdxs, j)
+		}
+	}
+
+	er := chunk.Buffer()
+	bySeries := make(map[string]int)
+	var series []timeSeries
+	var sb strings.Builder
+	for i := 0; i < chunk.Len(); i++ {
+		times := er.Times(timeIdx)
+		if times.IsNull(i) {
+			continue
+		}
+		var v float64
+		switch cols[valueIdx].Type {
+		case flux.TFloat:
+			vs := er.Floats(valueIdx)
+			if vs.IsNull(i) {
+				continue
+			}
+			v = vs.Value(i)
+		case flux.TInt:
+			vs := er.Ints(valueIdx)
+			if vs.IsNull(i) {
+				continue
+			}
+			v = float64(vs.Value(i))
+		case flux.TUInt:
+			vs := er.UInts(valueIdx)
+			if vs.IsNull(i) {
+				continue
+			}
+			v = float64(vs.Value(i))
+		}
+
+		fields := er.Strings(fieldIdx)
+		if fields.IsNull(i) {
+			continue
+		}
+		labels := []label{{Name: metricNameLabel, Value: fields.Value(i)}}
+		for _, j := range labelIdxs {
+			vs := er.Strings(j)
+			if vs.IsNull(i) || vs.Value(i) == "" {
+				continue
+			}
+			labels = append(labels, label{Name: cols[j].Label, Value: vs.Value(i)})
+		}
+		sortLabels(labels)
+
+		sb.Reset()
+		for _, l := range labels {
+			sb.WriteString(l.Name)
+			sb.WriteByte(0)
+			sb.WriteString(l.Value)
+			sb.WriteByte(0)
+		}
+		k := sb.String()
+		idx, ok := bySeries[k]
+		if !ok {
+			idx = len(series)
+			bySeries[k] = idx
+			series = append(series, timeSeries{Labels: labels})
+		}
+		series[idx].Samples = append(series[idx].Samples, sample{
+			Value:     v,
+			Timestamp: times.Value(i) / 1e6,
+		})
+	}
+
+	// Samples must be written in time order.
+	for _, ts := range series {
+		samples := ts.Samples
+		sort.SliceStable(samples, func(i, j int) bool {
+			return samples[i].Timestamp < samples[j].Timestamp
+		})
+	}
+	return series, nil
+}
@@ -18,6 +18,7 @@ import (
 	// Flux packages
 	"github.com/InfluxCommunity/flux"
 	"github.com/InfluxCommunity/flux/codes"
+	furl "github.com/InfluxCommunity/flux/dependencies/url"
 	"github.com/InfluxCommunity/flux/execute"
 	"github.com/InfluxCommunity/flux/internal/errors"
 	"github.com/InfluxCommunity/flux/plan"
@@ -145,7 +146,7 @@ func (p *PrometheusIterator) Connect(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	if err := validator.Validate(u); err != nil {
+	if err := furl.ForFunction(validator, "experimental/prometheus.scrape").Validate(u); err != nil {
 		return err
 	}
 
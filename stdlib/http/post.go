@@ -10,6 +10,9 @@ import (
 
 	"github.com/InfluxCommunity/flux"
 	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/dependencies/audit"
+	"github.com/InfluxCommunity/flux/dependencies/dryrun"
+	furl "github.com/InfluxCommunity/flux/dependencies/url"
 	"github.com/InfluxCommunity/flux/internal/errors"
 	"github.com/InfluxCommunity/flux/iocounter"
 	"github.com/InfluxCommunity/flux/runtime"
@@ -61,6 +64,21 @@ func init() {
 
 			// Perform request
 			deps := flux.GetDependencies(ctx)
+			validator, err := deps.URLValidator()
+			if err != nil {
+				return nil, err
+			}
+			if err := furl.ForFunction(validator, "http.post").Validate(req.URL); err != nil {
+				return nil, err
+			}
+			if sink := dryrun.GetSink(ctx); sink != nil {
+				sink.Capture(dryrun.Payload{
+					Function:    "http.post",
+					Destination: audit.URL(req.URL.String()),
+					Data:        data,
+				})
+				return values.NewInt(http.StatusOK), nil
+			}
 			dc, err := deps.HTTPClient()
 			if err != nil {
 				return nil, errors.Wrap(err, codes.Aborted, "missing client in http.post")
@@ -100,6 +118,7 @@ func init() {
 				)
 				return response.StatusCode, nil
 			}(req)
+			audit.Record(ctx, audit.HTTPEvent("http.post", req.URL.String(), len(data), statusCode, err))
 			if err != nil {
 				return nil, err
 			}
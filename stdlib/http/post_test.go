@@ -92,3 +92,37 @@ http.post(url:"http://127.1.1.1/path/a/b/c", headers: {x:"a",y:"b",z:"c"}, data:
 		t.Errorf("unexpected error code. Wanted %q got %q", codes.Invalid, code)
 	}
 }
+
+func TestPost_EgressPolicy(t *testing.T) {
+	var called bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(204)
+	}))
+	defer ts.Close()
+
+	script := fmt.Sprintf(`
+import "http"
+
+http.post(url:"%s/path/a/b/c", data: bytes(v: "body"))
+`, ts.URL)
+
+	deps := flux.NewDefaultDependencies()
+	deps.Deps.URLValidator = &url.EgressPolicy{
+		Allow: []url.EgressRule{
+			{Functions: []string{"http.post"}, Hosts: []string{"hooks.example.com"}},
+			{Functions: []string{"sql.from"}, Hosts: []string{"127.0.0.1"}},
+		},
+	}
+	ctx := deps.Inject(context.Background())
+	_, _, err := runtime.Eval(ctx, script)
+	if err == nil {
+		t.Fatal("expected failure")
+	}
+	if !strings.Contains(err.Error(), "not allowed by the egress policy") {
+		t.Errorf("unexpected cause of failure, got err: %v", err)
+	}
+	if called {
+		t.Error("request was sent to a host that is not allowed")
+	}
+}
@@ -0,0 +1,163 @@
+package requests
+
+import (
+	"context"
+	"crypto/tls"
+	"net/url"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	fhttp "github.com/InfluxCommunity/flux/dependencies/http"
+	furl "github.com/InfluxCommunity/flux/dependencies/url"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/semantic"
+	"github.com/InfluxCommunity/flux/values"
+)
+
+// DefaultMaxPages is the number of pages that are followed
+// when the config does not specify maxPages.
+const DefaultMaxPages = 100
+
+// Config is the Go representation of a requests config record.
+type Config struct {
+	Timeout            time.Duration `json:"timeout"`
+	InsecureSkipVerify bool          `json:"insecureSkipVerify"`
+	Retries            int           `json:"retries"`
+	RetryBackoff       time.Duration `json:"retryBackoff"`
+	MaxRetryBackoff    time.Duration `json:"maxRetryBackoff"`
+	FollowLinks        bool          `json:"followLinks"`
+	MaxPages           int           `json:"maxPages"`
+	Credential         string        `json:"credential"`
+}
+
+// readConfig reads a config record. The timeout and insecureSkipVerify
+// properties are required, all other properties are optional so records
+// built before they were introduced remain valid.
+func readConfig(v values.Value) (Config, error) {
+	if v.Type().Nature() != semantic.Object {
+		return Config{}, errors.Newf(codes.Invalid, "parameter \"config\" is not of type record: %v", v.Type())
+	}
+	obj := v.Object()
+	config := Config{MaxPages: DefaultMaxPages}
+
+	timeoutV, ok := obj.Get("timeout")
+	if !ok {
+		return Config{}, errors.New(codes.Invalid, "config is missing \"timeout\" property")
+	}
+	timeout, err := readDuration("timeout", timeoutV)
+	if err != nil {
+		return Config{}, err
+	}
+	config.Timeout = timeout
+
+	insecureSkipVerifyV, ok := obj.Get("insecureSkipVerify")
+	if !ok {
+		return Config{}, errors.New(codes.Invalid, "config is missing \"insecureSkipVerify\" property")
+	}
+	config.InsecureSkipVerify = insecureSkipVerifyV.Bool()
+
+	if retriesV, ok := obj.Get("retries"); ok {
+		if retriesV.Type().Nature() != semantic.Int {
+			return Config{}, errors.Newf(codes.Invalid, "config retries is not of type int: %v", retriesV.Type())
+		}
+		if retriesV.Int() < 0 {
+			return Config{}, errors.New(codes.Invalid, "config retries must not be negative")
+		}
+		config.Retries = int(retriesV.Int())
+	}
+	if backoffV, ok := obj.Get("retryBackoff"); ok {
+		if config.RetryBackoff, err = readDuration("retryBackoff", backoffV); err != nil {
+			return Config{}, err
+		}
+	}
+	if maxBackoffV, ok := obj.Get("maxRetryBackoff"); ok {
+		if config.MaxRetryBackoff, err = readDuration("maxRetryBackoff", maxBackoffV); err != nil {
+			return Config{}, err
+		}
+	}
+	if followLinksV, ok := obj.Get("followLinks"); ok {
+		if followLinksV.Type().Nature() != semantic.Bool {
+			return Config{}, errors.Newf(codes.Invalid, "config followLinks is not of type bool: %v", followLinksV.Type())
+		}
+		config.FollowLinks = followLinksV.Bool()
+	}
+	if maxPagesV, ok := obj.Get("maxPages"); ok {
+		if maxPagesV.Type().Nature() != semantic.Int {
+			return Config{}, errors.Newf(codes.Invalid, "config maxPages is not of type int: %v", maxPagesV.Type())
+		}
+		if maxPagesV.Int() <= 0 {
+			return Config{}, errors.New(codes.Invalid, "config maxPages must be positive")
+		}
+		config.MaxPages = int(maxPagesV.Int())
+	}
+	if credentialV, ok := obj.Get("credential"); ok {
+		if credentialV.Type().Nature() != semantic.String {
+			return Config{}, errors.Newf(codes.Invalid, "config credential is not of type string: %v", credentialV.Type())
+		}
+		config.Credential = credentialV.Str()
+	}
+	return config, nil
+}
+
+func readDuration(name string, v values.Value) (time.Duration, error) {
+	if v.Type().Nature() != semantic.Duration {
+		return 0, errors.Newf(codes.Invalid, "config %s is not of type duration: %v", name, v.Type())
+	}
+	d := v.Duration()
+	if d.IsMixed() {
+		return 0, errors.Newf(codes.Invalid, "config %s must not be a mixed duration", name)
+	}
+	return d.Duration(), nil
+}
+
+// validateURL validates the url with the URL validator
+// from the dependencies scoped to the function.
+func validateURL(ctx context.Context, function string, u *url.URL) error {
+	validator, err := flux.GetDependencies(ctx).URLValidator()
+	if err != nil {
+		return err
+	}
+	return furl.ForFunction(validator, function).Validate(u)
+}
+
+// client returns the HTTP client from the dependencies configured
+// according to the config.
+func (c Config) client(ctx context.Context) (fhttp.Client, error) {
+	deps := flux.GetDependencies(ctx)
+	dc, err := deps.HTTPClient()
+	if err != nil {
+		return nil, errors.Wrap(err, codes.Aborted, "missing client in http.request")
+	}
+
+	dc, err = fhttp.WithTimeout(dc, c.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.InsecureSkipVerify {
+		dc, err = fhttp.WithTLSConfig(dc, &tls.Config{
+			InsecureSkipVerify: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if c.Credential != "" {
+		cred, err := fhttp.GetCredentialProvider(ctx).Credential(ctx, c.Credential)
+		if err != nil {
+			return nil, err
+		}
+		if dc, err = fhttp.WithCredential(ctx, dc, cred); err != nil {
+			return nil, err
+		}
+	}
+
+	// The retry client wraps the configured client so it must be applied last.
+	return fhttp.WithRetry(dc, fhttp.RetryPolicy{
+		MaxRetries: c.Retries,
+		Backoff:    c.RetryBackoff,
+		MaxBackoff: c.MaxRetryBackoff,
+	}), nil
+}
@@ -0,0 +1,269 @@
+package requests
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/csv"
+	fhttp "github.com/InfluxCommunity/flux/dependencies/http"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/internal/line"
+	"github.com/InfluxCommunity/flux/internal/ndjson"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/InfluxCommunity/flux/values"
+)
+
+const FromKind = "requestsFrom"
+
+var decoders = []string{"json", "csv", "line"}
+
+type FromOpSpec struct {
+	URL     string            `json:"url"`
+	Decoder string            `json:"decoder"`
+	Params  url.Values        `json:"params"`
+	Headers map[string]string `json:"headers"`
+	Config  Config            `json:"config"`
+}
+
+func init() {
+	runtime.RegisterPackageValue("http/requests", "_from", flux.MustValue(flux.FunctionValue(FromKind, createFromOpSpec, runtime.MustLookupBuiltinType("http/requests", "_from"))))
+	plan.RegisterProcedureSpec(FromKind, newFromProcedure, FromKind)
+	execute.RegisterSource(FromKind, createFromSource)
+}
+
+func createFromOpSpec(args flux.Arguments, a *flux.Administration) (flux.OperationSpec, error) {
+	spec := new(FromOpSpec)
+
+	if u, err := args.GetRequiredString("url"); err != nil {
+		return nil, err
+	} else {
+		spec.URL = u
+	}
+
+	if d, ok, err := args.GetString("decoder"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.Decoder = d
+	} else {
+		spec.Decoder = decoders[0]
+	}
+	if !contains(decoders, spec.Decoder) {
+		return nil, errors.Newf(codes.Invalid, "invalid decoder %s, must be one of %v", spec.Decoder, decoders)
+	}
+
+	params, err := readParams(args)
+	if err != nil {
+		return nil, err
+	}
+	spec.Params = params
+
+	headers, err := readHeaders(args)
+	if err != nil {
+		return nil, err
+	}
+	spec.Headers = headers
+
+	configV, err := args.GetRequired("config")
+	if err != nil {
+		return nil, err
+	}
+	if spec.Config, err = readConfig(configV); err != nil {
+		return nil, err
+	}
+
+	return spec, nil
+}
+
+func (s *FromOpSpec) Kind() flux.OperationKind {
+	return FromKind
+}
+
+type FromProcedureSpec struct {
+	plan.DefaultCost
+	Spec *FromOpSpec
+}
+
+func newFromProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
+	spec, ok := qs.(*FromOpSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", qs)
+	}
+
+	return &FromProcedureSpec{
+		Spec: spec,
+	}, nil
+}
+
+func (s *FromProcedureSpec) Kind() plan.ProcedureKind {
+	return FromKind
+}
+
+func (s *FromProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := *s
+	return &ns
+}
+
+func createFromSource(s plan.ProcedureSpec, dsid execute.DatasetID, a execute.Administration) (execute.Source, error) {
+	spec, ok := s.(*FromProcedureSpec)
+	if !ok {
+		return nil, errors.Newf(codes.Internal, "invalid spec type %T", s)
+	}
+	return NewFromSource(spec, &nowTimeProvider{}, dsid, a.Allocator())
+}
+
+// nowTimeProvider provides wall clock time.
+type nowTimeProvider struct{}
+
+func (a *nowTimeProvider) CurrentTime() values.Time {
+	return values.ConvertTime(time.Now())
+}
+
+// NewFromSource creates a source that requests the URL and decodes
+// the response body of every page into tables.
+func NewFromSource(spec *FromProcedureSpec, tp line.TimeProvider, dsid execute.DatasetID, mem memory.Allocator) (execute.Source, error) {
+	u, err := url.Parse(spec.Spec.URL)
+	if err != nil {
+		return nil, errors.Newf(codes.Invalid, "invalid url: %v", err)
+	}
+	if len(spec.Spec.Params) > 0 {
+		u.RawQuery = spec.Spec.Params.Encode()
+	}
+	return execute.CreateSourceFromIterator(&fromIterator{
+		spec: spec.Spec,
+		url:  u,
+		tp:   tp,
+		mem:  mem,
+	}, dsid)
+}
+
+type fromIterator struct {
+	spec *FromOpSpec
+	url  *url.URL
+	tp   line.TimeProvider
+	mem  memory.Allocator
+}
+
+func (fi *fromIterator) Do(ctx context.Context, f func(flux.Table) error) error {
+	client, err := fi.spec.Config.client(ctx)
+	if err != nil {
+		return err
+	}
+
+	maxPages := 1
+	if fi.spec.Config.FollowLinks {
+		maxPages = fi.spec.Config.MaxPages
+	}
+	next := fi.url
+	for page := 0; next != nil && page < maxPages; page++ {
+		if next, err = fi.fetch(ctx, client, next, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetch requests a single page and passes its decoded tables to f.
+// It returns the URL of the next page if the response links to one.
+func (fi *fromIterator) fetch(ctx context.Context, client fhttp.Client, u *url.URL, f func(flux.Table) error) (*url.URL, error) {
+	if err := validateURL(ctx, "http/requests.from", u); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range fi.spec.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := doRequest(client, req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		code := codes.Invalid
+		if resp.StatusCode >= 500 {
+			code = codes.Unavailable
+		}
+		return nil, errors.Newf(code, "request to %s failed with status %s", u.Redacted(), resp.Status)
+	}
+
+	result, err := fi.newDecoder().Decode(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, codes.Inherit, "decode error")
+	}
+	if err := result.Tables().Do(f); err != nil {
+		return nil, errors.Wrap(err, codes.Inherit, "decode error")
+	}
+	return nextLink(u, resp.Header), nil
+}
+
+func (fi *fromIterator) newDecoder() flux.ResultDecoder {
+	switch fi.spec.Decoder {
+	case "csv":
+		return csv.NewResultDecoder(csv.ResultDecoderConfig{
+			Allocator: fi.mem,
+		})
+	case "line":
+		return line.NewResultDecoder(&line.ResultDecoderConfig{
+			Separator:    '\n',
+			TimeProvider: fi.tp,
+		})
+	default:
+		return ndjson.NewResultDecoder(&ndjson.ResultDecoderConfig{
+			TimeProvider: fi.tp,
+			Allocator:    fi.mem,
+		})
+	}
+}
+
+// nextLink returns the target of the rel="next" link in the Link headers,
+// resolved against the URL of the request.
+// It returns nil if there is no such link.
+func nextLink(base *url.URL, header http.Header) *url.URL {
+	for _, h := range header.Values("Link") {
+		for _, link := range strings.Split(h, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range parts[1:] {
+				k, v, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if !ok || !strings.EqualFold(strings.TrimSpace(k), "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(strings.TrimSpace(v), `"`)) {
+					if !strings.EqualFold(rel, "next") {
+						continue
+					}
+					u, err := base.Parse(target[1 : len(target)-1])
+					if err != nil {
+						return nil
+					}
+					return u
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func contains(ss []string, s string) bool {
+	for _, st := range ss {
+		if st == s {
+			return true
+		}
+	}
+	return false
+}
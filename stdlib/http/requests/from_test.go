@@ -0,0 +1,176 @@
+package requests_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/executetest"
+	"github.com/InfluxCommunity/flux/mock"
+	"github.com/InfluxCommunity/flux/stdlib/http/requests"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFromSource_Run(t *testing.T) {
+	pages := []string{
+		`{"id": "a", "v": 1}` + "\n" + `{"id": "b", "v": 2}`,
+		`[{"id": "c", "v": 3}]`,
+		`{"id": "d", "v": 4}`,
+	}
+	attempts := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var page int
+		if _, err := fmt.Sscanf(r.URL.Query().Get("page"), "%d", &page); err != nil {
+			t.Errorf("unexpected page parameter: %q", r.URL.RawQuery)
+		}
+		if want, got := "token", r.Header.Get("Authorization"); want != got {
+			t.Errorf("unexpected authorization header want: %q got: %q", want, got)
+		}
+		// Fail the first attempt of the second page so it is retried.
+		if page == 1 && attempts == 0 {
+			attempts++
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if page+1 < len(pages) {
+			w.Header().Set("Link", fmt.Sprintf(`</data?page=%d>; rel="next"`, page+1))
+		}
+		_, _ = w.Write([]byte(pages[page]))
+	}))
+	defer ts.Close()
+
+	testCases := []struct {
+		name    string
+		config  requests.Config
+		want    [][][]interface{}
+		wantErr bool
+	}{
+		{
+			name:   "single page",
+			config: requests.Config{Timeout: time.Second, Retries: 1, MaxPages: 100},
+			want: [][][]interface{}{{
+				{execute.Time(0), "a", 1.0},
+				{execute.Time(1), "b", 2.0},
+			}},
+		},
+		{
+			name:   "follow links",
+			config: requests.Config{Timeout: time.Second, Retries: 1, FollowLinks: true, MaxPages: 100},
+			want: [][][]interface{}{{
+				{execute.Time(0), "a", 1.0},
+				{execute.Time(1), "b", 2.0},
+			}, {
+				{execute.Time(2), "c", 3.0},
+			}, {
+				{execute.Time(3), "d", 4.0},
+			}},
+		},
+		{
+			name:   "max pages",
+			config: requests.Config{Timeout: time.Second, Retries: 1, FollowLinks: true, MaxPages: 2},
+			want: [][][]interface{}{{
+				{execute.Time(0), "a", 1.0},
+				{execute.Time(1), "b", 2.0},
+			}, {
+				{execute.Time(2), "c", 3.0},
+			}},
+		},
+		{
+			name:    "retries exhausted",
+			config:  requests.Config{Timeout: time.Second, FollowLinks: true, MaxPages: 100},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			attempts = 0
+			ctx := flux.NewDefaultDependencies().Inject(context.Background())
+
+			spec := &requests.FromProcedureSpec{
+				Spec: &requests.FromOpSpec{
+					URL:     ts.URL + "/data",
+					Decoder: "json",
+					Params:  map[string][]string{"page": {"0"}},
+					Headers: map[string]string{"Authorization": "token"},
+					Config:  tc.config,
+				},
+			}
+			src, err := requests.NewFromSource(spec, &mock.AscendingTimeProvider{}, executetest.RandomDatasetID(), executetest.UnlimitedAllocator)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Every page is decoded into its own table so
+			// collect the tables in order instead of merging them.
+			collector := &tableCollector{}
+			src.AddTransformation(collector)
+			src.Run(ctx)
+
+			if tc.wantErr {
+				if collector.err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			} else if collector.err != nil {
+				t.Fatal(collector.err)
+			}
+
+			var want []*executetest.Table
+			for _, data := range tc.want {
+				tbl := &executetest.Table{
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "id", Type: flux.TString},
+						{Label: "v", Type: flux.TFloat},
+					},
+					Data: data,
+				}
+				tbl.Normalize()
+				want = append(want, tbl)
+			}
+			got := collector.tables
+
+			if !cmp.Equal(want, got) {
+				t.Errorf("unexpected tables -want/+got\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+type tableCollector struct {
+	execute.ExecutionNode
+	tables []*executetest.Table
+	err    error
+}
+
+func (c *tableCollector) RetractTable(id execute.DatasetID, key flux.GroupKey) error {
+	return nil
+}
+
+func (c *tableCollector) Process(id execute.DatasetID, tbl flux.Table) error {
+	t, err := executetest.ConvertTable(tbl)
+	if err != nil {
+		return err
+	}
+	c.tables = append(c.tables, t)
+	return nil
+}
+
+func (c *tableCollector) UpdateWatermark(id execute.DatasetID, t execute.Time) error {
+	return nil
+}
+
+func (c *tableCollector) UpdateProcessingTime(id execute.DatasetID, t execute.Time) error {
+	return nil
+}
+
+func (c *tableCollector) Finish(id execute.DatasetID, err error) {
+	c.err = err
+}
@@ -3,15 +3,15 @@ package requests
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
 
-	"github.com/InfluxCommunity/flux"
 	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/dependencies/audit"
+	"github.com/InfluxCommunity/flux/dependencies/dryrun"
 	fhttp "github.com/InfluxCommunity/flux/dependencies/http"
 	"github.com/InfluxCommunity/flux/internal/errors"
 	"github.com/InfluxCommunity/flux/runtime"
@@ -36,32 +36,9 @@ var do = values.NewFunction(
 			return nil, err
 		}
 		// Get params
-		var params url.Values
-		if paramsV, ok := args.Get("params"); ok {
-			params = make(url.Values)
-			if paramsV.Type().Nature() != semantic.Dictionary {
-				return nil, errors.Newf(codes.Invalid, "parameter \"params\" is not of type [string:string]: %v", paramsV.Type())
-			}
-			paramsDict := paramsV.Dict()
-			keyType, _ := paramsDict.Type().KeyType()
-			if keyType.Nature() != semantic.String {
-				return nil, errors.Newf(codes.Invalid, "parameter \"params\"'s key type is not a string: %v", keyType)
-			}
-			valueType, _ := paramsDict.Type().ValueType()
-			if valueType.Nature() != semantic.Array {
-				return nil, errors.Newf(codes.Invalid, "parameter \"params\"'s value type is not a [string]: %v", valueType)
-			}
-			elementType, _ := valueType.ElemType()
-			if elementType.Nature() != semantic.String {
-				return nil, errors.Newf(codes.Invalid, "parameter \"params\"'s value type is not a string: %v", elementType)
-			}
-			paramsDict.Range(func(key, value values.Value) {
-				k := key.Str()
-				value.Array().Range(func(i int, value values.Value) {
-					v := value.Str()
-					params.Add(k, v)
-				})
-			})
+		params, err := readParams(args)
+		if err != nil {
+			return nil, err
 		}
 		if len(params) > 0 {
 			u.RawQuery = params.Encode()
@@ -85,17 +62,23 @@ var do = values.NewFunction(
 		if !ok {
 			return nil, errors.New(codes.Invalid, "missing \"config\" parameter")
 		}
-		if configV.Type().Nature() != semantic.Object {
-			return nil, errors.Newf(codes.Invalid, "parameter \"config\" is not of type record: %v", configV.Type())
+		config, err := readConfig(configV)
+		if err != nil {
+			return nil, err
 		}
-		config := configV.Object()
 
-		var body io.Reader
+		var (
+			body     io.Reader
+			bodyData []byte
+			bodySize int
+		)
 		if bodyV, ok := args.Get("body"); ok {
 			if bodyV.Type().Nature() != semantic.Bytes {
 				return nil, errors.Newf(codes.Invalid, "parameter \"body\" is not of type bytes: %v", bodyV.Type())
 			}
-			body = bytes.NewReader(bodyV.Bytes())
+			bodyData = bodyV.Bytes()
+			body = bytes.NewReader(bodyData)
+			bodySize = len(bodyData)
 		}
 
 		// Construct HTTP request
@@ -105,60 +88,45 @@ var do = values.NewFunction(
 		}
 
 		// Add headers to request
-		headersV, ok := args.Get("headers")
-		if ok && !headersV.IsNull() {
-			if headersV.Type().Nature() != semantic.Dictionary {
-				return nil, errors.Newf(codes.Invalid, "parameter \"headers\" is not of type [string:string] : %v", headersV.Type())
-			}
-			var rangeErr error
-			headersV.Dict().Range(func(k values.Value, v values.Value) {
-				if k.Type().Nature() == semantic.String &&
-					v.Type().Nature() == semantic.String {
-					req.Header.Set(k.Str(), v.Str())
-				} else {
-					rangeErr = errors.Newf(codes.Invalid, "header key and values must be a string: %q", k)
-				}
-			})
-			if rangeErr != nil {
-				return nil, rangeErr
-			}
-		}
-
-		// Get Client and configure it
-		deps := flux.GetDependencies(ctx)
-		dc, err := deps.HTTPClient()
+		headers, err := readHeaders(args)
 		if err != nil {
-			return nil, errors.Wrap(err, codes.Aborted, "missing client in http.request")
-		}
-
-		timeoutV, ok := config.Get("timeout")
-		if !ok {
-			return nil, errors.New(codes.Invalid, "config is missing \"timeout\" property")
+			return nil, err
 		}
-		timeout := timeoutV.Duration()
-		if timeout.IsMixed() {
-			return nil, errors.New(codes.Invalid, "config timeout must not be a mixed duration")
+		for k, v := range headers {
+			req.Header.Set(k, v)
 		}
-		dc, err = fhttp.WithTimeout(dc, timeout.Duration())
-		if err != nil {
+
+		if err := validateURL(ctx, "http/requests.do", req.URL); err != nil {
 			return nil, err
 		}
 
-		insecureSkipVerifyV, ok := config.Get("insecureSkipVerify")
-		if !ok {
-			return nil, errors.New(codes.Invalid, "config is missing \"insecureSkipVerify\" property")
-		}
-		if insecureSkipVerifyV.Bool() {
-			dc, err = fhttp.WithTLSConfig(dc, &tls.Config{
-				InsecureSkipVerify: true,
+		// Requests that can modify the server are not made in dry-run mode.
+		if sink := dryrun.GetSink(ctx); sink != nil && method != http.MethodGet && method != http.MethodHead {
+			sink.Capture(dryrun.Payload{
+				Function:    "http/requests.do",
+				Destination: method + " " + audit.URL(req.URL.String()),
+				Data:        bodyData,
 			})
+			responseHeaders, err := headerToDict(http.Header{})
 			if err != nil {
 				return nil, err
 			}
+			return values.NewObjectWithValues(map[string]values.Value{
+				"statusCode": values.NewInt(http.StatusOK),
+				"headers":    responseHeaders,
+				"body":       values.NewBytes(nil),
+				"duration":   values.NewDuration(values.ConvertDurationNsecs(0)),
+			}), nil
+		}
+
+		// Get Client and configure it
+		dc, err := config.client(ctx)
+		if err != nil {
+			return nil, err
 		}
 
 		// Do request, using local anonymous functions to facilitate timing the request
-		statusCode, responseBody, headers, duration, err := func(req *http.Request) (statusCode int, body []byte, headers values.Dictionary, duration time.Duration, err error) {
+		statusCode, responseBody, responseHeaders, duration, err := func(req *http.Request) (statusCode int, body []byte, headers values.Dictionary, duration time.Duration, err error) {
 			startTime := time.Now()
 			s, cctx := opentracing.StartSpanFromContext(req.Context(), "requests._do", opentracing.StartTime(startTime))
 			s.SetTag("url", req.URL.String())
@@ -172,15 +140,8 @@ var do = values.NewFunction(
 			}()
 
 			req = req.WithContext(cctx)
-			response, err := dc.Do(req)
+			response, err := doRequest(dc, req)
 			if err != nil {
-				// Alias the DNS lookup error so as not to disclose the
-				// DNS server address. This error is private in the net/http
-				// package, so string matching is used.
-				if strings.HasSuffix(err.Error(), "no such host") {
-					err = errors.New(codes.Invalid, "no such host")
-					return
-				}
 				return
 			}
 			body, err = io.ReadAll(response.Body)
@@ -199,13 +160,17 @@ var do = values.NewFunction(
 			statusCode = response.StatusCode
 			return
 		}(req)
+		// Only requests that can modify the server are audited.
+		if method != http.MethodGet && method != http.MethodHead {
+			audit.Record(ctx, audit.HTTPEvent("http/requests.do", req.URL.String(), bodySize, statusCode, err))
+		}
 		if err != nil {
 			return nil, err
 		}
 
 		return values.NewObjectWithValues(map[string]values.Value{
 			"statusCode": values.NewInt(int64(statusCode)),
-			"headers":    headers,
+			"headers":    responseHeaders,
 			"body":       values.NewBytes(responseBody),
 			"duration":   values.NewDuration(values.ConvertDurationNsecs(duration)),
 		}), nil
@@ -214,6 +179,85 @@ var do = values.NewFunction(
 	true, // _do has side-effects
 )
 
+// doRequest performs the request with the client.
+func doRequest(dc fhttp.Client, req *http.Request) (*http.Response, error) {
+	response, err := dc.Do(req)
+	if err != nil {
+		// Alias the DNS lookup error so as not to disclose the
+		// DNS server address. This error is private in the net/http
+		// package, so string matching is used.
+		if strings.HasSuffix(err.Error(), "no such host") {
+			return nil, errors.New(codes.Invalid, "no such host")
+		}
+		return nil, err
+	}
+	return response, nil
+}
+
+// argumentGetter retrieves named arguments. It is implemented
+// by both values.Object and flux.Arguments.
+type argumentGetter interface {
+	Get(name string) (values.Value, bool)
+}
+
+// readParams reads the optional params argument into query parameters.
+func readParams(args argumentGetter) (url.Values, error) {
+	params := make(url.Values)
+	paramsV, ok := args.Get("params")
+	if !ok {
+		return params, nil
+	}
+	if paramsV.Type().Nature() != semantic.Dictionary {
+		return nil, errors.Newf(codes.Invalid, "parameter \"params\" is not of type [string:string]: %v", paramsV.Type())
+	}
+	paramsDict := paramsV.Dict()
+	keyType, _ := paramsDict.Type().KeyType()
+	if keyType.Nature() != semantic.String {
+		return nil, errors.Newf(codes.Invalid, "parameter \"params\"'s key type is not a string: %v", keyType)
+	}
+	valueType, _ := paramsDict.Type().ValueType()
+	if valueType.Nature() != semantic.Array {
+		return nil, errors.Newf(codes.Invalid, "parameter \"params\"'s value type is not a [string]: %v", valueType)
+	}
+	elementType, _ := valueType.ElemType()
+	if elementType.Nature() != semantic.String {
+		return nil, errors.Newf(codes.Invalid, "parameter \"params\"'s value type is not a string: %v", elementType)
+	}
+	paramsDict.Range(func(key, value values.Value) {
+		k := key.Str()
+		value.Array().Range(func(i int, value values.Value) {
+			v := value.Str()
+			params.Add(k, v)
+		})
+	})
+	return params, nil
+}
+
+// readHeaders reads the optional headers argument.
+func readHeaders(args argumentGetter) (map[string]string, error) {
+	headers := make(map[string]string)
+	headersV, ok := args.Get("headers")
+	if !ok || headersV.IsNull() {
+		return headers, nil
+	}
+	if headersV.Type().Nature() != semantic.Dictionary {
+		return nil, errors.Newf(codes.Invalid, "parameter \"headers\" is not of type [string:string] : %v", headersV.Type())
+	}
+	var rangeErr error
+	headersV.Dict().Range(func(k values.Value, v values.Value) {
+		if k.Type().Nature() == semantic.String &&
+			v.Type().Nature() == semantic.String {
+			headers[k.Str()] = v.Str()
+		} else {
+			rangeErr = errors.Newf(codes.Invalid, "header key and values must be a string: %q", k)
+		}
+	})
+	if rangeErr != nil {
+		return nil, rangeErr
+	}
+	return headers, nil
+}
+
 // headerToDict constructs a values.Dictionary from a map of header keys and values.
 func headerToDict(header http.Header) (values.Dictionary, error) {
 	builder := values.NewDictBuilder(semantic.NewDictType(semantic.BasicString, semantic.BasicString))
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -179,6 +180,96 @@ requests.do(method: "GET", url:"%s/path/a/b/c", config: config)
 		t.Errorf("unexpected cause of failure, got err: %v", err)
 	}
 }
+func TestDo_Retries(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(503)
+			return
+		}
+		w.WriteHeader(204)
+	}))
+	defer ts.Close()
+
+	script := fmt.Sprintf(`
+import "http/requests"
+
+c = requests.defaultConfig
+config = {c with retries: 2, retryBackoff: 1ms}
+resp = requests.do(method: "GET", url:"%s/path/a/b/c", config: config)
+`, ts.URL)
+
+	ctx := flux.NewDefaultDependencies().Inject(context.Background())
+	_, scope, err := runtime.Eval(ctx, script)
+	if err != nil {
+		t.Fatal("evaluation of requests.do failed: ", err)
+	}
+	respV, ok := scope.Lookup("resp")
+	if !ok {
+		t.Fatal("no resp in scope")
+	}
+	if statusCode, ok := respV.Object().Get("statusCode"); !ok {
+		t.Error("no statusCode found in response")
+	} else if want, got := int64(204), statusCode.Int(); want != got {
+		t.Errorf("unexpected status code want: %d got: %d", want, got)
+	}
+	if want, got := int32(3), atomic.LoadInt32(&calls); want != got {
+		t.Errorf("unexpected number of calls want: %d got: %d", want, got)
+	}
+}
+func TestDo_Credential(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		if request.Header.Get("Authorization") != "Token secret" {
+			w.WriteHeader(401)
+			return
+		}
+		w.WriteHeader(204)
+	}))
+	defer ts.Close()
+
+	script := fmt.Sprintf(`
+import "http/requests"
+
+resp = requests.do(method: "GET", url:"%s", config: {requests.defaultConfig with credential: "api"})
+`, ts.URL)
+
+	deps := flux.NewDefaultDependencies()
+	deps.Deps.CredentialProvider = fhttp.StaticCredentialProvider{
+		"api": tokenCredential("secret"),
+	}
+	_, scope, err := runtime.Eval(deps.Inject(context.Background()), script)
+	if err != nil {
+		t.Fatal("evaluation of requests.do failed: ", err)
+	}
+	respV, ok := scope.Lookup("resp")
+	if !ok {
+		t.Fatal("no resp in scope")
+	}
+	if statusCode, ok := respV.Object().Get("statusCode"); !ok {
+		t.Error("no statusCode found in response")
+	} else if want, got := int64(204), statusCode.Int(); want != got {
+		t.Errorf("unexpected status code want: %d got: %d", want, got)
+	}
+}
+
+// tokenCredential authorizes requests with a static token.
+type tokenCredential string
+
+func (c tokenCredential) Client(ctx context.Context, client fhttp.Client) (fhttp.Client, error) {
+	return tokenClient{client: client, token: string(c)}, nil
+}
+
+type tokenClient struct {
+	client fhttp.Client
+	token  string
+}
+
+func (c tokenClient) Do(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Token "+c.token)
+	return c.client.Do(req)
+}
+
 func TestDo_VerifyTLS_Pass(t *testing.T) {
 	var req *http.Request
 
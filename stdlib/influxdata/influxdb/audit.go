@@ -0,0 +1,64 @@
+package influxdb
+
+import (
+	"context"
+
+	"github.com/InfluxCommunity/flux/dependencies/audit"
+	"github.com/InfluxCommunity/flux/dependencies/dryrun"
+	"github.com/InfluxCommunity/flux/dependencies/influxdb"
+)
+
+// auditWriter is a Writer that records the metrics
+// it writes for the audit log.
+type auditWriter struct {
+	Writer
+	write *audit.Write
+}
+
+// newAuditWriter wraps the Writer for the configuration
+// so the writes of the function are audited.
+func newAuditWriter(ctx context.Context, function string, conf influxdb.Config, w Writer) Writer {
+	return &auditWriter{
+		Writer: w,
+		write:  audit.NewWrite(ctx, function, destination(conf)),
+	}
+}
+
+// destination returns where the configuration writes to,
+// without the token.
+func destination(conf influxdb.Config) string {
+	destination := conf.Org.IdOrName() + "/" + conf.Bucket.IdOrName()
+	if conf.Host != "" {
+		destination = audit.URL(conf.Host) + "/" + destination
+	}
+	return destination
+}
+
+// writerFor returns the Writer of the provider for the function
+// with its writes audited. In dry-run mode, the provider is not used
+// and the metrics are captured as line protocol instead.
+func writerFor(ctx context.Context, function string, provider influxdb.Provider, conf influxdb.Config) (Writer, error) {
+	if sink := dryrun.GetSink(ctx); sink != nil {
+		return influxdb.NewDryRunWriter(sink.NewWriter(function, destination(conf))), nil
+	}
+	w, err := provider.WriterFor(ctx, conf)
+	if err != nil {
+		return nil, err
+	}
+	return newAuditWriter(ctx, function, conf, w), nil
+}
+
+func (w *auditWriter) Write(metrics ...Metric) error {
+	if err := w.Writer.Write(metrics...); err != nil {
+		w.write.Fail(err)
+		return err
+	}
+	w.write.Add(int64(len(metrics)), 0)
+	return nil
+}
+
+func (w *auditWriter) Close() error {
+	err := w.Writer.Close()
+	w.write.Done(err)
+	return err
+}
@@ -5,6 +5,8 @@
 package influxdb
 
 import (
+	"context"
+
 	"github.com/InfluxCommunity/flux"
 	"github.com/InfluxCommunity/flux/codes"
 	"github.com/InfluxCommunity/flux/dependencies/influxdb"
@@ -52,6 +54,9 @@ func init() {
 		FromRemoteRule{},
 		MergeRemoteRangeRule{},
 		MergeRemoteFilterRule{},
+		MergeRemoteWindowAggregateRule{},
+		MergeRemoteAggregateWindowRule{},
+		MergeRemoteGroupAggregateRule{},
 	)
 }
 
@@ -167,6 +172,51 @@ type FromRemoteProcedureSpec struct {
 	influxdb.Config
 	Bounds       flux.Bounds
 	PredicateSet influxdb.PredicateSet
+
+	// WindowAggregate and GroupAggregate are set when an aggregate
+	// of the read has been pushed down to the influxdb instance.
+	// At most one of them is set.
+	WindowAggregate *influxdb.WindowAggregate
+	GroupAggregate  *influxdb.GroupAggregate
+}
+
+// HasAggregate returns true if an aggregate
+// has been pushed down into the read.
+func (s *FromRemoteProcedureSpec) HasAggregate() bool {
+	return s.WindowAggregate != nil || s.GroupAggregate != nil
+}
+
+// readerFor constructs the reader for the read
+// and the aggregate that has been pushed down into it.
+func (s *FromRemoteProcedureSpec) readerFor(ctx context.Context, provider influxdb.Provider) (influxdb.Reader, error) {
+	if !s.HasAggregate() {
+		return provider.ReaderFor(ctx, s.Config, s.Bounds, s.PredicateSet)
+	}
+	ap, ok := provider.(influxdb.AggregateProvider)
+	if !ok {
+		return nil, errors.New(codes.Internal, "influxdb provider cannot evaluate the aggregate of the read")
+	}
+	if s.WindowAggregate != nil {
+		return ap.WindowAggregateReaderFor(ctx, s.Config, s.Bounds, s.PredicateSet, *s.WindowAggregate)
+	}
+	return ap.GroupAggregateReaderFor(ctx, s.Config, s.Bounds, s.PredicateSet, *s.GroupAggregate)
+}
+
+// supportsAggregate reports whether the provider can evaluate
+// the aggregate that has been pushed down into the read.
+func (s *FromRemoteProcedureSpec) supportsAggregate(provider influxdb.Provider) bool {
+	ap, ok := provider.(influxdb.AggregateProvider)
+	if !ok {
+		return false
+	}
+	switch {
+	case s.WindowAggregate != nil:
+		return ap.SupportsWindowAggregate(*s.WindowAggregate)
+	case s.GroupAggregate != nil:
+		return ap.SupportsGroupAggregate(*s.GroupAggregate)
+	default:
+		return true
+	}
 }
 
 func (s *FromRemoteProcedureSpec) Kind() plan.ProcedureKind {
@@ -191,6 +241,13 @@ func (s *FromRemoteProcedureSpec) Copy() plan.ProcedureSpec {
 	ns := new(FromRemoteProcedureSpec)
 	*ns = *s
 	ns.PredicateSet = s.PredicateSet.Copy()
+	if s.WindowAggregate != nil {
+		wa := *s.WindowAggregate
+		ns.WindowAggregate = &wa
+	}
+	if s.GroupAggregate != nil {
+		ns.GroupAggregate = s.GroupAggregate.Copy()
+	}
 	return ns
 }
 
@@ -214,7 +271,7 @@ func createFromSource(ps plan.ProcedureSpec, id execute.DatasetID, a execute.Adm
 	}
 
 	provider := influxdb.GetProvider(a.Context())
-	reader, err := provider.ReaderFor(a.Context(), spec.Config, spec.Bounds, spec.PredicateSet)
+	reader, err := spec.readerFor(a.Context(), provider)
 	if err != nil {
 		return nil, err
 	}
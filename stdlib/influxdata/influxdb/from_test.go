@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/InfluxCommunity/flux"
+	influxdeps "github.com/InfluxCommunity/flux/dependencies/influxdb"
 	"github.com/InfluxCommunity/flux/execute"
 	"github.com/InfluxCommunity/flux/execute/executetest"
 	"github.com/InfluxCommunity/flux/internal/operation"
@@ -395,6 +396,125 @@ from(bucket: "telegraf")
         },
         onEmpty: "keep",
     )
+`,
+				Tables: defaultTablesFn,
+			},
+		},
+		{
+			name: "window aggregate query",
+			spec: &influxdb.FromRemoteProcedureSpec{
+				Config: influxdb.Config{
+					Org:    influxdb.NameOrID{Name: "influxdata"},
+					Bucket: influxdb.NameOrID{Name: "telegraf"},
+					Token:  "mytoken",
+				},
+				Bounds: flux.Bounds{
+					Start: flux.Time{
+						IsRelative: true,
+						Relative:   -time.Minute,
+					},
+					Stop: flux.Time{
+						IsRelative: true,
+					},
+					Now: now,
+				},
+				WindowAggregate: &influxdeps.WindowAggregate{
+					Every:       flux.ConvertDuration(10 * time.Second),
+					Period:      flux.ConvertDuration(10 * time.Second),
+					Offset:      flux.ConvertDuration(-5 * time.Second),
+					CreateEmpty: true,
+					Aggregate:   "mean",
+				},
+			},
+			want: testutil.Want{
+				Params: url.Values{
+					"org": []string{"influxdata"},
+				},
+				Query: `package main
+
+
+from(bucket: "telegraf")
+    |> range(start: 2020-10-22T09:29:00Z, stop: 2020-10-22T09:30:00Z)
+    |> window(every: 10s, period: 10s, offset: -5s, createEmpty: true)
+    |> mean()
+`,
+				Tables: defaultTablesFn,
+			},
+		},
+		{
+			name: "aggregate window query",
+			spec: &influxdb.FromRemoteProcedureSpec{
+				Config: influxdb.Config{
+					Org:    influxdb.NameOrID{Name: "influxdata"},
+					Bucket: influxdb.NameOrID{Name: "telegraf"},
+					Token:  "mytoken",
+				},
+				Bounds: flux.Bounds{
+					Start: flux.Time{
+						IsRelative: true,
+						Relative:   -time.Minute,
+					},
+					Stop: flux.Time{
+						IsRelative: true,
+					},
+					Now: now,
+				},
+				WindowAggregate: &influxdeps.WindowAggregate{
+					Every:     flux.ConvertDuration(10 * time.Second),
+					Period:    flux.ConvertDuration(10 * time.Second),
+					Aggregate: "count",
+					TimeSrc:   "_stop",
+				},
+			},
+			want: testutil.Want{
+				Params: url.Values{
+					"org": []string{"influxdata"},
+				},
+				Query: `package main
+
+
+from(bucket: "telegraf")
+    |> range(start: 2020-10-22T09:29:00Z, stop: 2020-10-22T09:30:00Z)
+    |> aggregateWindow(every: 10s, period: 10s, fn: count, timeSrc: "_stop", createEmpty: false)
+`,
+				Tables: defaultTablesFn,
+			},
+		},
+		{
+			name: "group aggregate query",
+			spec: &influxdb.FromRemoteProcedureSpec{
+				Config: influxdb.Config{
+					Org:    influxdb.NameOrID{Name: "influxdata"},
+					Bucket: influxdb.NameOrID{Name: "telegraf"},
+					Token:  "mytoken",
+				},
+				Bounds: flux.Bounds{
+					Start: flux.Time{
+						IsRelative: true,
+						Relative:   -time.Minute,
+					},
+					Stop: flux.Time{
+						IsRelative: true,
+					},
+					Now: now,
+				},
+				GroupAggregate: &influxdeps.GroupAggregate{
+					Mode:      flux.GroupModeExcept,
+					Columns:   []string{"host"},
+					Aggregate: "sum",
+				},
+			},
+			want: testutil.Want{
+				Params: url.Values{
+					"org": []string{"influxdata"},
+				},
+				Query: `package main
+
+
+from(bucket: "telegraf")
+    |> range(start: 2020-10-22T09:29:00Z, stop: 2020-10-22T09:30:00Z)
+    |> group(columns: ["host"], mode: "except")
+    |> sum()
 `,
 				Tables: defaultTablesFn,
 			},
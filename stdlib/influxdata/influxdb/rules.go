@@ -2,8 +2,11 @@ package influxdb
 
 import (
 	"context"
+	"math"
 
+	"github.com/InfluxCommunity/flux"
 	"github.com/InfluxCommunity/flux/dependencies/influxdb"
+	"github.com/InfluxCommunity/flux/execute"
 	"github.com/InfluxCommunity/flux/plan"
 	"github.com/InfluxCommunity/flux/stdlib/universe"
 )
@@ -82,7 +85,7 @@ func (p MergeRemoteFilterRule) Pattern() plan.Pattern {
 func (p MergeRemoteFilterRule) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
 	fromNode := node.Predecessors()[0]
 	fromSpec := fromNode.ProcedureSpec().(*FromRemoteProcedureSpec)
-	if fromSpec.Bounds.IsEmpty() {
+	if fromSpec.Bounds.IsEmpty() || fromSpec.HasAggregate() {
 		return node, false, nil
 	}
 	filterSpec := node.ProcedureSpec().(*universe.FilterProcedureSpec)
@@ -111,6 +114,209 @@ func (p MergeRemoteFilterRule) Rewrite(ctx context.Context, node plan.Node) (pla
 	return n, true, nil
 }
 
+// remoteAggregateKinds are the aggregates that can be
+// pushed down into a read from an influxdb instance.
+var remoteAggregateKinds = []plan.ProcedureKind{
+	universe.CountKind,
+	universe.SumKind,
+	universe.MeanKind,
+	universe.MinKind,
+	universe.MaxKind,
+	universe.FirstKind,
+	universe.LastKind,
+}
+
+// remoteAggregate returns the name of the aggregate function
+// for the procedure spec if it only aggregates the _value column
+// and can be evaluated by the influxdb instance.
+func remoteAggregate(spec plan.ProcedureSpec) (string, bool) {
+	var (
+		simple   *execute.SimpleAggregateConfig
+		selector *execute.SelectorConfig
+	)
+	switch spec := spec.(type) {
+	case *universe.CountProcedureSpec:
+		simple = &spec.SimpleAggregateConfig
+	case *universe.SumProcedureSpec:
+		simple = &spec.SimpleAggregateConfig
+	case *universe.MeanProcedureSpec:
+		simple = &spec.SimpleAggregateConfig
+	case *universe.MinProcedureSpec:
+		selector = &spec.SelectorConfig
+	case *universe.MaxProcedureSpec:
+		selector = &spec.SelectorConfig
+	case *universe.FirstProcedureSpec:
+		selector = &spec.SelectorConfig
+	case *universe.LastProcedureSpec:
+		selector = &spec.SelectorConfig
+	default:
+		return "", false
+	}
+
+	if simple != nil {
+		if len(simple.Columns) != 1 || simple.Columns[0] != execute.DefaultValueColLabel {
+			return "", false
+		}
+	} else if selector.Column != execute.DefaultValueColLabel {
+		return "", false
+	}
+	return string(spec.Kind()), true
+}
+
+type MergeRemoteWindowAggregateRule struct{}
+
+func (p MergeRemoteWindowAggregateRule) Name() string {
+	return "influxdata/influxdb.MergeRemoteWindowAggregateRule"
+}
+
+func (p MergeRemoteWindowAggregateRule) Pattern() plan.Pattern {
+	return plan.MultiSuccessorOneOf(remoteAggregateKinds,
+		plan.SingleSuccessor(universe.WindowKind,
+			plan.SingleSuccessor(FromRemoteKind)))
+}
+
+func (p MergeRemoteWindowAggregateRule) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
+	windowNode := node.Predecessors()[0]
+	fromNode := windowNode.Predecessors()[0]
+	fromSpec := fromNode.ProcedureSpec().(*FromRemoteProcedureSpec)
+	if fromSpec.Bounds.IsEmpty() || fromSpec.HasAggregate() {
+		return node, false, nil
+	}
+
+	aggregate, ok := remoteAggregate(node.ProcedureSpec())
+	if !ok {
+		return node, false, nil
+	}
+
+	// The window must use the default columns and location
+	// since those are not sent to the influxdb instance.
+	// An infinite window cannot be written as a duration.
+	windowSpec := windowNode.ProcedureSpec().(*universe.WindowProcedureSpec)
+	if windowSpec.TimeColumn != execute.DefaultTimeColLabel ||
+		windowSpec.StartColumn != execute.DefaultStartColLabel ||
+		windowSpec.StopColumn != execute.DefaultStopColLabel ||
+		!windowSpec.Window.Location.IsUTC() ||
+		windowSpec.Window.Every.Nanoseconds() == math.MaxInt64 {
+		return node, false, nil
+	}
+
+	fromSpec = fromSpec.Copy().(*FromRemoteProcedureSpec)
+	fromSpec.WindowAggregate = &influxdb.WindowAggregate{
+		Every:       windowSpec.Window.Every,
+		Period:      windowSpec.Window.Period,
+		Offset:      windowSpec.Window.Offset,
+		CreateEmpty: windowSpec.CreateEmpty,
+		Aggregate:   aggregate,
+	}
+
+	// If the provider cannot evaluate the aggregate,
+	// it is evaluated by flux after the read.
+	if !fromSpec.supportsAggregate(influxdb.GetProvider(ctx)) {
+		return node, false, nil
+	}
+	return plan.ReplacePhysicalNodes(ctx, node, fromNode, "fromRemote", fromSpec), true, nil
+}
+
+// MergeRemoteAggregateWindowRule pushes down the aggregateWindow
+// that replaces a window, aggregate and the combination of the windows
+// when the aggregate window optimization is enabled.
+type MergeRemoteAggregateWindowRule struct{}
+
+func (p MergeRemoteAggregateWindowRule) Name() string {
+	return "influxdata/influxdb.MergeRemoteAggregateWindowRule"
+}
+
+func (p MergeRemoteAggregateWindowRule) Pattern() plan.Pattern {
+	return plan.MultiSuccessor(universe.AggregateWindowKind,
+		plan.SingleSuccessor(FromRemoteKind))
+}
+
+func (p MergeRemoteAggregateWindowRule) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
+	fromNode := node.Predecessors()[0]
+	fromSpec := fromNode.ProcedureSpec().(*FromRemoteProcedureSpec)
+	if fromSpec.Bounds.IsEmpty() || fromSpec.HasAggregate() {
+		return node, false, nil
+	}
+
+	spec := node.ProcedureSpec().(*universe.AggregateWindowProcedureSpec)
+	if spec.ValueCol != execute.DefaultValueColLabel ||
+		spec.ParallelMergeFactor > 1 ||
+		!spec.WindowSpec.Window.Location.IsUTC() {
+		return node, false, nil
+	}
+
+	timeSrc := execute.DefaultStopColLabel
+	if spec.UseStart {
+		timeSrc = execute.DefaultStartColLabel
+	}
+	fromSpec = fromSpec.Copy().(*FromRemoteProcedureSpec)
+	fromSpec.WindowAggregate = &influxdb.WindowAggregate{
+		Every:       spec.WindowSpec.Window.Every,
+		Period:      spec.WindowSpec.Window.Period,
+		Offset:      spec.WindowSpec.Window.Offset,
+		CreateEmpty: spec.WindowSpec.CreateEmpty,
+		Aggregate:   string(spec.AggregateKind),
+		TimeSrc:     timeSrc,
+	}
+
+	// If the provider cannot evaluate the aggregate,
+	// it is evaluated by flux after the read.
+	if !fromSpec.supportsAggregate(influxdb.GetProvider(ctx)) {
+		return node, false, nil
+	}
+
+	n, err := plan.MergeToPhysicalNode(node, fromNode, fromSpec)
+	if err != nil {
+		return nil, false, err
+	}
+	return n, true, nil
+}
+
+type MergeRemoteGroupAggregateRule struct{}
+
+func (p MergeRemoteGroupAggregateRule) Name() string {
+	return "influxdata/influxdb.MergeRemoteGroupAggregateRule"
+}
+
+func (p MergeRemoteGroupAggregateRule) Pattern() plan.Pattern {
+	return plan.MultiSuccessorOneOf(remoteAggregateKinds,
+		plan.SingleSuccessor(universe.GroupKind,
+			plan.SingleSuccessor(FromRemoteKind)))
+}
+
+func (p MergeRemoteGroupAggregateRule) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
+	groupNode := node.Predecessors()[0]
+	fromNode := groupNode.Predecessors()[0]
+	fromSpec := fromNode.ProcedureSpec().(*FromRemoteProcedureSpec)
+	if fromSpec.Bounds.IsEmpty() || fromSpec.HasAggregate() {
+		return node, false, nil
+	}
+
+	aggregate, ok := remoteAggregate(node.ProcedureSpec())
+	if !ok {
+		return node, false, nil
+	}
+
+	groupSpec := groupNode.ProcedureSpec().(*universe.GroupProcedureSpec)
+	if groupSpec.GroupMode != flux.GroupModeBy && groupSpec.GroupMode != flux.GroupModeExcept {
+		return node, false, nil
+	}
+
+	fromSpec = fromSpec.Copy().(*FromRemoteProcedureSpec)
+	fromSpec.GroupAggregate = &influxdb.GroupAggregate{
+		Mode:      groupSpec.GroupMode,
+		Columns:   append([]string(nil), groupSpec.GroupKeys...),
+		Aggregate: aggregate,
+	}
+
+	// If the provider cannot evaluate the aggregate,
+	// it is evaluated by flux after the read.
+	if !fromSpec.supportsAggregate(influxdb.GetProvider(ctx)) {
+		return node, false, nil
+	}
+	return plan.ReplacePhysicalNodes(ctx, node, fromNode, "fromRemote", fromSpec), true, nil
+}
+
 type BucketsRemoteRule struct{}
 
 func (p BucketsRemoteRule) Name() string {
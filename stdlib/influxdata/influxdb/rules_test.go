@@ -8,6 +8,7 @@ import (
 	"github.com/InfluxCommunity/flux"
 	"github.com/InfluxCommunity/flux/codes"
 	influxdeps "github.com/InfluxCommunity/flux/dependencies/influxdb"
+	"github.com/InfluxCommunity/flux/execute"
 	"github.com/InfluxCommunity/flux/execute/executetest"
 	"github.com/InfluxCommunity/flux/internal/errors"
 	"github.com/InfluxCommunity/flux/interpreter"
@@ -444,3 +445,268 @@ func TestDefaultFromAttributes(t *testing.T) {
 		})
 	}
 }
+
+func TestMergeRemoteAggregateRules(t *testing.T) {
+	withProvider := func(provider influxdeps.Provider) context.Context {
+		deps := flux.NewDefaultDependencies()
+		ctx := deps.Inject(context.Background())
+		return influxdeps.Dependency{
+			Provider: provider,
+		}.Inject(ctx)
+	}
+
+	fromSpec := influxdb.FromRemoteProcedureSpec{
+		Config: influxdb.Config{
+			Bucket: influxdb.NameOrID{Name: "telegraf"},
+			Host:   "http://localhost:8086",
+		},
+		Bounds: flux.Bounds{
+			Start: flux.Time{
+				IsRelative: true,
+				Relative:   -time.Hour,
+			},
+			Stop: flux.Time{
+				IsRelative: true,
+			},
+		},
+	}
+	windowSpec := universe.WindowProcedureSpec{
+		Window: plan.WindowSpec{
+			Every:  flux.ConvertDuration(time.Minute),
+			Period: flux.ConvertDuration(time.Minute),
+		},
+		TimeColumn:  "_time",
+		StartColumn: "_start",
+		StopColumn:  "_stop",
+		CreateEmpty: true,
+	}
+	meanSpec := universe.MeanProcedureSpec{
+		SimpleAggregateConfig: execute.DefaultSimpleAggregateConfig,
+	}
+	groupSpec := universe.GroupProcedureSpec{
+		GroupMode: flux.GroupModeBy,
+		GroupKeys: []string{"host"},
+	}
+	countSpec := universe.CountProcedureSpec{
+		SimpleAggregateConfig: execute.DefaultSimpleAggregateConfig,
+	}
+	aggregateWindowSpec := universe.AggregateWindowProcedureSpec{
+		WindowSpec:    &windowSpec,
+		AggregateKind: universe.MeanKind,
+		ValueCol:      "_value",
+	}
+
+	for _, tc := range []plantest.RuleTestCase{
+		{
+			Name:    "window aggregate",
+			Context: withProvider(influxdeps.HttpProvider{}),
+			Rules:   []plan.Rule{influxdb.MergeRemoteWindowAggregateRule{}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("fromRemote", &fromSpec),
+					plan.CreatePhysicalNode("window", &windowSpec),
+					plan.CreatePhysicalNode("mean", &meanSpec),
+				},
+				Edges: [][2]int{{0, 1}, {1, 2}},
+			},
+			After: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("fromRemote", &influxdb.FromRemoteProcedureSpec{
+						Config: fromSpec.Config,
+						Bounds: fromSpec.Bounds,
+						WindowAggregate: &influxdeps.WindowAggregate{
+							Every:       windowSpec.Window.Every,
+							Period:      windowSpec.Window.Period,
+							CreateEmpty: true,
+							Aggregate:   "mean",
+						},
+					}),
+				},
+			},
+		},
+		{
+			Name:    "window aggregate of another column",
+			Context: withProvider(influxdeps.HttpProvider{}),
+			Rules:   []plan.Rule{influxdb.MergeRemoteWindowAggregateRule{}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("fromRemote", &fromSpec),
+					plan.CreatePhysicalNode("window", &windowSpec),
+					plan.CreatePhysicalNode("mean", &universe.MeanProcedureSpec{
+						SimpleAggregateConfig: execute.SimpleAggregateConfig{
+							Columns: []string{"other"},
+						},
+					}),
+				},
+				Edges: [][2]int{{0, 1}, {1, 2}},
+			},
+			NoChange: true,
+		},
+		{
+			Name:    "window aggregate not supported by the provider",
+			Context: withProvider(influxdeps.UnimplementedProvider{}),
+			Rules:   []plan.Rule{influxdb.MergeRemoteWindowAggregateRule{}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("fromRemote", &fromSpec),
+					plan.CreatePhysicalNode("window", &windowSpec),
+					plan.CreatePhysicalNode("mean", &meanSpec),
+				},
+				Edges: [][2]int{{0, 1}, {1, 2}},
+			},
+			NoChange: true,
+		},
+		{
+			Name:    "aggregate window",
+			Context: withProvider(influxdeps.HttpProvider{}),
+			Rules:   []plan.Rule{influxdb.MergeRemoteAggregateWindowRule{}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("fromRemote", &fromSpec),
+					plan.CreatePhysicalNode("aggregateWindow", &aggregateWindowSpec),
+				},
+				Edges: [][2]int{{0, 1}},
+			},
+			After: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("merged_fromRemote_aggregateWindow", &influxdb.FromRemoteProcedureSpec{
+						Config: fromSpec.Config,
+						Bounds: fromSpec.Bounds,
+						WindowAggregate: &influxdeps.WindowAggregate{
+							Every:       windowSpec.Window.Every,
+							Period:      windowSpec.Window.Period,
+							CreateEmpty: true,
+							Aggregate:   "mean",
+							TimeSrc:     "_stop",
+						},
+					}),
+				},
+			},
+		},
+		{
+			Name:    "group aggregate",
+			Context: withProvider(influxdeps.HttpProvider{}),
+			Rules:   []plan.Rule{influxdb.MergeRemoteGroupAggregateRule{}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("fromRemote", &fromSpec),
+					plan.CreatePhysicalNode("group", &groupSpec),
+					plan.CreatePhysicalNode("count", &countSpec),
+				},
+				Edges: [][2]int{{0, 1}, {1, 2}},
+			},
+			After: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("fromRemote", &influxdb.FromRemoteProcedureSpec{
+						Config: fromSpec.Config,
+						Bounds: fromSpec.Bounds,
+						GroupAggregate: &influxdeps.GroupAggregate{
+							Mode:      flux.GroupModeBy,
+							Columns:   []string{"host"},
+							Aggregate: "count",
+						},
+					}),
+				},
+			},
+		},
+		{
+			Name:    "group aggregate after an aggregate",
+			Context: withProvider(influxdeps.HttpProvider{}),
+			Rules:   []plan.Rule{influxdb.MergeRemoteGroupAggregateRule{}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("fromRemote", &influxdb.FromRemoteProcedureSpec{
+						Config: fromSpec.Config,
+						Bounds: fromSpec.Bounds,
+						GroupAggregate: &influxdeps.GroupAggregate{
+							Mode:      flux.GroupModeBy,
+							Aggregate: "count",
+						},
+					}),
+					plan.CreatePhysicalNode("group", &groupSpec),
+					plan.CreatePhysicalNode("count", &countSpec),
+				},
+				Edges: [][2]int{{0, 1}, {1, 2}},
+			},
+			NoChange: true,
+		},
+		{
+			Name:    "group aggregate not supported by the provider",
+			Context: withProvider(influxdeps.UnimplementedProvider{}),
+			Rules:   []plan.Rule{influxdb.MergeRemoteGroupAggregateRule{}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("fromRemote", &fromSpec),
+					plan.CreatePhysicalNode("group", &groupSpec),
+					plan.CreatePhysicalNode("count", &countSpec),
+				},
+				Edges: [][2]int{{0, 1}, {1, 2}},
+			},
+			NoChange: true,
+		},
+		{
+			Name:    "group aggregate rejected by the provider",
+			Context: withProvider(countlessProvider{}),
+			Rules:   []plan.Rule{influxdb.MergeRemoteGroupAggregateRule{}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("fromRemote", &fromSpec),
+					plan.CreatePhysicalNode("group", &groupSpec),
+					plan.CreatePhysicalNode("count", &countSpec),
+				},
+				Edges: [][2]int{{0, 1}, {1, 2}},
+			},
+			NoChange: true,
+		},
+		{
+			// The host is not resolved when the query is planned.
+			Name:    "group aggregate with an unresolvable host",
+			Context: withProvider(influxdeps.HttpProvider{}),
+			Rules:   []plan.Rule{influxdb.MergeRemoteGroupAggregateRule{}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("fromRemote", &influxdb.FromRemoteProcedureSpec{
+						Config: influxdb.Config{
+							Bucket: influxdb.NameOrID{Name: "telegraf"},
+							Host:   "http://influxdb.invalid:8086",
+						},
+						Bounds: fromSpec.Bounds,
+					}),
+					plan.CreatePhysicalNode("group", &groupSpec),
+					plan.CreatePhysicalNode("count", &countSpec),
+				},
+				Edges: [][2]int{{0, 1}, {1, 2}},
+			},
+			After: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("fromRemote", &influxdb.FromRemoteProcedureSpec{
+						Config: influxdb.Config{
+							Bucket: influxdb.NameOrID{Name: "telegraf"},
+							Host:   "http://influxdb.invalid:8086",
+						},
+						Bounds: fromSpec.Bounds,
+						GroupAggregate: &influxdeps.GroupAggregate{
+							Mode:      flux.GroupModeBy,
+							Columns:   []string{"host"},
+							Aggregate: "count",
+						},
+					}),
+				},
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			plantest.PhysicalRuleTestHelper(t, &tc)
+		})
+	}
+}
+
+// countlessProvider is an AggregateProvider that cannot count.
+type countlessProvider struct {
+	influxdeps.HttpProvider
+}
+
+func (countlessProvider) SupportsGroupAggregate(spec influxdeps.GroupAggregate) bool {
+	return spec.Aggregate != "count"
+}
@@ -40,6 +40,27 @@ func TestGet(t *testing.T) {
 			},
 			err: "secret key \"missingkey\" not found",
 		},
+		{
+			name: "chained secret service",
+			secrets: secret.ChainedSecretService{
+				mock.SecretService{"otherkey": "othervalue"},
+				mock.SecretService{"mykey": "myvalue"},
+			},
+			args: map[string]values.Value{
+				"key": values.NewString("mykey"),
+			},
+			want: values.NewString("myvalue"),
+		},
+		{
+			name: "scoped environment secret service",
+			secrets: secret.ScopedEnvironmentSecretService{
+				Allow: []string{"otherkey"},
+			},
+			args: map[string]values.Value{
+				"key": values.NewString("mykey"),
+			},
+			err: "secret key \"mykey\" not found",
+		},
 		{
 			name: "no secret service",
 			args: map[string]values.Value{
@@ -14,6 +14,7 @@ import (
 	"github.com/InfluxCommunity/flux/codes"
 	"github.com/InfluxCommunity/flux/csv"
 	"github.com/InfluxCommunity/flux/dependencies/influxdb"
+	furl "github.com/InfluxCommunity/flux/dependencies/url"
 	"github.com/InfluxCommunity/flux/execute"
 	"github.com/InfluxCommunity/flux/internal/errors"
 	"github.com/InfluxCommunity/flux/memory"
@@ -103,7 +104,7 @@ func (s *source) validateHost(host string) error {
 	if err != nil {
 		return err
 	}
-	return validator.Validate(u)
+	return furl.ForFunction(validator, "influxdata/influxdb.from").Validate(u)
 }
 
 func (s *source) newRequest(ctx context.Context) (*http.Request, error) {
@@ -130,7 +131,7 @@ func (s *source) newRequest(ctx context.Context) (*http.Request, error) {
 		return nil, err
 	}
 
-	if err := urlv.Validate(u); err != nil {
+	if err := furl.ForFunction(urlv, "influxdata/influxdb.from").Validate(u); err != nil {
 		return nil, err
 	}
 
@@ -94,7 +94,7 @@ func NewToTransformation(ctx context.Context, id execute.DatasetID, spec *ToProc
 		Host:   spec.Spec.Host,
 		Token:  spec.Spec.Token,
 	}
-	writer, err := deps.WriterFor(ctx, conf)
+	writer, err := writerFor(ctx, "influxdata/influxdb.to", deps, conf)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -145,7 +145,7 @@ func (t *WideToTransformation) RetractTable(id execute.DatasetID, key flux.Group
 // NewWideToTransformation returns a new *WideToTransformation with the appropriate fields set.
 func NewWideToTransformation(ctx context.Context, d execute.Dataset, cache execute.TableBuilderCache, s *WideToProcedureSpec) (*WideToTransformation, error) {
 	provider := GetProvider(ctx)
-	writer, err := provider.WriterFor(ctx, s.Config)
+	writer, err := writerFor(ctx, "influxdata/influxdb.wideTo", provider, s.Config)
 	if err != nil {
 		return nil, err
 	}
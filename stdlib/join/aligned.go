@@ -0,0 +1,225 @@
+package join
+
+import (
+	"math"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/interpreter"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/InfluxCommunity/flux/values"
+)
+
+const AlignedJoinKind = "join.aligned"
+
+func init() {
+	alignedSignature := runtime.MustLookupBuiltinType("join", "aligned")
+	runtime.RegisterPackageValue(
+		"join", "aligned", flux.MustValue(flux.FunctionValue("aligned", createAlignedJoinOpSpec, alignedSignature)),
+	)
+	asofSignature := runtime.MustLookupBuiltinType("join", "asof")
+	runtime.RegisterPackageValue(
+		"join", "asof", flux.MustValue(flux.FunctionValue("asof", createAsofJoinOpSpec, asofSignature)),
+	)
+	plan.RegisterProcedureSpec(AlignedJoinKind, newAlignedJoinProcedure, AlignedJoinKind)
+	execute.RegisterTransformation(AlignedJoinKind, createAlignedJoinTransformation)
+}
+
+type AlignedJoinOpSpec struct {
+	on        []ColumnPair
+	as        interpreter.ResolvedFunction
+	left      *flux.TableObject
+	right     *flux.TableObject
+	method    string
+	direction string
+	tolerance flux.Duration
+}
+
+func (o *AlignedJoinOpSpec) Kind() flux.OperationKind {
+	return flux.OperationKind(AlignedJoinKind)
+}
+
+func createAlignedJoinOpSpec(args flux.Arguments, p *flux.Administration) (flux.OperationSpec, error) {
+	op := &AlignedJoinOpSpec{
+		method:    "inner",
+		direction: DirectionNearest,
+	}
+	if err := op.readArgs(args, p); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+func createAsofJoinOpSpec(args flux.Arguments, p *flux.Administration) (flux.OperationSpec, error) {
+	op := &AlignedJoinOpSpec{
+		method:    "left",
+		direction: DirectionBackward,
+		// Without a tolerance, rows match regardless of how far apart they are.
+		tolerance: values.ConvertDurationNsecs(math.MaxInt64),
+	}
+
+	if o, ok, err := args.GetFunction("on"); err != nil {
+		return nil, err
+	} else if ok {
+		on, err := interpreter.ResolveFunction(o)
+		if err != nil {
+			return nil, err
+		}
+		cols, err := getColumnPairs(on.Fn)
+		if err != nil {
+			return nil, err
+		}
+		for _, pair := range cols {
+			if pair.Left == execute.DefaultTimeColLabel || pair.Right == execute.DefaultTimeColLabel {
+				return nil, errors.Newf(
+					codes.Invalid,
+					"invalid argument for 'on' - rows are matched by '%s' and it cannot be compared for equality",
+					execute.DefaultTimeColLabel,
+				)
+			}
+		}
+		op.on = cols
+	}
+
+	if d, ok, err := args.GetString("direction"); err != nil {
+		return nil, err
+	} else if ok {
+		op.direction = d
+	}
+	if op.direction != DirectionBackward && op.direction != DirectionForward && op.direction != DirectionNearest {
+		return nil, errors.New(
+			codes.Invalid,
+			"invalid argument for 'direction' - must be \"backward\", \"forward\", or \"nearest\"",
+		)
+	}
+
+	if err := op.readArgs(args, p); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+// readArgs reads the arguments shared by join.aligned() and join.asof().
+func (o *AlignedJoinOpSpec) readArgs(args flux.Arguments, p *flux.Administration) error {
+	l, ok := args.Get("left")
+	if !ok {
+		return errors.New(codes.Invalid, "missing required argument 'left'")
+	}
+	left, ok := l.(*flux.TableObject)
+	if !ok {
+		return errors.New(codes.Invalid, "argument 'left' must be a table stream")
+	}
+	p.AddParent(left)
+	o.left = left
+
+	r, ok := args.Get("right")
+	if !ok {
+		return errors.New(codes.Invalid, "missing required argument 'right'")
+	}
+	right, ok := r.(*flux.TableObject)
+	if !ok {
+		return errors.New(codes.Invalid, "argument 'right' must be a table stream")
+	}
+	p.AddParent(right)
+	o.right = right
+
+	a, err := args.GetRequiredFunction("as")
+	if err != nil {
+		return err
+	}
+	if o.as, err = interpreter.ResolveFunction(a); err != nil {
+		return err
+	}
+
+	if m, ok, err := args.GetString("method"); err != nil {
+		return err
+	} else if ok {
+		o.method = m
+	}
+	if o.method != "inner" && o.method != "left" {
+		return errors.New(
+			codes.Invalid,
+			"invalid argument for 'method' - must be \"inner\" or \"left\"",
+		)
+	}
+
+	if d, ok, err := args.GetDuration("tolerance"); err != nil {
+		return err
+	} else if ok {
+		o.tolerance = d
+	}
+	if o.tolerance.IsNegative() || o.tolerance.Months() != 0 {
+		return errors.New(
+			codes.Invalid,
+			"invalid argument for 'tolerance' - must be a non-negative duration without a month component",
+		)
+	}
+	return nil
+}
+
+type AlignedJoinProcedureSpec struct {
+	On        []ColumnPair
+	As        interpreter.ResolvedFunction
+	Left      *flux.TableObject
+	Right     *flux.TableObject
+	Method    string
+	Direction string
+	Tolerance flux.Duration
+}
+
+func (p *AlignedJoinProcedureSpec) Kind() plan.ProcedureKind {
+	return plan.ProcedureKind(AlignedJoinKind)
+}
+
+func (p *AlignedJoinProcedureSpec) Copy() plan.ProcedureSpec {
+	return &AlignedJoinProcedureSpec{
+		On:        p.On,
+		As:        p.As,
+		Left:      p.Left,
+		Right:     p.Right,
+		Method:    p.Method,
+		Direction: p.Direction,
+		Tolerance: p.Tolerance,
+	}
+}
+
+func newAlignedJoinProcedure(spec flux.OperationSpec, p plan.Administration) (plan.ProcedureSpec, error) {
+	s, ok := spec.(*AlignedJoinOpSpec)
+	if !ok {
+		return nil, errors.New(codes.Internal, "invalid op spec for aligned join procedure")
+	}
+	proc := AlignedJoinProcedureSpec{
+		On:        s.on,
+		As:        s.as,
+		Left:      s.left,
+		Right:     s.right,
+		Method:    s.method,
+		Direction: s.direction,
+		Tolerance: s.tolerance,
+	}
+	return &proc, nil
+}
+
+func createAlignedJoinTransformation(
+	id execute.DatasetID,
+	mode execute.AccumulationMode,
+	spec plan.ProcedureSpec,
+	a execute.Administration,
+) (execute.Transformation, execute.Dataset, error) {
+	t, err := NewAlignedJoinTransformation(
+		a.Context(),
+		id,
+		spec,
+		a.Parents()[0],
+		a.Parents()[1],
+		a.Allocator(),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	tr := execute.NewTransformationFromTransport(t)
+	return tr, t.d, nil
+}
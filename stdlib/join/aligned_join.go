@@ -0,0 +1,563 @@
+package join
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/array"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/table"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/values"
+)
+
+// The directions in which an aligned join looks for a matching right row.
+const (
+	// DirectionBackward matches the last right row at or before the left row.
+	DirectionBackward = "backward"
+	// DirectionForward matches the first right row at or after the left row.
+	DirectionForward = "forward"
+	// DirectionNearest matches the right row nearest to the left row.
+	DirectionNearest = "nearest"
+)
+
+// AlignedJoinTransformation joins two table streams by matching each row
+// from the left stream with the row from the right stream that has the same
+// group key, the same values in the `on` columns and the nearest `_time`
+// in the join direction within a tolerance.
+//
+// Both inputs must be sorted by `_time` within each group key. Instead of
+// buffering each input, the transformation advances both inputs in time order.
+// A left row is joined as soon as no later right row could be a better match,
+// and a right row is discarded as soon as no later left row could match it.
+// Only the rows within the tolerance of each other are buffered.
+type AlignedJoinTransformation struct {
+	ctx         context.Context
+	on          []ColumnPair
+	as          *JoinFn
+	left, right execute.DatasetID
+	method      string
+	direction   string
+	tolerance   int64
+	d           *execute.TransportDataset
+	mu          sync.Mutex
+	mem         memory.Allocator
+
+	// leftSchema and rightSchema keep track of a union of all the schemas
+	// the join transformation has seen from each side. They are used to
+	// compile the `as` function when a group key only exists on one side.
+	leftSchema, rightSchema []flux.ColMeta
+
+	leftFinished,
+	rightFinished bool
+}
+
+func NewAlignedJoinTransformation(
+	ctx context.Context,
+	id execute.DatasetID,
+	s plan.ProcedureSpec,
+	leftID execute.DatasetID,
+	rightID execute.DatasetID,
+	mem memory.Allocator,
+) (*AlignedJoinTransformation, error) {
+	spec, ok := s.(*AlignedJoinProcedureSpec)
+	if !ok {
+		return nil, errors.New(codes.Internal, "unsupported join spec - not an aligned join")
+	}
+	direction := spec.Direction
+	if direction == "" {
+		direction = DirectionNearest
+	}
+	return &AlignedJoinTransformation{
+		ctx:       ctx,
+		on:        spec.On,
+		as:        NewJoinFn(spec.As),
+		left:      leftID,
+		right:     rightID,
+		method:    spec.Method,
+		direction: direction,
+		tolerance: spec.Tolerance.Nanoseconds(),
+		d:         execute.NewTransportDataset(id, mem),
+		mem:       mem,
+	}, nil
+}
+
+func (t *AlignedJoinTransformation) Dataset() *execute.TransportDataset {
+	return t.d
+}
+
+func (t *AlignedJoinTransformation) ProcessMessage(m execute.Message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	defer m.Ack()
+
+	switch m := m.(type) {
+	case execute.ProcessChunkMsg:
+		return t.processChunk(m.TableChunk(), m.SrcDatasetID())
+	case execute.FlushKeyMsg:
+		state, ok := t.d.Lookup(m.Key())
+		if !ok {
+			return nil
+		}
+		s := state.(*alignedState)
+		if id := m.SrcDatasetID(); id == t.left {
+			s.left.done = true
+		} else if id == t.right {
+			s.right.done = true
+		}
+		if err := t.advance(m.Key(), s); err != nil {
+			return err
+		}
+		if s.left.done && s.right.done {
+			s.release()
+			t.d.Delete(m.Key())
+		}
+	case execute.FinishMsg:
+		if err := m.Error(); err != nil {
+			t.release()
+			t.d.Finish(err)
+			return nil
+		}
+
+		// Once one side has finished, no group key will receive
+		// more rows from it so every group key can advance.
+		isLeft := m.SrcDatasetID() == t.left
+		if isLeft {
+			t.leftFinished = true
+		} else {
+			t.rightFinished = true
+		}
+		err := t.d.Range(func(key flux.GroupKey, value interface{}) error {
+			s := value.(*alignedState)
+			if isLeft {
+				s.left.done = true
+			} else {
+				s.right.done = true
+			}
+			return t.advance(key, s)
+		})
+		if err != nil || t.leftFinished && t.rightFinished {
+			t.release()
+			t.d.Finish(err)
+		}
+	}
+	return nil
+}
+
+func (t *AlignedJoinTransformation) processChunk(chunk table.Chunk, id execute.DatasetID) error {
+	if chunk.Len() == 0 {
+		return nil
+	}
+
+	s := t.d.LookupOrCreate(chunk.Key(), func() interface{} {
+		s := &alignedState{index: make(map[string]int)}
+		s.left.done = t.leftFinished
+		s.right.done = t.rightFinished
+		return s
+	}).(*alignedState)
+
+	isLeft := id == t.left
+	var side *alignedSide
+	if isLeft {
+		side = &s.left
+		t.leftSchema = schemaUnion(t.leftSchema, chunk.Cols())
+	} else if id == t.right {
+		side = &s.right
+		t.rightSchema = schemaUnion(t.rightSchema, chunk.Cols())
+	} else {
+		return errors.New(codes.Internal, "invalid chunk passed to join - dataset id is neither left nor right")
+	}
+	side.schema = schemaUnion(side.schema, chunk.Cols())
+
+	if err := side.validate(chunk); err != nil {
+		return err
+	}
+	if err := t.partition(s, chunk, isLeft); err != nil {
+		return err
+	}
+	return t.advance(chunk.Key(), s)
+}
+
+// partition adds each run of rows with the same values
+// in the `on` columns to the buffer for those values.
+func (t *AlignedJoinTransformation) partition(s *alignedState, chunk table.Chunk, isLeft bool) error {
+	rows := func(p *alignedPartition) *alignedRows {
+		if isLeft {
+			return &p.left
+		}
+		return &p.right
+	}
+	if len(t.on) == 0 {
+		chunk.Retain()
+		rows(s.partition("")).push(chunk)
+		return nil
+	}
+
+	labels := getJoinKeyCols(t.on, isLeft)
+	idx := make([]int, len(labels))
+	for i, label := range labels {
+		if idx[i] = chunk.Index(label); idx[i] < 0 {
+			return errors.Newf(codes.FailedPrecondition, "table is missing column '%s'", label)
+		}
+	}
+
+	start, key := 0, partitionKey(chunk, idx, 0)
+	for i, n := 1, chunk.Len(); i <= n; i++ {
+		var next string
+		if i < n {
+			if next = partitionKey(chunk, idx, i); next == key {
+				continue
+			}
+		}
+		rows(s.partition(key)).push(getChunkSlice(chunk, start, i))
+		start, key = i, next
+	}
+	return nil
+}
+
+// partitionKey returns a string that identifies the values
+// of the columns in row i of the chunk.
+func partitionKey(chunk table.Chunk, idx []int, i int) string {
+	var sb strings.Builder
+	buf := chunk.Buffer()
+	for _, j := range idx {
+		v := execute.ValueForRow(&buf, i, j)
+		if v.IsNull() {
+			sb.WriteString("null")
+		} else {
+			sb.WriteString(v.Type().String())
+			sb.WriteByte(':')
+			sb.WriteString(values.DisplayString(v))
+		}
+		sb.WriteByte(0)
+	}
+	return sb.String()
+}
+
+// advance joins every buffered left row that can no longer be matched
+// with a better right row and then discards the right rows that can no
+// longer be matched with any left row.
+func (t *AlignedJoinTransformation) advance(key flux.GroupKey, s *alignedState) error {
+	var builder *execute.ChunkBuilder
+	checkpoint := memory.NewCheckpoint(t.mem)
+	for _, p := range s.partitions {
+		for p.left.len() > 0 {
+			if err := checkpoint.Check(); err != nil {
+				return err
+			}
+
+			// Left rows are sorted so a right row that cannot match this
+			// left row will not match any later left row either.
+			lt := p.left.time(0)
+			p.right.discard(lt, t.tolerance, t.direction)
+			match := p.right.match(lt, t.tolerance, t.direction)
+			if !t.ready(s, p, lt, match) {
+				break
+			}
+			if match < 0 && t.method == "inner" {
+				p.left.pop()
+				continue
+			}
+
+			if builder == nil {
+				if err := t.prepare(s); err != nil {
+					return err
+				}
+			}
+
+			lc, li := p.left.row(0)
+			l := rowFromChunk(lc, li, t.as.leftType())
+			var r values.Object
+			if match >= 0 {
+				rc, ri := p.right.row(match)
+				r = rowFromChunk(rc, ri, t.as.rightType())
+			} else {
+				r = defaultRow(key, t.as.rightType())
+			}
+
+			joined, err := t.as.eval(t.ctx, l, r)
+			if err != nil {
+				return err
+			}
+			if err := validateGroupKey(joined, key); err != nil {
+				return err
+			}
+			if t.as.schema == nil {
+				cols, err := t.as.createSchema(joined)
+				if err != nil {
+					return err
+				}
+				t.as.schema = cols
+			}
+			if builder == nil {
+				builder = execute.NewChunkBuilder(t.as.schema, p.left.len(), t.mem)
+			}
+			if err := builder.AppendRecord(joined); err != nil {
+				return err
+			}
+			p.left.pop()
+		}
+
+		// No right row can match a left row that will never arrive
+		// and later left rows are never before the last left row.
+		if p.left.len() > 0 {
+			p.right.discard(p.left.time(0), t.tolerance, t.direction)
+		} else if s.left.done {
+			p.right.clear()
+		} else if s.left.seen {
+			p.right.discard(s.left.last, t.tolerance, t.direction)
+		}
+	}
+
+	if builder == nil {
+		return nil
+	}
+	for _, chunk := range splitChunk(builder.Build(key)) {
+		if err := t.d.Process(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ready reports whether the match for a left row at time ts cannot change
+// when more right rows arrive. The match is the index returned by match.
+func (t *AlignedJoinTransformation) ready(s *alignedState, p *alignedPartition, ts int64, match int) bool {
+	if s.right.done {
+		return true
+	} else if !s.right.seen {
+		return false
+	}
+
+	// Right rows are sorted so later right rows
+	// are not before the last right row.
+	last := s.right.last
+	if match < 0 {
+		if t.direction == DirectionBackward {
+			return last > ts
+		}
+		// Later right rows are too far after ts to match.
+		return last-ts > t.tolerance
+	}
+
+	mt := p.right.time(match)
+	switch {
+	case mt > ts || mt == ts && t.direction == DirectionForward:
+		// The first right row at or after ts is the match
+		// and later right rows are not nearer.
+		return true
+	case t.direction == DirectionBackward:
+		// A later right row at or before ts would replace the match.
+		return last > ts
+	default:
+		// A later right row nearer to ts would replace the match.
+		return last > ts && last-ts >= ts-mt
+	}
+}
+
+// prepare compiles the `as` function with the schemas seen so far.
+func (t *AlignedJoinTransformation) prepare(s *alignedState) error {
+	lschema, rschema := s.left.schema, s.right.schema
+	if len(lschema) == 0 {
+		lschema = t.leftSchema
+	}
+	if len(rschema) == 0 {
+		rschema = t.rightSchema
+	}
+	return t.as.Prepare(t.ctx, lschema, rschema)
+}
+
+func (t *AlignedJoinTransformation) release() {
+	_ = t.d.Range(func(key flux.GroupKey, value interface{}) error {
+		value.(*alignedState).release()
+		return nil
+	})
+}
+
+// alignedState holds the buffered rows for a single group key.
+type alignedState struct {
+	left, right alignedSide
+	// partitions holds the rows for each set of values in the `on`
+	// columns in the order they were first seen.
+	partitions []*alignedPartition
+	index      map[string]int
+}
+
+// partition returns the partition for the key and creates it if needed.
+func (s *alignedState) partition(key string) *alignedPartition {
+	if i, ok := s.index[key]; ok {
+		return s.partitions[i]
+	}
+	p := &alignedPartition{}
+	s.index[key] = len(s.partitions)
+	s.partitions = append(s.partitions, p)
+	return p
+}
+
+func (s *alignedState) release() {
+	for _, p := range s.partitions {
+		p.left.clear()
+		p.right.clear()
+	}
+}
+
+// alignedPartition holds the buffered rows from each side
+// that have the same values in the `on` columns.
+type alignedPartition struct {
+	left, right alignedRows
+}
+
+// alignedSide tracks the rows from one input for a single group key.
+type alignedSide struct {
+	schema []flux.ColMeta
+	// last is the time of the last row from this side
+	// and seen reports whether any row has been received.
+	last int64
+	seen bool
+	done bool
+}
+
+// validate checks that the rows of the chunk are not earlier
+// than the rows that have already been received.
+func (s *alignedSide) validate(c table.Chunk) error {
+	j := c.Index(execute.DefaultTimeColLabel)
+	if j < 0 {
+		return errors.Newf(codes.FailedPrecondition, "table is missing column '%s'", execute.DefaultTimeColLabel)
+	}
+	if typ := c.Col(j).Type; typ != flux.TTime {
+		return errors.Newf(codes.FailedPrecondition, "column '%s' must be of type time, got %s", execute.DefaultTimeColLabel, typ)
+	}
+
+	times := c.Ints(j)
+	for i, n := 0, times.Len(); i < n; i++ {
+		if times.IsNull(i) {
+			return errors.Newf(codes.FailedPrecondition, "column '%s' must not contain null values", execute.DefaultTimeColLabel)
+		}
+		ts := times.Value(i)
+		if s.seen && ts < s.last {
+			return errors.Newf(codes.FailedPrecondition, "input tables must be sorted by '%s'", execute.DefaultTimeColLabel)
+		}
+		s.last, s.seen = ts, true
+	}
+	return nil
+}
+
+// alignedRows buffers rows sorted by time.
+// Rows are consumed from the front of the buffer.
+type alignedRows struct {
+	chunks []table.Chunk
+	times  []*array.Int
+	// offset is the index of the first unconsumed row in chunks[0]
+	// and n is the number of unconsumed rows.
+	offset int
+	n      int
+}
+
+// push adds the rows of the chunk to the buffer and takes
+// ownership of the chunk.
+func (s *alignedRows) push(c table.Chunk) {
+	s.chunks = append(s.chunks, c)
+	s.times = append(s.times, c.Ints(c.Index(execute.DefaultTimeColLabel)))
+	s.n += c.Len()
+}
+
+// len returns the number of unconsumed rows.
+func (s *alignedRows) len() int {
+	return s.n
+}
+
+// row returns the chunk and index of the ith unconsumed row.
+func (s *alignedRows) row(i int) (table.Chunk, int) {
+	i += s.offset
+	for k, c := range s.chunks {
+		if i < c.Len() {
+			return s.chunks[k], i
+		}
+		i -= c.Len()
+	}
+	panic("aligned join: row index out of range")
+}
+
+// time returns the time of the ith unconsumed row.
+func (s *alignedRows) time(i int) int64 {
+	i += s.offset
+	for _, times := range s.times {
+		if i < times.Len() {
+			return times.Value(i)
+		}
+		i -= times.Len()
+	}
+	panic("aligned join: row index out of range")
+}
+
+// pop consumes the first unconsumed row and releases
+// its chunk once every row in it has been consumed.
+func (s *alignedRows) pop() {
+	s.offset++
+	s.n--
+	if s.offset == s.chunks[0].Len() {
+		s.chunks[0].Release()
+		s.chunks[0] = table.Chunk{}
+		s.chunks, s.times = s.chunks[1:], s.times[1:]
+		s.offset = 0
+	}
+}
+
+// discard consumes the rows that cannot match a row at time ts
+// or any later time in the direction.
+func (s *alignedRows) discard(ts, tolerance int64, direction string) {
+	for s.n > 0 {
+		t0 := s.time(0)
+		if direction == DirectionForward {
+			// Only rows at or after ts can match.
+			if t0 >= ts {
+				return
+			}
+		} else if ts-t0 <= tolerance && (s.n < 2 || s.time(1) > ts) {
+			// Only the last row at or before ts can match
+			// when it is within the tolerance.
+			return
+		}
+		s.pop()
+	}
+}
+
+// match returns the index of the unconsumed row that matches
+// a row at time ts or -1 if there is none. The rows must have
+// been discarded up to ts so only the first row may be before ts.
+// The last row at or before ts and the first row after ts are
+// candidates, and when they are equally near the row before ts
+// is returned.
+func (s *alignedRows) match(ts, tolerance int64, direction string) int {
+	if s.n == 0 {
+		return -1
+	}
+	t0 := s.time(0)
+	if t0 > ts {
+		if direction == DirectionBackward || t0-ts > tolerance {
+			return -1
+		}
+		return 0
+	}
+	if t0 < ts && direction == DirectionForward {
+		return -1
+	}
+	if direction == DirectionNearest && s.n > 1 && s.time(1)-ts < ts-t0 {
+		return 1
+	}
+	return 0
+}
+
+// clear releases every buffered row.
+func (s *alignedRows) clear() {
+	for _, c := range s.chunks {
+		c.Release()
+	}
+	s.chunks, s.times = nil, nil
+	s.offset, s.n = 0, 0
+}
@@ -0,0 +1,450 @@
+package join_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/executetest"
+	"github.com/InfluxCommunity/flux/execute/table"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/stdlib/join"
+	"github.com/InfluxCommunity/flux/values"
+	arrowmem "github.com/apache/arrow/go/v7/arrow/memory"
+	"github.com/google/go-cmp/cmp"
+)
+
+// alignedStep sends a chunk to one side of the join.
+type alignedStep struct {
+	left  bool
+	chunk table.Chunk
+}
+
+func TestAlignedJoin(t *testing.T) {
+	cols := []flux.ColMeta{
+		{Label: "_time", Type: flux.TTime},
+		{Label: "_value", Type: flux.TInt},
+	}
+	chunk := func(rows ...[2]int64) table.Chunk {
+		data := make([]map[string]interface{}, 0, len(rows))
+		for _, row := range rows {
+			data = append(data, map[string]interface{}{
+				"_time":  execute.Time(row[0]),
+				"_value": row[1],
+			})
+		}
+		return constructChunks(nil, cols, data)[0]
+	}
+	outCols := []flux.ColMeta{
+		{Label: "_time", Type: flux.TTime},
+		{Label: "lv", Type: flux.TInt},
+		{Label: "rv", Type: flux.TInt},
+	}
+
+	testCases := []struct {
+		name      string
+		method    string
+		tolerance time.Duration
+		steps     []alignedStep
+		// wantStreamed is the number of rows that should
+		// be produced before either input has finished.
+		wantStreamed int
+		want         [][]interface{}
+		wantErr      string
+	}{
+		{
+			name:      "nearest within tolerance",
+			method:    "left",
+			tolerance: 2,
+			steps: []alignedStep{
+				{left: true, chunk: chunk([2]int64{0, 1}, [2]int64{10, 2}, [2]int64{20, 3})},
+				{left: false, chunk: chunk([2]int64{1, 10}, [2]int64{18, 20})},
+			},
+			wantStreamed: 2,
+			want: [][]interface{}{
+				{execute.Time(0), int64(1), int64(10)},
+				{execute.Time(10), int64(2), nil},
+				{execute.Time(20), int64(3), int64(20)},
+			},
+		},
+		{
+			name:   "exact inner",
+			method: "inner",
+			steps: []alignedStep{
+				{left: true, chunk: chunk([2]int64{1, 1}, [2]int64{2, 2}, [2]int64{3, 3})},
+				{left: false, chunk: chunk([2]int64{2, 20}, [2]int64{3, 30}, [2]int64{4, 40})},
+			},
+			wantStreamed: 2,
+			want: [][]interface{}{
+				{execute.Time(2), int64(2), int64(20)},
+				{execute.Time(3), int64(3), int64(30)},
+			},
+		},
+		{
+			name:      "equally near prefers earlier",
+			method:    "inner",
+			tolerance: 1,
+			steps: []alignedStep{
+				{left: false, chunk: chunk([2]int64{4, 40}, [2]int64{6, 60})},
+				{left: true, chunk: chunk([2]int64{5, 5})},
+			},
+			wantStreamed: 1,
+			want: [][]interface{}{
+				{execute.Time(5), int64(5), int64(40)},
+			},
+		},
+		{
+			name:   "duplicate times use last",
+			method: "inner",
+			steps: []alignedStep{
+				{left: false, chunk: chunk([2]int64{4, 40})},
+				{left: false, chunk: chunk([2]int64{4, 41})},
+				{left: true, chunk: chunk([2]int64{4, 4})},
+			},
+			want: [][]interface{}{
+				{execute.Time(4), int64(4), int64(41)},
+			},
+		},
+		{
+			name:      "interleaved chunks",
+			method:    "left",
+			tolerance: 1,
+			steps: []alignedStep{
+				{left: true, chunk: chunk([2]int64{1, 1}, [2]int64{3, 3})},
+				{left: false, chunk: chunk([2]int64{1, 10})},
+				{left: false, chunk: chunk([2]int64{2, 20})},
+				{left: true, chunk: chunk([2]int64{5, 5}, [2]int64{8, 8})},
+				{left: false, chunk: chunk([2]int64{6, 60}, [2]int64{9, 90})},
+			},
+			wantStreamed: 4,
+			want: [][]interface{}{
+				{execute.Time(1), int64(1), int64(10)},
+				{execute.Time(3), int64(3), int64(20)},
+				{execute.Time(5), int64(5), int64(60)},
+				{execute.Time(8), int64(8), int64(90)},
+			},
+		},
+		{
+			name:   "unsorted input",
+			method: "inner",
+			steps: []alignedStep{
+				{left: true, chunk: chunk([2]int64{2, 2}, [2]int64{1, 1})},
+			},
+			wantErr: "input tables must be sorted by '_time'",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			fn, err := fnFromSrc(`(l, r) => ({_time: l._time, lv: l._value, rv: r._value})`)
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err)
+			}
+			spec := join.AlignedJoinProcedureSpec{
+				As:        *fn,
+				Method:    tc.method,
+				Tolerance: values.ConvertDurationNsecs(tc.tolerance),
+			}
+			checked := arrowmem.NewCheckedAllocator(memory.DefaultAllocator)
+			mem := memory.NewResourceAllocator(checked)
+			defer checked.AssertSize(t, 0)
+
+			ajt, err := join.NewAlignedJoinTransformation(
+				context.Background(),
+				executetest.RandomDatasetID(),
+				&spec,
+				leftID,
+				rightID,
+				mem,
+			)
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err)
+			}
+
+			store := &alignedStore{}
+			ajt.Dataset().AddTransformation(store)
+			tr := execute.NewTransformationFromTransport(ajt)
+
+			leftDataset := execute.NewTransportDataset(leftID, mem)
+			leftDataset.AddTransformation(tr)
+			rightDataset := execute.NewTransportDataset(rightID, mem)
+			rightDataset.AddTransformation(tr)
+
+			for _, step := range tc.steps {
+				d := rightDataset
+				if step.left {
+					d = leftDataset
+				}
+				if err := d.Process(step.chunk); err != nil {
+					if tc.wantErr == "" {
+						t.Fatalf("got unexpected error: %s", err)
+					} else if got := err.Error(); got != tc.wantErr {
+						t.Fatalf("expected error: %s - got: %s", tc.wantErr, got)
+					}
+					tr.Finish(leftID, err)
+					return
+				}
+			}
+			if tc.wantErr != "" {
+				t.Fatalf("expected error: %s - got: none", tc.wantErr)
+			}
+
+			if want, got := tc.wantStreamed, len(store.rows); want != got {
+				t.Errorf("unexpected number of rows before finish -want/+got:\n\t- %d\n\t+ %d", want, got)
+			}
+
+			tr.Finish(leftID, nil)
+			tr.Finish(rightID, nil)
+			if store.err != nil {
+				t.Fatalf("got unexpected error: %s", store.err)
+			}
+
+			if !cmp.Equal(outCols, store.cols) {
+				t.Errorf("unexpected columns -want/+got:\n%s", cmp.Diff(outCols, store.cols))
+			}
+			if !cmp.Equal(tc.want, store.rows) {
+				t.Errorf("unexpected rows -want/+got:\n%s", cmp.Diff(tc.want, store.rows))
+			}
+		})
+	}
+}
+
+// alignedStore collects the rows of every table it receives
+// since the join may produce many tables with the same group key.
+type alignedStore struct {
+	execute.ExecutionNode
+	cols []flux.ColMeta
+	rows [][]interface{}
+	err  error
+}
+
+func (s *alignedStore) RetractTable(id execute.DatasetID, key flux.GroupKey) error {
+	return nil
+}
+
+func (s *alignedStore) Process(id execute.DatasetID, tbl flux.Table) error {
+	t, err := executetest.ConvertTable(tbl)
+	if err != nil {
+		return err
+	}
+	s.cols = t.ColMeta
+	s.rows = append(s.rows, t.Data...)
+	return nil
+}
+
+func (s *alignedStore) UpdateWatermark(id execute.DatasetID, t execute.Time) error {
+	return nil
+}
+
+func (s *alignedStore) UpdateProcessingTime(id execute.DatasetID, t execute.Time) error {
+	return nil
+}
+
+func (s *alignedStore) Finish(id execute.DatasetID, err error) {
+	s.err = err
+}
+
+func TestAsofJoin(t *testing.T) {
+	cols := []flux.ColMeta{
+		{Label: "_time", Type: flux.TTime},
+		{Label: "room", Type: flux.TString},
+		{Label: "_value", Type: flux.TInt},
+	}
+	type row struct {
+		time  int64
+		room  string
+		value int64
+	}
+	chunk := func(rows ...row) table.Chunk {
+		data := make([]map[string]interface{}, 0, len(rows))
+		for _, r := range rows {
+			data = append(data, map[string]interface{}{
+				"_time":  execute.Time(r.time),
+				"room":   r.room,
+				"_value": r.value,
+			})
+		}
+		return constructChunks(nil, cols, data)[0]
+	}
+	outCols := []flux.ColMeta{
+		{Label: "_time", Type: flux.TTime},
+		{Label: "room", Type: flux.TString},
+		{Label: "lv", Type: flux.TInt},
+		{Label: "rv", Type: flux.TInt},
+	}
+	on := []join.ColumnPair{{Left: "room", Right: "room"}}
+
+	testCases := []struct {
+		name      string
+		on        []join.ColumnPair
+		method    string
+		direction string
+		tolerance time.Duration
+		steps     []alignedStep
+		// wantStreamed is the number of rows that should
+		// be produced before either input has finished.
+		wantStreamed int
+		want         [][]interface{}
+		wantErr      string
+	}{
+		{
+			name:      "backward on room",
+			on:        on,
+			method:    "left",
+			direction: join.DirectionBackward,
+			tolerance: math.MaxInt64,
+			steps: []alignedStep{
+				{left: true, chunk: chunk(row{0, "k", 1}, row{10, "h", 2}, row{20, "k", 3}, row{30, "h", 4})},
+				{left: false, chunk: chunk(row{5, "k", 10}, row{8, "h", 20}, row{25, "h", 30})},
+			},
+			wantStreamed: 3,
+			want: [][]interface{}{
+				{execute.Time(0), "k", int64(1), nil},
+				{execute.Time(20), "k", int64(3), int64(10)},
+				{execute.Time(10), "h", int64(2), int64(20)},
+				{execute.Time(30), "h", int64(4), int64(30)},
+			},
+		},
+		{
+			name:      "backward duplicate times use last",
+			method:    "inner",
+			direction: join.DirectionBackward,
+			tolerance: 3,
+			steps: []alignedStep{
+				{left: false, chunk: chunk(row{1, "", 10}, row{4, "", 20}, row{4, "", 21})},
+				{left: true, chunk: chunk(row{0, "", 1}, row{4, "", 2}, row{6, "", 3}, row{9, "", 4})},
+				{left: false, chunk: chunk(row{12, "", 30})},
+			},
+			wantStreamed: 2,
+			want: [][]interface{}{
+				{execute.Time(4), "", int64(2), int64(21)},
+				{execute.Time(6), "", int64(3), int64(21)},
+			},
+		},
+		{
+			name:      "forward duplicate times use first",
+			method:    "left",
+			direction: join.DirectionForward,
+			tolerance: 10,
+			steps: []alignedStep{
+				{left: true, chunk: chunk(row{0, "", 1}, row{5, "", 2}, row{6, "", 3}, row{30, "", 4})},
+				{left: false, chunk: chunk(row{5, "", 10}, row{5, "", 11}, row{14, "", 20})},
+				{left: false, chunk: chunk(row{50, "", 30})},
+			},
+			wantStreamed: 4,
+			want: [][]interface{}{
+				{execute.Time(0), "", int64(1), int64(10)},
+				{execute.Time(5), "", int64(2), int64(10)},
+				{execute.Time(6), "", int64(3), int64(20)},
+				{execute.Time(30), "", int64(4), nil},
+			},
+		},
+		{
+			name:      "nearest on room",
+			on:        on,
+			method:    "left",
+			direction: join.DirectionNearest,
+			tolerance: 5,
+			steps: []alignedStep{
+				{left: true, chunk: chunk(row{10, "a", 1}, row{10, "b", 2}, row{20, "a", 3})},
+				{left: false, chunk: chunk(row{8, "b", 10}, row{13, "a", 20}, row{14, "b", 30})},
+				{left: false, chunk: chunk(row{40, "a", 40})},
+			},
+			wantStreamed: 3,
+			want: [][]interface{}{
+				{execute.Time(10), "a", int64(1), int64(20)},
+				{execute.Time(10), "b", int64(2), int64(10)},
+				{execute.Time(20), "a", int64(3), nil},
+			},
+		},
+		{
+			name:      "missing on column",
+			on:        []join.ColumnPair{{Left: "building", Right: "building"}},
+			method:    "left",
+			direction: join.DirectionBackward,
+			steps: []alignedStep{
+				{left: true, chunk: chunk(row{0, "k", 1})},
+			},
+			wantErr: "table is missing column 'building'",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			fn, err := fnFromSrc(`(l, r) => ({_time: l._time, room: l.room, lv: l._value, rv: r._value})`)
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err)
+			}
+			spec := join.AlignedJoinProcedureSpec{
+				On:        tc.on,
+				As:        *fn,
+				Method:    tc.method,
+				Direction: tc.direction,
+				Tolerance: values.ConvertDurationNsecs(tc.tolerance),
+			}
+			checked := arrowmem.NewCheckedAllocator(memory.DefaultAllocator)
+			mem := memory.NewResourceAllocator(checked)
+			defer checked.AssertSize(t, 0)
+
+			ajt, err := join.NewAlignedJoinTransformation(
+				context.Background(),
+				executetest.RandomDatasetID(),
+				&spec,
+				leftID,
+				rightID,
+				mem,
+			)
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err)
+			}
+
+			store := &alignedStore{}
+			ajt.Dataset().AddTransformation(store)
+			tr := execute.NewTransformationFromTransport(ajt)
+
+			leftDataset := execute.NewTransportDataset(leftID, mem)
+			leftDataset.AddTransformation(tr)
+			rightDataset := execute.NewTransportDataset(rightID, mem)
+			rightDataset.AddTransformation(tr)
+
+			for _, step := range tc.steps {
+				d := rightDataset
+				if step.left {
+					d = leftDataset
+				}
+				if err := d.Process(step.chunk); err != nil {
+					if tc.wantErr == "" {
+						t.Fatalf("got unexpected error: %s", err)
+					} else if got := err.Error(); got != tc.wantErr {
+						t.Fatalf("expected error: %s - got: %s", tc.wantErr, got)
+					}
+					tr.Finish(leftID, err)
+					return
+				}
+			}
+			if tc.wantErr != "" {
+				t.Fatalf("expected error: %s - got: none", tc.wantErr)
+			}
+
+			if want, got := tc.wantStreamed, len(store.rows); want != got {
+				t.Errorf("unexpected number of rows before finish -want/+got:\n\t- %d\n\t+ %d", want, got)
+			}
+
+			tr.Finish(leftID, nil)
+			tr.Finish(rightID, nil)
+			if store.err != nil {
+				t.Fatalf("got unexpected error: %s", store.err)
+			}
+
+			if !cmp.Equal(outCols, store.cols) {
+				t.Errorf("unexpected columns -want/+got:\n%s", cmp.Diff(outCols, store.cols))
+			}
+			if !cmp.Equal(tc.want, store.rows) {
+				t.Errorf("unexpected rows -want/+got:\n%s", cmp.Diff(tc.want, store.rows))
+			}
+		})
+	}
+}
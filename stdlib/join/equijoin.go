@@ -78,17 +78,30 @@ func (EquiJoinPredicateRule) Rewrite(ctx context.Context, n plan.Node) (plan.Nod
 		return nil, false, errors.New(codes.Internal, "invalid spec type on join node")
 	}
 
-	fnBody := spec.On.Fn.Block.Body
+	cols, err := getColumnPairs(spec.On.Fn)
+	if err != nil {
+		return nil, false, err
+	}
+	n.ReplaceSpec(newEquiJoinProcedureSpec(spec, cols))
+	return n, true, nil
+}
+
+// getColumnPairs returns the pairs of columns compared by the `on` function.
+// The body of the function must be a single expression that compares a
+// property of `l` with a property of `r` for equality, chained together
+// by the `and` operator.
+func getColumnPairs(fn *semantic.FunctionExpression) ([]ColumnPair, error) {
+	fnBody := fn.Block.Body
 
 	if len(fnBody) != 1 {
-		return nil, false, wrapErr(
+		return nil, wrapErr(
 			codes.Invalid,
 			"function body should be a single logical expression that compares columns from each table",
 		)
 	}
 	rs, ok := fnBody[0].(*semantic.ReturnStatement)
 	if !ok {
-		return nil, false, wrapErr(
+		return nil, wrapErr(
 			codes.Invalid,
 			"function body should be a single logical expression that compares columns from each table",
 		)
@@ -170,10 +183,9 @@ func (EquiJoinPredicateRule) Rewrite(ctx context.Context, n plan.Node) (plan.Nod
 		}
 	}), expr)
 	if walkErr != nil {
-		return nil, false, walkErr
+		return nil, walkErr
 	}
-	n.ReplaceSpec(newEquiJoinProcedureSpec(spec, cols))
-	return n, true, nil
+	return cols, nil
 }
 
 func wrapErr(code codes.Code, msg string) error {
@@ -123,10 +123,14 @@ func (f *JoinFn) Eval(ctx context.Context, p *joinProduct, method string, mem me
 
 func (f *JoinFn) crossProduct(ctx context.Context, p *joinProduct, mem memory.Allocator) (*table.Chunk, error) {
 	var builder *execute.ChunkBuilder
+	checkpoint := memory.NewCheckpoint(mem)
 	for i := 0; i < p.left.nrows(); i++ {
 		l := p.left.getRow(i, f.leftType())
 
 		for j := 0; j < p.right.nrows(); j++ {
+			if err := checkpoint.Check(); err != nil {
+				return nil, err
+			}
 			r := p.right.getRow(j, f.rightType())
 			joined, err := f.eval(ctx, l, r)
 			if err != nil {
@@ -7,10 +7,14 @@ import (
 	"io"
 	"net/url"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/InfluxCommunity/flux"
 	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/dependencies/audit"
+	"github.com/InfluxCommunity/flux/dependencies/dryrun"
+	furl "github.com/InfluxCommunity/flux/dependencies/url"
 	"github.com/InfluxCommunity/flux/execute"
 	"github.com/InfluxCommunity/flux/internal/errors"
 	"github.com/InfluxCommunity/flux/internal/pkg/syncutil"
@@ -214,7 +218,18 @@ func createToKafkaTransformation(id execute.DatasetID, mode execute.Accumulation
 	d := execute.NewDataset(id, mode, cache)
 	deps := flux.GetDependencies(a.Context())
 	t, err := NewToKafkaTransformation(d, deps, cache, s)
-	return t, d, err
+	if err != nil {
+		return nil, nil, err
+	}
+	destination := strings.Join(s.Spec.Brokers, ",") + "/" + s.Spec.Topic
+	if sink := dryrun.GetSink(a.Context()); sink != nil {
+		t.newWriter = func(kafka.WriterConfig) KafkaWriter {
+			return &dryRunKafkaWriter{w: sink.NewWriter("kafka.to", destination)}
+		}
+	} else {
+		t.write = audit.NewWrite(a.Context(), "kafka.to", destination)
+	}
+	return t, d, nil
 }
 
 type ToKafkaTransformation struct {
@@ -222,6 +237,11 @@ type ToKafkaTransformation struct {
 	d     execute.Dataset
 	cache execute.TableBuilderCache
 	spec  *ToKafkaProcedureSpec
+
+	// write records the messages for the audit log.
+	write *audit.Write
+	// newWriter replaces DefaultKafkaWriterFactory in dry-run mode.
+	newWriter func(kafka.WriterConfig) KafkaWriter
 }
 
 func (t *ToKafkaTransformation) RetractTable(id execute.DatasetID, key flux.GroupKey) error {
@@ -237,7 +257,7 @@ func NewToKafkaTransformation(d execute.Dataset, deps flux.Dependencies, cache e
 		if err != nil {
 			return nil, errors.Newf(codes.Invalid, "invalid kafka broker url: %v", err)
 		}
-		if err := validator.Validate(u); err != nil {
+		if err := furl.ForFunction(validator, "kafka.to").Validate(u); err != nil {
 			return nil, errors.Newf(codes.Invalid, "kafka broker url did not pass validation: %v", err)
 		}
 	}
@@ -278,7 +298,11 @@ type idxType struct {
 }
 
 func (t *ToKafkaTransformation) Process(id execute.DatasetID, tbl flux.Table) (err error) {
-	w := DefaultKafkaWriterFactory(kafka.WriterConfig{
+	newWriter := DefaultKafkaWriterFactory
+	if t.newWriter != nil {
+		newWriter = t.newWriter
+	}
+	w := newWriter(kafka.WriterConfig{
 		Brokers:       t.spec.Spec.Brokers,
 		Topic:         t.spec.Spec.Topic,
 		Balancer:      t.spec.balancer,
@@ -286,6 +310,9 @@ func (t *ToKafkaTransformation) Process(id execute.DatasetID, tbl flux.Table) (e
 		QueueCapacity: t.spec.Spec.MsgBufSize,
 	})
 
+	defer func() {
+		t.write.Fail(err)
+	}()
 	defer func() {
 		err2 := w.Close()
 		// don't overwrite current error
@@ -406,6 +433,7 @@ func (t *ToKafkaTransformation) Process(id execute.DatasetID, tbl flux.Table) (e
 			}
 			binary.LittleEndian.PutUint64(msgBuf[i].Key, xxhash.Sum64(v))
 			msgBuf[i].Value = v
+			t.write.Add(1, int64(len(v)))
 			if i == t.spec.Spec.MsgBufSize-1 {
 				if err = w.WriteMessages(context.Background(), msgBuf...); err != nil {
 					return err
@@ -426,6 +454,26 @@ func (t *ToKafkaTransformation) Process(id execute.DatasetID, tbl flux.Table) (e
 	return err
 }
 
+// dryRunKafkaWriter captures the messages of a table
+// as a payload with a message on each line.
+type dryRunKafkaWriter struct {
+	w *dryrun.Writer
+}
+
+func (d *dryRunKafkaWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	for _, msg := range msgs {
+		if _, err := d.w.Write(append(msg.Value, '\n')); err != nil {
+			return err
+		}
+	}
+	d.w.AddRows(int64(len(msgs)))
+	return nil
+}
+
+func (d *dryRunKafkaWriter) Close() error {
+	return d.w.Close()
+}
+
 func (t *ToKafkaTransformation) UpdateWatermark(id execute.DatasetID, pt execute.Time) error {
 	return t.d.UpdateWatermark(pt)
 }
@@ -435,5 +483,6 @@ func (t *ToKafkaTransformation) UpdateProcessingTime(id execute.DatasetID, pt ex
 }
 
 func (t *ToKafkaTransformation) Finish(id execute.DatasetID, err error) {
+	t.write.Done(err)
 	t.d.Finish(err)
 }
@@ -6,6 +6,7 @@ import (
 	"math"
 
 	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/compiler"
 	"github.com/InfluxCommunity/flux/internal/errors"
 	"github.com/InfluxCommunity/flux/runtime"
 	"github.com/InfluxCommunity/flux/semantic"
@@ -64,6 +65,12 @@ func generateMathFunctionXY(name string, mathFn func(float64, float64) float64,
 	)
 }
 
+// registerPure registers a math function that only depends on
+// its arguments so the compiler may fold and share calls to it.
+func registerPure(name string, fn values.Function) {
+	runtime.RegisterPackageValue("math", name, compiler.MarkPure(fn))
+}
+
 func init() {
 	// constants
 	runtime.RegisterPackageValue("math", "pi", values.NewFloat(math.Pi))
@@ -84,55 +91,55 @@ func init() {
 	runtime.RegisterPackageValue("math", "minint", values.NewInt(math.MinInt64))
 	runtime.RegisterPackageValue("math", "maxuint", values.NewUInt(math.MaxUint64))
 
-	runtime.RegisterPackageValue("math", "abs", generateMathFunctionX("abs", math.Abs))
-	runtime.RegisterPackageValue("math", "acos", generateMathFunctionX("acos", math.Acos))
-	runtime.RegisterPackageValue("math", "acosh", generateMathFunctionX("acosh", math.Acosh))
-	runtime.RegisterPackageValue("math", "asin", generateMathFunctionX("asin", math.Asin))
-	runtime.RegisterPackageValue("math", "asinh", generateMathFunctionX("asinh", math.Asinh))
-	runtime.RegisterPackageValue("math", "atan", generateMathFunctionX("atan", math.Atan))
+	registerPure("abs", generateMathFunctionX("abs", math.Abs))
+	registerPure("acos", generateMathFunctionX("acos", math.Acos))
+	registerPure("acosh", generateMathFunctionX("acosh", math.Acosh))
+	registerPure("asin", generateMathFunctionX("asin", math.Asin))
+	registerPure("asinh", generateMathFunctionX("asinh", math.Asinh))
+	registerPure("atan", generateMathFunctionX("atan", math.Atan))
 	// atan2's args are math.Atan2(y,x) so specify them explicitly
-	runtime.RegisterPackageValue("math", "atan2", generateMathFunctionXY("atan2", math.Atan2, "y", "x"))
-	runtime.RegisterPackageValue("math", "atanh", generateMathFunctionX("atanh", math.Atanh))
-	runtime.RegisterPackageValue("math", "cbrt", generateMathFunctionX("cbrt", math.Cbrt))
-	runtime.RegisterPackageValue("math", "ceil", generateMathFunctionX("ceil", math.Ceil))
-	runtime.RegisterPackageValue("math", "copysign", generateMathFunctionXY("copysign", math.Copysign))
-	runtime.RegisterPackageValue("math", "cos", generateMathFunctionX("cos", math.Cos))
-	runtime.RegisterPackageValue("math", "cosh", generateMathFunctionX("cosh", math.Cosh))
-	runtime.RegisterPackageValue("math", "dim", generateMathFunctionXY("dim", math.Dim))
-	runtime.RegisterPackageValue("math", "erf", generateMathFunctionX("erf", math.Erf))
-	runtime.RegisterPackageValue("math", "erfc", generateMathFunctionX("erfc", math.Erfc))
-	runtime.RegisterPackageValue("math", "erfcinv", generateMathFunctionX("erfcinv", math.Erfcinv))
-	runtime.RegisterPackageValue("math", "erfinv", generateMathFunctionX("erfinv", math.Erfinv))
-	runtime.RegisterPackageValue("math", "exp", generateMathFunctionX("exp", math.Exp))
-	runtime.RegisterPackageValue("math", "exp2", generateMathFunctionX("exp2", math.Exp2))
-	runtime.RegisterPackageValue("math", "expm1", generateMathFunctionX("expm1", math.Expm1))
-	runtime.RegisterPackageValue("math", "floor", generateMathFunctionX("floor", math.Floor))
-	runtime.RegisterPackageValue("math", "gamma", generateMathFunctionX("gamma", math.Gamma))
-	runtime.RegisterPackageValue("math", "hypot", generateMathFunctionXY("hypot", math.Hypot, "p", "q"))
-	runtime.RegisterPackageValue("math", "j0", generateMathFunctionX("j0", math.J0))
-	runtime.RegisterPackageValue("math", "j1", generateMathFunctionX("j1", math.J1))
-	runtime.RegisterPackageValue("math", "log", generateMathFunctionX("log", math.Log))
-	runtime.RegisterPackageValue("math", "log10", generateMathFunctionX("log10", math.Log10))
-	runtime.RegisterPackageValue("math", "log1p", generateMathFunctionX("log1p", math.Log1p))
-	runtime.RegisterPackageValue("math", "log2", generateMathFunctionX("log2", math.Log2))
-	runtime.RegisterPackageValue("math", "logb", generateMathFunctionX("logb", math.Logb))
+	registerPure("atan2", generateMathFunctionXY("atan2", math.Atan2, "y", "x"))
+	registerPure("atanh", generateMathFunctionX("atanh", math.Atanh))
+	registerPure("cbrt", generateMathFunctionX("cbrt", math.Cbrt))
+	registerPure("ceil", generateMathFunctionX("ceil", math.Ceil))
+	registerPure("copysign", generateMathFunctionXY("copysign", math.Copysign))
+	registerPure("cos", generateMathFunctionX("cos", math.Cos))
+	registerPure("cosh", generateMathFunctionX("cosh", math.Cosh))
+	registerPure("dim", generateMathFunctionXY("dim", math.Dim))
+	registerPure("erf", generateMathFunctionX("erf", math.Erf))
+	registerPure("erfc", generateMathFunctionX("erfc", math.Erfc))
+	registerPure("erfcinv", generateMathFunctionX("erfcinv", math.Erfcinv))
+	registerPure("erfinv", generateMathFunctionX("erfinv", math.Erfinv))
+	registerPure("exp", generateMathFunctionX("exp", math.Exp))
+	registerPure("exp2", generateMathFunctionX("exp2", math.Exp2))
+	registerPure("expm1", generateMathFunctionX("expm1", math.Expm1))
+	registerPure("floor", generateMathFunctionX("floor", math.Floor))
+	registerPure("gamma", generateMathFunctionX("gamma", math.Gamma))
+	registerPure("hypot", generateMathFunctionXY("hypot", math.Hypot, "p", "q"))
+	registerPure("j0", generateMathFunctionX("j0", math.J0))
+	registerPure("j1", generateMathFunctionX("j1", math.J1))
+	registerPure("log", generateMathFunctionX("log", math.Log))
+	registerPure("log10", generateMathFunctionX("log10", math.Log10))
+	registerPure("log1p", generateMathFunctionX("log1p", math.Log1p))
+	registerPure("log2", generateMathFunctionX("log2", math.Log2))
+	registerPure("logb", generateMathFunctionX("logb", math.Logb))
 	// TODO: change to max and min when we eliminate namespace collisions
-	runtime.RegisterPackageValue("math", "mMax", generateMathFunctionXY("mMax", math.Max))
-	runtime.RegisterPackageValue("math", "mMin", generateMathFunctionXY("mMin", math.Min))
-	runtime.RegisterPackageValue("math", "mod", generateMathFunctionXY("mod", math.Mod))
-	runtime.RegisterPackageValue("math", "nextafter", generateMathFunctionXY("nextafter", math.Nextafter))
-	runtime.RegisterPackageValue("math", "pow", generateMathFunctionXY("pow", math.Pow))
-	runtime.RegisterPackageValue("math", "remainder", generateMathFunctionXY("remainder", math.Remainder))
-	runtime.RegisterPackageValue("math", "round", generateMathFunctionX("round", math.Round))
-	runtime.RegisterPackageValue("math", "roundtoeven", generateMathFunctionX("roundtoeven", math.RoundToEven))
-	runtime.RegisterPackageValue("math", "sin", generateMathFunctionX("sin", math.Sin))
-	runtime.RegisterPackageValue("math", "sinh", generateMathFunctionX("sinh", math.Sinh))
-	runtime.RegisterPackageValue("math", "sqrt", generateMathFunctionX("sqrt", math.Sqrt))
-	runtime.RegisterPackageValue("math", "tan", generateMathFunctionX("tan", math.Tan))
-	runtime.RegisterPackageValue("math", "tanh", generateMathFunctionX("tanh", math.Tanh))
-	runtime.RegisterPackageValue("math", "trunc", generateMathFunctionX("trunc", math.Trunc))
-	runtime.RegisterPackageValue("math", "y0", generateMathFunctionX("y0", math.Y0))
-	runtime.RegisterPackageValue("math", "y1", generateMathFunctionX("y1", math.Y1))
+	registerPure("mMax", generateMathFunctionXY("mMax", math.Max))
+	registerPure("mMin", generateMathFunctionXY("mMin", math.Min))
+	registerPure("mod", generateMathFunctionXY("mod", math.Mod))
+	registerPure("nextafter", generateMathFunctionXY("nextafter", math.Nextafter))
+	registerPure("pow", generateMathFunctionXY("pow", math.Pow))
+	registerPure("remainder", generateMathFunctionXY("remainder", math.Remainder))
+	registerPure("round", generateMathFunctionX("round", math.Round))
+	registerPure("roundtoeven", generateMathFunctionX("roundtoeven", math.RoundToEven))
+	registerPure("sin", generateMathFunctionX("sin", math.Sin))
+	registerPure("sinh", generateMathFunctionX("sinh", math.Sinh))
+	registerPure("sqrt", generateMathFunctionX("sqrt", math.Sqrt))
+	registerPure("tan", generateMathFunctionX("tan", math.Tan))
+	registerPure("tanh", generateMathFunctionX("tanh", math.Tanh))
+	registerPure("trunc", generateMathFunctionX("trunc", math.Trunc))
+	registerPure("y0", generateMathFunctionX("y0", math.Y0))
+	registerPure("y1", generateMathFunctionX("y1", math.Y1))
 
 	SpecialFns = map[string]values.Function{
 		// float --> uint
@@ -474,20 +481,20 @@ func init() {
 	}
 
 	// special case args and/or return types not worth generalizing
-	runtime.RegisterPackageValue("math", "float64bits", SpecialFns["float64bits"])
-	runtime.RegisterPackageValue("math", "float64frombits", SpecialFns["float64frombits"])
-	runtime.RegisterPackageValue("math", "ilogb", SpecialFns["ilogb"])
-	runtime.RegisterPackageValue("math", "frexp", SpecialFns["frexp"])
-	runtime.RegisterPackageValue("math", "lgamma", SpecialFns["lgamma"])
-	runtime.RegisterPackageValue("math", "modf", SpecialFns["modf"])
-	runtime.RegisterPackageValue("math", "sincos", SpecialFns["sincos"])
-	runtime.RegisterPackageValue("math", "isInf", SpecialFns["isInf"])
-	runtime.RegisterPackageValue("math", "isNaN", SpecialFns["isNaN"])
-	runtime.RegisterPackageValue("math", "signbit", SpecialFns["signbit"])
-	runtime.RegisterPackageValue("math", "NaN", SpecialFns["NaN"])
-	runtime.RegisterPackageValue("math", "mInf", SpecialFns["mInf"])
-	runtime.RegisterPackageValue("math", "jn", SpecialFns["jn"])
-	runtime.RegisterPackageValue("math", "yn", SpecialFns["yn"])
-	runtime.RegisterPackageValue("math", "ldexp", SpecialFns["ldexp"])
-	runtime.RegisterPackageValue("math", "pow10", SpecialFns["pow10"])
+	registerPure("float64bits", SpecialFns["float64bits"])
+	registerPure("float64frombits", SpecialFns["float64frombits"])
+	registerPure("ilogb", SpecialFns["ilogb"])
+	registerPure("frexp", SpecialFns["frexp"])
+	registerPure("lgamma", SpecialFns["lgamma"])
+	registerPure("modf", SpecialFns["modf"])
+	registerPure("sincos", SpecialFns["sincos"])
+	registerPure("isInf", SpecialFns["isInf"])
+	registerPure("isNaN", SpecialFns["isNaN"])
+	registerPure("signbit", SpecialFns["signbit"])
+	registerPure("NaN", SpecialFns["NaN"])
+	registerPure("mInf", SpecialFns["mInf"])
+	registerPure("jn", SpecialFns["jn"])
+	registerPure("yn", SpecialFns["yn"])
+	registerPure("ldexp", SpecialFns["ldexp"])
+	registerPure("pow10", SpecialFns["pow10"])
 }
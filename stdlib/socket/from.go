@@ -1,6 +1,7 @@
 // Package socket implements a source that gets input from a socket connection and produces tables given a decoder.
 // This is a good candidate for streaming use cases. For now, it produces a single table for everything
-// that it receives from the start to the end of the connection.
+// that it receives from the start to the end of the connection, or until the connection is idle
+// or the maximum number of records has been read.
 package socket
 
 import (
@@ -14,19 +15,30 @@ import (
 	"github.com/InfluxCommunity/flux"
 	"github.com/InfluxCommunity/flux/codes"
 	"github.com/InfluxCommunity/flux/csv"
+	furl "github.com/InfluxCommunity/flux/dependencies/url"
 	"github.com/InfluxCommunity/flux/execute"
 	"github.com/InfluxCommunity/flux/internal/errors"
 	"github.com/InfluxCommunity/flux/internal/line"
+	"github.com/InfluxCommunity/flux/internal/ndjson"
+	"github.com/InfluxCommunity/flux/memory"
 	"github.com/InfluxCommunity/flux/plan"
 	"github.com/InfluxCommunity/flux/runtime"
 	"github.com/InfluxCommunity/flux/values"
+	"golang.org/x/net/websocket"
 )
 
 const FromSocketKind = "fromSocket"
 
+// DefaultIdleTimeout is the idle timeout when none is specified,
+// so a source whose peer stops sending without closing the
+// connection still finishes.
+const DefaultIdleTimeout = 10 * time.Second
+
 type FromSocketOpSpec struct {
-	URL     string `json:"url"`
-	Decoder string `json:"decoder"`
+	URL         string        `json:"url"`
+	Decoder     string        `json:"decoder"`
+	IdleTimeout time.Duration `json:"idleTimeout"`
+	MaxRecords  int64         `json:"maxRecords"`
 }
 
 func init() {
@@ -45,8 +57,8 @@ func (a *nowTimeProvider) CurrentTime() values.Time {
 }
 
 var (
-	decoders = []string{"csv", "line"}
-	schemes  = []string{"tcp", "unix"}
+	decoders = []string{"csv", "line", "json"}
+	schemes  = []string{"tcp", "unix", "udp", "ws", "wss"}
 )
 
 func contains(ss []string, s string) bool {
@@ -79,6 +91,24 @@ func createFromSocketOpSpec(args flux.Arguments, a *flux.Administration) (flux.O
 		return nil, errors.Newf(codes.Invalid, "invalid decoder %s, must be one of %v", spec.Decoder, decoders)
 	}
 
+	if d, ok, err := args.GetDuration("idleTimeout"); err != nil {
+		return nil, err
+	} else if ok {
+		spec.IdleTimeout = values.Duration(d).Duration()
+		if spec.IdleTimeout <= 0 {
+			return nil, errors.New(codes.Invalid, "idleTimeout must be positive")
+		}
+	}
+
+	if n, ok, err := args.GetInt("maxRecords"); err != nil {
+		return nil, err
+	} else if ok {
+		if n <= 0 {
+			return nil, errors.New(codes.Invalid, "maxRecords must be positive")
+		}
+		spec.MaxRecords = n
+	}
+
 	return spec, nil
 }
 
@@ -88,8 +118,10 @@ func (s *FromSocketOpSpec) Kind() flux.OperationKind {
 
 type FromSocketProcedureSpec struct {
 	plan.DefaultCost
-	URL     string
-	Decoder string
+	URL         string
+	Decoder     string
+	IdleTimeout time.Duration
+	MaxRecords  int64
 }
 
 func newFromSocketProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
@@ -99,8 +131,10 @@ func newFromSocketProcedure(qs flux.OperationSpec, pa plan.Administration) (plan
 	}
 
 	return &FromSocketProcedureSpec{
-		URL:     spec.URL,
-		Decoder: spec.Decoder,
+		URL:         spec.URL,
+		Decoder:     spec.Decoder,
+		IdleTimeout: spec.IdleTimeout,
+		MaxRecords:  spec.MaxRecords,
 	}, nil
 }
 
@@ -112,6 +146,8 @@ func (s *FromSocketProcedureSpec) Copy() plan.ProcedureSpec {
 	ns := new(FromSocketProcedureSpec)
 	ns.URL = s.URL
 	ns.Decoder = s.Decoder
+	ns.IdleTimeout = s.IdleTimeout
+	ns.MaxRecords = s.MaxRecords
 	return ns
 }
 
@@ -136,7 +172,7 @@ func createFromSocketSource(s plan.ProcedureSpec, dsid execute.DatasetID, a exec
 	if err != nil {
 		return nil, err
 	}
-	if err := validator.Validate(url); err != nil {
+	if err := furl.ForFunction(validator, "socket.from").Validate(url); err != nil {
 		return nil, errors.Newf(codes.Invalid, "url did not pass validation: %v", err)
 	}
 	scheme = url.Scheme
@@ -145,30 +181,106 @@ func createFromSocketSource(s plan.ProcedureSpec, dsid execute.DatasetID, a exec
 		return nil, errors.Newf(codes.Invalid, "invalid scheme %s, must be one of %v", scheme, schemes)
 	}
 
-	conn, err := net.Dial(scheme, address)
+	rc, err := dial(scheme, address, url, spec.IdleTimeout)
 	if err != nil {
 		return nil, errors.Wrap(err, codes.Inherit, "error in creating socket source")
 	}
 
-	return NewSocketSource(spec, conn, &nowTimeProvider{}, dsid)
+	return NewSocketSource(spec, rc, &nowTimeProvider{}, dsid, a.Allocator())
+}
+
+// dial connects to the address and returns a reader that reports EOF
+// once the connection has been idle for the idle timeout.
+func dial(scheme, address string, url *neturl.URL, idle time.Duration) (io.ReadCloser, error) {
+	if idle <= 0 {
+		idle = DefaultIdleTimeout
+	}
+	switch scheme {
+	case "udp":
+		// A udp source receives the datagrams sent to the address.
+		conn, err := net.ListenPacket(scheme, address)
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, 64*1024)
+		return &messageReader{
+			receive: func() ([]byte, error) {
+				n, _, err := conn.ReadFrom(buf)
+				return buf[:n], err
+			},
+			conn:   conn,
+			closer: conn,
+			idle:   idle,
+		}, nil
+	case "ws", "wss":
+		origin := &neturl.URL{Scheme: "http", Host: url.Host}
+		if scheme == "wss" {
+			origin.Scheme = "https"
+		}
+		config, err := websocket.NewConfig(url.String(), origin.String())
+		if err != nil {
+			return nil, err
+		}
+		config.Dialer = &net.Dialer{Timeout: 30 * time.Second}
+		ws, err := websocket.DialConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		return &messageReader{
+			receive: func() ([]byte, error) {
+				var msg []byte
+				err := websocket.Message.Receive(ws, &msg)
+				return msg, err
+			},
+			conn:   ws,
+			closer: ws,
+			idle:   idle,
+		}, nil
+	default:
+		conn, err := net.Dial(scheme, address)
+		if err != nil {
+			return nil, err
+		}
+		return newIdleReader(conn, idle), nil
+	}
 }
 
-func NewSocketSource(spec *FromSocketProcedureSpec, rc io.ReadCloser, tp line.TimeProvider, dsid execute.DatasetID) (execute.Source, error) {
+func NewSocketSource(spec *FromSocketProcedureSpec, rc io.ReadCloser, tp line.TimeProvider, dsid execute.DatasetID, mem memory.Allocator) (execute.Source, error) {
 	var decoder flux.ResultDecoder
 	switch spec.Decoder {
 	case "csv":
-		decoder = csv.NewResultDecoder(csv.ResultDecoderConfig{})
+		decoder = csv.NewResultDecoder(csv.ResultDecoderConfig{
+			Allocator: mem,
+		})
 	case "line":
 		decoder = line.NewResultDecoder(&line.ResultDecoderConfig{
 			Separator:    '\n',
 			TimeProvider: tp,
 		})
+	case "json":
+		decoder = ndjson.NewResultDecoder(&ndjson.ResultDecoderConfig{
+			TimeProvider: tp,
+			MaxRecords:   int(spec.MaxRecords),
+			Allocator:    mem,
+		})
 	}
 
 	if decoder == nil {
 		return nil, errors.Newf(codes.Invalid, "unknown decoder type: %v", spec.Decoder)
 	}
 
+	// The json decoder counts objects itself. The other decoders
+	// read at most one row per line.
+	if spec.MaxRecords > 0 {
+		switch spec.Decoder {
+		case "csv":
+			rows := &csvRows{header: true}
+			rc = &recordLimitReader{ReadCloser: rc, remaining: spec.MaxRecords, isRecord: rows.isRecord}
+		case "line":
+			rc = &recordLimitReader{ReadCloser: rc, remaining: spec.MaxRecords, isRecord: func(byte) bool { return true }}
+		}
+	}
+
 	return &socketSource{
 		d:       dsid,
 		rc:      rc,
@@ -1,10 +1,18 @@
 package socket
 
 import (
+	"io"
+	"net"
+	"net/http/httptest"
+	neturl "net/url"
+	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
 
 	"github.com/InfluxCommunity/flux/dependencies/url"
 	"github.com/InfluxCommunity/flux/execute/executetest"
+	"golang.org/x/net/websocket"
 )
 
 func TestFromSocketUrlValidation(t *testing.T) {
@@ -45,3 +53,101 @@ func TestFromSocketUrlValidation(t *testing.T) {
 	}
 	testCases.Run(t, createFromSocketSource)
 }
+
+func TestDial_UDP(t *testing.T) {
+	// Reserve a free port for the source to listen on.
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := l.LocalAddr().String()
+	_ = l.Close()
+
+	rc, err := dial("udp", address, nil, 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	for _, msg := range []string{"first", "second\n"} {
+		if _, err := conn.Write([]byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "first\nsecond\n"; want != string(got) {
+		t.Errorf("unexpected data -want/+got:\n\t- %q\n\t+ %q", want, string(got))
+	}
+}
+
+func TestDial_WebSocket(t *testing.T) {
+	done := make(chan struct{})
+	ts := httptest.NewServer(websocket.Handler(func(ws *websocket.Conn) {
+		for _, msg := range []string{`{"v": 1}`, `{"v": 2}`} {
+			if err := websocket.Message.Send(ws, msg); err != nil {
+				t.Error(err)
+			}
+		}
+		// Keep the connection open so the reader ends on the idle timeout.
+		<-done
+	}))
+	defer ts.Close()
+	defer close(done)
+
+	u, err := neturl.Parse(strings.Replace(ts.URL, "http://", "ws://", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc, err := dial("ws", u.Host, u, 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\"v\": 1}\n{\"v\": 2}\n"; want != string(got) {
+		t.Errorf("unexpected data -want/+got:\n\t- %q\n\t+ %q", want, string(got))
+	}
+}
+
+func TestRecordLimitReader_CSV(t *testing.T) {
+	input := `#datatype,string,long,double
+#group,false,false,false
+#default,,,
+,result,table,_value
+,,0,1.0
+,,0,2.0
+
+#datatype,string,long,double
+#group,false,false,false
+#default,,,
+,result,table,_value
+,,1,3.0
+,,1,4.0
+`
+	rows := &csvRows{header: true}
+	r := &recordLimitReader{
+		ReadCloser: io.NopCloser(iotest.OneByteReader(strings.NewReader(input))),
+		remaining:  3,
+		isRecord:   rows.isRecord,
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := input[:strings.Index(input, ",,1,4.0")]; want != string(got) {
+		t.Errorf("unexpected data -want/+got:\n\t- %q\n\t+ %q", want, string(got))
+	}
+}
@@ -80,6 +80,30 @@ socket.from(url: "url", decoder: "line") |> range(start:-4h, stop:-2h) |> sum()`
 		},
 	}
 
+	tests = append(tests, querytest.NewQueryTestCase{
+		Name: "from with bounds",
+		Raw: `import "socket"
+socket.from(url: "ws://localhost:8080/feed", decoder: "json", idleTimeout: 5s, maxRecords: 10)`,
+		Want: &operation.Spec{
+			Operations: []*operation.Node{
+				{
+					ID: "fromSocket0",
+					Spec: &socket.FromSocketOpSpec{
+						URL:         "ws://localhost:8080/feed",
+						Decoder:     "json",
+						IdleTimeout: 5 * time.Second,
+						MaxRecords:  10,
+					},
+				},
+			},
+		},
+	}, querytest.NewQueryTestCase{
+		Name: "from negative max records",
+		Raw: `import "socket"
+socket.from(url: "url", maxRecords: -1)`,
+		WantErr: true,
+	})
+
 	for _, tc := range tests {
 		tc := tc
 		t.Run(tc.Name, func(t *testing.T) {
@@ -117,6 +141,87 @@ source
 				},
 			}},
 		},
+		{
+			name: "max records",
+			spec: &socket.FromSocketProcedureSpec{Decoder: "line", MaxRecords: 2},
+			input: `this is
+a line
+socket
+source
+`,
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TString},
+				},
+				Data: [][]interface{}{
+					{execute.Time(0), "this is"},
+					{execute.Time(1), "a line"},
+				},
+			}},
+		},
+		{
+			name: "json",
+			spec: &socket.FromSocketProcedureSpec{Decoder: "json", MaxRecords: 2},
+			input: `{"host": "a", "value": 1.5}
+{"host": "b", "value": 2}
+{"host": "c", "value": 3}
+`,
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "host", Type: flux.TString},
+					{Label: "value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(0), "a", 1.5},
+					{execute.Time(1), "b", 2.0},
+				},
+			}},
+		},
+		{
+			name: "csv max records",
+			spec: &socket.FromSocketProcedureSpec{Decoder: "csv", MaxRecords: 3},
+			input: `#datatype,string,long,dateTime:RFC3339,string,double
+#group,false,false,false,true,false
+#default,,,,,
+,result,table,_time,tag,double
+,,0,1970-01-01T00:00:00Z,a,1.0
+,,0,1970-01-01T00:00:00Z,a,2.0
+
+#datatype,string,long,dateTime:RFC3339,string,double
+#group,false,false,false,true,false
+#default,,,,,
+,result,table,_time,tag,double
+,,1,1970-01-01T00:00:00Z,b,3.0
+,,1,1970-01-01T00:00:00Z,b,4.0
+`,
+			want: []*executetest.Table{
+				{
+					KeyCols: []string{"tag"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "tag", Type: flux.TString},
+						{Label: "double", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(0), "a", 1.0},
+						{execute.Time(0), "a", 2.0},
+					},
+				},
+				{
+					KeyCols: []string{"tag"},
+					ColMeta: []flux.ColMeta{
+						{Label: "_time", Type: flux.TTime},
+						{Label: "tag", Type: flux.TString},
+						{Label: "double", Type: flux.TFloat},
+					},
+					Data: [][]interface{}{
+						{execute.Time(0), "b", 3.0},
+					},
+				},
+			},
+		},
 		{
 			name: "csv",
 			spec: &socket.FromSocketProcedureSpec{Decoder: "csv"},
@@ -204,7 +309,7 @@ source
 			c := execute.NewTableBuilderCache(executetest.UnlimitedAllocator)
 			c.SetTriggerSpec(plan.DefaultTriggerSpec)
 			r := io.NopCloser(bytes.NewReader([]byte(tc.input)))
-			ss, err := socket.NewSocketSource(tc.spec, r, &mock.AscendingTimeProvider{}, id)
+			ss, err := socket.NewSocketSource(tc.spec, r, &mock.AscendingTimeProvider{}, id, executetest.UnlimitedAllocator)
 			if err != nil {
 				t.Fatal(err)
 			}
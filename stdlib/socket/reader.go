@@ -0,0 +1,155 @@
+package socket
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"time"
+)
+
+// deadlineSetter is implemented by connections that support read deadlines.
+type deadlineSetter interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// setIdleDeadline sets a read deadline idle time from now, if idle is positive.
+func setIdleDeadline(d deadlineSetter, idle time.Duration) error {
+	if idle <= 0 {
+		return nil
+	}
+	return d.SetReadDeadline(time.Now().Add(idle))
+}
+
+// isTimeout reports whether the error is caused by a read deadline.
+func isTimeout(err error) bool {
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// idleReader reads from a connection until no data has been received
+// for the idle timeout, at which point it reports EOF.
+type idleReader struct {
+	conn net.Conn
+	idle time.Duration
+}
+
+func newIdleReader(conn net.Conn, idle time.Duration) io.ReadCloser {
+	if idle <= 0 {
+		return conn
+	}
+	return &idleReader{conn: conn, idle: idle}
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	if err := setIdleDeadline(r.conn, r.idle); err != nil {
+		return 0, err
+	}
+	n, err := r.conn.Read(p)
+	if err != nil && isTimeout(err) {
+		return n, io.EOF
+	}
+	return n, err
+}
+
+func (r *idleReader) Close() error {
+	return r.conn.Close()
+}
+
+// messageReader turns a connection that receives discrete messages,
+// such as datagrams or websocket frames, into a stream of records.
+// Every message is terminated with a newline so it is decoded
+// as at least one record.
+// The reader reports EOF when no message has been received for the idle timeout.
+type messageReader struct {
+	receive func() ([]byte, error)
+	conn    deadlineSetter
+	closer  io.Closer
+	idle    time.Duration
+	buf     bytes.Buffer
+}
+
+func (r *messageReader) Read(p []byte) (int, error) {
+	for r.buf.Len() == 0 {
+		if err := setIdleDeadline(r.conn, r.idle); err != nil {
+			return 0, err
+		}
+		msg, err := r.receive()
+		if err != nil {
+			if isTimeout(err) {
+				return 0, io.EOF
+			}
+			return 0, err
+		}
+		r.buf.Write(msg)
+		if len(msg) > 0 && msg[len(msg)-1] != '\n' {
+			r.buf.WriteByte('\n')
+		}
+	}
+	return r.buf.Read(p)
+}
+
+func (r *messageReader) Close() error {
+	return r.closer.Close()
+}
+
+// recordLimitReader reports EOF after reading the given number of
+// newline-terminated records.
+type recordLimitReader struct {
+	io.ReadCloser
+	remaining int64
+	// isRecord reports whether a line is decoded into a row
+	// given the first byte of the line.
+	isRecord func(first byte) bool
+
+	first  byte
+	inLine bool
+}
+
+func (r *recordLimitReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		return 0, io.EOF
+	}
+	n, err := r.ReadCloser.Read(p)
+	for i := 0; i < n; i++ {
+		if !r.inLine {
+			r.first, r.inLine = p[i], true
+		}
+		if p[i] != '\n' {
+			continue
+		}
+		r.inLine = false
+		if r.isRecord(r.first) {
+			r.remaining--
+			if r.remaining == 0 {
+				return i + 1, nil
+			}
+		}
+	}
+	return n, err
+}
+
+// csvRows tells the rows of annotated CSV apart from the annotations,
+// the header that follows them and the empty lines between tables.
+type csvRows struct {
+	// header reports whether the next line that is
+	// not an annotation is a header.
+	header bool
+}
+
+func (c *csvRows) isRecord(first byte) bool {
+	switch {
+	case first == '#', first == '\n', first == '\r':
+		c.header = true
+		return false
+	case c.header:
+		c.header = false
+		return false
+	default:
+		return true
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/InfluxCommunity/flux"
 	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/dependencies/url"
 	"github.com/InfluxCommunity/flux/execute"
 	"github.com/InfluxCommunity/flux/internal/errors"
 	"github.com/InfluxCommunity/flux/memory"
@@ -102,7 +103,7 @@ func createFromSQLSource(prSpec plan.ProcedureSpec, dsid execute.DatasetID, a ex
 	if err != nil {
 		return nil, err
 	}
-	if err := validateDataSource(validator, spec.DriverName, spec.DataSourceName); err != nil {
+	if err := validateDataSource(url.ForFunction(validator, "sql.from"), spec.DriverName, spec.DataSourceName); err != nil {
 		return nil, err
 	}
 
@@ -3,10 +3,15 @@ package sql
 import (
 	"database/sql"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/InfluxCommunity/flux"
 	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/dependencies/audit"
+	"github.com/InfluxCommunity/flux/dependencies/dryrun"
+	"github.com/InfluxCommunity/flux/dependencies/url"
 	"github.com/InfluxCommunity/flux/execute"
 	"github.com/InfluxCommunity/flux/internal/errors"
 	"github.com/InfluxCommunity/flux/plan"
@@ -127,10 +132,21 @@ func createToSQLTransformation(id execute.DatasetID, mode execute.AccumulationMo
 	cache := execute.NewTableBuilderCache(a.Allocator())
 	d := execute.NewDataset(id, mode, cache)
 	deps := flux.GetDependencies(a.Context())
+	// The data source name is not part of the destination
+	// since it may hold credentials in a driver specific format.
+	destination := s.Spec.DriverName + ":" + s.Spec.Table
+	if sink := dryrun.GetSink(a.Context()); sink != nil {
+		t, err := newDryRunToSQLTransformation(d, deps, cache, s, sink.NewWriter("sql.to", destination))
+		if err != nil {
+			return nil, nil, err
+		}
+		return t, d, nil
+	}
 	t, err := NewToSQLTransformation(d, deps, cache, s)
 	if err != nil {
 		return nil, nil, err
 	}
+	t.write = audit.NewWrite(a.Context(), "sql.to", destination)
 	return t, d, nil
 }
 
@@ -141,6 +157,12 @@ type ToSQLTransformation struct {
 	spec  *ToSQLProcedureSpec
 	db    *sql.DB
 	tx    *sql.Tx
+
+	// write records the inserted rows for the audit log.
+	write *audit.Write
+	// dryRun captures the statements in place of the database
+	// in dry-run mode, in which case db and tx are nil.
+	dryRun *dryrun.Writer
 }
 
 func (t *ToSQLTransformation) RetractTable(id execute.DatasetID, key flux.GroupKey) error {
@@ -152,7 +174,7 @@ func NewToSQLTransformation(d execute.Dataset, deps flux.Dependencies, cache exe
 	if err != nil {
 		return nil, err
 	}
-	if err := validateDataSource(validator, spec.Spec.DriverName, spec.Spec.DataSourceName); err != nil {
+	if err := validateDataSource(url.ForFunction(validator, "sql.to"), spec.Spec.DriverName, spec.Spec.DataSourceName); err != nil {
 		return nil, err
 	}
 
@@ -177,6 +199,29 @@ func NewToSQLTransformation(d execute.Dataset, deps flux.Dependencies, cache exe
 	}, nil
 }
 
+// newDryRunToSQLTransformation creates a ToSQLTransformation
+// that writes its statements to w without opening the database.
+func newDryRunToSQLTransformation(d execute.Dataset, deps flux.Dependencies, cache execute.TableBuilderCache, spec *ToSQLProcedureSpec, w *dryrun.Writer) (*ToSQLTransformation, error) {
+	validator, err := deps.URLValidator()
+	if err != nil {
+		return nil, err
+	}
+	if err := validateDataSource(url.ForFunction(validator, "sql.to"), spec.Spec.DriverName, spec.Spec.DataSourceName); err != nil {
+		return nil, err
+	}
+	// The driver name is validated by getting its translation function
+	// since the database is not opened.
+	if _, err := getTranslationFunc(spec.Spec.DriverName); err != nil {
+		return nil, err
+	}
+	return &ToSQLTransformation{
+		d:      d,
+		cache:  cache,
+		spec:   spec,
+		dryRun: w,
+	}, nil
+}
+
 type idxType struct {
 	Idx  int
 	Type flux.ColType
@@ -188,9 +233,18 @@ func (t *ToSQLTransformation) Process(id execute.DatasetID, tbl flux.Table) (err
 		return err
 	}
 	for i := range valStrings {
+		if t.dryRun != nil {
+			if err := writeDryRunInsert(t.dryRun, t.spec.Spec, colNames, valArgs[i]); err != nil {
+				return err
+			}
+			t.dryRun.AddRows(int64(len(valStrings[i])))
+			continue
+		}
 		if err := ExecuteQueries(t.tx, t.spec.Spec, colNames, &valStrings[i], &valArgs[i]); err != nil {
 			return err
 		}
+		// Each value string inserts one row.
+		t.write.Add(int64(len(valStrings[i])), 0)
 	}
 
 	return err
@@ -205,7 +259,9 @@ func (t *ToSQLTransformation) UpdateProcessingTime(id execute.DatasetID, pt exec
 }
 
 func (t *ToSQLTransformation) Finish(id execute.DatasetID, err error) {
-	if supportsTx(t.spec.Spec.DriverName) {
+	if t.dryRun != nil {
+		_ = t.dryRun.Close()
+	} else if supportsTx(t.spec.Spec.DriverName) {
 		var txErr error
 		if err == nil {
 			txErr = t.tx.Commit()
@@ -219,6 +275,7 @@ func (t *ToSQLTransformation) Finish(id execute.DatasetID, err error) {
 			err = errors.Wrap(err, codes.Inherit, dbErr)
 		}
 	}
+	t.write.Done(err)
 	t.d.Finish(err)
 }
 
@@ -442,7 +499,11 @@ func CreateInsertComponents(t *ToSQLTransformation, tbl flux.Table) (colNames []
 					strings.Join(newSQLTableCols, ","),
 				)
 			}
-			_, err = t.tx.Exec(q)
+			if t.dryRun != nil {
+				_, err = fmt.Fprintf(t.dryRun, "%s;\n", q)
+			} else {
+				_, err = t.tx.Exec(q)
+			}
 			if err != nil {
 				return err
 			}
@@ -539,14 +600,8 @@ func ExecuteQueries(tx *sql.Tx, s *ToSQLOpSpec, colNames []string, valueStrings
 		}
 	}
 
-	// N.B. identifiers that will be string formatted into SQL statements must be
-	// quoted/escaped, ref: influxdata/idpe#8689
 	quotedTable := quoteIdent(s.Table)
-	quotedColNames := make([]string, len(colNames))
-	for idx, name := range colNames {
-		quotedColNames[idx] = quoteIdent(name)
-	}
-	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", quotedTable, strings.Join(quotedColNames, ","), concatValueStrings)
+	query := insertStatement(quoteIdent, s.Table, colNames, concatValueStrings)
 
 	if isMssqlDriver(s.DriverName) && mssqlCheckParameter(s.DataSourceName, mssqlIdentityInsertEnabled) {
 		// XXX: identifiers that will be string formatted into SQL statements must be quoted, ref: influxdata/idpe#8689
@@ -571,3 +626,54 @@ func ExecuteQueries(tx *sql.Tx, s *ToSQLOpSpec, colNames []string, valueStrings
 	}
 	return err
 }
+
+// insertStatement formats the statement that inserts the values into the columns of the table.
+func insertStatement(quoteIdent quoteIdentFunc, table string, colNames []string, values string) string {
+	// N.B. identifiers that will be string formatted into SQL statements must be
+	// quoted/escaped, ref: influxdata/idpe#8689
+	quotedColNames := make([]string, len(colNames))
+	for idx, name := range colNames {
+		quotedColNames[idx] = quoteIdent(name)
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", quoteIdent(table), strings.Join(quotedColNames, ","), values)
+}
+
+// writeDryRunInsert writes the statement that inserts the values with the
+// values formatted as literals, since the statement is only meant to be read.
+func writeDryRunInsert(w *dryrun.Writer, s *ToSQLOpSpec, colNames []string, valueArgs []interface{}) error {
+	quoteIdent, err := getQuoteIdentFunc(s.DriverName)
+	if err != nil {
+		return err
+	}
+	rows := make([]string, 0, len(valueArgs)/len(colNames))
+	literals := make([]string, len(colNames))
+	for i := 0; i+len(colNames) <= len(valueArgs); i += len(colNames) {
+		for j, v := range valueArgs[i : i+len(colNames)] {
+			literals[j] = sqlLiteral(v)
+		}
+		rows = append(rows, "("+strings.Join(literals, ",")+")")
+	}
+	_, err = fmt.Fprintf(w, "%s;\n", insertStatement(quoteIdent, s.Table, colNames, strings.Join(rows, ",")))
+	return err
+}
+
+// sqlLiteral formats a value of a row as a SQL literal.
+func sqlLiteral(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return singleQuote(v)
+	case time.Time:
+		return singleQuote(v.UTC().Format(time.RFC3339Nano))
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"unicode"
 	"unicode/utf8"
 
+	"github.com/InfluxCommunity/flux/compiler"
 	"github.com/InfluxCommunity/flux/interpreter"
 	"github.com/InfluxCommunity/flux/runtime"
 	"github.com/InfluxCommunity/flux/semantic"
@@ -371,74 +372,80 @@ var substring = values.NewFunction(
 	}, false,
 )
 
+// registerPure registers a strings function that only depends on
+// its arguments so the compiler may fold and share calls to it.
+func registerPure(name string, fn values.Function) {
+	runtime.RegisterPackageValue("strings", name, compiler.MarkPure(fn))
+}
+
 func init() {
-	runtime.RegisterPackageValue("strings", "strlen", strlen)
-	runtime.RegisterPackageValue("strings", "substring", substring)
+	registerPure("strlen", strlen)
+	registerPure("substring", substring)
 
-	runtime.RegisterPackageValue("strings", "trim",
+	registerPure("trim",
 		generateDualArgStringFunction("trim", []string{stringArgV, cutset}, strings.Trim))
-	runtime.RegisterPackageValue("strings", "trimSpace",
+	registerPure("trimSpace",
 		generateSingleArgStringFunction("trimSpace", strings.TrimSpace))
-	runtime.RegisterPackageValue("strings", "trimPrefix",
+	registerPure("trimPrefix",
 		generateDualArgStringFunction("trimSuffix", []string{stringArgV, prefix}, strings.TrimPrefix))
-	runtime.RegisterPackageValue("strings", "trimSuffix",
+	registerPure("trimSuffix",
 		generateDualArgStringFunction("trimSuffix", []string{stringArgV, suffix}, strings.TrimSuffix))
-	runtime.RegisterPackageValue("strings", "title",
+	registerPure("title",
 		//lint:ignore SA1019 https://github.com/InfluxCommunity/flux/issues/4946
 		generateSingleArgStringFunction("title", strings.Title))
-	runtime.RegisterPackageValue("strings", "toUpper",
+	registerPure("toUpper",
 		generateSingleArgStringFunction("toUpper", strings.ToUpper))
-	runtime.RegisterPackageValue("strings", "toLower",
+	registerPure("toLower",
 		generateSingleArgStringFunction("toLower", strings.ToLower))
-	runtime.RegisterPackageValue("strings", "trimRight",
+	registerPure("trimRight",
 		generateDualArgStringFunction("trimRight", []string{stringArgV, cutset}, strings.TrimRight))
-	runtime.RegisterPackageValue("strings", "trimLeft",
+	registerPure("trimLeft",
 		generateDualArgStringFunction("trimLeft", []string{stringArgV, cutset}, strings.TrimLeft))
-	runtime.RegisterPackageValue("strings", "toTitle",
+	registerPure("toTitle",
 		generateSingleArgStringFunction("toTitle", strings.ToTitle))
-	runtime.RegisterPackageValue("strings", "hasPrefix",
+	registerPure("hasPrefix",
 		generateDualArgStringFunctionReturnBool("hasPrefix", []string{stringArgV, prefix}, strings.HasPrefix))
-	runtime.RegisterPackageValue("strings", "hasSuffix",
+	registerPure("hasSuffix",
 		generateDualArgStringFunctionReturnBool("hasSuffix", []string{stringArgV, suffix}, strings.HasSuffix))
-	runtime.RegisterPackageValue("strings", "containsStr",
+	registerPure("containsStr",
 		generateDualArgStringFunctionReturnBool("containsStr", []string{stringArgV, substr}, strings.Contains))
-	runtime.RegisterPackageValue("strings", "containsAny",
+	registerPure("containsAny",
 		generateDualArgStringFunctionReturnBool("containsAny", []string{stringArgV, chars}, strings.ContainsAny))
-	runtime.RegisterPackageValue("strings", "equalFold",
+	registerPure("equalFold",
 		generateDualArgStringFunctionReturnBool("equalFold", []string{stringArgV, stringArgT}, strings.EqualFold))
-	runtime.RegisterPackageValue("strings", "compare",
+	registerPure("compare",
 		generateDualArgStringFunctionReturnInt("compare", []string{stringArgV, stringArgT}, strings.Compare))
-	runtime.RegisterPackageValue("strings", "countStr",
+	registerPure("countStr",
 		generateDualArgStringFunctionReturnInt("countStr", []string{stringArgV, substr}, strings.Count))
-	runtime.RegisterPackageValue("strings", "index",
+	registerPure("index",
 		generateDualArgStringFunctionReturnInt("index", []string{stringArgV, substr}, strings.Index))
-	runtime.RegisterPackageValue("strings", "indexAny",
+	registerPure("indexAny",
 		generateDualArgStringFunctionReturnInt("indexAny", []string{stringArgV, chars}, strings.IndexAny))
-	runtime.RegisterPackageValue("strings", "lastIndex",
+	registerPure("lastIndex",
 		generateDualArgStringFunctionReturnInt("lastIndex", []string{stringArgV, substr}, strings.LastIndex))
-	runtime.RegisterPackageValue("strings", "lastIndexAny",
+	registerPure("lastIndexAny",
 		generateDualArgStringFunctionReturnInt("lastIndexAny", []string{stringArgV, chars}, strings.LastIndexAny))
-	runtime.RegisterPackageValue("strings", "isDigit",
+	registerPure("isDigit",
 		generateUnicodeIsFunction("isDigit", unicode.IsDigit))
-	runtime.RegisterPackageValue("strings", "isLetter",
+	registerPure("isLetter",
 		generateUnicodeIsFunction("isLetter", unicode.IsLetter))
-	runtime.RegisterPackageValue("strings", "isLower",
+	registerPure("isLower",
 		generateUnicodeIsFunction("isLower", unicode.IsLower))
-	runtime.RegisterPackageValue("strings", "isUpper",
+	registerPure("isUpper",
 		generateUnicodeIsFunction("isUpper", unicode.IsUpper))
-	runtime.RegisterPackageValue("strings", "repeat",
+	registerPure("repeat",
 		generateRepeat("repeat", []string{stringArgV, integer}, strings.Repeat))
-	runtime.RegisterPackageValue("strings", "replace",
+	registerPure("replace",
 		generateReplace("replace", []string{stringArgV, stringArgT, stringArgU, integer}, strings.Replace))
-	runtime.RegisterPackageValue("strings", "replaceAll",
+	registerPure("replaceAll",
 		generateReplaceAll("replaceAll", []string{stringArgV, stringArgT, stringArgU}, replaceAll))
-	runtime.RegisterPackageValue("strings", "split",
+	registerPure("split",
 		generateSplit("split", []string{stringArgV, stringArgT}, strings.Split))
-	runtime.RegisterPackageValue("strings", "splitAfter",
+	registerPure("splitAfter",
 		generateSplit("splitAfter", []string{stringArgV, stringArgT}, strings.SplitAfter))
-	runtime.RegisterPackageValue("strings", "splitN",
+	registerPure("splitN",
 		generateSplitN("splitN", []string{stringArgV, stringArgT, integer}, strings.SplitN))
-	runtime.RegisterPackageValue("strings", "splitAfterN",
+	registerPure("splitAfterN",
 		generateSplitN("splitAfterN", []string{stringArgV, stringArgT, integer}, strings.SplitAfterN))
 
 	SpecialFns = map[string]values.Function{
@@ -490,6 +497,6 @@ func init() {
 		),
 	}
 
-	runtime.RegisterPackageValue("strings", "joinStr", SpecialFns["joinStr"])
+	registerPure("joinStr", SpecialFns["joinStr"])
 
 }
@@ -167,6 +167,16 @@ func (s *FillProcedureSpec) Copy() plan.ProcedureSpec {
 	return ns
 }
 
+func (s *FillProcedureSpec) PassThroughAttribute(attrKey string) bool {
+	switch attrKey {
+	case plan.ParallelRunKey:
+		// Filling with the previous value depends on the rows
+		// that came before, which may be in another partition.
+		return !s.UsePrevious
+	}
+	return false
+}
+
 func createFillTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
 	s, ok := spec.(*FillProcedureSpec)
 	if !ok {
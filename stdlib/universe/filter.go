@@ -196,9 +196,14 @@ func (t *filterTransformation) filterChunk(fn *execute.RowPredicatePreparedFn, c
 		return table.Chunk{}, false, err
 	}
 	defer bitset.Release()
+	return filterChunkWithBitset(chunk, bitset, t.keepEmptyTables, mem)
+}
 
+// filterChunkWithBitset produces a chunk that only contains the rows
+// whose bit is set in the bitset.
+func filterChunkWithBitset(chunk table.Chunk, bitset *arrowmem.Buffer, keepEmptyTables bool, mem arrowmem.Allocator) (table.Chunk, bool, error) {
 	n := bitutil.CountSetBits(bitset.Buf(), 0, bitset.Len())
-	if n == 0 && !t.keepEmptyTables {
+	if n == 0 && !keepEmptyTables {
 		// Drop this chunk if it is empty and we are not keeping empty tables.
 		return table.Chunk{}, false, nil
 	}
@@ -227,11 +232,18 @@ func (t *filterTransformation) filter(fn *execute.RowPredicatePreparedFn, cr flu
 	bitset := arrowmem.NewResizableBuffer(mem)
 	bitset.Resize(l)
 	for i := 0; i < l; i++ {
-		for _, j := range indices {
-			record.Set(cols[j].Label, execute.ValueForRow(cr, i, j))
+		var (
+			val bool
+			err error
+		)
+		if fn.Specialized() {
+			val, err = fn.EvalRow(t.ctx, i, cr)
+		} else {
+			for _, j := range indices {
+				record.Set(cols[j].Label, execute.ValueForRow(cr, i, j))
+			}
+			val, err = fn.Eval(t.ctx, record)
 		}
-
-		val, err := fn.Eval(t.ctx, record)
 		if err != nil {
 			bitset.Release()
 			return nil, errors.Wrap(err, codes.Inherit, "failed to evaluate filter function")
@@ -0,0 +1,19 @@
+package universe
+
+import (
+	"context"
+
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/interpreter"
+	"github.com/InfluxCommunity/flux/memory"
+)
+
+func NewVectorizedFilterTransformation(ctx context.Context, spec *FilterProcedureSpec, id execute.DatasetID, mem memory.Allocator) (execute.Transformation, execute.Dataset, error) {
+	return newVectorizedFilterTransformation(ctx, &vectorizedFilterProcedureSpec{
+		Fn: interpreter.ResolvedFunction{
+			Fn:    spec.Fn.Fn.Vectorized,
+			Scope: spec.Fn.Scope,
+		},
+		KeepEmptyTables: spec.KeepEmptyTables,
+	}, id, mem)
+}
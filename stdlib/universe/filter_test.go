@@ -972,11 +972,29 @@ func TestFilter_MergeFilterRule(t *testing.T) {
 func BenchmarkFilter_Values(b *testing.B) {
 	b.Run("1000", func(b *testing.B) {
 		fn := executetest.FunctionExpression(b, `(r) => r._value > 0.0`)
-		benchmarkFilter(b, 1000, fn)
+		benchmarkFilter(b, 1000, fn, false)
 	})
 }
 
-func benchmarkFilter(b *testing.B, n int, fn *semantic.FunctionExpression) {
+func BenchmarkFilter_Process(b *testing.B) {
+	const source = `(r) => r._value > 0.0 and r.t0 != "a"`
+	b.Run("Row", func(b *testing.B) {
+		fn := executetest.FunctionExpression(b, source)
+		benchmarkFilter(b, 1000, fn, false)
+	})
+
+	b.Run("Vectorized", func(b *testing.B) {
+		fn := executetest.FunctionExpression(b, source)
+		if fn.Vectorized == nil {
+			b.Fatal("expected the filter function to be vectorized")
+		}
+		benchmarkFilter(b, 1000, fn, true)
+	})
+}
+
+// benchmarkFilter benchmarks the filter transformation with the given function.
+// The vectorized transformation is used when vectorized is true.
+func benchmarkFilter(b *testing.B, n int, fn *semantic.FunctionExpression, vectorized bool) {
 	b.ReportAllocs()
 	spec := &universe.FilterProcedureSpec{
 		Fn: interpreter.ResolvedFunction{
@@ -999,7 +1017,11 @@ func benchmarkFilter(b *testing.B, n int, fn *semantic.FunctionExpression) {
 			return gen.Input(context.Background(), schema)
 		},
 		func(id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset) {
-			t, d, err := universe.NewFilterTransformation(context.Background(), spec, id, alloc)
+			newTransformation := universe.NewFilterTransformation
+			if vectorized {
+				newTransformation = universe.NewVectorizedFilterTransformation
+			}
+			t, d, err := newTransformation(context.Background(), spec, id, alloc)
 			if err != nil {
 				b.Fatal(err)
 			}
@@ -0,0 +1,160 @@
+package universe
+
+import (
+	"context"
+
+	"github.com/InfluxCommunity/flux/array"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/compiler"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/table"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/interpreter"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/values"
+	"github.com/apache/arrow/go/v7/arrow/bitutil"
+	arrowmem "github.com/apache/arrow/go/v7/arrow/memory"
+)
+
+const (
+	vectorizedFilterKind = "vectorizedFilter"
+)
+
+func init() {
+	// The filter is vectorized after the physical rules have been applied
+	// so that filters which can be pushed down into a source are merged
+	// into it before they are rewritten to a different procedure kind.
+	plan.RegisterParallelizeRules(vectorizeFilterRule{})
+	execute.RegisterTransformation(vectorizedFilterKind, createVectorizedFilterTransformation)
+}
+
+type vectorizedFilterProcedureSpec struct {
+	plan.DefaultCost
+	Fn              interpreter.ResolvedFunction
+	KeepEmptyTables bool
+}
+
+func (s *vectorizedFilterProcedureSpec) Kind() plan.ProcedureKind {
+	return vectorizedFilterKind
+}
+
+func (s *vectorizedFilterProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := new(vectorizedFilterProcedureSpec)
+	*ns = *s
+	ns.Fn = s.Fn.Copy()
+	return ns
+}
+
+func (s *vectorizedFilterProcedureSpec) PassThroughAttribute(attrKey string) bool {
+	switch attrKey {
+	case plan.ParallelRunKey, plan.CollationKey:
+		return true
+	}
+	return false
+}
+
+// TriggerSpec implements plan.TriggerAwareProcedureSpec
+func (s *vectorizedFilterProcedureSpec) TriggerSpec() plan.TriggerSpec {
+	return plan.NarrowTransformationTriggerSpec{}
+}
+
+type vectorizeFilterRule struct{}
+
+func (v vectorizeFilterRule) Name() string {
+	return "vectorizeFilterRule"
+}
+
+func (v vectorizeFilterRule) Pattern() plan.Pattern {
+	return plan.MultiSuccessor(FilterKind)
+}
+
+func (v vectorizeFilterRule) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
+	filterSpec := node.ProcedureSpec().(*FilterProcedureSpec)
+	if filterSpec.Fn.Fn.Vectorized == nil {
+		return node, false, nil
+	}
+
+	return plan.ReplacePhysicalNodes(ctx, node, node, vectorizedFilterKind, &vectorizedFilterProcedureSpec{
+		Fn: interpreter.ResolvedFunction{
+			Fn:    filterSpec.Fn.Fn.Vectorized,
+			Scope: filterSpec.Fn.Scope,
+		},
+		KeepEmptyTables: filterSpec.KeepEmptyTables,
+	}), true, nil
+}
+
+func createVectorizedFilterTransformation(
+	id execute.DatasetID,
+	mode execute.AccumulationMode,
+	spec plan.ProcedureSpec,
+	a execute.Administration,
+) (execute.Transformation, execute.Dataset, error) {
+	s, ok := spec.(*vectorizedFilterProcedureSpec)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	return newVectorizedFilterTransformation(a.Context(), s, id, a.Allocator())
+}
+
+func newVectorizedFilterTransformation(ctx context.Context, spec *vectorizedFilterProcedureSpec, id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset, error) {
+	tr := &vectorizedFilterTransformation{
+		ctx:             ctx,
+		fn:              execute.NewVectorPredicateFn(spec.Fn.Fn, compiler.ToScope(spec.Fn.Scope)),
+		keepEmptyTables: spec.KeepEmptyTables,
+	}
+	return execute.NewNarrowTransformation(id, tr, alloc)
+}
+
+type vectorizedFilterTransformation struct {
+	ctx             context.Context
+	fn              *execute.VectorPredicateFn
+	keepEmptyTables bool
+}
+
+func (t *vectorizedFilterTransformation) Process(chunk table.Chunk, d *execute.TransportDataset, mem arrowmem.Allocator) error {
+	fn, err := t.fn.Prepare(t.ctx, chunk.Cols())
+	if err != nil {
+		return err
+	}
+
+	mask, err := fn.Eval(t.ctx, chunk)
+	if err != nil {
+		return errors.Wrap(err, codes.Inherit, "failed to evaluate filter function")
+	}
+	defer mask.Release()
+
+	bitset := maskBitset(mask, chunk.Len(), false, mem)
+	defer bitset.Release()
+
+	out, ok, err := filterChunkWithBitset(chunk, bitset, t.keepEmptyTables, mem)
+	if err != nil || !ok {
+		return err
+	}
+	return d.Process(out)
+}
+
+// maskBitset converts a boolean mask, such as the one produced by a
+// predicate, into a bitset. Null values in the mask are false. The bits
+// are inverted when negate is set.
+func maskBitset(mask values.Vector, n int, negate bool, mem arrowmem.Allocator) *arrowmem.Buffer {
+	bitset := arrowmem.NewResizableBuffer(mem)
+	bitset.Resize(n)
+
+	if mask.IsRepeat() {
+		v := mask.(*values.VectorRepeatValue).Value()
+		set := (!v.IsNull() && v.Bool()) != negate
+		for i := 0; i < n; i++ {
+			bitutil.SetBitTo(bitset.Buf(), i, set)
+		}
+		return bitset
+	}
+
+	arr := mask.Arr().(*array.Boolean)
+	for i := 0; i < n; i++ {
+		bitutil.SetBitTo(bitset.Buf(), i, (arr.IsValid(i) && arr.Value(i)) != negate)
+	}
+	return bitset
+}
+
+func (t *vectorizedFilterTransformation) Close() error { return nil }
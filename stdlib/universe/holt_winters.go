@@ -350,9 +350,13 @@ func (hwt *holtWintersTransformation) getCleanData(tbl flux.Table, colIdx, timeI
 	isFirst := func() bool {
 		return bucketEnd == -1
 	}
+	checkpoint := memory.NewCheckpoint(hwt.alloc)
 	if err := tbl.Do(func(cr flux.ColReader) error {
 		// we work row-wise
 		for i := 0; i < cr.Len(); i++ {
+			if err := checkpoint.Check(); err != nil {
+				return err
+			}
 			// drop values with invalid timestamp
 			if cts := cr.Times(timeIdx); cts.IsValid(i) {
 				// the first value must be valid, skip it if it isn't so
@@ -867,10 +867,14 @@ func (c *MergeJoinCache) join(left, right *execute.ColListTableBuilder) (flux.Ta
 	}
 
 	// Perform sort merge join
+	checkpoint := memory.NewCheckpoint(c.alloc)
 	for !leftSet.Empty() && !rightSet.Empty() {
 		if leftKey.EqualTrueNulls(rightKey) {
 			for l := leftSet.Start; l < leftSet.Stop; l++ {
 				for r := rightSet.Start; r < rightSet.Stop; r++ {
+					if err := checkpoint.Check(); err != nil {
+						return nil, err
+					}
 
 					leftRecord := left.GetRow(l)
 					rightRecord := right.GetRow(r)
@@ -99,6 +99,14 @@ func (s *MapProcedureSpec) Copy() plan.ProcedureSpec {
 	return ns
 }
 
+func (s *MapProcedureSpec) PassThroughAttribute(attrKey string) bool {
+	switch attrKey {
+	case plan.ParallelRunKey:
+		return true
+	}
+	return false
+}
+
 func createMapTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
 	s, ok := spec.(*MapProcedureSpec)
 	if !ok {
@@ -43,6 +43,14 @@ func (v *vectorizedMapProcedureSpec) Copy() plan.ProcedureSpec {
 	return ns
 }
 
+func (v *vectorizedMapProcedureSpec) PassThroughAttribute(attrKey string) bool {
+	switch attrKey {
+	case plan.ParallelRunKey:
+		return true
+	}
+	return false
+}
+
 type vectorizeMapRule struct{}
 
 func (v vectorizeMapRule) Name() string {
@@ -21,6 +21,11 @@ const (
 type PartitionMergeProcedureSpec struct {
 	plan.DefaultCost
 	Factor int
+
+	// CombineTables is set when more than one partition may produce
+	// a table with the same group key. The merge then combines the
+	// tables that share a group key into one table.
+	CombineTables bool
 }
 
 func (o *PartitionMergeProcedureSpec) OutputAttributes() plan.PhysicalAttributes {
@@ -43,15 +48,75 @@ func (o *PartitionMergeProcedureSpec) Kind() plan.ProcedureKind {
 
 func (o *PartitionMergeProcedureSpec) Copy() plan.ProcedureSpec {
 	return &PartitionMergeProcedureSpec{
-		DefaultCost: o.DefaultCost,
-		Factor:      o.Factor,
+		DefaultCost:   o.DefaultCost,
+		Factor:        o.Factor,
+		CombineTables: o.CombineTables,
 	}
 }
 
 func init() {
+	plan.RegisterParallelizeRules(DelayPartitionMergeRule{})
 	execute.RegisterTransformation(ParallelMergeKind, createPartitionMergeTransformation)
 }
 
+// DelayPartitionMergeRule moves a partition merge below any successor
+// that passes the parallel run attribute through. Each partition then runs
+// its own copy of that successor and the merge happens as late as possible.
+//
+// The partitions produce tables with distinct group keys, but a successor
+// that may change the group key can make two partitions produce tables with
+// the same group key. When the merge is moved below such a successor, the
+// merge combines those tables.
+type DelayPartitionMergeRule struct{}
+
+func (DelayPartitionMergeRule) Name() string {
+	return "DelayPartitionMergeRule"
+}
+
+func (DelayPartitionMergeRule) Pattern() plan.Pattern {
+	return plan.MultiSuccessor(plan.AnyKind, plan.SingleSuccessor(ParallelMergeKind))
+}
+
+func (DelayPartitionMergeRule) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
+	ppn, ok := node.(*plan.PhysicalPlanNode)
+	if !ok || len(node.Predecessors()) != 1 {
+		return node, false, nil
+	}
+	spec, ok := ppn.ProcedureSpec().(plan.PassThroughAttributer)
+	if !ok || !spec.PassThroughAttribute(plan.ParallelRunKey) {
+		return node, false, nil
+	}
+
+	merge := node.Predecessors()[0]
+	if len(merge.Predecessors()) != 1 {
+		return node, false, nil
+	}
+	swapped, err := plan.SwapPlanNodes(node, merge)
+	if err != nil {
+		return nil, false, err
+	}
+
+	mergeSpec := swapped.ProcedureSpec().(*PartitionMergeProcedureSpec)
+	if !mergeSpec.CombineTables && !preservesGroupKeys(ppn.ProcedureSpec()) {
+		mergeSpec = mergeSpec.Copy().(*PartitionMergeProcedureSpec)
+		mergeSpec.CombineTables = true
+		if err := swapped.(*plan.PhysicalPlanNode).ReplaceSpec(mergeSpec); err != nil {
+			return nil, false, err
+		}
+	}
+	return swapped, true, nil
+}
+
+// preservesGroupKeys reports whether each table produced by the procedure
+// has the group key of the table it was produced from.
+func preservesGroupKeys(spec plan.ProcedureSpec) bool {
+	switch spec.(type) {
+	case *FilterProcedureSpec, *vectorizedFilterProcedureSpec:
+		return true
+	}
+	return false
+}
+
 func createPartitionMergeTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
 	s, ok := spec.(*PartitionMergeProcedureSpec)
 	if !ok {
@@ -77,6 +142,10 @@ type PartitionMergeTransformation struct {
 	span    opentracing.Span
 	alloc   memory.Allocator
 
+	// tables holds a builder for each group key
+	// when the tables that share a group key are combined.
+	tables *execute.GroupLookup
+
 	mu               sync.Mutex
 	predecessorState map[execute.DatasetID]*parallelPredecessorState
 	finished         bool
@@ -101,16 +170,24 @@ func NewPartitionMergeTransformation(ctx context.Context, dataset *execute.Passt
 		predecessorState[id] = new(parallelPredecessorState)
 	}
 
-	return &PartitionMergeTransformation{
+	t := &PartitionMergeTransformation{
 		ctx:              ctx,
 		dataset:          dataset,
 		span:             span,
 		alloc:            alloc,
 		predecessorState: predecessorState,
-	}, nil
+	}
+	if spec.CombineTables {
+		t.tables = execute.NewGroupLookup()
+	}
+	return t, nil
 }
 
 func (t *PartitionMergeTransformation) Process(id execute.DatasetID, tbl flux.Table) error {
+	if t.tables != nil {
+		return t.combine(tbl)
+	}
+
 	passthroughBuilder := table.NewBufferedBuilder(tbl.Key(), t.alloc)
 
 	err := tbl.Do(func(er flux.ColReader) error {
@@ -135,6 +212,44 @@ func (t *PartitionMergeTransformation) Process(id execute.DatasetID, tbl flux.Ta
 	return t.dataset.Process(out)
 }
 
+// combine appends the table to the builder for its group key.
+// The combined tables are produced when every partition has finished.
+func (t *PartitionMergeTransformation) combine(tbl flux.Table) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.finished {
+		tbl.Done()
+		return nil
+	}
+
+	builder := t.tables.LookupOrCreate(tbl.Key(), func() interface{} {
+		return table.NewBufferedBuilder(tbl.Key(), t.alloc)
+	}).(*table.BufferedBuilder)
+	return builder.AppendTable(tbl)
+}
+
+// flush produces the combined tables unless the merge failed
+// and releases the buffers of any tables that were not produced.
+func (t *PartitionMergeTransformation) flush(err error) error {
+	_ = t.tables.Range(func(key flux.GroupKey, value interface{}) error {
+		builder := value.(*table.BufferedBuilder)
+		defer builder.Release()
+		if err != nil {
+			return nil
+		}
+
+		out, tblErr := builder.Table()
+		if tblErr == nil {
+			tblErr = t.dataset.Process(out)
+		}
+		err = tblErr
+		return nil
+	})
+	t.tables.Clear()
+	return err
+}
+
 func (t *PartitionMergeTransformation) UpdateWatermark(id execute.DatasetID, mark execute.Time) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -144,6 +259,11 @@ func (t *PartitionMergeTransformation) UpdateWatermark(id execute.DatasetID, mar
 	}
 
 	t.predecessorState[id].mark = mark
+	if t.tables != nil {
+		// The combined tables are only produced when every partition
+		// has finished, so the watermark cannot be advanced before then.
+		return nil
+	}
 
 	min := execute.Time(math.MaxInt64)
 	for _, state := range t.predecessorState {
@@ -164,6 +284,9 @@ func (t *PartitionMergeTransformation) UpdateProcessingTime(id execute.DatasetID
 	}
 
 	t.predecessorState[id].processing = pt
+	if t.tables != nil {
+		return nil
+	}
 
 	min := execute.Time(math.MaxInt64)
 	for _, state := range t.predecessorState {
@@ -199,6 +322,9 @@ func (t *PartitionMergeTransformation) Finish(id execute.DatasetID, err error) {
 	}
 
 	if t.finished {
+		if t.tables != nil {
+			err = t.flush(err)
+		}
 		t.dataset.Finish(err)
 	}
 }
@@ -0,0 +1,305 @@
+package universe_test
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/dependencies/filesystem"
+	"github.com/InfluxCommunity/flux/execute/executetest"
+	"github.com/InfluxCommunity/flux/internal/feature"
+	"github.com/InfluxCommunity/flux/lang"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/plan/plantest"
+	"github.com/InfluxCommunity/flux/runtime"
+	"github.com/InfluxCommunity/flux/stdlib/universe"
+	"github.com/InfluxCommunity/flux/values"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestDelayPartitionMergeRule(t *testing.T) {
+	var (
+		from = plantest.MockProcedureSpec{
+			OutputAttributesFn: func() plan.PhysicalAttributes {
+				return plan.PhysicalAttributes{
+					plan.ParallelRunKey: plan.ParallelRunAttribute{Factor: 2},
+				}
+			},
+		}
+		merge        = &universe.PartitionMergeProcedureSpec{Factor: 2}
+		combine      = &universe.PartitionMergeProcedureSpec{Factor: 2, CombineTables: true}
+		filter       = &universe.FilterProcedureSpec{}
+		mapFn        = &universe.MapProcedureSpec{}
+		drop         = &universe.SchemaMutationProcedureSpec{Mutations: []universe.SchemaMutation{&universe.DropOpSpec{Columns: []string{"a"}}}}
+		fill         = &universe.FillProcedureSpec{Column: "_value", Value: values.NewFloat(0)}
+		fillPrevious = &universe.FillProcedureSpec{Column: "_value", UsePrevious: true}
+		sort         = &universe.SortProcedureSpec{Columns: []string{"_value"}}
+		rules        = []plan.Rule{universe.DelayPartitionMergeRule{}}
+		create       = plantest.CreatePhysicalNode
+	)
+
+	tests := []plantest.RuleTestCase{
+		{
+			Name:  "merge then map",
+			Rules: rules,
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					create("from", from),
+					create("merge", merge),
+					create("map", mapFn),
+				},
+				Edges: [][2]int{
+					{0, 1},
+					{1, 2},
+				},
+			},
+			After: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					create("from", from),
+					create("map_copy", mapFn),
+					create("merge", combine),
+				},
+				Edges: [][2]int{
+					{0, 1},
+					{1, 2},
+				},
+			},
+		},
+		{
+			Name:  "merge then narrow chain",
+			Rules: rules,
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					create("from", from),
+					create("merge", merge),
+					create("map", mapFn),
+					create("drop", drop),
+					create("fill", fill),
+					create("sort", sort),
+				},
+				Edges: [][2]int{
+					{0, 1},
+					{1, 2},
+					{2, 3},
+					{3, 4},
+					{4, 5},
+				},
+			},
+			After: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					create("from", from),
+					create("map_copy", mapFn),
+					create("drop_copy", drop),
+					create("fill_copy", fill),
+					create("merge", combine),
+					create("sort", sort),
+				},
+				Edges: [][2]int{
+					{0, 1},
+					{1, 2},
+					{2, 3},
+					{3, 4},
+					{4, 5},
+				},
+			},
+		},
+		{
+			// Filter does not change the group key so
+			// the tables of the partitions are not combined.
+			Name:  "merge then filter",
+			Rules: rules,
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					create("from", from),
+					create("merge", merge),
+					create("filter", filter),
+				},
+				Edges: [][2]int{
+					{0, 1},
+					{1, 2},
+				},
+			},
+			After: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					create("from", from),
+					create("filter_copy", filter),
+					create("merge", merge),
+				},
+				Edges: [][2]int{
+					{0, 1},
+					{1, 2},
+				},
+			},
+		},
+		{
+			Name:  "merge then sort",
+			Rules: rules,
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					create("from", from),
+					create("merge", merge),
+					create("sort", sort),
+				},
+				Edges: [][2]int{
+					{0, 1},
+					{1, 2},
+				},
+			},
+			NoChange: true,
+		},
+		{
+			Name:  "merge then fill previous",
+			Rules: rules,
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					create("from", from),
+					create("merge", merge),
+					create("fill", fillPrevious),
+				},
+				Edges: [][2]int{
+					{0, 1},
+					{1, 2},
+				},
+			},
+			NoChange: true,
+		},
+		{
+			Name:  "merge with multiple successors",
+			Rules: rules,
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					create("from", from),
+					create("merge", merge),
+					create("map0", mapFn),
+					create("map1", mapFn),
+				},
+				Edges: [][2]int{
+					{0, 1},
+					{1, 2},
+					{1, 3},
+				},
+			},
+			NoChange: true,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			// Copies of the mock spec drop its attribute functions.
+			plantest.PhysicalRuleTestHelper(t, &tc, cmpopts.IgnoreFields(plantest.MockProcedureSpec{}, "OutputAttributesFn"))
+		})
+	}
+}
+
+// TestPartitionMerge_CombineTables runs transformations that change
+// the group key in each partition and checks that the partition merge
+// combines the tables with the same group key from different partitions.
+func TestPartitionMerge_CombineTables(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString(`#datatype,string,long,dateTime:RFC3339,string,string,double
+#group,false,false,false,true,true,false
+#default,_result,,,,,
+,result,table,_time,_measurement,host,_value
+`)
+	start := time.Date(2018, 4, 17, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 20; i++ {
+		for j := 0; j < 10; j++ {
+			fmt.Fprintf(&sb, ",,%d,%s,m%d,h%d,%d\n", i, start.Add(time.Duration(j)*time.Second).Format(time.RFC3339), i%5, i/5, i*j)
+		}
+	}
+	fs := filesystem.NewMemoryFS(map[string]string{
+		"/data.csv": sb.String(),
+	})
+
+	// Each query ends with a sort, which runs after the partition merge,
+	// so that the rows of each table are in a deterministic order.
+	testCases := []struct {
+		name  string
+		query string
+	}{
+		{
+			name:  "drop",
+			query: `|> drop(columns: ["host"]) |> sort(columns: ["_time", "_value"])`,
+		},
+		{
+			name:  "keep",
+			query: `|> keep(columns: ["_measurement", "_time", "_value"]) |> sort(columns: ["_time", "_value"])`,
+		},
+		{
+			name:  "set",
+			query: `|> set(key: "_measurement", value: "m") |> sort(columns: ["host", "_time", "_value"])`,
+		},
+		{
+			name:  "rename",
+			query: `|> drop(columns: ["host"]) |> rename(columns: {_measurement: "m"}) |> sort(columns: ["_time", "_value"])`,
+		},
+		{
+			name:  "duplicate",
+			query: `|> duplicate(column: "host", as: "_measurement") |> sort(columns: ["_time", "_value"])`,
+		},
+		{
+			name:  "map",
+			query: `|> map(fn: (r) => ({r with host: "h"})) |> sort(columns: ["_measurement", "_time", "_value"])`,
+		},
+	}
+
+	run := func(t *testing.T, query string, factor int) []*executetest.Table {
+		t.Helper()
+
+		flagger := executetest.TestFlagger{}
+		flagger[feature.ParallelizationFactor().Key()] = factor
+		ctx := feature.Inject(filesystem.Inject(context.Background(), fs), flagger)
+
+		program, err := (&lang.FluxCompiler{Query: query}).Compile(ctx, runtime.Default)
+		if err != nil {
+			t.Fatal(err)
+		}
+		q, err := program.Start(ctx, &memory.ResourceAllocator{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer q.Done()
+
+		var tables []*executetest.Table
+		for res := range q.Results() {
+			if err := res.Tables().Do(func(tbl flux.Table) error {
+				et, err := executetest.ConvertTable(tbl)
+				if err != nil {
+					return err
+				}
+				tables = append(tables, et)
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		q.Done()
+		if err := q.Err(); err != nil {
+			t.Fatalf("unexpected error with %d partitions: %s", factor, err)
+		}
+		executetest.NormalizeTables(tables)
+		sort.Sort(executetest.SortedTables(tables))
+		return tables
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			query := "import \"csv\"\ncsv.from(file: \"/data.csv\")\n\t" + tc.query
+			want := run(t, query, 0)
+			for factor := 2; factor <= 4; factor++ {
+				if got := run(t, query, factor); !cmp.Equal(want, got) {
+					t.Errorf("unexpected tables with %d partitions -want/+got\n%s", factor, cmp.Diff(want, got))
+				}
+			}
+		})
+	}
+}
@@ -157,6 +157,7 @@ func createPivotTransformation(id execute.DatasetID, mode execute.AccumulationMo
 	cache := execute.NewTableBuilderCache(a.Allocator())
 	d := execute.NewDataset(id, mode, cache)
 	t := NewPivotTransformation(d, cache, s)
+	t.checkpoint = memory.NewCheckpoint(a.Allocator())
 	return t, d, nil
 }
 
@@ -174,6 +175,7 @@ type pivotTransformation struct {
 	colKeyMaps map[string]map[string]int
 	rowKeyMaps map[string]map[string]int
 	nextRowCol map[string]rowCol
+	checkpoint *memory.Checkpoint
 }
 
 func NewPivotTransformation(d execute.Dataset, cache execute.TableBuilderCache, spec *PivotProcedureSpec) *pivotTransformation {
@@ -268,6 +270,9 @@ func (t *pivotTransformation) Process(id execute.DatasetID, tbl flux.Table) erro
 
 	return tbl.Do(func(cr flux.ColReader) error {
 		for row := 0; row < cr.Len(); row++ {
+			if err := t.checkpoint.Check(); err != nil {
+				return err
+			}
 			rowKey := ""
 			colKey := ""
 			for _, rk := range t.spec.RowKey {
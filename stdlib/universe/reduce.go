@@ -10,6 +10,7 @@ import (
 	"github.com/InfluxCommunity/flux/execute"
 	"github.com/InfluxCommunity/flux/internal/errors"
 	"github.com/InfluxCommunity/flux/interpreter"
+	"github.com/InfluxCommunity/flux/memory"
 	"github.com/InfluxCommunity/flux/plan"
 	"github.com/InfluxCommunity/flux/runtime"
 	"github.com/InfluxCommunity/flux/semantic"
@@ -102,6 +103,7 @@ func createReduceTransformation(id execute.DatasetID, mode execute.AccumulationM
 	if err != nil {
 		return nil, nil, err
 	}
+	t.checkpoint = memory.NewCheckpoint(a.Allocator())
 	return t, d, nil
 }
 
@@ -112,6 +114,12 @@ type reduceTransformation struct {
 	ctx      context.Context
 	fn       *execute.RowReduceFn
 	identity values.Object
+
+	// reducer is set when the reduce function can be vectorized.
+	reducer *vectorizedReducer
+	mem     memory.Allocator
+
+	checkpoint *memory.Checkpoint
 }
 
 func NewReduceTransformation(ctx context.Context, spec *ReduceProcedureSpec, d execute.Dataset, cache execute.TableBuilderCache) (*reduceTransformation, error) {
@@ -126,34 +134,20 @@ func NewReduceTransformation(ctx context.Context, spec *ReduceProcedureSpec, d e
 }
 
 func (t *reduceTransformation) Process(id execute.DatasetID, tbl flux.Table) error {
-	// Prepare the function with the column types list.
-	cols := tbl.Cols()
-	fn, err := t.fn.Prepare(t.ctx, cols, map[string]semantic.MonoType{"accumulator": t.identity.Type()})
-	if err != nil {
-		return err
+	var (
+		m   values.Object
+		err error
+	)
+	if prepared, ok := t.prepareVectorized(tbl.Cols()); ok {
+		m, err = t.reducer.Reduce(t.ctx, prepared, tbl, t.identity, t.mem)
+	} else {
+		m, err = t.reduce(tbl)
 	}
-
-	// Start the reduce operation with the neutral element as the accumulator.
-	const accumulatorParamName = "accumulator"
-	params := map[string]values.Value{accumulatorParamName: t.identity}
-	if err := tbl.Do(func(cr flux.ColReader) error {
-		l := cr.Len()
-		for i := 0; i < l; i++ {
-			// the RowReduce function type takes a row of values, and an accumulator value, and
-			// computes a new accumulator result.
-			m, err := fn.Eval(t.ctx, i, cr, params)
-			if err != nil {
-				return errors.Wrap(err, codes.Inherit, "failed to evaluate reduce function")
-			}
-			params[accumulatorParamName] = m
-		}
-		return nil
-	}); err != nil {
+	if err != nil {
 		return err
 	}
 
 	// Compute the group key by replacing columns from the reducer if needed.
-	m := params[accumulatorParamName].Object()
 	key := t.computeGroupKey(tbl.Key(), m)
 
 	builder, created := t.cache.TableBuilder(key)
@@ -203,6 +197,48 @@ func (t *reduceTransformation) Process(id execute.DatasetID, tbl flux.Table) err
 	return nil
 }
 
+// prepareVectorized prepares the vectorized reducer for the columns of the
+// table. It reports false if the table must be reduced row by row.
+func (t *reduceTransformation) prepareVectorized(cols []flux.ColMeta) ([]preparedPropertyReducer, bool) {
+	if t.reducer == nil {
+		return nil, false
+	}
+	return t.reducer.prepare(t.ctx, cols, t.identity)
+}
+
+// reduce evaluates the reduce function for each row of the table.
+func (t *reduceTransformation) reduce(tbl flux.Table) (values.Object, error) {
+	// Prepare the function with the column types list.
+	cols := tbl.Cols()
+	fn, err := t.fn.Prepare(t.ctx, cols, map[string]semantic.MonoType{"accumulator": t.identity.Type()})
+	if err != nil {
+		return nil, err
+	}
+
+	// Start the reduce operation with the neutral element as the accumulator.
+	const accumulatorParamName = "accumulator"
+	params := map[string]values.Value{accumulatorParamName: t.identity}
+	if err := tbl.Do(func(cr flux.ColReader) error {
+		l := cr.Len()
+		for i := 0; i < l; i++ {
+			if err := t.checkpoint.Check(); err != nil {
+				return err
+			}
+			// the RowReduce function type takes a row of values, and an accumulator value, and
+			// computes a new accumulator result.
+			m, err := fn.Eval(t.ctx, i, cr, params)
+			if err != nil {
+				return errors.Wrap(err, codes.Inherit, "failed to evaluate reduce function")
+			}
+			params[accumulatorParamName] = m
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return params[accumulatorParamName].Object(), nil
+}
+
 func (t *reduceTransformation) computeGroupKey(key flux.GroupKey, v values.Object) flux.GroupKey {
 	replace := false
 	v.Range(func(name string, v values.Value) {
@@ -0,0 +1,21 @@
+package universe
+
+import (
+	"context"
+
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/memory"
+)
+
+// NewVectorizedReduceTransformation creates a vectorized reduce transformation.
+// It reports false if the reduce function cannot be vectorized.
+func NewVectorizedReduceTransformation(ctx context.Context, spec *ReduceProcedureSpec, d execute.Dataset, cache execute.TableBuilderCache, mem memory.Allocator) (execute.Transformation, bool, error) {
+	if _, ok := newVectorizedReducer(spec.Fn, spec.Identity); !ok {
+		return nil, false, nil
+	}
+	t, err := newVectorizedReduceTransformation(ctx, &vectorizedReduceProcedureSpec{
+		Fn:       spec.Fn,
+		Identity: spec.Identity,
+	}, d, cache, mem)
+	return t, true, err
+}
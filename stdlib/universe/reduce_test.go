@@ -2,6 +2,7 @@ package universe_test
 
 import (
 	"context"
+	"math"
 	"testing"
 
 	"github.com/InfluxCommunity/flux"
@@ -11,7 +12,9 @@ import (
 	"github.com/InfluxCommunity/flux/execute"
 	"github.com/InfluxCommunity/flux/execute/executetest"
 	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/internal/gen"
 	"github.com/InfluxCommunity/flux/interpreter"
+	"github.com/InfluxCommunity/flux/memory"
 	"github.com/InfluxCommunity/flux/stdlib/universe"
 	"github.com/InfluxCommunity/flux/values"
 	"github.com/InfluxCommunity/flux/values/valuestest"
@@ -111,6 +114,152 @@ func TestReduce_Process(t *testing.T) {
 			}},
 			wantErr: errors.New(codes.Invalid, `null values are not supported for "prod" in the reduce() function`),
 		},
+		{
+			name: `count min max`,
+			spec: &universe.ReduceProcedureSpec{
+				Identity: values.NewObjectWithValues(map[string]values.Value{
+					"count": values.NewInt(0),
+					"min":   values.NewFloat(math.MaxFloat64),
+					"max":   values.NewFloat(-math.MaxFloat64),
+				}),
+				Fn: interpreter.ResolvedFunction{
+					Fn: executetest.FunctionExpression(t, `(r, accumulator) => ({
+						count: accumulator.count + 1,
+						min: if r._value < accumulator.min then r._value else accumulator.min,
+						max: if accumulator.max >= r._value then accumulator.max else r._value,
+					})`),
+					Scope: valuestest.Scope(),
+				},
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), 4.1},
+					{execute.Time(2), nil},
+					{execute.Time(3), -2.5},
+					{execute.Time(4), 6.2},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "count", Type: flux.TInt},
+					{Label: "max", Type: flux.TFloat},
+					{Label: "min", Type: flux.TFloat},
+				},
+				Data: [][]interface{}{
+					{int64(4), 6.2, -2.5},
+				},
+			}},
+		},
+		{
+			name: `conditional sum`,
+			spec: &universe.ReduceProcedureSpec{
+				Identity: values.NewObjectWithValues(map[string]values.Value{
+					"sum":   values.NewInt(0),
+					"other": values.NewInt(0),
+				}),
+				Fn: interpreter.ResolvedFunction{
+					Fn: executetest.FunctionExpression(t, `(r, accumulator) => ({accumulator with
+						sum: if r.t0 == "a" and r._value > 1 then accumulator.sum + r._value else accumulator.sum,
+					})`),
+					Scope: valuestest.Scope(),
+				},
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: flux.TInt},
+					{Label: "t0", Type: flux.TString},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), int64(1), "a"},
+					{execute.Time(2), int64(2), "a"},
+					{execute.Time(3), int64(3), "b"},
+					{execute.Time(4), int64(4), nil},
+					{execute.Time(5), int64(5), "a"},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "other", Type: flux.TInt},
+					{Label: "sum", Type: flux.TInt},
+				},
+				Data: [][]interface{}{
+					{int64(0), int64(7)},
+				},
+			}},
+		},
+		{
+			name: `guarded division`,
+			spec: &universe.ReduceProcedureSpec{
+				Identity: values.NewObjectWithValues(map[string]values.Value{
+					"s": values.NewInt(0),
+					"t": values.NewInt(0),
+				}),
+				Fn: interpreter.ResolvedFunction{
+					Fn: executetest.FunctionExpression(t, `(r, accumulator) => ({
+						s: if r.d != 0 then accumulator.s + r.n / r.d else accumulator.s,
+						t: if r.d == 0 then accumulator.t else accumulator.t + r.n % r.d,
+					})`),
+					Scope: valuestest.Scope(),
+				},
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "n", Type: flux.TInt},
+					{Label: "d", Type: flux.TInt},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), int64(6), int64(2)},
+					{execute.Time(2), int64(5), int64(0)},
+					{execute.Time(3), int64(7), int64(3)},
+					{execute.Time(4), int64(1), int64(0)},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "s", Type: flux.TInt},
+					{Label: "t", Type: flux.TInt},
+				},
+				Data: [][]interface{}{
+					{int64(5), int64(1)},
+				},
+			}},
+		},
+		{
+			name: `concatenate strings`,
+			spec: &universe.ReduceProcedureSpec{
+				Identity: values.NewObjectWithValues(map[string]values.Value{
+					"s": values.NewString(""),
+				}),
+				Fn: interpreter.ResolvedFunction{
+					Fn:    executetest.FunctionExpression(t, `(r, accumulator) => ({s: accumulator.s + r.t0})`),
+					Scope: valuestest.Scope(),
+				},
+			},
+			data: []flux.Table{&executetest.Table{
+				ColMeta: []flux.ColMeta{
+					{Label: "_time", Type: flux.TTime},
+					{Label: "t0", Type: flux.TString},
+				},
+				Data: [][]interface{}{
+					{execute.Time(1), "a"},
+					{execute.Time(2), "b"},
+				},
+			}},
+			want: []*executetest.Table{{
+				ColMeta: []flux.ColMeta{
+					{Label: "s", Type: flux.TString},
+				},
+				Data: [][]interface{}{
+					{"ab"},
+				},
+			}},
+		},
 	}
 	for _, tc := range testCases {
 		tc := tc
@@ -131,5 +280,85 @@ func TestReduce_Process(t *testing.T) {
 				},
 			)
 		})
+
+		// Vectorized reduce must produce the same results as the row based reduce.
+		// Tables with columns it does not support are reduced row by row.
+		t.Run(tc.name+"/vectorized", func(t *testing.T) {
+			executetest.ProcessTestHelper(
+				t,
+				tc.data,
+				tc.want,
+				tc.wantErr,
+				func(d execute.Dataset, c execute.TableBuilderCache) execute.Transformation {
+					ctx, deps := dependency.Inject(context.Background(), dependenciestest.Default())
+					defer deps.Finish()
+					f, ok, err := universe.NewVectorizedReduceTransformation(ctx, tc.spec, d, c, memory.DefaultAllocator)
+					if err != nil {
+						t.Fatal(err)
+					} else if !ok {
+						t.Skip("reduce function cannot be vectorized")
+					}
+					return f
+				},
+			)
+		})
+	}
+}
+
+func BenchmarkReduce_Process(b *testing.B) {
+	genSource := func(alloc memory.Allocator) (flux.TableIterator, error) {
+		return gen.Input(context.Background(), gen.Schema{
+			Tags: []gen.Tag{
+				{Name: "t0", Cardinality: 1},
+				{Name: "t1", Cardinality: 1},
+			},
+			NumPoints: 1000,
+			Alloc:     alloc,
+		})
 	}
+
+	spec := func(b *testing.B) *universe.ReduceProcedureSpec {
+		return &universe.ReduceProcedureSpec{
+			Identity: values.NewObjectWithValues(map[string]values.Value{
+				"sum":   values.NewFloat(0),
+				"count": values.NewInt(0),
+			}),
+			Fn: interpreter.ResolvedFunction{
+				Fn:    executetest.FunctionExpression(b, `(r, accumulator) => ({sum: accumulator.sum + r._value, count: accumulator.count + 1})`),
+				Scope: valuestest.Scope(),
+			},
+		}
+	}
+
+	b.Run("Row", func(b *testing.B) {
+		spec := spec(b)
+		executetest.ProcessBenchmarkHelper(b, genSource,
+			func(id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset) {
+				cache := execute.NewTableBuilderCache(alloc)
+				d := execute.NewDataset(id, execute.DiscardingMode, cache)
+				t, err := universe.NewReduceTransformation(context.Background(), spec, d, cache)
+				if err != nil {
+					b.Fatal(err)
+				}
+				return t, d
+			},
+		)
+	})
+
+	b.Run("Vectorized", func(b *testing.B) {
+		spec := spec(b)
+		executetest.ProcessBenchmarkHelper(b, genSource,
+			func(id execute.DatasetID, alloc memory.Allocator) (execute.Transformation, execute.Dataset) {
+				cache := execute.NewTableBuilderCache(alloc)
+				d := execute.NewDataset(id, execute.DiscardingMode, cache)
+				t, ok, err := universe.NewVectorizedReduceTransformation(context.Background(), spec, d, cache, alloc)
+				if err != nil {
+					b.Fatal(err)
+				} else if !ok {
+					b.Fatal("expected the reduce function to be vectorized")
+				}
+				return t, d
+			},
+		)
+	})
 }
@@ -0,0 +1,571 @@
+package universe
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/InfluxCommunity/flux"
+	"github.com/InfluxCommunity/flux/ast"
+	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/compiler"
+	"github.com/InfluxCommunity/flux/execute"
+	"github.com/InfluxCommunity/flux/execute/table"
+	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/interpreter"
+	"github.com/InfluxCommunity/flux/memory"
+	"github.com/InfluxCommunity/flux/plan"
+	"github.com/InfluxCommunity/flux/semantic"
+	"github.com/InfluxCommunity/flux/values"
+)
+
+const (
+	vectorizedReduceKind = "vectorizedReduce"
+)
+
+func init() {
+	plan.RegisterPhysicalRules(vectorizeReduceRule{})
+	execute.RegisterTransformation(vectorizedReduceKind, createVectorizedReduceTransformation)
+}
+
+type vectorizedReduceProcedureSpec struct {
+	plan.DefaultCost
+	Fn       interpreter.ResolvedFunction
+	Identity values.Object
+}
+
+func (s *vectorizedReduceProcedureSpec) Kind() plan.ProcedureKind {
+	return vectorizedReduceKind
+}
+
+func (s *vectorizedReduceProcedureSpec) Copy() plan.ProcedureSpec {
+	ns := new(vectorizedReduceProcedureSpec)
+	*ns = *s
+	ns.Fn = s.Fn.Copy()
+	return ns
+}
+
+// vectorizeReduceRule replaces reduce with a vectorized reduce
+// when every property of the reduce function is an associative
+// reduction that can be computed over whole columns.
+type vectorizeReduceRule struct{}
+
+func (v vectorizeReduceRule) Name() string {
+	return "vectorizeReduceRule"
+}
+
+func (v vectorizeReduceRule) Pattern() plan.Pattern {
+	return plan.MultiSuccessor(ReduceKind)
+}
+
+func (v vectorizeReduceRule) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
+	reduceSpec := node.ProcedureSpec().(*ReduceProcedureSpec)
+	if _, ok := newVectorizedReducer(reduceSpec.Fn, reduceSpec.Identity); !ok {
+		return node, false, nil
+	}
+
+	return plan.ReplacePhysicalNodes(ctx, node, node, vectorizedReduceKind, &vectorizedReduceProcedureSpec{
+		Fn:       reduceSpec.Fn,
+		Identity: reduceSpec.Identity,
+	}), true, nil
+}
+
+func createVectorizedReduceTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
+	s, ok := spec.(*vectorizedReduceProcedureSpec)
+	if !ok {
+		return nil, nil, errors.Newf(codes.Internal, "invalid spec type %T", spec)
+	}
+	cache := execute.NewTableBuilderCache(a.Allocator())
+	d := execute.NewDataset(id, mode, cache)
+	t, err := newVectorizedReduceTransformation(a.Context(), s, d, cache, a.Allocator())
+	if err != nil {
+		return nil, nil, err
+	}
+	return t, d, nil
+}
+
+func newVectorizedReduceTransformation(ctx context.Context, spec *vectorizedReduceProcedureSpec, d execute.Dataset, cache execute.TableBuilderCache, mem memory.Allocator) (*reduceTransformation, error) {
+	// The vectorized evaluators allocate the vectors
+	// with the allocator from the context.
+	ctx = memory.WithAllocator(ctx, mem)
+	t, err := NewReduceTransformation(ctx, &ReduceProcedureSpec{
+		Fn:       spec.Fn,
+		Identity: spec.Identity,
+	}, d, cache)
+	if err != nil {
+		return nil, err
+	}
+	reducer, ok := newVectorizedReducer(spec.Fn, spec.Identity)
+	if !ok {
+		return nil, errors.New(codes.Internal, "reduce function cannot be vectorized")
+	}
+	t.reducer = reducer
+	t.mem = mem
+	return t, nil
+}
+
+type reducerKind int
+
+const (
+	// keepReducer leaves the accumulator unchanged.
+	keepReducer reducerKind = iota
+	// sumReducer adds each element to the accumulator.
+	sumReducer
+	// productReducer multiplies the accumulator by each element.
+	productReducer
+	// selectReducer replaces the accumulator with an element depending
+	// on the result of comparing the two, such as for a min or a max.
+	selectReducer
+)
+
+// propertyReducer describes how a single property of the accumulator
+// is computed from the previous accumulator and the row.
+type propertyReducer struct {
+	label string
+	kind  reducerKind
+
+	// elem is the expression, only depending on the row,
+	// that is folded into the accumulator.
+	elem semantic.Expression
+
+	// op is the comparison used by the select reducer.
+	// The element is on the left side of the comparison when elemLeft is set.
+	// The element replaces the accumulator when the comparison is true
+	// and selectOnTrue is set, or when it is false or null otherwise.
+	op           ast.OperatorKind
+	elemLeft     bool
+	selectOnTrue bool
+
+	// cond, when set, guards the reduction so that only the rows where
+	// the condition is true, or false and null when negate is set, are
+	// folded into the accumulator.
+	cond   semantic.Expression
+	negate bool
+
+	// elemFn and condFn evaluate elem and cond
+	// over the columns of a table.
+	elemFn, condFn *execute.VectorExprFn
+}
+
+// vectorizedReducer folds the columns of a table into the accumulator
+// without calling the reduce function for each row.
+type vectorizedReducer struct {
+	properties []*propertyReducer
+}
+
+// newVectorizedReducer analyzes the reduce function and reports whether
+// each of its properties is a sum, product, count, minimum or maximum
+// of an expression of the row, optionally guarded by a condition on the row.
+// Whether those expressions can be vectorized depends on the types of
+// the columns, so it is decided for each table when it is reduced.
+func newVectorizedReducer(fn interpreter.ResolvedFunction, identity values.Object) (*vectorizedReducer, bool) {
+	if fn.Fn == nil || fn.Fn.Parameters == nil || len(fn.Fn.Parameters.List) != 2 {
+		return nil, false
+	}
+	for _, param := range fn.Fn.Parameters.List {
+		if name := param.Key.Name.Name(); name != "r" && name != "accumulator" {
+			return nil, false
+		}
+	}
+
+	body, ok := fn.Fn.GetFunctionBodyExpression()
+	if !ok {
+		return nil, false
+	}
+	obj, ok := body.(*semantic.ObjectExpression)
+	if !ok || (obj.With != nil && obj.With.Name.Name() != "accumulator") {
+		return nil, false
+	}
+
+	exprs := make(map[string]semantic.Expression, len(obj.Properties))
+	for _, p := range obj.Properties {
+		if _, ok := identity.Get(p.Key.Key()); !ok {
+			return nil, false
+		}
+		exprs[p.Key.Key()] = p.Value
+	}
+
+	r := &vectorizedReducer{
+		properties: make([]*propertyReducer, 0, identity.Len()),
+	}
+	valid := true
+	identity.Range(func(label string, v values.Value) {
+		if !valid {
+			return
+		}
+		e, ok := exprs[label]
+		if !ok {
+			if obj.With == nil {
+				valid = false
+				return
+			}
+			r.properties = append(r.properties, &propertyReducer{label: label, kind: keepReducer})
+			return
+		}
+
+		p, ok := analyzeProperty(label, e)
+		if !ok {
+			valid = false
+			return
+		}
+		r.properties = append(r.properties, p)
+	})
+	if !valid {
+		return nil, false
+	}
+
+	scope := compiler.ToScope(fn.Scope)
+	for _, p := range r.properties {
+		if p.elem != nil {
+			p.elemFn = execute.NewVectorExprFn(p.elem, "r", scope)
+		}
+		if p.cond != nil {
+			p.condFn = execute.NewVectorExprFn(p.cond, "r", scope)
+		}
+	}
+	return r, true
+}
+
+func analyzeProperty(label string, e semantic.Expression) (*propertyReducer, bool) {
+	if isAccumulatorProperty(e, label) {
+		return &propertyReducer{label: label, kind: keepReducer}, true
+	}
+
+	c, ok := e.(*semantic.ConditionalExpression)
+	if !ok {
+		return analyzeReduction(label, e)
+	} else if p, ok := analyzeSelect(label, c); ok {
+		return p, true
+	}
+
+	// A conditional that leaves the accumulator unchanged
+	// in one of its branches guards the other branch.
+	var (
+		inner  semantic.Expression
+		negate bool
+	)
+	switch {
+	case isAccumulatorProperty(c.Alternate, label):
+		inner = c.Consequent
+	case isAccumulatorProperty(c.Consequent, label):
+		inner, negate = c.Alternate, true
+	default:
+		return nil, false
+	}
+	if !isRowExpression(c.Test) {
+		return nil, false
+	}
+
+	p, ok := analyzeReduction(label, inner)
+	if !ok {
+		return nil, false
+	}
+	p.cond, p.negate = c.Test, negate
+	return p, true
+}
+
+// analyzeReduction analyzes an unguarded sum, product or select.
+func analyzeReduction(label string, e semantic.Expression) (*propertyReducer, bool) {
+	switch e := e.(type) {
+	case *semantic.BinaryExpression:
+		var kind reducerKind
+		switch e.Operator {
+		case ast.AdditionOperator:
+			kind = sumReducer
+		case ast.MultiplicationOperator:
+			kind = productReducer
+		default:
+			return nil, false
+		}
+
+		var elem semantic.Expression
+		if isAccumulatorProperty(e.Left, label) {
+			elem = e.Right
+		} else if isAccumulatorProperty(e.Right, label) {
+			elem = e.Left
+		}
+		if elem == nil || !isRowExpression(elem) {
+			return nil, false
+		}
+		return &propertyReducer{label: label, kind: kind, elem: elem}, true
+	case *semantic.ConditionalExpression:
+		return analyzeSelect(label, e)
+	default:
+		return nil, false
+	}
+}
+
+// analyzeSelect analyzes a conditional of the form
+// `if elem > accumulator.label then elem else accumulator.label`
+// with any ordering comparison and any order of the operands.
+func analyzeSelect(label string, c *semantic.ConditionalExpression) (*propertyReducer, bool) {
+	test, ok := c.Test.(*semantic.BinaryExpression)
+	if !ok {
+		return nil, false
+	}
+	switch test.Operator {
+	case ast.LessThanOperator, ast.LessThanEqualOperator,
+		ast.GreaterThanOperator, ast.GreaterThanEqualOperator:
+	default:
+		return nil, false
+	}
+
+	p := &propertyReducer{label: label, kind: selectReducer, op: test.Operator}
+	if isAccumulatorProperty(test.Right, label) {
+		p.elem, p.elemLeft = test.Left, true
+	} else if isAccumulatorProperty(test.Left, label) {
+		p.elem = test.Right
+	} else {
+		return nil, false
+	}
+	if !isRowExpression(p.elem) {
+		return nil, false
+	}
+
+	switch {
+	case isAccumulatorProperty(c.Alternate, label) && sameExpression(c.Consequent, p.elem):
+		p.selectOnTrue = true
+	case isAccumulatorProperty(c.Consequent, label) && sameExpression(c.Alternate, p.elem):
+		p.selectOnTrue = false
+	default:
+		return nil, false
+	}
+	return p, true
+}
+
+// isRowExpression reports whether the expression does not depend on the
+// accumulator. It can only be vectorized if it does not.
+func isRowExpression(e semantic.Expression) bool {
+	row := true
+	semantic.Walk(semantic.CreateVisitor(func(n semantic.Node) {
+		if id, ok := n.(*semantic.IdentifierExpression); ok && id.Name.Name() == "accumulator" {
+			row = false
+		}
+	}), e)
+	return row
+}
+
+func isAccumulatorProperty(e semantic.Expression, label string) bool {
+	m, ok := e.(*semantic.MemberExpression)
+	if !ok || m.Property.Name() != label {
+		return false
+	}
+	id, ok := m.Object.(*semantic.IdentifierExpression)
+	return ok && id.Name.Name() == "accumulator"
+}
+
+func sameExpression(a, b semantic.Expression) bool {
+	return fmt.Sprint(semantic.Formatted(a)) == fmt.Sprint(semantic.Formatted(b))
+}
+
+// preparedPropertyReducer is a propertyReducer compiled
+// for the columns of a specific table.
+type preparedPropertyReducer struct {
+	*propertyReducer
+	elem *execute.VectorExprPreparedFn
+	cond *execute.VectorExprPreparedFn
+}
+
+// prepare compiles the reducer for the columns of a table.
+// It reports false if the expressions of the reducer cannot be
+// vectorized for these columns, in which case the table must be
+// reduced row by row.
+func (r *vectorizedReducer) prepare(ctx context.Context, cols []flux.ColMeta, identity values.Object) ([]preparedPropertyReducer, bool) {
+	prepared := make([]preparedPropertyReducer, len(r.properties))
+	for i, p := range r.properties {
+		prepared[i].propertyReducer = p
+		if p.kind == keepReducer {
+			continue
+		}
+
+		acc, _ := identity.Get(p.label)
+		switch acc.Type().Nature() {
+		case semantic.Int, semantic.UInt, semantic.Float:
+		default:
+			return nil, false
+		}
+
+		elem, err := p.elemFn.Prepare(ctx, cols)
+		if err != nil || elem.Type().Nature() != acc.Type().Nature() {
+			return nil, false
+		}
+		prepared[i].elem = elem
+
+		if p.condFn != nil {
+			cond, err := p.condFn.Prepare(ctx, cols)
+			if err != nil || cond.Type().Nature() != semantic.Bool {
+				return nil, false
+			}
+			prepared[i].cond = cond
+		}
+	}
+	return prepared, true
+}
+
+// Reduce folds the table into the identity and returns the final accumulator.
+func (r *vectorizedReducer) Reduce(ctx context.Context, prepared []preparedPropertyReducer, tbl flux.Table, identity values.Object, mem memory.Allocator) (values.Object, error) {
+	accs := make([]values.Value, len(prepared))
+	for i, p := range prepared {
+		accs[i], _ = identity.Get(p.label)
+	}
+
+	if err := tbl.Do(func(cr flux.ColReader) error {
+		chunk := table.ChunkFromReader(cr)
+		for i, p := range prepared {
+			if p.kind == keepReducer {
+				continue
+			}
+			acc, err := p.reduce(ctx, accs[i], chunk, mem)
+			if err != nil {
+				return errors.Wrap(err, codes.Inherit, "failed to evaluate reduce function")
+			}
+			accs[i] = acc
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]values.Value, len(prepared))
+	for i, p := range prepared {
+		m[p.label] = accs[i]
+	}
+	return values.NewObjectWithValues(m), nil
+}
+
+func (p *preparedPropertyReducer) reduce(ctx context.Context, acc values.Value, chunk table.Chunk, mem memory.Allocator) (values.Value, error) {
+	if chunk.Len() == 0 {
+		return acc, nil
+	}
+
+	if p.cond != nil {
+		// The element is only evaluated for the rows selected by the
+		// condition. It may fail for the other rows, such as when the
+		// condition guards against a division by zero.
+		mask, err := p.cond.Eval(ctx, chunk)
+		if err != nil {
+			return nil, err
+		}
+		bitset := maskBitset(mask, chunk.Len(), p.negate, mem)
+		mask.Release()
+
+		selected, ok, err := filterChunkWithBitset(chunk, bitset, false, mem)
+		bitset.Release()
+		if err != nil || !ok {
+			return acc, err
+		}
+		defer selected.Release()
+		chunk = selected
+	}
+
+	elems, err := p.elem.Eval(ctx, chunk)
+	if err != nil {
+		return nil, err
+	}
+	defer elems.Release()
+
+	switch p.elem.Type().Nature() {
+	case semantic.Int:
+		a, valid := int64(0), !acc.IsNull()
+		if valid {
+			a = acc.Int()
+		}
+		return reduceNumbers(p.propertyReducer, a, valid, numbersOf[int64](elems), chunk.Len()), nil
+	case semantic.UInt:
+		a, valid := uint64(0), !acc.IsNull()
+		if valid {
+			a = acc.UInt()
+		}
+		return reduceNumbers(p.propertyReducer, a, valid, numbersOf[uint64](elems), chunk.Len()), nil
+	case semantic.Float:
+		a, valid := float64(0), !acc.IsNull()
+		if valid {
+			a = acc.Float()
+		}
+		return reduceNumbers(p.propertyReducer, a, valid, numbersOf[float64](elems), chunk.Len()), nil
+	default:
+		return nil, errors.Newf(codes.Internal, "unsupported reduce element type %s", p.elem.Type())
+	}
+}
+
+type number interface {
+	int64 | uint64 | float64
+}
+
+// numbers gives access to the elements of a vector
+// whether or not it is backed by an array.
+type numbers[T number] struct {
+	arr interface {
+		IsValid(i int) bool
+		Value(i int) T
+	}
+	value T
+	valid bool
+}
+
+func numbersOf[T number](v values.Vector) numbers[T] {
+	if v.IsRepeat() {
+		rv := v.(*values.VectorRepeatValue).Value()
+		if rv.IsNull() {
+			return numbers[T]{}
+		}
+		return numbers[T]{value: values.Unwrap(rv).(T), valid: true}
+	}
+	return numbers[T]{arr: v.Arr().(interface {
+		IsValid(i int) bool
+		Value(i int) T
+	})}
+}
+
+func (ns numbers[T]) at(i int) (T, bool) {
+	if ns.arr == nil {
+		return ns.value, ns.valid
+	}
+	return ns.arr.Value(i), ns.arr.IsValid(i)
+}
+
+// reduceNumbers folds the elements into the accumulator
+// in row order with the same null semantics as evaluating
+// the reduce function for each row.
+func reduceNumbers[T number](p *propertyReducer, acc T, valid bool, elems numbers[T], n int) values.Value {
+	for i := 0; i < n; i++ {
+		v, ok := elems.at(i)
+		switch p.kind {
+		case sumReducer:
+			valid = valid && ok
+			acc += v
+		case productReducer:
+			valid = valid && ok
+			acc *= v
+		case selectReducer:
+			// A comparison with null is null which selects the alternate.
+			test := false
+			if valid && ok {
+				if p.elemLeft {
+					test = compareNumbers(p.op, v, acc)
+				} else {
+					test = compareNumbers(p.op, acc, v)
+				}
+			}
+			if test == p.selectOnTrue {
+				acc, valid = v, ok
+			}
+		}
+	}
+	if !valid {
+		return values.Null
+	}
+	return values.New(acc)
+}
+
+func compareNumbers[T number](op ast.OperatorKind, l, r T) bool {
+	switch op {
+	case ast.LessThanOperator:
+		return l < r
+	case ast.LessThanEqualOperator:
+		return l <= r
+	case ast.GreaterThanOperator:
+		return l > r
+	default:
+		return l >= r
+	}
+}
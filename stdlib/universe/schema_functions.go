@@ -385,6 +385,14 @@ func (s *SchemaMutationProcedureSpec) Copy() plan.ProcedureSpec {
 	}
 }
 
+func (s *SchemaMutationProcedureSpec) PassThroughAttribute(attrKey string) bool {
+	switch attrKey {
+	case plan.ParallelRunKey:
+		return true
+	}
+	return false
+}
+
 func newSchemaMutationProcedure(qs flux.OperationSpec, pa plan.Administration) (plan.ProcedureSpec, error) {
 	s, ok := qs.(SchemaMutation)
 	if !ok {
@@ -82,6 +82,14 @@ func (s *SetProcedureSpec) Copy() plan.ProcedureSpec {
 	return ns
 }
 
+func (s *SetProcedureSpec) PassThroughAttribute(attrKey string) bool {
+	switch attrKey {
+	case plan.ParallelRunKey:
+		return true
+	}
+	return false
+}
+
 func createSetTransformation(id execute.DatasetID, mode execute.AccumulationMode, spec plan.ProcedureSpec, a execute.Administration) (execute.Transformation, execute.Dataset, error) {
 	s, ok := spec.(*SetProcedureSpec)
 	if !ok {
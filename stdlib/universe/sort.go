@@ -15,10 +15,11 @@ import (
 	"github.com/InfluxCommunity/flux/internal/errors"
 	"github.com/InfluxCommunity/flux/internal/mutable"
 	"github.com/InfluxCommunity/flux/interpreter"
+	"github.com/InfluxCommunity/flux/memory"
 	"github.com/InfluxCommunity/flux/plan"
 	"github.com/InfluxCommunity/flux/runtime"
 	"github.com/InfluxCommunity/flux/semantic"
-	"github.com/apache/arrow/go/v7/arrow/memory"
+	arrowmem "github.com/apache/arrow/go/v7/arrow/memory"
 )
 
 const SortKind = "sort"
@@ -121,18 +122,20 @@ func createSortTransformation(id execute.DatasetID, mode execute.AccumulationMod
 
 type sortTransformation struct {
 	execute.ExecutionNode
-	d       *execute.PassthroughDataset
-	mem     memory.Allocator
-	cols    []string
-	compare arrowutil.CompareFunc
+	d          *execute.PassthroughDataset
+	mem        arrowmem.Allocator
+	cols       []string
+	compare    arrowutil.CompareFunc
+	checkpoint *memory.Checkpoint
 }
 
-func NewSortTransformation(id execute.DatasetID, spec *SortProcedureSpec, mem memory.Allocator) (execute.Transformation, execute.Dataset, error) {
+func NewSortTransformation(id execute.DatasetID, spec *SortProcedureSpec, mem arrowmem.Allocator) (execute.Transformation, execute.Dataset, error) {
 	t := &sortTransformation{
-		d:       execute.NewPassthroughDataset(id),
-		mem:     mem,
-		cols:    spec.Columns,
-		compare: arrowutil.Compare,
+		d:          execute.NewPassthroughDataset(id),
+		mem:        mem,
+		cols:       spec.Columns,
+		compare:    arrowutil.Compare,
+		checkpoint: memory.NewCheckpoint(mem),
 	}
 	if spec.Desc {
 		// If descending, use the descending comparison.
@@ -185,7 +188,12 @@ func (s *sortTransformation) processView(mh *sortTableMergeHeap, cr flux.ColRead
 	cr.Retain()
 	item := &sortTableMergeHeapItem{cr: cr}
 	if !s.isSorted(cr, mh.sortCols) {
-		item.indices = s.sort(cr, mh.sortCols)
+		indices, err := s.sort(cr, mh.sortCols)
+		if err != nil {
+			cr.Release()
+			return err
+		}
+		item.indices = indices
 		item.offset = int(item.indices.Value(0))
 	}
 	mh.items = append(mh.items, item)
@@ -221,7 +229,7 @@ func (s *sortTransformation) isSorted(cr flux.ColReader, cols []int) bool {
 	return true
 }
 
-func (s *sortTransformation) sort(cr flux.ColReader, cols []int) *array.Int {
+func (s *sortTransformation) sort(cr flux.ColReader, cols []int) (*array.Int, error) {
 	// Construct the indices.
 	indices := mutable.NewInt64Array(s.mem)
 	indices.Resize(cr.Len())
@@ -233,7 +241,15 @@ func (s *sortTransformation) sort(cr flux.ColReader, cols []int) *array.Int {
 	}
 
 	// Sort the offsets by using the comparison method.
+	// If the query is canceled, the remaining comparisons
+	// return false so the sort finishes quickly.
+	var err error
 	sort.SliceStable(offsets, func(i, j int) bool {
+		if err != nil {
+			return false
+		} else if err = s.checkpoint.Check(); err != nil {
+			return false
+		}
 		i, j = int(offsets[i]), int(offsets[j])
 		for _, col := range cols {
 			arr := table.Values(cr, col)
@@ -243,9 +259,13 @@ func (s *sortTransformation) sort(cr flux.ColReader, cols []int) *array.Int {
 		}
 		return false
 	})
+	if err != nil {
+		indices.Release()
+		return nil, err
+	}
 
 	// Return the now sorted indices.
-	return indices.NewInt64Array()
+	return indices.NewInt64Array(), nil
 }
 
 func (s *sortTransformation) RetractTable(id execute.DatasetID, key flux.GroupKey) error {
@@ -336,7 +356,7 @@ func (s *sortTableMergeHeap) ValueLen() int {
 	return n
 }
 
-func (s *sortTableMergeHeap) Table(limit int, mem memory.Allocator) (flux.Table, error) {
+func (s *sortTableMergeHeap) Table(limit int, mem arrowmem.Allocator) (flux.Table, error) {
 	if s.ValueLen() == 0 {
 		// Degenerate case where there are no rows to merge sort.
 		for len(s.items) > 0 {
@@ -413,7 +433,7 @@ func (s *sortTableMergeHeap) Table(limit int, mem memory.Allocator) (flux.Table,
 	return builder.Table()
 }
 
-func (s *sortTableMergeHeap) NextBuffer(builders []array.Builder, keys []array.Array, n int, mem memory.Allocator) arrow.TableBuffer {
+func (s *sortTableMergeHeap) NextBuffer(builders []array.Builder, keys []array.Array, n int, mem arrowmem.Allocator) arrow.TableBuffer {
 	// Ensure there is enough space in each builder
 	for _, b := range builders {
 		if b == nil {
@@ -11,8 +11,9 @@ import (
 	"github.com/InfluxCommunity/flux/execute/table"
 	"github.com/InfluxCommunity/flux/internal/arrowutil"
 	"github.com/InfluxCommunity/flux/internal/errors"
+	"github.com/InfluxCommunity/flux/memory"
 	"github.com/InfluxCommunity/flux/plan"
-	"github.com/apache/arrow/go/v7/arrow/memory"
+	arrowmem "github.com/apache/arrow/go/v7/arrow/memory"
 )
 
 func init() {
@@ -50,12 +51,13 @@ type sortLimitTransformation struct {
 	limit int64
 }
 
-func NewSortLimitTransformation(id execute.DatasetID, spec *SortLimitProcedureSpec, mem memory.Allocator) (execute.Transformation, execute.Dataset, error) {
+func NewSortLimitTransformation(id execute.DatasetID, spec *SortLimitProcedureSpec, mem arrowmem.Allocator) (execute.Transformation, execute.Dataset, error) {
 	t := sortLimitTransformation{
 		sortTransformation: sortTransformation{
-			mem:     mem,
-			cols:    spec.Columns,
-			compare: arrowutil.Compare,
+			mem:        mem,
+			cols:       spec.Columns,
+			compare:    arrowutil.Compare,
+			checkpoint: memory.NewCheckpoint(mem),
 		},
 		limit: spec.N,
 	}
@@ -66,7 +68,7 @@ func NewSortLimitTransformation(id execute.DatasetID, spec *SortLimitProcedureSp
 	return execute.NewAggregateTransformation(id, &t, mem)
 }
 
-func (s *sortLimitTransformation) Aggregate(chunk table.Chunk, state interface{}, mem memory.Allocator) (interface{}, bool, error) {
+func (s *sortLimitTransformation) Aggregate(chunk table.Chunk, state interface{}, mem arrowmem.Allocator) (interface{}, bool, error) {
 	var mh *sortTableMergeHeap
 	if state != nil {
 		mh = state.(*sortTableMergeHeap)
@@ -106,21 +108,26 @@ func (s *sortLimitTransformation) Aggregate(chunk table.Chunk, state interface{}
 	return mh, true, nil
 }
 
-func (s *sortLimitTransformation) appendChunk(mh *sortTableMergeHeap, chunk table.Chunk, mem memory.Allocator) error {
+func (s *sortLimitTransformation) appendChunk(mh *sortTableMergeHeap, chunk table.Chunk, mem arrowmem.Allocator) error {
 	buffer := chunk.Buffer()
 	buffer.Retain()
 	s.reconcileSchema(mh, &buffer, mem)
 
 	item := &sortTableMergeHeapItem{cr: &buffer}
 	if !s.isSorted(&buffer, mh.sortCols) {
-		item.indices = s.sort(&buffer, mh.sortCols)
+		indices, err := s.sort(&buffer, mh.sortCols)
+		if err != nil {
+			buffer.Release()
+			return err
+		}
+		item.indices = indices
 		item.offset = int(item.indices.Value(0))
 	}
 	mh.items = append(mh.items, item)
 	return nil
 }
 
-func (s *sortLimitTransformation) reconcileSchema(mh *sortTableMergeHeap, buffer *arrow.TableBuffer, mem memory.Allocator) {
+func (s *sortLimitTransformation) reconcileSchema(mh *sortTableMergeHeap, buffer *arrow.TableBuffer, mem arrowmem.Allocator) {
 	if len(buffer.Columns) == len(mh.cols) {
 		equivalent := true
 		for i, col := range mh.cols {
@@ -164,7 +171,7 @@ func (s *sortLimitTransformation) reconcileSchema(mh *sortTableMergeHeap, buffer
 	buffer.Values = vals
 }
 
-func (s *sortLimitTransformation) backfillColumn(mh *sortTableMergeHeap, i int, mem memory.Allocator) {
+func (s *sortLimitTransformation) backfillColumn(mh *sortTableMergeHeap, i int, mem arrowmem.Allocator) {
 	for _, item := range mh.items {
 		cpy := &arrow.TableBuffer{
 			GroupKey: item.cr.Key(),
@@ -179,7 +186,7 @@ func (s *sortLimitTransformation) backfillColumn(mh *sortTableMergeHeap, i int,
 	}
 }
 
-func (s *sortLimitTransformation) Compute(key flux.GroupKey, state interface{}, d *execute.TransportDataset, mem memory.Allocator) error {
+func (s *sortLimitTransformation) Compute(key flux.GroupKey, state interface{}, d *execute.TransportDataset, mem arrowmem.Allocator) error {
 	// The chunks are in sorted order already and already chunked.
 	mh := state.(*sortTableMergeHeap)
 	for _, item := range mh.items {
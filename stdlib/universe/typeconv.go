@@ -9,6 +9,7 @@ import (
 
 	"github.com/InfluxCommunity/flux/array"
 	"github.com/InfluxCommunity/flux/codes"
+	"github.com/InfluxCommunity/flux/compiler"
 	"github.com/InfluxCommunity/flux/internal/errors"
 	"github.com/InfluxCommunity/flux/internal/parser"
 	"github.com/InfluxCommunity/flux/memory"
@@ -18,14 +19,14 @@ import (
 )
 
 func init() {
-	runtime.RegisterPackageValue("universe", "string", stringConv)
-	runtime.RegisterPackageValue("universe", "int", intConv)
-	runtime.RegisterPackageValue("universe", "uint", uintConv)
-	runtime.RegisterPackageValue("universe", "float", floatConv)
-	runtime.RegisterPackageValue("universe", "bool", boolConv)
-	runtime.RegisterPackageValue("universe", "time", timeConv)
-	runtime.RegisterPackageValue("universe", "duration", durationConv)
-	runtime.RegisterPackageValue("universe", "bytes", byteConv)
+	runtime.RegisterPackageValue("universe", "string", compiler.MarkPure(stringConv))
+	runtime.RegisterPackageValue("universe", "int", compiler.MarkPure(intConv))
+	runtime.RegisterPackageValue("universe", "uint", compiler.MarkPure(uintConv))
+	runtime.RegisterPackageValue("universe", "float", compiler.MarkPure(floatConv))
+	runtime.RegisterPackageValue("universe", "bool", compiler.MarkPure(boolConv))
+	runtime.RegisterPackageValue("universe", "time", compiler.MarkPure(timeConv))
+	runtime.RegisterPackageValue("universe", "duration", compiler.MarkPure(durationConv))
+	runtime.RegisterPackageValue("universe", "bytes", compiler.MarkPure(byteConv))
 	runtime.RegisterPackageValue("universe", "_vectorizedFloat", vectorizedFloatConv)
 }
 